@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 
 	"github.com/githubnext/gh-aw/pkg/cli"
 	"github.com/githubnext/gh-aw/pkg/console"
@@ -20,10 +22,29 @@ var verbose bool
 
 // validateEngine validates the engine flag value
 func validateEngine(engine string) error {
-	if engine != "" && engine != "claude" && engine != "codex" {
-		return fmt.Errorf("invalid engine value '%s'. Must be 'claude' or 'codex'", engine)
+	if engine == "" {
+		return nil
 	}
-	return nil
+	engines := overridableEngines()
+	for _, id := range engines {
+		if id == engine {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid engine value '%s'. Must be one of: %s", engine, strings.Join(engines, ", "))
+}
+
+// overridableEngines returns the sorted engine IDs accepted by --engine, including engines
+// declared in manifests. The custom engine is excluded since it requires steps in frontmatter.
+func overridableEngines() []string {
+	var engines []string
+	for _, id := range cli.RepositoryEngineRegistry().GetSupportedEngines() {
+		if id != "custom" {
+			engines = append(engines, id)
+		}
+	}
+	sort.Strings(engines)
+	return engines
 }
 
 var rootCmd = &cobra.Command{
@@ -223,6 +244,25 @@ Examples:
 	},
 }
 
+var verifyCmd = &cobra.Command{
+	Use:   "verify [workflow]...",
+	Short: "Verify that compiled lock files have not been edited by hand",
+	Long: `Verify that one or more lock files still match the hash recorded by the compiler.
+
+If no workflows are specified, all .lock.yml files in .github/workflows will be verified.
+
+Examples:
+  ` + constants.CLIExtensionPrefix + ` verify                    # Verify all lock files
+  ` + constants.CLIExtensionPrefix + ` verify weekly-research    # Verify a specific workflow
+  ` + constants.CLIExtensionPrefix + ` verify workflow.lock.yml  # Verify by file path`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := cli.VerifyWorkflows(args, verbose); err != nil {
+			fmt.Fprintln(os.Stderr, console.FormatErrorMessage(err.Error()))
+			os.Exit(1)
+		}
+	},
+}
+
 var runCmd = &cobra.Command{
 	Use:   "run <workflow-id-or-name>...",
 	Short: "Run one or more agentic workflows on GitHub Actions",
@@ -237,10 +277,33 @@ It executes 'gh workflow run <workflow-lock-file>' to trigger each workflow on G
 Examples:
   gh aw run weekly-research
   gh aw run weekly-research daily-plan
-  gh aw run weekly-research --repeat 3600  # Run every hour`,
+  gh aw run weekly-research --repeat 3600  # Run every hour
+  gh aw run weekly-research --follow       # Stream the run until it completes
+
+With --follow, the job and step transitions of the triggered run are streamed until it
+completes, with the agent transcript as soon as the agent log artifact is uploaded, then its
+metrics and safe outputs are displayed. The artifacts are downloaded to ./logs and the command
+exits with status 0 when the run succeeded and 1 otherwise.`,
 	Args: cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		repeatSeconds, _ := cmd.Flags().GetInt("repeat")
+		if follow, _ := cmd.Flags().GetBool("follow"); follow {
+			var conclusion string
+			var err error
+			if len(args) > 1 || repeatSeconds > 0 {
+				err = fmt.Errorf("--follow requires a single workflow and cannot be combined with --repeat")
+			} else {
+				conclusion, err = cli.FollowWorkflowOnGitHub(args[0], "./logs", verbose)
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, console.FormatError(console.CompilerError{
+					Type:    "error",
+					Message: fmt.Sprintf("following workflow run on GitHub Actions: %v", err),
+				}))
+				os.Exit(1)
+			}
+			os.Exit(cli.RunConclusionExitCode(conclusion))
+		}
 		if err := cli.RunWorkflowsOnGitHub(args, repeatSeconds, verbose); err != nil {
 			fmt.Fprintln(os.Stderr, console.FormatError(console.CompilerError{
 				Type:    "error",
@@ -317,7 +380,7 @@ func init() {
 	addCmd.Flags().StringP("name", "n", "", "Specify name for the added workflow (without .md extension)")
 
 	// Add AI flag to add command
-	addCmd.Flags().StringP("engine", "a", "", "Override AI engine (claude, codex)")
+	addCmd.Flags().StringP("engine", "a", "", "Override AI engine (claude, codex, gemini)")
 
 	// Add repository flag to add command
 	addCmd.Flags().StringP("repo", "r", "", "Install and use workflows from specified repository (org/repo)")
@@ -342,7 +405,7 @@ func init() {
 	uninstallCmd.Flags().BoolP("local", "l", false, "Uninstall packages from local .aw/packages instead of global ~/.aw/packages")
 
 	// Add AI flag to compile and add commands
-	compileCmd.Flags().StringP("engine", "a", "", "Override AI engine (claude, codex)")
+	compileCmd.Flags().StringP("engine", "a", "", "Override AI engine (claude, codex, gemini)")
 	compileCmd.Flags().Bool("validate", false, "Enable GitHub Actions workflow schema validation")
 	compileCmd.Flags().BoolP("watch", "w", false, "Watch for changes to workflow files and recompile automatically")
 	compileCmd.Flags().Bool("instructions", false, "Generate or update GitHub Copilot instructions file")
@@ -352,6 +415,7 @@ func init() {
 
 	// Add flags to run command
 	runCmd.Flags().Int("repeat", 0, "Repeat running workflows every SECONDS (0 = run once)")
+	runCmd.Flags().Bool("follow", false, "Stream the triggered run until it completes and exit with its conclusion")
 
 	// Add all commands to root
 	rootCmd.AddCommand(listCmd)
@@ -360,12 +424,14 @@ func init() {
 	rootCmd.AddCommand(installCmd)
 	rootCmd.AddCommand(uninstallCmd)
 	rootCmd.AddCommand(compileCmd)
+	rootCmd.AddCommand(verifyCmd)
 	rootCmd.AddCommand(runCmd)
 	rootCmd.AddCommand(removeCmd)
 	rootCmd.AddCommand(statusCmd)
 	rootCmd.AddCommand(enableCmd)
 	rootCmd.AddCommand(disableCmd)
 	rootCmd.AddCommand(cli.NewLogsCommand())
+	rootCmd.AddCommand(cli.NewStatsCommand())
 	rootCmd.AddCommand(cli.NewMCPInspectCommand())
 	rootCmd.AddCommand(versionCmd)
 }
@@ -33,6 +33,11 @@ func TestValidateEngine(t *testing.T) {
 			engine:    "codex",
 			expectErr: false,
 		},
+		{
+			name:      "valid gemini engine",
+			engine:    "gemini",
+			expectErr: false,
+		},
 		{
 			name:       "invalid engine",
 			engine:     "gpt4",
@@ -75,7 +80,7 @@ func TestValidateEngine(t *testing.T) {
 					return
 				}
 
-				if tt.errMessage != "" && err.Error() != fmt.Sprintf("invalid engine value '%s'. Must be 'claude' or 'codex'", tt.engine) {
+				if tt.errMessage != "" && err.Error() != fmt.Sprintf("invalid engine value '%s'. Must be one of: claude, codex, gemini", tt.engine) {
 					t.Errorf("validateEngine(%q) error message = %v, want to contain %v", tt.engine, err.Error(), tt.errMessage)
 				}
 			} else {
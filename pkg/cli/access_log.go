@@ -62,7 +62,7 @@ func parseSquidAccessLog(logPath string, verbose bool) (*DomainAnalysis, error)
 		entry, err := parseSquidLogLine(line)
 		if err != nil {
 			if verbose {
-				fmt.Println(console.FormatWarningMessage(fmt.Sprintf("Failed to parse log line: %v", err)))
+				fmt.Fprintln(os.Stderr, console.FormatWarningMessage(fmt.Sprintf("Failed to parse log line: %v", err)))
 			}
 			continue
 		}
@@ -169,7 +169,7 @@ func analyzeAccessLogs(runDir string, verbose bool) (*DomainAnalysis, error) {
 
 	// No access logs found
 	if verbose {
-		fmt.Println(console.FormatInfoMessage(fmt.Sprintf("No access logs found in %s", runDir)))
+		fmt.Fprintln(os.Stderr, console.FormatInfoMessage(fmt.Sprintf("No access logs found in %s", runDir)))
 	}
 	return nil, nil
 }
@@ -183,13 +183,13 @@ func analyzeMultipleAccessLogs(accessLogsDir string, verbose bool) (*DomainAnaly
 
 	if len(files) == 0 {
 		if verbose {
-			fmt.Println(console.FormatInfoMessage(fmt.Sprintf("No access log files found in %s", accessLogsDir)))
+			fmt.Fprintln(os.Stderr, console.FormatInfoMessage(fmt.Sprintf("No access log files found in %s", accessLogsDir)))
 		}
 		return nil, nil
 	}
 
 	if verbose {
-		fmt.Println(console.FormatInfoMessage(fmt.Sprintf("Analyzing %d access log files from %s", len(files), accessLogsDir)))
+		fmt.Fprintln(os.Stderr, console.FormatInfoMessage(fmt.Sprintf("Analyzing %d access log files from %s", len(files), accessLogsDir)))
 	}
 
 	// Aggregate analysis from all files
@@ -203,13 +203,13 @@ func analyzeMultipleAccessLogs(accessLogsDir string, verbose bool) (*DomainAnaly
 
 	for _, file := range files {
 		if verbose {
-			fmt.Println(console.FormatInfoMessage(fmt.Sprintf("Parsing %s", filepath.Base(file))))
+			fmt.Fprintln(os.Stderr, console.FormatInfoMessage(fmt.Sprintf("Parsing %s", filepath.Base(file))))
 		}
 
 		analysis, err := parseSquidAccessLog(file, verbose)
 		if err != nil {
 			if verbose {
-				fmt.Println(console.FormatWarningMessage(fmt.Sprintf("Failed to parse %s: %v", filepath.Base(file), err)))
+				fmt.Fprintln(os.Stderr, console.FormatWarningMessage(fmt.Sprintf("Failed to parse %s: %v", filepath.Base(file), err)))
 			}
 			continue
 		}
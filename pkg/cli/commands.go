@@ -14,6 +14,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -69,10 +70,27 @@ func ListWorkflows(verbose bool) error {
 	return listPackageWorkflows(verbose)
 }
 
+var repositoryEnginesOnce sync.Once
+
+// RepositoryEngineRegistry returns the global engine registry, with the engines declared in the
+// manifests of the current repository loaded on first use
+func RepositoryEngineRegistry() *workflow.EngineRegistry {
+	registry := workflow.GetGlobalEngineRegistry()
+	repositoryEnginesOnce.Do(func() {
+		gitRoot, err := findGitRoot()
+		if err != nil {
+			return
+		}
+		if err := registry.LoadManifestEngines(gitRoot); err != nil {
+			fmt.Fprintln(os.Stderr, console.FormatWarningMessage(err.Error()))
+		}
+	})
+	return registry
+}
+
 // listAgenticEngines lists all available agentic engines with their characteristics
 func listAgenticEngines(verbose bool) error {
-	// Create an engine registry directly to access the engines
-	registry := workflow.GetGlobalEngineRegistry()
+	registry := RepositoryEngineRegistry()
 
 	// Get all supported engines from the registry
 	engines := registry.GetSupportedEngines()
@@ -2147,6 +2165,11 @@ func downloadWorkflows(repo, version, targetDir string, verbose bool) error {
 		return err
 	}
 
+	// Copy engine manifests shipped alongside the workflows
+	if err := copyEngineManifests(filepath.Join(tempDir, "engines"), filepath.Join(targetDir, "engines"), verbose); err != nil {
+		return err
+	}
+
 	// Store the commit SHA in a metadata file
 	metadataFile := filepath.Join(targetDir, ".aw-metadata")
 	metadataContent := fmt.Sprintf("commit_sha=%s\n", commitSHA)
@@ -2161,6 +2184,44 @@ func downloadWorkflows(repo, version, targetDir string, verbose bool) error {
 	return nil
 }
 
+// copyEngineManifests copies the *.yml and *.yaml engine manifests from sourceDir, if it exists, into targetDir
+func copyEngineManifests(sourceDir, targetDir string, verbose bool) error {
+	entries, err := os.ReadDir(sourceDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read engines directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yml" && ext != ".yaml") {
+			continue
+		}
+
+		if err := os.MkdirAll(targetDir, 0755); err != nil {
+			return fmt.Errorf("failed to create engines directory %s: %w", targetDir, err)
+		}
+
+		sourceFile := filepath.Join(sourceDir, entry.Name())
+		targetFile := filepath.Join(targetDir, entry.Name())
+		if verbose {
+			fmt.Printf("Copying engine manifest: %s -> %s\n", entry.Name(), targetFile)
+		}
+
+		content, err := os.ReadFile(sourceFile)
+		if err != nil {
+			return fmt.Errorf("failed to read engine manifest %s: %w", sourceFile, err)
+		}
+		if err := os.WriteFile(targetFile, content, 0644); err != nil {
+			return fmt.Errorf("failed to write engine manifest %s: %w", targetFile, err)
+		}
+	}
+
+	return nil
+}
+
 // copyMarkdownFiles recursively copies markdown files from source to target directory
 func copyMarkdownFiles(sourceDir, targetDir string, verbose bool) error {
 	return filepath.Walk(sourceDir, func(path string, info os.FileInfo, err error) error {
@@ -3119,8 +3180,35 @@ func resolveWorkflowFile(fileOrWorkflowName string, verbose bool) (string, error
 
 // RunWorkflowOnGitHub runs an agentic workflow on GitHub Actions
 func RunWorkflowOnGitHub(workflowIdOrName string, verbose bool) error {
+	_, err := runWorkflowOnGitHub(workflowIdOrName, verbose)
+	return err
+}
+
+// FollowWorkflowOnGitHub runs an agentic workflow on GitHub Actions and follows the triggered
+// run until it completes, downloading its artifacts to outputDir. It returns the conclusion
+// of the run.
+func FollowWorkflowOnGitHub(workflowIdOrName, outputDir string, verbose bool) (string, error) {
+	runURL, err := runWorkflowOnGitHub(workflowIdOrName, verbose)
+	if err != nil {
+		return "", err
+	}
+	if runURL == "" {
+		return "", fmt.Errorf("could not find the triggered run of workflow '%s' to follow", workflowIdOrName)
+	}
+
+	runID, err := strconv.ParseInt(runURL[strings.LastIndex(runURL, "/")+1:], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("could not get the run ID from URL %s: %w", runURL, err)
+	}
+	fmt.Println()
+	return FollowRun(runID, outputDir, verbose)
+}
+
+// runWorkflowOnGitHub runs an agentic workflow on GitHub Actions and returns the URL of the
+// triggered run, or an empty string when it could not be found
+func runWorkflowOnGitHub(workflowIdOrName string, verbose bool) (string, error) {
 	if workflowIdOrName == "" {
-		return fmt.Errorf("workflow name or ID is required")
+		return "", fmt.Errorf("workflow name or ID is required")
 	}
 
 	if verbose {
@@ -3129,23 +3217,23 @@ func RunWorkflowOnGitHub(workflowIdOrName string, verbose bool) error {
 
 	// Check if gh CLI is available
 	if !isGHCLIAvailable() {
-		return fmt.Errorf("GitHub CLI (gh) is required but not available")
+		return "", fmt.Errorf("GitHub CLI (gh) is required but not available")
 	}
 
 	// Try to resolve the workflow file path to find the corresponding .lock.yml file
 	workflowFile, err := resolveWorkflowFile(workflowIdOrName, verbose)
 	if err != nil {
-		return fmt.Errorf("failed to resolve workflow: %w", err)
+		return "", fmt.Errorf("failed to resolve workflow: %w", err)
 	}
 
 	// Check if the workflow is runnable (has workflow_dispatch trigger)
 	runnable, err := IsRunnable(workflowFile)
 	if err != nil {
-		return fmt.Errorf("failed to check if workflow %s is runnable: %w", workflowFile, err)
+		return "", fmt.Errorf("failed to check if workflow %s is runnable: %w", workflowFile, err)
 	}
 
 	if !runnable {
-		return fmt.Errorf("workflow '%s' cannot be run on GitHub Actions - it must have 'workflow_dispatch' trigger", workflowIdOrName)
+		return "", fmt.Errorf("workflow '%s' cannot be run on GitHub Actions - it must have 'workflow_dispatch' trigger", workflowIdOrName)
 	}
 
 	// Determine the lock file name based on the workflow source
@@ -3156,7 +3244,7 @@ func RunWorkflowOnGitHub(workflowIdOrName string, verbose bool) error {
 
 	_, sourceInfo, err := findAndReadWorkflow(workflowIdOrName+".md", workflowsDir, verbose)
 	if err != nil {
-		return fmt.Errorf("failed to find workflow source info: %w", err)
+		return "", fmt.Errorf("failed to find workflow source info: %w", err)
 	}
 
 	filename := strings.TrimSuffix(filepath.Base(workflowIdOrName), ".md")
@@ -3182,7 +3270,7 @@ func RunWorkflowOnGitHub(workflowIdOrName string, verbose bool) error {
 	// Check if the lock file exists in .github/workflows
 	lockFilePath := filepath.Join(".github/workflows", lockFileName)
 	if _, err := os.Stat(lockFilePath); os.IsNotExist(err) {
-		return fmt.Errorf("workflow lock file '%s' not found in .github/workflows - run '"+constants.CLIExtensionPrefix+" compile' first", lockFileName)
+		return "", fmt.Errorf("workflow lock file '%s' not found in .github/workflows - run '"+constants.CLIExtensionPrefix+" compile' first", lockFileName)
 	}
 
 	if verbose {
@@ -3203,7 +3291,7 @@ func RunWorkflowOnGitHub(workflowIdOrName string, verbose bool) error {
 		if exitError, ok := err.(*exec.ExitError); ok {
 			fmt.Fprintf(os.Stderr, "%s", exitError.Stderr)
 		}
-		return fmt.Errorf("failed to run workflow on GitHub Actions: %w", err)
+		return "", fmt.Errorf("failed to run workflow on GitHub Actions: %w", err)
 	}
 
 	// Display the output from gh workflow run
@@ -3216,13 +3304,14 @@ func RunWorkflowOnGitHub(workflowIdOrName string, verbose bool) error {
 
 	// Try to get the latest run for this workflow to show a direct link
 	// Add a delay to allow GitHub Actions time to register the new workflow run
-	if runURL, err := getLatestWorkflowRunURLWithRetry(lockFileName, verbose); err == nil && runURL != "" {
+	runURL, err := getLatestWorkflowRunURLWithRetry(lockFileName, verbose)
+	if err == nil && runURL != "" {
 		fmt.Printf("\n🔗 View workflow run: %s\n", runURL)
 	} else if verbose && err != nil {
 		fmt.Printf("Note: Could not get workflow run URL: %v\n", err)
 	}
 
-	return nil
+	return runURL, nil
 }
 
 // RunWorkflowsOnGitHub runs multiple agentic workflows on GitHub Actions, optionally repeating at intervals
@@ -1083,3 +1083,48 @@ func initTestGitRepo(dir string) error {
 
 	return nil
 }
+
+func TestCopyEngineManifests(t *testing.T) {
+	sourceDir := filepath.Join(t.TempDir(), "engines")
+	targetDir := filepath.Join(t.TempDir(), "org", "repo", "engines")
+
+	// A missing engines directory is not an error
+	if err := copyEngineManifests(sourceDir, targetDir, false); err != nil {
+		t.Fatalf("Unexpected error for missing engines directory: %v", err)
+	}
+	if _, err := os.Stat(targetDir); !os.IsNotExist(err) {
+		t.Errorf("Expected no target directory to be created")
+	}
+
+	if err := os.MkdirAll(sourceDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"aider.yml":  "id: aider\n",
+		"goose.yaml": "id: goose\n",
+		"README.md":  "# Engines\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(sourceDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := copyEngineManifests(sourceDir, targetDir, false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, name := range []string{"aider.yml", "goose.yaml"} {
+		content, err := os.ReadFile(filepath.Join(targetDir, name))
+		if err != nil {
+			t.Errorf("Expected %s to be copied: %v", name, err)
+			continue
+		}
+		if string(content) != files[name] {
+			t.Errorf("Expected %s content %q, got %q", name, files[name], string(content))
+		}
+	}
+	if _, err := os.Stat(filepath.Join(targetDir, "README.md")); !os.IsNotExist(err) {
+		t.Errorf("Expected non-manifest files to be skipped")
+	}
+}
@@ -22,23 +22,33 @@ import (
 
 // WorkflowRun represents a GitHub Actions workflow run with metrics
 type WorkflowRun struct {
-	DatabaseID    int64     `json:"databaseId"`
-	Number        int       `json:"number"`
-	URL           string    `json:"url"`
-	Status        string    `json:"status"`
-	Conclusion    string    `json:"conclusion"`
-	WorkflowName  string    `json:"workflowName"`
-	CreatedAt     time.Time `json:"createdAt"`
-	StartedAt     time.Time `json:"startedAt"`
-	UpdatedAt     time.Time `json:"updatedAt"`
-	Event         string    `json:"event"`
-	HeadBranch    string    `json:"headBranch"`
-	HeadSha       string    `json:"headSha"`
-	DisplayTitle  string    `json:"displayTitle"`
-	Duration      time.Duration
-	TokenUsage    int
-	EstimatedCost float64
-	LogsPath      string
+	DatabaseID    int64             `json:"databaseId"`
+	Number        int               `json:"number"`
+	URL           string            `json:"url"`
+	Status        string            `json:"status"`
+	Conclusion    string            `json:"conclusion"`
+	WorkflowName  string            `json:"workflowName"`
+	CreatedAt     time.Time         `json:"createdAt"`
+	StartedAt     time.Time         `json:"startedAt"`
+	UpdatedAt     time.Time         `json:"updatedAt"`
+	Event         string            `json:"event"`
+	HeadBranch    string            `json:"headBranch"`
+	HeadSha       string            `json:"headSha"`
+	DisplayTitle  string            `json:"displayTitle"`
+	Duration      time.Duration     `json:"-"`
+	TokenUsage    int               `json:"-"`
+	EstimatedCost float64           `json:"-"`
+	CostUnknown   bool              `json:"-"`
+	Turns         int               `json:"-"`
+	ToolCalls     int               `json:"-"`
+	ToolFailures  int               `json:"-"`
+	StopReason    string            `json:"-"`
+	FailureCauses []FailureCause    `json:"-"`
+	FilesChanged  int               `json:"-"`
+	LinesAdded    int               `json:"-"`
+	LinesRemoved  int               `json:"-"`
+	SafeOutputs   []SafeOutputCount `json:"-"`
+	LogsPath      string            `json:"-"`
 }
 
 // LogMetrics represents extracted metrics from log files
@@ -48,6 +58,7 @@ type LogMetrics = workflow.LogMetrics
 // ProcessedRun represents a workflow run with its associated analysis
 type ProcessedRun struct {
 	Run            WorkflowRun
+	Metrics        LogMetrics
 	AccessAnalysis *DomainAnalysis
 	MissingTools   []MissingToolReport
 }
@@ -102,24 +113,39 @@ const (
 // NewLogsCommand creates the logs command
 func NewLogsCommand() *cobra.Command {
 	logsCmd := &cobra.Command{
-		Use:   "logs [agentic-workflow-id]",
+		Use:   "logs [agentic-workflow-id | run-id]",
 		Short: "Download and analyze agentic workflow logs with aggregated metrics",
 		Long: `Download workflow run logs and artifacts from GitHub Actions for agentic workflows.
 
 This command fetches workflow runs, downloads their artifacts, and extracts them into
 organized folders named by run ID. It also provides an overview table with aggregate
-metrics including duration, token usage, and cost information.
+metrics including duration, token usage, and cost information. Failed or degraded runs
+are classified by cause, e.g. max-turns, engine-auth or network-blocked, and grouped in
+a failure causes summary.
 
 Downloaded artifacts include:
 - aw_info.json: Engine configuration and workflow metadata
 - safe_output.jsonl: Agent's final output content (available when non-empty)
 - agent_output.json: Full/raw agent output (if the workflow uploaded this artifact)
 - aw.patch: Git patch of changes made during execution
+- prompt.txt: Prompt given to the agent
 - Various log files with execution details and metrics
 
 The agentic-workflow-id is the basename of the markdown file without the .md extension.
 For example, for 'weekly-research.md', use 'weekly-research' as the workflow ID.
 
+With --render, the argument is a workflow run ID and the agent transcript of that run
+is printed as it appears in the step summary of the run.
+
+With --suggest-network, the domains accessed by the runs of the workflow are collapsed into
+ecosystem identifiers and wildcards to propose a network.allowed list, which --apply writes
+into the frontmatter of the workflow.
+
+With --follow, the argument is the ID of an in-progress workflow run. Its job and step
+transitions are streamed until it completes, with the agent transcript as soon as the agent
+log artifact is uploaded, then its metrics and safe outputs are displayed. The command exits
+with status 0 when the run succeeded and 1 otherwise.
+
 Examples:
   ` + constants.CLIExtensionPrefix + ` logs                           # Download logs for all workflows
   ` + constants.CLIExtensionPrefix + ` logs weekly-research           # Download logs for specific agentic workflow
@@ -131,8 +157,74 @@ Examples:
   ` + constants.CLIExtensionPrefix + ` logs --start-date -1mo         # Filter runs from last month
   ` + constants.CLIExtensionPrefix + ` logs --engine claude           # Filter logs by claude engine
   ` + constants.CLIExtensionPrefix + ` logs --engine codex            # Filter logs by codex engine
-  ` + constants.CLIExtensionPrefix + ` logs -o ./my-logs              # Custom output directory`,
+  ` + constants.CLIExtensionPrefix + ` logs --engine gemini           # Filter logs by gemini engine
+  ` + constants.CLIExtensionPrefix + ` logs --conclusion failure --event pull_request --safe-output-type create-pull-request  # Failed PR runs that tried to open a PR
+  ` + constants.CLIExtensionPrefix + ` logs --min-cost 1.5 --has-missing-tools  # Expensive runs reporting missing tools
+  ` + constants.CLIExtensionPrefix + ` logs -o ./my-logs              # Custom output directory
+  ` + constants.CLIExtensionPrefix + ` logs --trace-format otlp-json  # Export agent traces for Jaeger or Tempo
+  ` + constants.CLIExtensionPrefix + ` logs --format json > runs.json  # Write run records as JSON (also csv, ndjson)
+  ` + constants.CLIExtensionPrefix + ` logs --offline ./logs           # Re-analyze downloaded runs without network access
+  ` + constants.CLIExtensionPrefix + ` logs --offline --html report/   # Write a static HTML report of downloaded runs
+  ` + constants.CLIExtensionPrefix + ` logs weekly-research --suggest-network  # Suggest a network allow-list from the access logs
+  ` + constants.CLIExtensionPrefix + ` logs 1234567890 --render       # Print the agent transcript of a run
+  ` + constants.CLIExtensionPrefix + ` logs 1234567890 --follow       # Stream an in-progress run until it completes
+  ` + constants.CLIExtensionPrefix + ` logs diff 1234567890 1234567899 # Compare two runs side by side`,
 		Run: func(cmd *cobra.Command, args []string) {
+			if follow, _ := cmd.Flags().GetBool("follow"); follow {
+				outputDir, _ := cmd.Flags().GetString("output")
+				verbose, _ := cmd.Flags().GetBool("verbose")
+
+				var runID int64
+				var conclusion string
+				var err error
+				if len(args) == 0 {
+					err = fmt.Errorf("--follow requires a workflow run ID")
+				} else if runID, err = strconv.ParseInt(args[0], 10, 64); err != nil {
+					err = fmt.Errorf("invalid run ID '%s': --follow requires a numeric workflow run ID", args[0])
+				} else {
+					conclusion, err = FollowRun(runID, outputDir, verbose)
+				}
+				if err != nil {
+					fmt.Fprintln(os.Stderr, console.FormatError(console.CompilerError{
+						Type:    "error",
+						Message: err.Error(),
+					}))
+					os.Exit(1)
+				}
+				os.Exit(RunConclusionExitCode(conclusion))
+			}
+
+			if render, _ := cmd.Flags().GetBool("render"); render {
+				outputDir, _ := cmd.Flags().GetString("output")
+				verbose, _ := cmd.Flags().GetBool("verbose")
+
+				var runID int64
+				var err error
+				if len(args) == 0 {
+					err = fmt.Errorf("--render requires a workflow run ID")
+				} else if runID, err = strconv.ParseInt(args[0], 10, 64); err != nil {
+					err = fmt.Errorf("invalid run ID '%s': --render requires a numeric workflow run ID", args[0])
+				} else {
+					err = RenderRunLogs(runID, outputDir, verbose)
+				}
+				if err != nil {
+					fmt.Fprintln(os.Stderr, console.FormatError(console.CompilerError{
+						Type:    "error",
+						Message: err.Error(),
+					}))
+					os.Exit(1)
+				}
+				return
+			}
+
+			// In offline mode the last argument can be the directory of previously downloaded logs
+			offline, _ := cmd.Flags().GetBool("offline")
+			outputDir, _ := cmd.Flags().GetString("output")
+			if offline && len(args) > 0 && dirExists(args[len(args)-1]) {
+				outputDir = args[len(args)-1]
+				args = args[:len(args)-1]
+			}
+
 			var workflowName string
 			if len(args) > 0 && args[0] != "" {
 				// Convert agentic workflow ID to GitHub Actions workflow name
@@ -142,8 +234,9 @@ Examples:
 					// If that fails, check if it's already a GitHub Actions workflow name
 					// by checking if any .lock.yml files have this as their name
 					agenticWorkflowNames, nameErr := getAgenticWorkflowNames(false)
-					if nameErr == nil && contains(agenticWorkflowNames, args[0]) {
-						// It's already a valid GitHub Actions workflow name
+					if (nameErr == nil && contains(agenticWorkflowNames, args[0])) || offline {
+						// It's already a valid GitHub Actions workflow name, or the name of
+						// downloaded runs whose workflow is not in this repository
 						workflowName = args[0]
 					} else {
 						// Neither agentic workflow ID nor valid GitHub Actions workflow name
@@ -161,10 +254,30 @@ Examples:
 			count, _ := cmd.Flags().GetInt("count")
 			startDate, _ := cmd.Flags().GetString("start-date")
 			endDate, _ := cmd.Flags().GetString("end-date")
-			outputDir, _ := cmd.Flags().GetString("output")
 			engine, _ := cmd.Flags().GetString("engine")
+			traceFormat, _ := cmd.Flags().GetString("trace-format")
+			format, _ := cmd.Flags().GetString("format")
+			htmlDir, _ := cmd.Flags().GetString("html")
 			verbose, _ := cmd.Flags().GetBool("verbose")
 
+			var filter LogsFilter
+			filter.Conclusion, _ = cmd.Flags().GetString("conclusion")
+			filter.Event, _ = cmd.Flags().GetString("event")
+			filter.Branch, _ = cmd.Flags().GetString("branch")
+			filter.Actor, _ = cmd.Flags().GetString("actor")
+			filter.MinCost, _ = cmd.Flags().GetFloat64("min-cost")
+			filter.MinTokens, _ = cmd.Flags().GetInt("min-tokens")
+			filter.HasMissingTools, _ = cmd.Flags().GetBool("has-missing-tools")
+			filter.HasPatch, _ = cmd.Flags().GetBool("has-patch")
+			filter.SafeOutputType, _ = cmd.Flags().GetString("safe-output-type")
+			if err := filter.Validate(); err != nil {
+				fmt.Fprintln(os.Stderr, console.FormatError(console.CompilerError{
+					Type:    "error",
+					Message: err.Error(),
+				}))
+				os.Exit(1)
+			}
+
 			// Resolve relative dates to absolute dates for GitHub CLI
 			now := time.Now()
 			if startDate != "" {
@@ -192,7 +305,7 @@ Examples:
 
 			// Validate engine parameter using the engine registry
 			if engine != "" {
-				registry := workflow.GetGlobalEngineRegistry()
+				registry := RepositoryEngineRegistry()
 				if !registry.IsValidEngine(engine) {
 					supportedEngines := registry.GetSupportedEngines()
 					fmt.Fprintln(os.Stderr, console.FormatError(console.CompilerError{
@@ -203,7 +316,44 @@ Examples:
 				}
 			}
 
-			if err := DownloadWorkflowLogs(workflowName, count, startDate, endDate, outputDir, engine, verbose); err != nil {
+			if traceFormat != "" && !isValidTraceFormat(traceFormat) {
+				fmt.Fprintln(os.Stderr, console.FormatError(console.CompilerError{
+					Type:    "error",
+					Message: fmt.Sprintf("invalid trace-format value '%s'. Must be one of: %s, %s", traceFormat, TraceFormatOTLPJSON, TraceFormatJSONL),
+				}))
+				os.Exit(1)
+			}
+
+			if format != "" && !isValidLogsFormat(format) {
+				fmt.Fprintln(os.Stderr, console.FormatError(console.CompilerError{
+					Type:    "error",
+					Message: fmt.Sprintf("invalid format value '%s'. Must be one of: %s, %s, %s", format, LogsFormatJSON, LogsFormatCSV, LogsFormatNDJSON),
+				}))
+				os.Exit(1)
+			}
+
+			var err error
+			if suggestNetwork, _ := cmd.Flags().GetBool("suggest-network"); suggestNetwork {
+				apply, _ := cmd.Flags().GetBool("apply")
+				var processedRuns []ProcessedRun
+				if workflowName == "" {
+					err = fmt.Errorf("--suggest-network requires a workflow")
+				} else if offline {
+					processedRuns, err = loadOfflineProcessedRuns(outputDir, workflowName, count, startDate, endDate, engine, filter, traceFormat, verbose)
+				} else {
+					processedRuns, err = downloadProcessedRuns(workflowName, count, startDate, endDate, outputDir, engine, filter, traceFormat, verbose)
+				}
+				if err == nil {
+					err = SuggestNetworkAllowList(args[0], processedRuns, apply, verbose)
+				}
+			} else if apply, _ := cmd.Flags().GetBool("apply"); apply {
+				err = fmt.Errorf("--apply requires --suggest-network")
+			} else if offline {
+				err = AnalyzeOfflineLogs(outputDir, workflowName, count, startDate, endDate, engine, filter, traceFormat, format, htmlDir, verbose)
+			} else {
+				err = DownloadWorkflowLogs(workflowName, count, startDate, endDate, outputDir, engine, filter, traceFormat, format, htmlDir, verbose)
+			}
+			if err != nil {
 				fmt.Fprintln(os.Stderr, console.FormatError(console.CompilerError{
 					Type:    "error",
 					Message: err.Error(),
@@ -218,15 +368,52 @@ Examples:
 	logsCmd.Flags().String("start-date", "", "Filter runs created after this date (YYYY-MM-DD or delta like -1d, -1w, -1mo)")
 	logsCmd.Flags().String("end-date", "", "Filter runs created before this date (YYYY-MM-DD or delta like -1d, -1w, -1mo)")
 	logsCmd.Flags().StringP("output", "o", "./logs", "Output directory for downloaded logs and artifacts")
-	logsCmd.Flags().String("engine", "", "Filter logs by agentic engine type (claude, codex)")
+	logsCmd.Flags().String("engine", "", "Filter logs by agentic engine type (claude, codex, gemini)")
+	logsCmd.Flags().String("conclusion", "", "Filter runs by conclusion (success, failure, cancelled, timed_out, ...)")
+	logsCmd.Flags().String("event", "", "Filter runs by triggering event (push, pull_request, schedule, workflow_dispatch, ...)")
+	logsCmd.Flags().String("branch", "", "Filter runs by head branch")
+	logsCmd.Flags().String("actor", "", "Filter runs by the user who triggered them")
+	logsCmd.Flags().Float64("min-cost", 0, "Only include runs with an estimated cost of at least this many USD")
+	logsCmd.Flags().Int("min-tokens", 0, "Only include runs using at least this many tokens")
+	logsCmd.Flags().Bool("has-missing-tools", false, "Only include runs reporting missing tools")
+	logsCmd.Flags().Bool("has-patch", false, "Only include runs with changes in aw.patch")
+	logsCmd.Flags().String("safe-output-type", "", "Only include runs with a safe output of this type (create-issue, create-pull-request, add-issue-comment, ...)")
+	logsCmd.Flags().String("trace-format", "", "Write the agent trace of each run to its logs directory (otlp-json, jsonl)")
+	logsCmd.Flags().String("format", "", "Write run records to stdout instead of tables (json, csv, ndjson)")
+	logsCmd.Flags().Bool("offline", false, "Analyze the runs previously downloaded to the output directory, or the given directory, without network access")
+	logsCmd.Flags().String("html", "", "Write a static HTML report of the runs to this directory")
+	logsCmd.Flags().Bool("suggest-network", false, "Suggest a minimal network.allowed list for the given workflow from the domains its runs accessed")
+	logsCmd.Flags().Bool("apply", false, "With --suggest-network, write the suggested list into the frontmatter of the workflow")
+	logsCmd.Flags().Bool("render", false, "Print the agent transcript of the given run ID as shown in its step summary")
+	logsCmd.Flags().Bool("follow", false, "Stream the status and agent transcript of the given run ID until it completes, exiting with its conclusion")
+
+	logsCmd.AddCommand(NewLogsDiffCommand())
 
 	return logsCmd
 }
 
-// DownloadWorkflowLogs downloads and analyzes workflow logs with metrics
-func DownloadWorkflowLogs(workflowName string, count int, startDate, endDate, outputDir, engine string, verbose bool) error {
+// DownloadWorkflowLogs downloads and analyzes workflow logs with metrics. With a format, the
+// runs are written to stdout as records in that format instead of being displayed as tables.
+func DownloadWorkflowLogs(workflowName string, count int, startDate, endDate, outputDir, engine string, filter LogsFilter, traceFormat, format, htmlDir string, verbose bool) error {
+	processedRuns, err := downloadProcessedRuns(workflowName, count, startDate, endDate, outputDir, engine, filter, traceFormat, verbose)
+	if err != nil {
+		return err
+	}
+
+	if err := recordRunHistory(outputDir, processedRuns); err != nil {
+		fmt.Fprintln(os.Stderr, console.FormatWarningMessage(err.Error()))
+	}
+
+	absOutputDir, _ := filepath.Abs(outputDir)
+	return reportProcessedRuns(processedRuns, format, htmlDir, fmt.Sprintf("Downloaded %d logs to %s", len(processedRuns), absOutputDir), verbose)
+}
+
+// downloadProcessedRuns fetches the runs matching the filters with their artifacts until count
+// runs with artifacts are processed, or no more runs are found
+func downloadProcessedRuns(workflowName string, count int, startDate, endDate, outputDir, engine string, filter LogsFilter, traceFormat string, verbose bool) ([]ProcessedRun, error) {
 	if verbose {
-		fmt.Println(console.FormatInfoMessage("Fetching workflow runs from GitHub Actions..."))
+		fmt.Fprintln(os.Stderr, console.FormatInfoMessage("Fetching workflow runs from GitHub Actions..."))
+		fmt.Fprintln(os.Stderr, console.FormatInfoMessage(fmt.Sprintf("Costs not reported in logs are estimated with model pricing %s", workflow.GetModelPricing().Version)))
 	}
 
 	var processedRuns []ProcessedRun
@@ -238,7 +425,7 @@ func DownloadWorkflowLogs(workflowName string, count int, startDate, endDate, ou
 		iteration++
 
 		if verbose && iteration > 1 {
-			fmt.Println(console.FormatInfoMessage(fmt.Sprintf("Iteration %d: Need %d more runs with artifacts, fetching more...", iteration, count-len(processedRuns))))
+			fmt.Fprintln(os.Stderr, console.FormatInfoMessage(fmt.Sprintf("Iteration %d: Need %d more runs with artifacts, fetching more...", iteration, count-len(processedRuns))))
 		}
 
 		// Fetch a batch of runs
@@ -262,20 +449,20 @@ func DownloadWorkflowLogs(workflowName string, count int, startDate, endDate, ou
 			}
 		}
 
-		runs, err := listWorkflowRunsWithPagination(workflowName, batchSize, startDate, endDate, beforeDate, verbose)
+		runs, err := listWorkflowRunsWithPagination(workflowName, batchSize, startDate, endDate, beforeDate, filter, verbose)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		if len(runs) == 0 {
 			if verbose {
-				fmt.Println(console.FormatInfoMessage("No more workflow runs found, stopping iteration"))
+				fmt.Fprintln(os.Stderr, console.FormatInfoMessage("No more workflow runs found, stopping iteration"))
 			}
 			break
 		}
 
 		if verbose {
-			fmt.Println(console.FormatInfoMessage(fmt.Sprintf("Found %d workflow runs in batch %d", len(runs), iteration)))
+			fmt.Fprintln(os.Stderr, console.FormatInfoMessage(fmt.Sprintf("Found %d workflow runs in batch %d", len(runs), iteration)))
 		}
 
 		// Process each run in this batch
@@ -291,79 +478,33 @@ func DownloadWorkflowLogs(workflowName string, count int, startDate, endDate, ou
 			if result.Skipped {
 				if verbose {
 					if result.Error != nil {
-						fmt.Println(console.FormatWarningMessage(fmt.Sprintf("Skipping run %d: %v", result.Run.DatabaseID, result.Error)))
+						fmt.Fprintln(os.Stderr, console.FormatWarningMessage(fmt.Sprintf("Skipping run %d: %v", result.Run.DatabaseID, result.Error)))
 					}
 				}
 				continue
 			}
 
 			if result.Error != nil {
-				fmt.Println(console.FormatWarningMessage(fmt.Sprintf("Failed to download artifacts for run %d: %v", result.Run.DatabaseID, result.Error)))
+				fmt.Fprintln(os.Stderr, console.FormatWarningMessage(fmt.Sprintf("Failed to download artifacts for run %d: %v", result.Run.DatabaseID, result.Error)))
 				continue
 			}
 
-			// Apply engine filtering if specified
-			if engine != "" {
-				// Check if the run's engine matches the filter
-				awInfoPath := filepath.Join(result.LogsPath, "aw_info.json")
-				detectedEngine := extractEngineFromAwInfo(awInfoPath, verbose)
-
-				var engineMatches bool
-				if detectedEngine != nil {
-					// Get the engine ID to compare with the filter
-					registry := workflow.GetGlobalEngineRegistry()
-					for _, supportedEngine := range []string{"claude", "codex"} {
-						if testEngine, err := registry.GetEngine(supportedEngine); err == nil && testEngine == detectedEngine {
-							engineMatches = (supportedEngine == engine)
-							break
-						}
-					}
-				}
-
-				if !engineMatches {
-					if verbose {
-						engineName := "unknown"
-						if detectedEngine != nil {
-							// Try to get a readable name for the detected engine
-							registry := workflow.GetGlobalEngineRegistry()
-							for _, supportedEngine := range []string{"claude", "codex"} {
-								if testEngine, err := registry.GetEngine(supportedEngine); err == nil && testEngine == detectedEngine {
-									engineName = supportedEngine
-									break
-								}
-							}
-						}
-						fmt.Println(console.FormatInfoMessage(fmt.Sprintf("Skipping run %d: engine '%s' does not match filter '%s'", result.Run.DatabaseID, engineName, engine)))
-					}
-					continue
-				}
-			}
-
-			// Update run with metrics and path
-			run := result.Run
-			run.TokenUsage = result.Metrics.TokenUsage
-			run.EstimatedCost = result.Metrics.EstimatedCost
-			run.LogsPath = result.LogsPath
-
-			// Store access analysis for later display (we'll access it via the result)
-			// No need to modify the WorkflowRun struct for this
-
-			// Always use GitHub API timestamps for duration calculation
-			if !run.StartedAt.IsZero() && !run.UpdatedAt.IsZero() {
-				run.Duration = run.UpdatedAt.Sub(run.StartedAt)
+			processedRun, ok := processDownloadResult(result, engine, traceFormat, verbose)
+			if !ok {
+				continue
 			}
-
-			processedRun := ProcessedRun{
-				Run:            run,
-				AccessAnalysis: result.AccessAnalysis,
-				MissingTools:   result.MissingTools,
+			if !filter.matchesProcessedRun(processedRun, verbose) {
+				if verbose {
+					fmt.Fprintln(os.Stderr, console.FormatInfoMessage(fmt.Sprintf("Skipping run %d: artifacts do not match the filters", result.Run.DatabaseID)))
+				}
+				continue
 			}
 			processedRuns = append(processedRuns, processedRun)
 			batchProcessed++
 		}
 
 		if verbose {
-			fmt.Println(console.FormatInfoMessage(fmt.Sprintf("Processed %d runs with artifacts in batch %d (total: %d/%d)", batchProcessed, iteration, len(processedRuns), count)))
+			fmt.Fprintln(os.Stderr, console.FormatInfoMessage(fmt.Sprintf("Processed %d runs with artifacts in batch %d (total: %d/%d)", batchProcessed, iteration, len(processedRuns), count)))
 		}
 
 		// Prepare for next iteration: set beforeDate to the oldest run from this batch
@@ -375,7 +516,7 @@ func DownloadWorkflowLogs(workflowName string, count int, startDate, endDate, ou
 		// If we got fewer runs than requested in this batch, we've likely hit the end
 		if len(runs) < batchSize {
 			if verbose {
-				fmt.Println(console.FormatInfoMessage("Received fewer runs than requested, likely reached end of available runs"))
+				fmt.Fprintln(os.Stderr, console.FormatInfoMessage("Received fewer runs than requested, likely reached end of available runs"))
 			}
 			break
 		}
@@ -383,11 +524,99 @@ func DownloadWorkflowLogs(workflowName string, count int, startDate, endDate, ou
 
 	// Check if we hit the maximum iterations limit
 	if iteration >= MaxIterations && len(processedRuns) < count {
-		fmt.Println(console.FormatWarningMessage(fmt.Sprintf("Reached maximum iterations (%d), collected %d runs with artifacts out of %d requested", MaxIterations, len(processedRuns), count)))
+		fmt.Fprintln(os.Stderr, console.FormatWarningMessage(fmt.Sprintf("Reached maximum iterations (%d), collected %d runs with artifacts out of %d requested", MaxIterations, len(processedRuns), count)))
+	}
+
+	return processedRuns, nil
+}
+
+// processDownloadResult applies the engine filter to a downloaded run, completes the run with
+// its metrics and writes its agent trace. It returns false when the run is filtered out.
+func processDownloadResult(result DownloadResult, engine, traceFormat string, verbose bool) (ProcessedRun, bool) {
+	// Apply engine filtering if specified
+	if engine != "" {
+		// Check if the run's engine matches the filter
+		awInfoPath := filepath.Join(result.LogsPath, "aw_info.json")
+		detectedEngine := extractEngineFromAwInfo(awInfoPath, verbose)
+
+		if detectedEngine == nil || detectedEngine.GetID() != engine {
+			if verbose {
+				engineName := "unknown"
+				if detectedEngine != nil {
+					engineName = detectedEngine.GetID()
+				}
+				fmt.Fprintln(os.Stderr, console.FormatInfoMessage(fmt.Sprintf("Skipping run %d: engine '%s' does not match filter '%s'", result.Run.DatabaseID, engineName, engine)))
+			}
+			return ProcessedRun{}, false
+		}
+	}
+
+	// Update run with metrics and path
+	run := result.Run
+	run.TokenUsage = result.Metrics.TokenUsage
+	run.EstimatedCost = result.Metrics.EstimatedCost
+	run.CostUnknown = result.Metrics.CostUnknown
+	run.Turns = result.Metrics.Turns
+	run.ToolCalls = result.Metrics.TotalToolCalls()
+	run.ToolFailures = result.Metrics.TotalToolFailures()
+	run.StopReason = result.Metrics.StopReason
+	run.LogsPath = result.LogsPath
+
+	// Store access analysis for later display (we'll access it via the result)
+	// No need to modify the WorkflowRun struct for this
+
+	// Always use GitHub API timestamps for duration calculation
+	if !run.StartedAt.IsZero() && !run.UpdatedAt.IsZero() {
+		run.Duration = run.UpdatedAt.Sub(run.StartedAt)
+	}
+
+	run.FailureCauses = classifyRunFailure(run, result.AccessAnalysis, verbose)
+	run.FilesChanged, run.LinesAdded, run.LinesRemoved = extractPatchStats(run.LogsPath)
+	run.SafeOutputs = extractSafeOutputCounts(run.LogsPath, verbose)
+
+	if traceFormat != "" {
+		tracePath, err := writeRunTrace(run, traceFormat, verbose)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, console.FormatWarningMessage(err.Error()))
+		} else if tracePath != "" && verbose {
+			fmt.Fprintln(os.Stderr, console.FormatInfoMessage(fmt.Sprintf("Wrote agent trace for run %d to %s", run.DatabaseID, tracePath)))
+		}
+	}
+
+	processedRun := ProcessedRun{
+		Run:            run,
+		Metrics:        result.Metrics,
+		AccessAnalysis: result.AccessAnalysis,
+		MissingTools:   result.MissingTools,
+	}
+	return processedRun, true
+}
+
+// reportProcessedRuns displays the overview and analysis tables of the processed runs followed
+// by the done message, or writes them to stdout as records when a format is given. With an
+// HTML directory, it also writes the static HTML report of the runs there.
+func reportProcessedRuns(processedRuns []ProcessedRun, format, htmlDir, doneMessage string, verbose bool) error {
+	if htmlDir != "" && len(processedRuns) > 0 {
+		if err := writeHTMLReport(htmlDir, processedRuns, verbose); err != nil {
+			return err
+		}
+		absHTMLDir, _ := filepath.Abs(htmlDir)
+		fmt.Fprintln(os.Stderr, console.FormatSuccessMessage(fmt.Sprintf("Wrote HTML report to %s", filepath.Join(absHTMLDir, "index.html"))))
 	}
 
 	if len(processedRuns) == 0 {
-		fmt.Println(console.FormatWarningMessage("No workflow runs with artifacts found matching the specified criteria"))
+		fmt.Fprintln(os.Stderr, console.FormatWarningMessage("No workflow runs with artifacts found matching the specified criteria"))
+		if format != "" {
+			return writeLogsRecords(os.Stdout, format, processedRuns)
+		}
+		return nil
+	}
+
+	if format != "" {
+		if err := writeLogsRecords(os.Stdout, format, processedRuns); err != nil {
+			return fmt.Errorf("failed to write %s output: %w", format, err)
+		}
+		fmt.Fprintln(os.Stderr, console.FormatSuccessMessage(doneMessage))
 		return nil
 	}
 
@@ -398,6 +627,15 @@ func DownloadWorkflowLogs(workflowName string, count int, startDate, endDate, ou
 	}
 	displayLogsOverview(workflowRuns)
 
+	// Display what each workflow shipped
+	displayWorkflowProductivity(processedRuns)
+
+	// Display runs grouped by failure cause
+	displayFailureCauseAnalysis(processedRuns, verbose)
+
+	// Display tool usage analysis
+	displayToolUsageAnalysis(processedRuns)
+
 	// Display access log analysis
 	displayAccessLogAnalysis(processedRuns, verbose)
 
@@ -405,8 +643,7 @@ func DownloadWorkflowLogs(workflowName string, count int, startDate, endDate, ou
 	displayMissingToolsAnalysis(processedRuns, verbose)
 
 	// Display logs location prominently
-	absOutputDir, _ := filepath.Abs(outputDir)
-	fmt.Println(console.FormatSuccessMessage(fmt.Sprintf("Downloaded %d logs to %s", len(processedRuns), absOutputDir)))
+	fmt.Println(console.FormatSuccessMessage(doneMessage))
 	return nil
 }
 
@@ -423,7 +660,7 @@ func downloadRunArtifactsConcurrent(runs []WorkflowRun, outputDir string, verbos
 	}
 
 	if verbose {
-		fmt.Println(console.FormatInfoMessage(fmt.Sprintf("Processing %d runs in parallel...", len(actualRuns))))
+		fmt.Fprintln(os.Stderr, console.FormatInfoMessage(fmt.Sprintf("Processing %d runs in parallel...", len(actualRuns))))
 	}
 
 	// Use conc pool for controlled concurrency with results
@@ -434,7 +671,7 @@ func downloadRunArtifactsConcurrent(runs []WorkflowRun, outputDir string, verbos
 		run := run // capture loop variable
 		p.Go(func() DownloadResult {
 			if verbose {
-				fmt.Println(console.FormatInfoMessage(fmt.Sprintf("Processing run %d (%s)...", run.DatabaseID, run.Status)))
+				fmt.Fprintln(os.Stderr, console.FormatInfoMessage(fmt.Sprintf("Processing run %d (%s)...", run.DatabaseID, run.Status)))
 			}
 
 			// Download artifacts and logs for this run
@@ -455,34 +692,10 @@ func downloadRunArtifactsConcurrent(runs []WorkflowRun, outputDir string, verbos
 					result.Error = err
 				}
 			} else {
-				// Extract metrics from logs
-				metrics, metricsErr := extractLogMetrics(runOutputDir, verbose)
-				if metricsErr != nil {
-					if verbose {
-						fmt.Println(console.FormatWarningMessage(fmt.Sprintf("Failed to extract metrics for run %d: %v", run.DatabaseID, metricsErr)))
-					}
-					// Don't fail the whole download for metrics errors
-					metrics = LogMetrics{}
-				}
-				result.Metrics = metrics
-
-				// Analyze access logs if available
-				accessAnalysis, accessErr := analyzeAccessLogs(runOutputDir, verbose)
-				if accessErr != nil {
-					if verbose {
-						fmt.Println(console.FormatWarningMessage(fmt.Sprintf("Failed to analyze access logs for run %d: %v", run.DatabaseID, accessErr)))
-					}
+				if err := saveWorkflowRun(run, runOutputDir); err != nil && verbose {
+					fmt.Fprintln(os.Stderr, console.FormatWarningMessage(err.Error()))
 				}
-				result.AccessAnalysis = accessAnalysis
-
-				// Extract missing tools if available
-				missingTools, missingErr := extractMissingToolsFromRun(runOutputDir, run, verbose)
-				if missingErr != nil {
-					if verbose {
-						fmt.Println(console.FormatWarningMessage(fmt.Sprintf("Failed to extract missing tools for run %d: %v", run.DatabaseID, missingErr)))
-					}
-				}
-				result.MissingTools = missingTools
+				analyzeRunArtifacts(&result, verbose)
 			}
 
 			return result
@@ -499,14 +712,14 @@ func downloadRunArtifactsConcurrent(runs []WorkflowRun, outputDir string, verbos
 				successCount++
 			}
 		}
-		fmt.Println(console.FormatSuccessMessage(fmt.Sprintf("Completed parallel processing: %d successful, %d total", successCount, len(results))))
+		fmt.Fprintln(os.Stderr, console.FormatSuccessMessage(fmt.Sprintf("Completed parallel processing: %d successful, %d total", successCount, len(results))))
 	}
 
 	return results
 }
 
 // listWorkflowRunsWithPagination fetches workflow runs from GitHub with pagination support
-func listWorkflowRunsWithPagination(workflowName string, count int, startDate, endDate, beforeDate string, verbose bool) ([]WorkflowRun, error) {
+func listWorkflowRunsWithPagination(workflowName string, count int, startDate, endDate, beforeDate string, filter LogsFilter, verbose bool) ([]WorkflowRun, error) {
 	args := []string{"run", "list", "--json", "databaseId,number,url,status,conclusion,workflowName,createdAt,startedAt,updatedAt,event,headBranch,headSha,displayTitle"}
 
 	// Add filters
@@ -526,9 +739,10 @@ func listWorkflowRunsWithPagination(workflowName string, count int, startDate, e
 	if beforeDate != "" {
 		args = append(args, "--created", "<"+beforeDate)
 	}
+	args = append(args, filter.ghRunListArgs()...)
 
 	if verbose {
-		fmt.Println(console.FormatInfoMessage(fmt.Sprintf("Executing: gh %s", strings.Join(args, " "))))
+		fmt.Fprintln(os.Stderr, console.FormatInfoMessage(fmt.Sprintf("Executing: gh %s", strings.Join(args, " "))))
 	}
 
 	// Start spinner for network operation
@@ -550,7 +764,7 @@ func listWorkflowRunsWithPagination(workflowName string, count int, startDate, e
 		outputMsg := string(output)
 		combinedMsg := errMsg + " " + outputMsg
 		if verbose {
-			fmt.Println(console.FormatVerboseMessage(outputMsg))
+			fmt.Fprintln(os.Stderr, console.FormatVerboseMessage(outputMsg))
 		}
 		if strings.Contains(combinedMsg, "exit status 4") ||
 			strings.Contains(combinedMsg, "exit status 1") ||
@@ -595,12 +809,45 @@ func listWorkflowRunsWithPagination(workflowName string, count int, startDate, e
 	return agenticRuns, nil
 }
 
+// analyzeRunArtifacts extracts the metrics, access log analysis and missing tools of a run
+// from its downloaded artifacts
+func analyzeRunArtifacts(result *DownloadResult, verbose bool) {
+	// Extract metrics from logs
+	metrics, metricsErr := extractLogMetrics(result.LogsPath, verbose)
+	if metricsErr != nil {
+		if verbose {
+			fmt.Fprintln(os.Stderr, console.FormatWarningMessage(fmt.Sprintf("Failed to extract metrics for run %d: %v", result.Run.DatabaseID, metricsErr)))
+		}
+		// Don't fail the whole download for metrics errors
+		metrics = LogMetrics{}
+	}
+	result.Metrics = metrics
+
+	// Analyze access logs if available
+	accessAnalysis, accessErr := analyzeAccessLogs(result.LogsPath, verbose)
+	if accessErr != nil {
+		if verbose {
+			fmt.Fprintln(os.Stderr, console.FormatWarningMessage(fmt.Sprintf("Failed to analyze access logs for run %d: %v", result.Run.DatabaseID, accessErr)))
+		}
+	}
+	result.AccessAnalysis = accessAnalysis
+
+	// Extract missing tools if available
+	missingTools, missingErr := extractMissingToolsFromRun(result.LogsPath, result.Run, verbose)
+	if missingErr != nil {
+		if verbose {
+			fmt.Fprintln(os.Stderr, console.FormatWarningMessage(fmt.Sprintf("Failed to extract missing tools for run %d: %v", result.Run.DatabaseID, missingErr)))
+		}
+	}
+	result.MissingTools = missingTools
+}
+
 // downloadRunArtifacts downloads artifacts for a specific workflow run
 func downloadRunArtifacts(runID int64, outputDir string, verbose bool) error {
 	// Check if artifacts already exist on disk (since they're immutable)
 	if dirExists(outputDir) && !isDirEmpty(outputDir) {
 		if verbose {
-			fmt.Println(console.FormatInfoMessage(fmt.Sprintf("Artifacts for run %d already exist at %s, skipping download", runID, outputDir)))
+			fmt.Fprintln(os.Stderr, console.FormatInfoMessage(fmt.Sprintf("Artifacts for run %d already exist at %s, skipping download", runID, outputDir)))
 		}
 		return nil
 	}
@@ -612,7 +859,7 @@ func downloadRunArtifacts(runID int64, outputDir string, verbose bool) error {
 	args := []string{"run", "download", strconv.FormatInt(runID, 10), "--dir", outputDir}
 
 	if verbose {
-		fmt.Println(console.FormatInfoMessage(fmt.Sprintf("Executing: gh %s", strings.Join(args, " "))))
+		fmt.Fprintln(os.Stderr, console.FormatInfoMessage(fmt.Sprintf("Executing: gh %s", strings.Join(args, " "))))
 	}
 
 	// Start spinner for network operation
@@ -630,7 +877,7 @@ func downloadRunArtifacts(runID int64, outputDir string, verbose bool) error {
 	}
 	if err != nil {
 		if verbose {
-			fmt.Println(console.FormatVerboseMessage(string(output)))
+			fmt.Fprintln(os.Stderr, console.FormatVerboseMessage(string(output)))
 		}
 
 		// Check if it's because there are no artifacts
@@ -647,7 +894,7 @@ func downloadRunArtifacts(runID int64, outputDir string, verbose bool) error {
 	}
 
 	if verbose {
-		fmt.Println(console.FormatSuccessMessage(fmt.Sprintf("Downloaded artifacts for run %d to %s", runID, outputDir)))
+		fmt.Fprintln(os.Stderr, console.FormatSuccessMessage(fmt.Sprintf("Downloaded artifacts for run %d to %s", runID, outputDir)))
 	}
 
 	return nil
@@ -665,11 +912,14 @@ func extractLogMetrics(logDir string, verbose bool) (LogMetrics, error) {
 		if engine := extractEngineFromAwInfo(infoFilePath, verbose); engine != nil {
 			detectedEngine = engine
 			if verbose {
-				fmt.Println(console.FormatInfoMessage(fmt.Sprintf("Detected engine from aw_info.json: %s", engine.GetID())))
+				fmt.Fprintln(os.Stderr, console.FormatInfoMessage(fmt.Sprintf("Detected engine from aw_info.json: %s", engine.GetID())))
 			}
 		}
 	}
 
+	// Engine matrix runs upload one aw_info.json per leg, e.g. aw_info.json-codex
+	legEngines := extractMatrixLegEngines(logDir, verbose)
+
 	// Check for safe_output.jsonl artifact file
 	awOutputPath := filepath.Join(logDir, "safe_output.jsonl")
 	if _, err := os.Stat(awOutputPath); err == nil {
@@ -677,7 +927,7 @@ func extractLogMetrics(logDir string, verbose bool) (LogMetrics, error) {
 			// Report that the agentic output file was found
 			fileInfo, statErr := os.Stat(awOutputPath)
 			if statErr == nil {
-				fmt.Println(console.FormatInfoMessage(fmt.Sprintf("Found agentic output file: safe_output.jsonl (%s)", formatFileSize(fileInfo.Size()))))
+				fmt.Fprintln(os.Stderr, console.FormatInfoMessage(fmt.Sprintf("Found agentic output file: safe_output.jsonl (%s)", formatFileSize(fileInfo.Size()))))
 			}
 		}
 	}
@@ -689,7 +939,7 @@ func extractLogMetrics(logDir string, verbose bool) (LogMetrics, error) {
 			// Report that the git patch file was found
 			fileInfo, statErr := os.Stat(awPatchPath)
 			if statErr == nil {
-				fmt.Println(console.FormatInfoMessage(fmt.Sprintf("Found git patch file: aw.patch (%s)", formatFileSize(fileInfo.Size()))))
+				fmt.Fprintln(os.Stderr, console.FormatInfoMessage(fmt.Sprintf("Found git patch file: aw.patch (%s)", formatFileSize(fileInfo.Size()))))
 			}
 		}
 	}
@@ -700,7 +950,7 @@ func extractLogMetrics(logDir string, verbose bool) (LogMetrics, error) {
 		if verbose {
 			fileInfo, statErr := os.Stat(agentOutputPath)
 			if statErr == nil {
-				fmt.Println(console.FormatInfoMessage(fmt.Sprintf("Found agent output file: %s (%s)", filepath.Base(agentOutputPath), formatFileSize(fileInfo.Size()))))
+				fmt.Fprintln(os.Stderr, console.FormatInfoMessage(fmt.Sprintf("Found agent output file: %s (%s)", filepath.Base(agentOutputPath), formatFileSize(fileInfo.Size()))))
 			}
 		}
 		// If the file is not already in the logDir root, copy it for convenience
@@ -708,7 +958,7 @@ func extractLogMetrics(logDir string, verbose bool) (LogMetrics, error) {
 			rootCopy := filepath.Join(logDir, "agent_output.json")
 			if _, err := os.Stat(rootCopy); errors.Is(err, os.ErrNotExist) {
 				if copyErr := copyFileSimple(agentOutputPath, rootCopy); copyErr == nil && verbose {
-					fmt.Println(console.FormatInfoMessage("Copied agent_output.json to run root for easy access"))
+					fmt.Fprintln(os.Stderr, console.FormatInfoMessage("Copied agent_output.json to run root for easy access"))
 				}
 			}
 		}
@@ -726,21 +976,21 @@ func extractLogMetrics(logDir string, verbose bool) (LogMetrics, error) {
 		}
 
 		// Process log files
-		if strings.HasSuffix(strings.ToLower(info.Name()), ".log") ||
-			strings.HasSuffix(strings.ToLower(info.Name()), ".txt") ||
-			strings.Contains(strings.ToLower(info.Name()), "log") {
+		if isLogFile(info.Name()) {
+
+			fileEngine := detectedEngine
+			if legEngine := matrixLegEngineForFile(logDir, path, legEngines); legEngine != nil {
+				fileEngine = legEngine
+			}
 
-			fileMetrics, err := parseLogFileWithEngine(path, detectedEngine, verbose)
+			fileMetrics, err := parseLogFileWithEngine(path, fileEngine, verbose)
 			if err != nil && verbose {
-				fmt.Println(console.FormatWarningMessage(fmt.Sprintf("Failed to parse log file %s: %v", path, err)))
+				fmt.Fprintln(os.Stderr, console.FormatWarningMessage(fmt.Sprintf("Failed to parse log file %s: %v", path, err)))
 				return nil // Continue processing other files
 			}
 
 			// Aggregate metrics
-			metrics.TokenUsage += fileMetrics.TokenUsage
-			metrics.EstimatedCost += fileMetrics.EstimatedCost
-			metrics.ErrorCount += fileMetrics.ErrorCount
-			metrics.WarningCount += fileMetrics.WarningCount
+			metrics.Add(fileMetrics)
 		}
 
 		return nil
@@ -749,6 +999,97 @@ func extractLogMetrics(logDir string, verbose bool) (LogMetrics, error) {
 	return metrics, err
 }
 
+// nonLogArtifacts lists the text artifacts that are uploaded next to the agent logs but are
+// not written by the agent, so their content must not be parsed as log output
+var nonLogArtifacts = map[string]bool{
+	"prompt.txt":            true,
+	"workflow-complete.txt": true,
+}
+
+// isLogFile reports whether a downloaded artifact file may hold agent logs
+func isLogFile(name string) bool {
+	lowerName := strings.ToLower(name)
+	if nonLogArtifacts[lowerName] {
+		return false
+	}
+	return strings.HasSuffix(lowerName, ".log") ||
+		strings.HasSuffix(lowerName, ".txt") ||
+		strings.Contains(lowerName, "log")
+}
+
+// walkAgentLogs calls fn with the content and engine of each agent log file in the log
+// directory, using the engine of the matrix leg that uploaded the file when there is one
+func walkAgentLogs(logDir string, verbose bool, fn func(path string, engine workflow.CodingAgentEngine, content string)) error {
+	detectedEngine := extractEngineFromAwInfo(filepath.Join(logDir, "aw_info.json"), false)
+	legEngines := extractMatrixLegEngines(logDir, verbose)
+
+	return filepath.Walk(logDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !isLogFile(info.Name()) {
+			return nil
+		}
+
+		engine := detectedEngine
+		if legEngine := matrixLegEngineForFile(logDir, path, legEngines); legEngine != nil {
+			engine = legEngine
+		}
+		if engine == nil {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			if verbose {
+				fmt.Fprintln(os.Stderr, console.FormatWarningMessage(fmt.Sprintf("Failed to read log file %s: %v", path, err)))
+			}
+			return nil
+		}
+		fn(path, engine, string(content))
+		return nil
+	})
+}
+
+// extractMatrixLegEngines returns the engine of each engine matrix leg in the log directory,
+// keyed by leg name. Legs upload their artifacts with a "-<leg>" suffix.
+func extractMatrixLegEngines(logDir string, verbose bool) map[string]workflow.CodingAgentEngine {
+	matches, err := filepath.Glob(filepath.Join(logDir, "aw_info.json-*"))
+	if err != nil || len(matches) == 0 {
+		return nil
+	}
+
+	legEngines := make(map[string]workflow.CodingAgentEngine)
+	for _, match := range matches {
+		leg := strings.TrimPrefix(filepath.Base(match), "aw_info.json-")
+		if engine := extractEngineFromAwInfo(match, verbose); engine != nil {
+			legEngines[leg] = engine
+			if verbose {
+				fmt.Fprintln(os.Stderr, console.FormatInfoMessage(fmt.Sprintf("Detected engine for matrix leg %s: %s", leg, engine.GetID())))
+			}
+		}
+	}
+	return legEngines
+}
+
+// matrixLegEngineForFile returns the engine of the matrix leg whose artifact contains the file, or nil
+func matrixLegEngineForFile(logDir, path string, legEngines map[string]workflow.CodingAgentEngine) workflow.CodingAgentEngine {
+	if len(legEngines) == 0 {
+		return nil
+	}
+	rel, err := filepath.Rel(logDir, path)
+	if err != nil {
+		return nil
+	}
+	artifact := strings.Split(filepath.ToSlash(rel), "/")[0]
+	for leg, engine := range legEngines {
+		if strings.HasSuffix(artifact, "-"+leg) {
+			return engine
+		}
+	}
+	return nil
+}
+
 // extractEngineFromAwInfo reads aw_info.json and returns the appropriate engine
 // Handles cases where aw_info.json is a file or a directory containing the actual file
 func extractEngineFromAwInfo(infoFilePath string, verbose bool) workflow.CodingAgentEngine {
@@ -759,7 +1100,7 @@ func extractEngineFromAwInfo(infoFilePath string, verbose bool) workflow.CodingA
 	stat, statErr := os.Stat(infoFilePath)
 	if statErr != nil {
 		if verbose {
-			fmt.Println(console.FormatWarningMessage(fmt.Sprintf("Failed to stat aw_info.json: %v", statErr)))
+			fmt.Fprintln(os.Stderr, console.FormatWarningMessage(fmt.Sprintf("Failed to stat aw_info.json: %v", statErr)))
 		}
 		return nil
 	}
@@ -768,7 +1109,7 @@ func extractEngineFromAwInfo(infoFilePath string, verbose bool) workflow.CodingA
 		// It's a directory - look for nested aw_info.json
 		nestedPath := filepath.Join(infoFilePath, "aw_info.json")
 		if verbose {
-			fmt.Println(console.FormatInfoMessage(fmt.Sprintf("aw_info.json is a directory, trying nested file: %s", nestedPath)))
+			fmt.Fprintln(os.Stderr, console.FormatInfoMessage(fmt.Sprintf("aw_info.json is a directory, trying nested file: %s", nestedPath)))
 		}
 		data, err = os.ReadFile(nestedPath)
 	} else {
@@ -778,7 +1119,7 @@ func extractEngineFromAwInfo(infoFilePath string, verbose bool) workflow.CodingA
 
 	if err != nil {
 		if verbose {
-			fmt.Println(console.FormatWarningMessage(fmt.Sprintf("Failed to read aw_info.json: %v", err)))
+			fmt.Fprintln(os.Stderr, console.FormatWarningMessage(fmt.Sprintf("Failed to read aw_info.json: %v", err)))
 		}
 		return nil
 	}
@@ -786,7 +1127,7 @@ func extractEngineFromAwInfo(infoFilePath string, verbose bool) workflow.CodingA
 	var info map[string]interface{}
 	if err := json.Unmarshal(data, &info); err != nil {
 		if verbose {
-			fmt.Println(console.FormatWarningMessage(fmt.Sprintf("Failed to parse aw_info.json: %v", err)))
+			fmt.Fprintln(os.Stderr, console.FormatWarningMessage(fmt.Sprintf("Failed to parse aw_info.json: %v", err)))
 		}
 		return nil
 	}
@@ -794,16 +1135,16 @@ func extractEngineFromAwInfo(infoFilePath string, verbose bool) workflow.CodingA
 	engineID, ok := info["engine_id"].(string)
 	if !ok || engineID == "" {
 		if verbose {
-			fmt.Println(console.FormatWarningMessage("No engine_id found in aw_info.json"))
+			fmt.Fprintln(os.Stderr, console.FormatWarningMessage("No engine_id found in aw_info.json"))
 		}
 		return nil
 	}
 
-	registry := workflow.GetGlobalEngineRegistry()
+	registry := RepositoryEngineRegistry()
 	engine, err := registry.GetEngine(engineID)
 	if err != nil {
 		if verbose {
-			fmt.Println(console.FormatWarningMessage(fmt.Sprintf("Unknown engine in aw_info.json: %s", engineID)))
+			fmt.Fprintln(os.Stderr, console.FormatWarningMessage(fmt.Sprintf("Unknown engine in aw_info.json: %s", engineID)))
 		}
 		return nil
 	}
@@ -842,7 +1183,7 @@ func parseLogFileWithEngine(filePath string, detectedEngine workflow.CodingAgent
 
 	// No aw_info.json metadata available - return empty metrics
 	if verbose {
-		fmt.Println(console.FormatWarningMessage("No aw_info.json found, unable to parse engine-specific metrics"))
+		fmt.Fprintln(os.Stderr, console.FormatWarningMessage("No aw_info.json found, unable to parse engine-specific metrics"))
 	}
 	return LogMetrics{}, nil
 }
@@ -857,13 +1198,23 @@ func displayLogsOverview(runs []WorkflowRun) {
 		return
 	}
 
+	fmt.Print(console.RenderTable(buildLogsOverviewTable(runs)))
+}
+
+// buildLogsOverviewTable builds the summary table of workflow runs and metrics, with a total row
+func buildLogsOverviewTable(runs []WorkflowRun) console.TableConfig {
 	// Prepare table data
-	headers := []string{"Run ID", "Workflow", "Status", "Duration", "Tokens", "Cost ($)", "Created", "Logs Path"}
+	headers := []string{"Run ID", "Workflow", "Status", "Duration", "Turns", "Tokens", "Cost ($)", "Tool Calls", "Changes", "Safe Outputs", "Stop", "Cause", "Created", "Logs Path"}
 	var rows [][]string
 
 	var totalTokens int
 	var totalCost float64
 	var totalDuration time.Duration
+	var totalTurns int
+	var totalToolCalls int
+	var totalToolFailures int
+	var totalFiles, totalAdded, totalRemoved int
+	var totalAccepted, totalRejected int
 
 	for _, run := range runs {
 		// Format duration
@@ -875,7 +1226,9 @@ func displayLogsOverview(runs []WorkflowRun) {
 
 		// Format cost
 		costStr := "N/A"
-		if run.EstimatedCost > 0 {
+		if run.CostUnknown {
+			costStr = "unknown"
+		} else if run.EstimatedCost > 0 {
 			costStr = fmt.Sprintf("%.3f", run.EstimatedCost)
 			totalCost += run.EstimatedCost
 		}
@@ -887,6 +1240,33 @@ func displayLogsOverview(runs []WorkflowRun) {
 			totalTokens += run.TokenUsage
 		}
 
+		// Format turns
+		turnsStr := "N/A"
+		if run.Turns > 0 {
+			turnsStr = fmt.Sprintf("%d", run.Turns)
+			totalTurns += run.Turns
+		}
+
+		// Format tool calls with their failures
+		toolCallsStr := formatToolCalls(run.ToolCalls, run.ToolFailures)
+		totalToolCalls += run.ToolCalls
+		totalToolFailures += run.ToolFailures
+
+		// Format patch changes and safe outputs
+		changesStr := formatPatchChanges(run.FilesChanged, run.LinesAdded, run.LinesRemoved)
+		totalFiles += run.FilesChanged
+		totalAdded += run.LinesAdded
+		totalRemoved += run.LinesRemoved
+		accepted, rejected := sumSafeOutputCounts(run.SafeOutputs)
+		safeOutputsStr := formatSafeOutputTotals(accepted, rejected)
+		totalAccepted += accepted
+		totalRejected += rejected
+
+		stopReason := run.StopReason
+		if stopReason == "" {
+			stopReason = "N/A"
+		}
+
 		// Truncate workflow name if too long
 		workflowName := run.WorkflowName
 		if len(workflowName) > 20 {
@@ -901,8 +1281,14 @@ func displayLogsOverview(runs []WorkflowRun) {
 			workflowName,
 			run.Status,
 			durationStr,
+			turnsStr,
 			tokensStr,
 			costStr,
+			toolCallsStr,
+			changesStr,
+			safeOutputsStr,
+			stopReason,
+			formatFailureCauses(run.FailureCauses),
 			run.CreatedAt.Format("2006-01-02"),
 			relPath,
 		}
@@ -915,22 +1301,97 @@ func displayLogsOverview(runs []WorkflowRun) {
 		"",
 		"",
 		formatDuration(totalDuration),
+		fmt.Sprintf("%d", totalTurns),
 		formatNumber(totalTokens),
 		fmt.Sprintf("%.3f", totalCost),
+		formatToolCalls(totalToolCalls, totalToolFailures),
+		formatPatchChanges(totalFiles, totalAdded, totalRemoved),
+		formatSafeOutputTotals(totalAccepted, totalRejected),
+		"",
+		"",
 		"",
 		"",
 	}
 
-	// Render table using console helper
-	tableConfig := console.TableConfig{
+	return console.TableConfig{
 		Title:     "Workflow Logs Overview",
 		Headers:   headers,
 		Rows:      rows,
 		ShowTotal: true,
 		TotalRow:  totalRow,
 	}
+}
 
-	fmt.Print(console.RenderTable(tableConfig))
+// formatToolCalls formats a tool call count with its failures, e.g. "42 (3 failed)"
+func formatToolCalls(calls, failures int) string {
+	if calls == 0 {
+		return "N/A"
+	}
+	if failures == 0 {
+		return fmt.Sprintf("%d", calls)
+	}
+	return fmt.Sprintf("%d (%d failed)", calls, failures)
+}
+
+// displayToolUsageAnalysis displays the tool calls of all runs, to show which tools
+// the agents actually use
+func displayToolUsageAnalysis(processedRuns []ProcessedRun) {
+	if tableConfig, ok := buildToolUsageTable(processedRuns); ok {
+		fmt.Print(console.RenderTable(tableConfig))
+	}
+}
+
+// buildToolUsageTable builds the table of the tool calls of all runs, most called first. It
+// returns false when the runs called no tools.
+func buildToolUsageTable(processedRuns []ProcessedRun) (console.TableConfig, bool) {
+	var total LogMetrics
+	toolRuns := make(map[string]int)
+	for _, pr := range processedRuns {
+		total.Add(LogMetrics{ToolCalls: pr.Metrics.ToolCalls})
+		for name := range pr.Metrics.ToolCalls {
+			toolRuns[name]++
+		}
+	}
+
+	if len(total.ToolCalls) == 0 {
+		return console.TableConfig{}, false
+	}
+
+	// Sort by calls (descending), then by name
+	names := make([]string, 0, len(total.ToolCalls))
+	for name := range total.ToolCalls {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		ci, cj := total.ToolCalls[names[i]].Calls, total.ToolCalls[names[j]].Calls
+		if ci != cj {
+			return ci > cj
+		}
+		return names[i] < names[j]
+	})
+
+	headers := []string{"Tool", "Calls", "Failures", "Runs", "Latency"}
+	var rows [][]string
+	for _, name := range names {
+		tool := total.ToolCalls[name]
+		latency := "N/A"
+		if tool.Duration > 0 {
+			latency = tool.Duration.Round(time.Millisecond).String()
+		}
+		rows = append(rows, []string{
+			name,
+			fmt.Sprintf("%d", tool.Calls),
+			fmt.Sprintf("%d", tool.Failures),
+			fmt.Sprintf("%d/%d", toolRuns[name], len(processedRuns)),
+			latency,
+		})
+	}
+
+	return console.TableConfig{
+		Title:   "Tool Usage",
+		Headers: headers,
+		Rows:    rows,
+	}, true
 }
 
 // formatDuration formats a duration in a human-readable way
@@ -1077,7 +1538,7 @@ func getAgenticWorkflowNames(verbose bool) ([]string, error) {
 	workflowsDir := ".github/workflows"
 	if _, err := os.Stat(workflowsDir); os.IsNotExist(err) {
 		if verbose {
-			fmt.Println(console.FormatWarningMessage("No .github/workflows directory found"))
+			fmt.Fprintln(os.Stderr, console.FormatWarningMessage("No .github/workflows directory found"))
 		}
 		return workflowNames, nil
 	}
@@ -1089,13 +1550,13 @@ func getAgenticWorkflowNames(verbose bool) ([]string, error) {
 
 	for _, file := range files {
 		if verbose {
-			fmt.Println(console.FormatInfoMessage(fmt.Sprintf("Reading workflow file: %s", file)))
+			fmt.Fprintln(os.Stderr, console.FormatInfoMessage(fmt.Sprintf("Reading workflow file: %s", file)))
 		}
 
 		content, err := os.ReadFile(file)
 		if err != nil {
 			if verbose {
-				fmt.Println(console.FormatWarningMessage(fmt.Sprintf("Failed to read %s: %v", file, err)))
+				fmt.Fprintln(os.Stderr, console.FormatWarningMessage(fmt.Sprintf("Failed to read %s: %v", file, err)))
 			}
 			continue
 		}
@@ -1114,7 +1575,7 @@ func getAgenticWorkflowNames(verbose bool) ([]string, error) {
 					if name != "" {
 						workflowNames = append(workflowNames, name)
 						if verbose {
-							fmt.Println(console.FormatInfoMessage(fmt.Sprintf("Found agentic workflow: %s", name)))
+							fmt.Fprintln(os.Stderr, console.FormatInfoMessage(fmt.Sprintf("Found agentic workflow: %s", name)))
 						}
 						break
 					}
@@ -1124,7 +1585,7 @@ func getAgenticWorkflowNames(verbose bool) ([]string, error) {
 	}
 
 	if verbose {
-		fmt.Println(console.FormatInfoMessage(fmt.Sprintf("Found %d agentic workflows", len(workflowNames))))
+		fmt.Fprintln(os.Stderr, console.FormatInfoMessage(fmt.Sprintf("Found %d agentic workflows", len(workflowNames))))
 	}
 
 	return workflowNames, nil
@@ -1152,7 +1613,7 @@ func extractMissingToolsFromRun(runDir string, run WorkflowRun, verbose bool) ([
 		content, readErr := os.ReadFile(agentOutputPath)
 		if readErr != nil {
 			if verbose {
-				fmt.Println(console.FormatWarningMessage(fmt.Sprintf("Failed to read safe output file %s: %v", agentOutputPath, readErr)))
+				fmt.Fprintln(os.Stderr, console.FormatWarningMessage(fmt.Sprintf("Failed to read safe output file %s: %v", agentOutputPath, readErr)))
 			}
 			return missingTools, nil // Continue processing without this file
 		}
@@ -1165,7 +1626,7 @@ func extractMissingToolsFromRun(runDir string, run WorkflowRun, verbose bool) ([
 
 		if err := json.Unmarshal(content, &safeOutput); err != nil {
 			if verbose {
-				fmt.Println(console.FormatWarningMessage(fmt.Sprintf("Failed to parse safe output JSON from %s: %v", agentOutputPath, err)))
+				fmt.Fprintln(os.Stderr, console.FormatWarningMessage(fmt.Sprintf("Failed to parse safe output JSON from %s: %v", agentOutputPath, err)))
 			}
 			return missingTools, nil // Continue processing without this file
 		}
@@ -1182,7 +1643,7 @@ func extractMissingToolsFromRun(runDir string, run WorkflowRun, verbose bool) ([
 
 			if err := json.Unmarshal(itemRaw, &item); err != nil {
 				if verbose {
-					fmt.Println(console.FormatWarningMessage(fmt.Sprintf("Failed to parse item from safe output: %v", err)))
+					fmt.Fprintln(os.Stderr, console.FormatWarningMessage(fmt.Sprintf("Failed to parse item from safe output: %v", err)))
 				}
 				continue // Skip malformed items
 			}
@@ -1200,17 +1661,17 @@ func extractMissingToolsFromRun(runDir string, run WorkflowRun, verbose bool) ([
 				missingTools = append(missingTools, missingTool)
 
 				if verbose {
-					fmt.Println(console.FormatInfoMessage(fmt.Sprintf("Found missing-tool entry: %s (%s)", item.Tool, item.Reason)))
+					fmt.Fprintln(os.Stderr, console.FormatInfoMessage(fmt.Sprintf("Found missing-tool entry: %s (%s)", item.Tool, item.Reason)))
 				}
 			}
 		}
 
 		if verbose && len(missingTools) > 0 {
-			fmt.Println(console.FormatInfoMessage(fmt.Sprintf("Found %d missing tool reports in safe output artifact for run %d", len(missingTools), run.DatabaseID)))
+			fmt.Fprintln(os.Stderr, console.FormatInfoMessage(fmt.Sprintf("Found %d missing tool reports in safe output artifact for run %d", len(missingTools), run.DatabaseID)))
 		}
 	} else {
 		if verbose {
-			fmt.Println(console.FormatInfoMessage(fmt.Sprintf("No safe output artifact found at %s for run %d", agentOutputPath, run.DatabaseID)))
+			fmt.Fprintln(os.Stderr, console.FormatInfoMessage(fmt.Sprintf("No safe output artifact found at %s for run %d", agentOutputPath, run.DatabaseID)))
 		}
 	}
 
@@ -1219,39 +1680,7 @@ func extractMissingToolsFromRun(runDir string, run WorkflowRun, verbose bool) ([
 
 // displayMissingToolsAnalysis displays a summary of missing tools across all runs
 func displayMissingToolsAnalysis(processedRuns []ProcessedRun, verbose bool) {
-	// Aggregate missing tools across all runs
-	toolSummary := make(map[string]*MissingToolSummary)
-	var totalReports int
-
-	for _, pr := range processedRuns {
-		for _, tool := range pr.MissingTools {
-			totalReports++
-			if summary, exists := toolSummary[tool.Tool]; exists {
-				summary.Count++
-				// Add workflow if not already in the list
-				found := false
-				for _, wf := range summary.Workflows {
-					if wf == tool.WorkflowName {
-						found = true
-						break
-					}
-				}
-				if !found {
-					summary.Workflows = append(summary.Workflows, tool.WorkflowName)
-				}
-				summary.RunIDs = append(summary.RunIDs, tool.RunID)
-			} else {
-				toolSummary[tool.Tool] = &MissingToolSummary{
-					Tool:        tool.Tool,
-					Count:       1,
-					Workflows:   []string{tool.WorkflowName},
-					FirstReason: tool.Reason,
-					RunIDs:      []int64{tool.RunID},
-				}
-			}
-		}
-	}
-
+	summaries, totalReports := buildMissingToolSummaries(processedRuns)
 	if totalReports == 0 {
 		return // No missing tools to display
 	}
@@ -1260,17 +1689,6 @@ func displayMissingToolsAnalysis(processedRuns []ProcessedRun, verbose bool) {
 	fmt.Printf("\n%s\n", console.FormatListHeader("🛠️  Missing Tools Summary"))
 	fmt.Printf("%s\n\n", console.FormatListHeader("======================="))
 
-	// Convert map to slice for sorting
-	var summaries []*MissingToolSummary
-	for _, summary := range toolSummary {
-		summaries = append(summaries, summary)
-	}
-
-	// Sort by count (descending)
-	sort.Slice(summaries, func(i, j int) bool {
-		return summaries[i].Count > summaries[j].Count
-	})
-
 	// Display summary table
 	headers := []string{"Tool", "Occurrences", "Workflows", "First Reason"}
 	var rows [][]string
@@ -1302,7 +1720,7 @@ func displayMissingToolsAnalysis(processedRuns []ProcessedRun, verbose bool) {
 	fmt.Print(console.RenderTable(tableConfig))
 
 	// Display total summary
-	uniqueTools := len(toolSummary)
+	uniqueTools := len(summaries)
 	fmt.Printf("\n📊 %s: %d unique missing tools reported %d times across workflows\n",
 		console.FormatCountMessage("Total"),
 		uniqueTools,
@@ -1314,6 +1732,59 @@ func displayMissingToolsAnalysis(processedRuns []ProcessedRun, verbose bool) {
 	}
 }
 
+// buildMissingToolSummaries aggregates the missing tool reports of the runs by tool, most
+// reported first, and returns them with the total number of reports
+func buildMissingToolSummaries(processedRuns []ProcessedRun) ([]*MissingToolSummary, int) {
+	// Aggregate missing tools across all runs
+	toolSummary := make(map[string]*MissingToolSummary)
+	var totalReports int
+
+	for _, pr := range processedRuns {
+		for _, tool := range pr.MissingTools {
+			totalReports++
+			if summary, exists := toolSummary[tool.Tool]; exists {
+				summary.Count++
+				// Add workflow if not already in the list
+				found := false
+				for _, wf := range summary.Workflows {
+					if wf == tool.WorkflowName {
+						found = true
+						break
+					}
+				}
+				if !found {
+					summary.Workflows = append(summary.Workflows, tool.WorkflowName)
+				}
+				summary.RunIDs = append(summary.RunIDs, tool.RunID)
+			} else {
+				toolSummary[tool.Tool] = &MissingToolSummary{
+					Tool:        tool.Tool,
+					Count:       1,
+					Workflows:   []string{tool.WorkflowName},
+					FirstReason: tool.Reason,
+					RunIDs:      []int64{tool.RunID},
+				}
+			}
+		}
+	}
+
+	// Convert map to slice for sorting
+	var summaries []*MissingToolSummary
+	for _, summary := range toolSummary {
+		summaries = append(summaries, summary)
+	}
+
+	// Sort by count (descending), then by tool
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Count != summaries[j].Count {
+			return summaries[i].Count > summaries[j].Count
+		}
+		return summaries[i].Tool < summaries[j].Tool
+	})
+
+	return summaries, totalReports
+}
+
 // displayDetailedMissingToolsBreakdown shows missing tools organized by workflow (verbose mode)
 func displayDetailedMissingToolsBreakdown(processedRuns []ProcessedRun) {
 	fmt.Printf("\n%s\n", console.FormatListHeader("🔍 Detailed Missing Tools Breakdown"))
@@ -0,0 +1,236 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/githubnext/gh-aw/pkg/console"
+	"github.com/githubnext/gh-aw/pkg/workflow"
+)
+
+// Failure causes assigned to failed or degraded runs, in the order they are reported
+const (
+	FailureCauseEngineAuth        = "engine-auth"
+	FailureCauseRateLimit         = "rate-limit"
+	FailureCauseMCPStartup        = "mcp-startup"
+	FailureCauseTimeout           = "timeout"
+	FailureCauseMaxTurns          = "max-turns"
+	FailureCausePermissionDenied  = "permission-denied"
+	FailureCauseNetworkBlocked    = "network-blocked"
+	FailureCauseSafeOutputInvalid = "safe-output-invalid"
+	FailureCausePatchGeneration   = "patch-generation-failed" // The agent job could not generate its git patch
+	FailureCauseUnknown           = "unknown"                 // Failed runs without a recognized cause
+)
+
+// failureCauseOrder ranks the causes, root causes like a missing API key first
+var failureCauseOrder = []string{
+	FailureCauseEngineAuth,
+	FailureCauseRateLimit,
+	FailureCauseMCPStartup,
+	FailureCauseTimeout,
+	FailureCauseMaxTurns,
+	FailureCausePermissionDenied,
+	FailureCauseNetworkBlocked,
+	FailureCauseSafeOutputInvalid,
+	FailureCausePatchGeneration,
+	FailureCauseUnknown,
+}
+
+// FailureCause is a cause of a failed or degraded run with the evidence it was found from
+type FailureCause struct {
+	Cause    string `json:"cause"`
+	Evidence string `json:"evidence,omitempty"`
+}
+
+// failureLogPatterns match the agent log lines giving away a failure cause
+var failureLogPatterns = []struct {
+	cause   string
+	pattern *regexp.Regexp
+}{
+	{FailureCauseEngineAuth, regexp.MustCompile(`(?i)authentication_error|invalid (?:x-)?api[ _-]?key|incorrect api key|401 unauthorized|"status":\s*401\b|could not resolve authentication|(?:ANTHROPIC|OPENAI)_API_KEY (?:is )?(?:not set|missing)`)},
+	{FailureCauseRateLimit, regexp.MustCompile(`(?i)rate_limit_error|rate limit (?:reached|exceeded)|429 too many requests|"status":\s*429\b|exceeded retry limit`)},
+	{FailureCauseMCPStartup, regexp.MustCompile(`(?i)mcp client for .+ failed to start|failed to start mcp server|mcp server .+ failed to start|"name":\s*"[^"]*",\s*"status":\s*"failed"`)},
+	{FailureCauseTimeout, regexp.MustCompile(`(?i)exceeded the maximum execution time`)},
+	{FailureCausePermissionDenied, regexp.MustCompile(`(?i)requested permissions to use .+ but you haven't granted it|permission to use \S+ (?:has been|was) denied|sandbox denied|exec command rejected`)},
+}
+
+// classifyRunFailure returns the causes of a failed or degraded run found in its artifacts,
+// or nil for a healthy run. A failed run without a recognized cause is classified as unknown.
+func classifyRunFailure(run WorkflowRun, accessAnalysis *DomainAnalysis, verbose bool) []FailureCause {
+	found := make(map[string]string)
+	add := func(cause, evidence string) {
+		if _, exists := found[cause]; !exists {
+			found[cause] = evidence
+		}
+	}
+
+	if run.Conclusion == "timed_out" {
+		add(FailureCauseTimeout, "run conclusion timed_out")
+	}
+	if run.StopReason == workflow.StopReasonMaxTurns {
+		add(FailureCauseMaxTurns, fmt.Sprintf("agent stopped after %d turns", run.Turns))
+	}
+	if accessAnalysis != nil && accessAnalysis.DeniedCount > 0 {
+		add(FailureCauseNetworkBlocked, fmt.Sprintf("%d requests denied: %s", accessAnalysis.DeniedCount, strings.Join(accessAnalysis.DeniedDomains, ", ")))
+	}
+
+	if run.LogsPath != "" {
+		accessLogsDir := filepath.Join(run.LogsPath, "access.log")
+		_ = walkAgentLogs(run.LogsPath, verbose, func(path string, engine workflow.CodingAgentEngine, content string) {
+			if strings.HasPrefix(path, accessLogsDir+string(filepath.Separator)) {
+				return
+			}
+			for _, p := range failureLogPatterns {
+				if match := p.pattern.FindString(content); match != "" {
+					add(p.cause, match)
+				}
+			}
+		})
+
+		if validationErrors := readSafeOutputErrors(run.LogsPath, verbose); len(validationErrors) > 0 {
+			add(FailureCauseSafeOutputInvalid, fmt.Sprintf("%d validation errors, first: %s", len(validationErrors), validationErrors[0]))
+		}
+		if content, err := readRunArtifact(run.LogsPath, "aw.patch"); err == nil && strings.Contains(string(content), "Failed to generate patch") {
+			add(FailureCausePatchGeneration, strings.TrimSpace(string(content)))
+		}
+	}
+
+	if len(found) == 0 && isFailedConclusion(run.Conclusion) {
+		add(FailureCauseUnknown, "run conclusion "+run.Conclusion)
+	}
+
+	var causes []FailureCause
+	for _, cause := range failureCauseOrder {
+		if evidence, exists := found[cause]; exists {
+			causes = append(causes, FailureCause{Cause: cause, Evidence: evidence})
+		}
+	}
+	return causes
+}
+
+// readSafeOutputErrors returns the validation errors of the safe outputs in agent_output.json
+func readSafeOutputErrors(runDir string, verbose bool) []string {
+	content, err := readRunArtifact(runDir, "agent_output.json")
+	if err != nil {
+		return nil
+	}
+	var safeOutput struct {
+		Errors []string `json:"errors,omitempty"`
+	}
+	if err := json.Unmarshal(content, &safeOutput); err != nil {
+		if verbose {
+			fmt.Fprintln(os.Stderr, console.FormatWarningMessage(fmt.Sprintf("Failed to parse safe output errors from %s: %v", runDir, err)))
+		}
+		return nil
+	}
+	return safeOutput.Errors
+}
+
+// formatFailureCauses formats the causes of a run for the overview, e.g. "max-turns (+1)"
+func formatFailureCauses(causes []FailureCause) string {
+	switch len(causes) {
+	case 0:
+		return "N/A"
+	case 1:
+		return causes[0].Cause
+	}
+	return fmt.Sprintf("%s (+%d)", causes[0].Cause, len(causes)-1)
+}
+
+// failureCauseSummary aggregates the runs of a failure cause
+type failureCauseSummary struct {
+	Cause     string
+	Failed    int // Runs with a failed conclusion
+	Degraded  int // Other runs
+	Workflows []string
+	RunIDs    []int64
+	Evidence  string // Evidence from the first run
+}
+
+// buildFailureCauseSummaries groups the runs by failure cause, most frequent first
+func buildFailureCauseSummaries(processedRuns []ProcessedRun) []failureCauseSummary {
+	summaries := make(map[string]*failureCauseSummary)
+	for _, pr := range processedRuns {
+		for _, cause := range pr.Run.FailureCauses {
+			summary, exists := summaries[cause.Cause]
+			if !exists {
+				summary = &failureCauseSummary{Cause: cause.Cause, Evidence: cause.Evidence}
+				summaries[cause.Cause] = summary
+			}
+			if isFailedConclusion(pr.Run.Conclusion) {
+				summary.Failed++
+			} else {
+				summary.Degraded++
+			}
+			if !contains(summary.Workflows, pr.Run.WorkflowName) {
+				summary.Workflows = append(summary.Workflows, pr.Run.WorkflowName)
+			}
+			summary.RunIDs = append(summary.RunIDs, pr.Run.DatabaseID)
+		}
+	}
+
+	var result []failureCauseSummary
+	for _, cause := range failureCauseOrder {
+		if summary, exists := summaries[cause]; exists {
+			sort.Strings(summary.Workflows)
+			result = append(result, *summary)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return len(result[i].RunIDs) > len(result[j].RunIDs)
+	})
+	return result
+}
+
+// displayFailureCauseAnalysis displays the runs grouped by failure cause
+func displayFailureCauseAnalysis(processedRuns []ProcessedRun, verbose bool) {
+	summaries := buildFailureCauseSummaries(processedRuns)
+	if len(summaries) == 0 {
+		return
+	}
+
+	fmt.Printf("\n%s\n", console.FormatListHeader("🩺 Failure Causes"))
+	fmt.Printf("%s\n\n", console.FormatListHeader("================"))
+
+	headers := []string{"Cause", "Failed", "Degraded", "Workflows", "Runs", "Evidence"}
+	var rows [][]string
+	for _, summary := range summaries {
+		workflowList := strings.Join(summary.Workflows, ", ")
+		if len(workflowList) > 40 {
+			workflowList = workflowList[:37] + "..."
+		}
+
+		var runIDs []string
+		for _, runID := range summary.RunIDs {
+			runIDs = append(runIDs, fmt.Sprintf("%d", runID))
+		}
+		runList := strings.Join(runIDs, ", ")
+		if !verbose && len(runIDs) > 3 {
+			runList = strings.Join(runIDs[:3], ", ") + fmt.Sprintf(" (+%d)", len(runIDs)-3)
+		}
+
+		evidence := strings.Join(strings.Fields(summary.Evidence), " ")
+		if len(evidence) > 60 && !verbose {
+			evidence = evidence[:57] + "..."
+		}
+
+		rows = append(rows, []string{
+			summary.Cause,
+			fmt.Sprintf("%d", summary.Failed),
+			fmt.Sprintf("%d", summary.Degraded),
+			workflowList,
+			runList,
+			evidence,
+		})
+	}
+
+	fmt.Print(console.RenderTable(console.TableConfig{
+		Headers: headers,
+		Rows:    rows,
+	}))
+}
@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/githubnext/gh-aw/pkg/workflow"
+)
+
+func writeClassifyTestRun(t *testing.T, files map[string]string) string {
+	t.Helper()
+	runDir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(runDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return runDir
+}
+
+func failureCauseNames(causes []FailureCause) string {
+	var names []string
+	for _, cause := range causes {
+		names = append(names, cause.Cause)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestClassifyRunFailure(t *testing.T) {
+	awInfo := `{"engine_id": "claude", "workflow_name": "Triage"}`
+
+	tests := []struct {
+		name     string
+		run      WorkflowRun
+		access   *DomainAnalysis
+		files    map[string]string
+		expected string
+	}{
+		{
+			name:     "healthy run",
+			run:      WorkflowRun{Conclusion: "success", StopReason: workflow.StopReasonCompleted},
+			files:    map[string]string{"aw_info.json": awInfo, "agent-stdio.log": `{"type":"result","subtype":"success"}`},
+			expected: "",
+		},
+		{
+			name:     "failed run without a recognized cause",
+			run:      WorkflowRun{Conclusion: "failure"},
+			files:    map[string]string{"aw_info.json": awInfo},
+			expected: FailureCauseUnknown,
+		},
+		{
+			name:     "max turns and timeout",
+			run:      WorkflowRun{Conclusion: "timed_out", StopReason: workflow.StopReasonMaxTurns, Turns: 20},
+			expected: "timeout,max-turns",
+		},
+		{
+			name:     "engine authentication",
+			run:      WorkflowRun{Conclusion: "failure"},
+			files:    map[string]string{"aw_info.json": awInfo, "agent-stdio.log": `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`},
+			expected: FailureCauseEngineAuth,
+		},
+		{
+			name:     "rate limit",
+			run:      WorkflowRun{Conclusion: "failure"},
+			files:    map[string]string{"aw_info.json": awInfo, "agent-stdio.log": `API Error: 429 {"type":"error","error":{"type":"rate_limit_error"}}`},
+			expected: FailureCauseRateLimit,
+		},
+		{
+			name:     "MCP server failed to start",
+			run:      WorkflowRun{Conclusion: "success"},
+			files:    map[string]string{"aw_info.json": awInfo, "agent-stdio.log": `{"type":"system","subtype":"init","mcp_servers":[{"name":"github","status":"failed"}]}`},
+			expected: FailureCauseMCPStartup,
+		},
+		{
+			name:     "tool permission denied",
+			run:      WorkflowRun{Conclusion: "success"},
+			files:    map[string]string{"aw_info.json": awInfo, "agent-stdio.log": `"content":"Claude requested permissions to use Bash, but you haven't granted it yet."`},
+			expected: FailureCausePermissionDenied,
+		},
+		{
+			name:     "network blocked, ignoring the access logs themselves",
+			run:      WorkflowRun{Conclusion: "success"},
+			access:   &DomainAnalysis{DeniedCount: 2, DeniedDomains: []string{"example.com"}},
+			files:    map[string]string{"aw_info.json": awInfo, "access.log/access-1.log": `1.0 0 10.0.0.1 TCP_DENIED/403 0 CONNECT example.com:443 - HIER_NONE/- - "401 Unauthorized"`},
+			expected: FailureCauseNetworkBlocked,
+		},
+		{
+			name:     "prompt and completion artifacts are not agent logs",
+			run:      WorkflowRun{Conclusion: "success"},
+			files:    map[string]string{"aw_info.json": awInfo, "prompt.txt/prompt.txt": "Investigate the 401 Unauthorized error: authentication_error in the API", "workflow-complete.txt/workflow-complete.txt": "Workflow completed"},
+			expected: "",
+		},
+		{
+			name:     "safe output validation and patch generation failures",
+			run:      WorkflowRun{Conclusion: "failure"},
+			files:    map[string]string{"agent_output.json": `{"items":[],"errors":["Line 1: Missing required 'type' field"]}`, "aw.patch": "Failed to generate patch\n"},
+			expected: "safe-output-invalid,patch-generation-failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run := tt.run
+			if tt.files != nil {
+				run.LogsPath = writeClassifyTestRun(t, tt.files)
+			}
+			causes := classifyRunFailure(run, tt.access, false)
+			if names := failureCauseNames(causes); names != tt.expected {
+				t.Errorf("Expected causes %q, got %q (%+v)", tt.expected, names, causes)
+			}
+			for _, cause := range causes {
+				if cause.Evidence == "" {
+					t.Errorf("Expected evidence for cause %s", cause.Cause)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildFailureCauseSummaries(t *testing.T) {
+	maxTurns := FailureCause{Cause: FailureCauseMaxTurns, Evidence: "agent stopped after 20 turns"}
+	blocked := FailureCause{Cause: FailureCauseNetworkBlocked, Evidence: "1 requests denied: example.com"}
+	processedRuns := []ProcessedRun{
+		{Run: WorkflowRun{DatabaseID: 1, WorkflowName: "Triage", Conclusion: "failure", FailureCauses: []FailureCause{maxTurns}}},
+		{Run: WorkflowRun{DatabaseID: 2, WorkflowName: "Research", Conclusion: "success", FailureCauses: []FailureCause{maxTurns, blocked}}},
+		{Run: WorkflowRun{DatabaseID: 3, WorkflowName: "Triage", Conclusion: "success"}},
+	}
+
+	summaries := buildFailureCauseSummaries(processedRuns)
+	if len(summaries) != 2 {
+		t.Fatalf("Expected 2 causes, got %d", len(summaries))
+	}
+	first := summaries[0]
+	if first.Cause != FailureCauseMaxTurns || first.Failed != 1 || first.Degraded != 1 || strings.Join(first.Workflows, ",") != "Research,Triage" {
+		t.Errorf("Unexpected max-turns summary: %+v", first)
+	}
+	if summaries[1].Cause != FailureCauseNetworkBlocked || len(summaries[1].RunIDs) != 1 {
+		t.Errorf("Unexpected network-blocked summary: %+v", summaries[1])
+	}
+
+	if got := formatFailureCauses([]FailureCause{maxTurns, blocked}); got != "max-turns (+1)" {
+		t.Errorf("Expected \"max-turns (+1)\", got %q", got)
+	}
+	if got := formatFailureCauses(nil); got != "N/A" {
+		t.Errorf("Expected \"N/A\", got %q", got)
+	}
+}
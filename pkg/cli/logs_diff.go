@@ -0,0 +1,647 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/githubnext/gh-aw/pkg/console"
+	"github.com/githubnext/gh-aw/pkg/constants"
+	"github.com/githubnext/gh-aw/pkg/workflow"
+	"github.com/spf13/cobra"
+)
+
+// Output formats of the logs diff --format flag
+const (
+	LogsDiffFormatTerminal = "terminal"
+	LogsDiffFormatMarkdown = "markdown"
+)
+
+// lineDiffContext is the number of unchanged lines shown around changes
+const lineDiffContext = 2
+
+// NewLogsDiffCommand creates the logs diff command
+func NewLogsDiffCommand() *cobra.Command {
+	diffCmd := &cobra.Command{
+		Use:   "diff <run-a> <run-b>",
+		Short: "Compare two workflow runs side by side",
+		Long: `Compare two workflow runs side by side to find out why a workflow regressed.
+
+The artifacts of both runs are downloaded to the output directory, unless they are already
+there, and compared:
+- Engine, model and engine version, from aw_info.json and the agent logs
+- Token usage, cost, turns and tool call deltas
+- The prompt given to the agent
+- The sequence of tool calls of the agent
+- The safe output items in agent_output.json
+- The files changed by aw.patch
+
+Examples:
+  ` + constants.CLIExtensionPrefix + ` logs diff 1234567890 1234567899                    # Compare two runs
+  ` + constants.CLIExtensionPrefix + ` logs diff 1234567890 1234567899 --format markdown  # Markdown for an issue or pull request`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			outputDir, _ := cmd.Flags().GetString("output")
+			format, _ := cmd.Flags().GetString("format")
+			verbose, _ := cmd.Flags().GetBool("verbose")
+
+			var err error
+			var runIDs [2]int64
+			for i, arg := range args {
+				if runIDs[i], err = strconv.ParseInt(arg, 10, 64); err != nil {
+					err = fmt.Errorf("invalid run ID '%s': expected a numeric workflow run ID", arg)
+					break
+				}
+			}
+			if err == nil && format != LogsDiffFormatTerminal && format != LogsDiffFormatMarkdown {
+				err = fmt.Errorf("invalid format value '%s'. Must be one of: %s, %s", format, LogsDiffFormatTerminal, LogsDiffFormatMarkdown)
+			}
+			if err == nil {
+				err = DiffRuns(runIDs[0], runIDs[1], outputDir, format, verbose)
+			}
+			if err != nil {
+				fmt.Fprintln(os.Stderr, console.FormatError(console.CompilerError{
+					Type:    "error",
+					Message: err.Error(),
+				}))
+				os.Exit(1)
+			}
+		},
+	}
+
+	diffCmd.Flags().StringP("output", "o", "./logs", "Output directory for downloaded logs and artifacts")
+	diffCmd.Flags().String("format", LogsDiffFormatTerminal, "Output format (terminal, markdown)")
+
+	return diffCmd
+}
+
+// DiffRuns downloads the artifacts of two workflow runs, unless they are already on disk,
+// and writes their differences to stdout in the given format
+func DiffRuns(runA, runB int64, outputDir, format string, verbose bool) error {
+	sideA, err := loadRunDiffSide(runA, outputDir, verbose)
+	if err != nil {
+		return err
+	}
+	sideB, err := loadRunDiffSide(runB, outputDir, verbose)
+	if err != nil {
+		return err
+	}
+
+	diff := buildRunDiff(sideA, sideB)
+	if format == LogsDiffFormatMarkdown {
+		return writeRunDiffMarkdown(os.Stdout, diff)
+	}
+	return writeRunDiffTerminal(os.Stdout, diff)
+}
+
+// runDiffSide is a run with the artifacts compared by logs diff
+type runDiffSide struct {
+	Run         WorkflowRun
+	Metrics     LogMetrics
+	Info        awInfoRun
+	Prompt      string
+	ToolCalls   []string
+	SafeOutputs []string
+	Patch       string
+}
+
+// loadRunDiffSide downloads the artifacts of a run and loads what logs diff compares
+func loadRunDiffSide(runID int64, outputDir string, verbose bool) (runDiffSide, error) {
+	runDir := filepath.Join(outputDir, fmt.Sprintf("run-%d", runID))
+	if err := downloadRunArtifacts(runID, runDir, verbose); err != nil {
+		return runDiffSide{}, fmt.Errorf("failed to download run %d: %w", runID, err)
+	}
+
+	run, err := loadOfflineRun(runDir, runID)
+	if err != nil && verbose {
+		fmt.Fprintln(os.Stderr, console.FormatWarningMessage(err.Error()))
+	}
+	result := DownloadResult{Run: run, LogsPath: runDir}
+	analyzeRunArtifacts(&result, verbose)
+	processedRun, _ := processDownloadResult(result, "", "", verbose)
+
+	side := runDiffSide{Run: processedRun.Run, Metrics: processedRun.Metrics}
+	if info, err := readAwInfo(runDir); err == nil {
+		side.Info = info
+	}
+	if prompt, err := readRunArtifact(runDir, "prompt.txt"); err == nil {
+		side.Prompt = string(prompt)
+	}
+	if patch, err := readRunArtifact(runDir, "aw.patch"); err == nil {
+		side.Patch = string(patch)
+	}
+	side.SafeOutputs = readSafeOutputItems(runDir, verbose)
+
+	err = walkAgentLogs(runDir, verbose, func(path string, engine workflow.CodingAgentEngine, content string) {
+		trace := engine.ParseLogTrace(content)
+		if trace.IsEmpty() {
+			return
+		}
+		for _, event := range trace.Events {
+			if event.Type == workflow.TraceEventToolCall {
+				side.ToolCalls = append(side.ToolCalls, event.ToolName)
+			}
+		}
+	})
+	return side, err
+}
+
+// readSafeOutputItems summarizes each safe output item of agent_output.json on a single line
+func readSafeOutputItems(runDir string, verbose bool) []string {
+	content, err := readRunArtifact(runDir, "agent_output.json")
+	if err != nil {
+		return nil
+	}
+	var safeOutput struct {
+		Items []map[string]any `json:"items"`
+	}
+	if err := json.Unmarshal(content, &safeOutput); err != nil {
+		if verbose {
+			fmt.Fprintln(os.Stderr, console.FormatWarningMessage(fmt.Sprintf("Failed to parse safe output items from %s: %v", runDir, err)))
+		}
+		return nil
+	}
+
+	var items []string
+	for _, item := range safeOutput.Items {
+		itemType, _ := item["type"].(string)
+		summary := itemType
+		for _, field := range []string{"title", "tool", "branch", "body"} {
+			if value, ok := item[field].(string); ok && strings.TrimSpace(value) != "" {
+				firstLine, _, _ := strings.Cut(strings.TrimSpace(value), "\n")
+				if len(firstLine) > 80 {
+					firstLine = firstLine[:77] + "..."
+				}
+				summary += ": " + firstLine
+				break
+			}
+		}
+		items = append(items, summary)
+	}
+	return items
+}
+
+// runDiffField is a property of both runs
+type runDiffField struct {
+	Name string
+	A, B string
+}
+
+// runDiffMetric is a metric of both runs
+type runDiffMetric struct {
+	Name   string
+	A, B   float64
+	Format func(float64) string
+}
+
+// Delta formats the change of the metric from run A to run B, e.g. "+1,200 (+25%)"
+func (m runDiffMetric) Delta() string {
+	delta := m.B - m.A
+	if delta == 0 {
+		return "="
+	}
+	sign := "+"
+	if delta < 0 {
+		sign = "-"
+	}
+	text := sign + m.Format(math.Abs(delta))
+	if m.A != 0 {
+		text += fmt.Sprintf(" (%s%.0f%%)", sign, math.Abs(delta)/m.A*100)
+	}
+	return text
+}
+
+// patchFileDiff compares the changes of a file in the patches of both runs
+type patchFileDiff struct {
+	Path   string
+	A, B   *patchFileStats // Nil when the patch does not change the file
+	Status string          // only in A, only in B, identical or different
+}
+
+// patchFileStats counts the changed lines of a file in a patch
+type patchFileStats struct {
+	Added, Removed int
+	changes        []string // The added and removed lines, to compare the patches
+}
+
+// runDiff is the comparison of two runs
+type runDiff struct {
+	A, B        runDiffSide
+	Fields      []runDiffField
+	Metrics     []runDiffMetric
+	Prompt      []string
+	ToolCalls   []string
+	SafeOutputs []string
+	PatchFiles  []patchFileDiff
+}
+
+// buildRunDiff compares two runs
+func buildRunDiff(a, b runDiffSide) runDiff {
+	diff := runDiff{A: a, B: b}
+
+	diff.Fields = []runDiffField{
+		{"Workflow", a.Run.WorkflowName, b.Run.WorkflowName},
+		{"Conclusion", a.Run.Conclusion, b.Run.Conclusion},
+		{"Engine", a.Info.EngineID, b.Info.EngineID},
+		{"Engine Version", a.Info.Version, b.Info.Version},
+		{"Configured Model", a.Info.Model, b.Info.Model},
+		{"Model", a.Metrics.Model, b.Metrics.Model},
+		{"Stop Reason", a.Run.StopReason, b.Run.StopReason},
+		{"Failure Causes", formatFailureCauses(a.Run.FailureCauses), formatFailureCauses(b.Run.FailureCauses)},
+		{"Commit", a.Run.HeadSha, b.Run.HeadSha},
+	}
+
+	formatCount := func(v float64) string { return formatNumber(int(v)) }
+	formatCost := func(v float64) string { return fmt.Sprintf("%.3f", v) }
+	formatSeconds := func(v float64) string { return formatDuration(time.Duration(v) * time.Second) }
+	diff.Metrics = []runDiffMetric{
+		{"Duration", a.Run.Duration.Seconds(), b.Run.Duration.Seconds(), formatSeconds},
+		{"Turns", float64(a.Run.Turns), float64(b.Run.Turns), formatCount},
+		{"Tool Calls", float64(a.Run.ToolCalls), float64(b.Run.ToolCalls), formatCount},
+		{"Tool Failures", float64(a.Run.ToolFailures), float64(b.Run.ToolFailures), formatCount},
+		{"Tokens", float64(a.Run.TokenUsage), float64(b.Run.TokenUsage), formatCount},
+		{"Input Tokens", float64(a.Metrics.InputTokens), float64(b.Metrics.InputTokens), formatCount},
+		{"Output Tokens", float64(a.Metrics.OutputTokens), float64(b.Metrics.OutputTokens), formatCount},
+		{"Cache Tokens", float64(a.Metrics.CacheTokens), float64(b.Metrics.CacheTokens), formatCount},
+		{"Cost ($)", a.Run.EstimatedCost, b.Run.EstimatedCost, formatCost},
+		{"Errors", float64(a.Metrics.ErrorCount), float64(b.Metrics.ErrorCount), formatCount},
+		{"Warnings", float64(a.Metrics.WarningCount), float64(b.Metrics.WarningCount), formatCount},
+	}
+
+	diff.Prompt = formatLineDiff(diffLines(splitLines(a.Prompt), splitLines(b.Prompt)), lineDiffContext)
+	diff.ToolCalls = formatLineDiff(diffLines(a.ToolCalls, b.ToolCalls), lineDiffContext)
+	diff.SafeOutputs = formatLineDiff(diffLines(a.SafeOutputs, b.SafeOutputs), lineDiffContext)
+	diff.PatchFiles = diffPatches(a.Patch, b.Patch)
+	return diff
+}
+
+// splitLines splits text into lines, without the trailing newline
+func splitLines(text string) []string {
+	text = strings.TrimRight(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// lineDiffOp is a line of a diff: ' ' when in both sides, '-' when only in A, '+' when only in B
+type lineDiffOp struct {
+	Op   byte
+	Line string
+}
+
+// maxLineDiffCells bounds the size of the longest common subsequence table
+const maxLineDiffCells = 4_000_000
+
+// diffLines computes the line diff from a to b from their longest common subsequence.
+// Inputs too large to compare line by line are reported as replaced.
+func diffLines(a, b []string) []lineDiffOp {
+	var ops []lineDiffOp
+	if len(a)*len(b) > maxLineDiffCells {
+		for _, line := range a {
+			ops = append(ops, lineDiffOp{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, lineDiffOp{'+', line})
+		}
+		return ops
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, lineDiffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, lineDiffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, lineDiffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, lineDiffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, lineDiffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// formatLineDiff formats the changed lines of a diff with unified diff prefixes and the given
+// number of unchanged lines around them, or returns nil when nothing changed
+func formatLineDiff(ops []lineDiffOp, context int) []string {
+	show := make([]bool, len(ops))
+	changed := false
+	for i, op := range ops {
+		if op.Op == ' ' {
+			continue
+		}
+		changed = true
+		for k := max(0, i-context); k <= min(len(ops)-1, i+context); k++ {
+			show[k] = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	var lines []string
+	for i, op := range ops {
+		if !show[i] {
+			if i == 0 || show[i-1] {
+				lines = append(lines, "@@ ... @@")
+			}
+			continue
+		}
+		lines = append(lines, string(op.Op)+op.Line)
+	}
+	return lines
+}
+
+// patchHunkHeaderRegex matches a hunk header, capturing the line counts of the old and new side,
+// which are omitted when they are 1
+var patchHunkHeaderRegex = regexp.MustCompile(`^@@ -\d+(?:,(\d+))? \+\d+(?:,(\d+))? @@`)
+
+// patchCommitHeaderRegex matches the header line starting each commit of git format-patch output
+var patchCommitHeaderRegex = regexp.MustCompile(`^From [0-9a-f]{7,40} `)
+
+// parsePatchFiles counts the changed lines of each file of a git patch. Only the lines of a hunk are
+// counted, up to the line counts in its header, so commit messages and the "-- " signature of
+// git format-patch output are not mistaken for changes.
+func parsePatchFiles(patch string) map[string]*patchFileStats {
+	files := make(map[string]*patchFileStats)
+	var current *patchFileStats
+	oldLines, newLines := 0, 0 // Lines left in the current hunk
+	for _, line := range strings.Split(patch, "\n") {
+		if oldLines > 0 || newLines > 0 {
+			switch {
+			case strings.HasPrefix(line, "+"):
+				newLines--
+				current.Added++
+				current.changes = append(current.changes, line)
+				continue
+			case strings.HasPrefix(line, "-"):
+				oldLines--
+				current.Removed++
+				current.changes = append(current.changes, line)
+				continue
+			case strings.HasPrefix(line, " ") || line == "":
+				oldLines--
+				newLines--
+				continue
+			case strings.HasPrefix(line, "\\"):
+				// "\ No newline at end of file"
+				continue
+			}
+			// The hunk is shorter than its header says
+			oldLines, newLines = 0, 0
+		}
+
+		if rest, ok := strings.CutPrefix(line, "diff --git a/"); ok {
+			path := rest
+			if index := strings.Index(rest, " b/"); index >= 0 {
+				path = rest[index+len(" b/"):]
+			}
+			current = files[path]
+			if current == nil {
+				current = &patchFileStats{}
+				files[path] = current
+			}
+			continue
+		}
+		if patchCommitHeaderRegex.MatchString(line) {
+			current = nil
+			continue
+		}
+		if match := patchHunkHeaderRegex.FindStringSubmatch(line); match != nil && current != nil {
+			oldLines, newLines = parseHunkLineCount(match[1]), parseHunkLineCount(match[2])
+		}
+	}
+	return files
+}
+
+// parseHunkLineCount parses a line count of a hunk header, which defaults to 1 when omitted
+func parseHunkLineCount(count string) int {
+	if count == "" {
+		return 1
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// diffPatches compares the files changed by two git patches, by path
+func diffPatches(a, b string) []patchFileDiff {
+	filesA, filesB := parsePatchFiles(a), parsePatchFiles(b)
+	paths := make(map[string]bool)
+	for path := range filesA {
+		paths[path] = true
+	}
+	for path := range filesB {
+		paths[path] = true
+	}
+
+	var diffs []patchFileDiff
+	for path := range paths {
+		d := patchFileDiff{Path: path, A: filesA[path], B: filesB[path]}
+		switch {
+		case d.B == nil:
+			d.Status = "only in A"
+		case d.A == nil:
+			d.Status = "only in B"
+		case strings.Join(d.A.changes, "\n") == strings.Join(d.B.changes, "\n"):
+			d.Status = "identical"
+		default:
+			d.Status = "different"
+		}
+		diffs = append(diffs, d)
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+	return diffs
+}
+
+// formatPatchFileStats formats the changed lines of a file, e.g. "+12 -3"
+func formatPatchFileStats(stats *patchFileStats) string {
+	if stats == nil {
+		return "-"
+	}
+	return fmt.Sprintf("+%d -%d", stats.Added, stats.Removed)
+}
+
+// runDiffSection is a line diff section of the comparison
+type runDiffSection struct {
+	Title string
+	Lines []string
+	Empty string // Shown when neither run has the compared content
+	HasA  bool
+	HasB  bool
+}
+
+// sections returns the line diff sections of the comparison
+func (d runDiff) sections() []runDiffSection {
+	return []runDiffSection{
+		{"Prompt", d.Prompt, "No prompt.txt artifact in either run", d.A.Prompt != "", d.B.Prompt != ""},
+		{"Tool Calls", d.ToolCalls, "No tool calls in either run", len(d.A.ToolCalls) > 0, len(d.B.ToolCalls) > 0},
+		{"Safe Outputs", d.SafeOutputs, "No safe output items in either run", len(d.A.SafeOutputs) > 0, len(d.B.SafeOutputs) > 0},
+	}
+}
+
+// summary returns the one line result of a section
+func (s runDiffSection) summary() string {
+	switch {
+	case !s.HasA && !s.HasB:
+		return s.Empty
+	case len(s.Lines) == 0:
+		return "Identical"
+	}
+	return ""
+}
+
+// patchRows returns the rows of the patch file comparison
+func (d runDiff) patchRows() [][]string {
+	var rows [][]string
+	for _, file := range d.PatchFiles {
+		rows = append(rows, []string{file.Path, formatPatchFileStats(file.A), formatPatchFileStats(file.B), file.Status})
+	}
+	return rows
+}
+
+// writeRunDiffTerminal writes the comparison as console tables and diffs
+func writeRunDiffTerminal(w io.Writer, d runDiff) error {
+	runA, runB := fmt.Sprintf("Run %d", d.A.Run.DatabaseID), fmt.Sprintf("Run %d", d.B.Run.DatabaseID)
+
+	var fieldRows [][]string
+	for _, field := range d.Fields {
+		marker := ""
+		if field.A != field.B {
+			marker = "≠"
+		}
+		fieldRows = append(fieldRows, []string{field.Name, field.A, field.B, marker})
+	}
+	fmt.Fprint(w, console.RenderTable(console.TableConfig{
+		Title:   "Runs",
+		Headers: []string{"", runA, runB, ""},
+		Rows:    fieldRows,
+	}))
+
+	var metricRows [][]string
+	for _, metric := range d.Metrics {
+		metricRows = append(metricRows, []string{metric.Name, metric.Format(metric.A), metric.Format(metric.B), metric.Delta()})
+	}
+	fmt.Fprint(w, console.RenderTable(console.TableConfig{
+		Title:   "Metrics",
+		Headers: []string{"", runA, runB, "Delta"},
+		Rows:    metricRows,
+	}))
+
+	for _, section := range d.sections() {
+		fmt.Fprintf(w, "\n%s\n", console.FormatListHeader(section.Title))
+		if summary := section.summary(); summary != "" {
+			fmt.Fprintln(w, console.FormatInfoMessage(summary))
+			continue
+		}
+		for _, line := range section.Lines {
+			fmt.Fprintln(w, line)
+		}
+	}
+
+	fmt.Fprintf(w, "\n%s\n", console.FormatListHeader("Patch"))
+	if len(d.PatchFiles) == 0 {
+		fmt.Fprintln(w, console.FormatInfoMessage("No changes in either patch"))
+		return nil
+	}
+	fmt.Fprint(w, console.RenderTable(console.TableConfig{
+		Headers: []string{"File", runA, runB, "Status"},
+		Rows:    d.patchRows(),
+	}))
+	return nil
+}
+
+// writeRunDiffMarkdown writes the comparison as markdown, to paste into an issue or pull request
+func writeRunDiffMarkdown(w io.Writer, d runDiff) error {
+	var b strings.Builder
+	runA, runB := runDiffMarkdownLink(d.A.Run), runDiffMarkdownLink(d.B.Run)
+
+	fmt.Fprintf(&b, "## Run Comparison: %s vs %s\n\n", runA, runB)
+	b.WriteString("| | " + runA + " | " + runB + " |\n|---|---|---|\n")
+	for _, field := range d.Fields {
+		name := field.Name
+		if field.A != field.B {
+			name = "**" + name + "**"
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", name, markdownTableCell(field.A), markdownTableCell(field.B))
+	}
+
+	b.WriteString("\n### Metrics\n\n")
+	b.WriteString("| | " + runA + " | " + runB + " | Delta |\n|---|---:|---:|---:|\n")
+	for _, metric := range d.Metrics {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", metric.Name, metric.Format(metric.A), metric.Format(metric.B), metric.Delta())
+	}
+
+	for _, section := range d.sections() {
+		fmt.Fprintf(&b, "\n### %s\n\n", section.Title)
+		if summary := section.summary(); summary != "" {
+			b.WriteString(summary + "\n")
+			continue
+		}
+		b.WriteString("```diff\n" + strings.Join(section.Lines, "\n") + "\n```\n")
+	}
+
+	b.WriteString("\n### Patch\n\n")
+	if len(d.PatchFiles) == 0 {
+		b.WriteString("No changes in either patch\n")
+	} else {
+		b.WriteString("| File | " + runA + " | " + runB + " | Status |\n|---|---|---|---|\n")
+		for _, row := range d.patchRows() {
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s |\n", row[0], row[1], row[2], row[3])
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// runDiffMarkdownLink formats a run as a markdown link to the run when its URL is known
+func runDiffMarkdownLink(run WorkflowRun) string {
+	if run.URL == "" {
+		return fmt.Sprintf("%d", run.DatabaseID)
+	}
+	return fmt.Sprintf("[%d](%s)", run.DatabaseID, run.URL)
+}
+
+// markdownTableCell escapes a value for a markdown table cell
+func markdownTableCell(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return strings.ReplaceAll(value, "|", "\\|")
+}
@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testFormatPatch is git format-patch output of two commits, with commit message lines starting with
+// - and +, and the "-- " signature ending each commit
+const testFormatPatch = `From 424dd1ec7c3ea6c275f576c05584e84e2066c9ed Mon Sep 17 00:00:00 2001
+From: A <a@b.c>
+Date: Sun, 18 Oct 2026 15:10:12 +0000
+Subject: [PATCH 1/2] Update README
+
+- removed the old wording
++ added the new wording
+---
+ README.md | 2 +-
+ 1 file changed, 1 insertion(+), 1 deletion(-)
+
+diff --git a/README.md b/README.md
+index 0c2aa38..66d7f36 100644
+--- a/README.md
++++ b/README.md
+@@ -1,3 +1,3 @@
+ line one
+-line two
++line 2
+ line three
+-- 
+2.39.5
+
+
+From 0e802e51b08d71da8fa4e69bf0495db3978a9b3e Mon Sep 17 00:00:00 2001
+From: A <a@b.c>
+Date: Sun, 18 Oct 2026 15:10:12 +0000
+Subject: [PATCH 2/2] Add main.go
+
+-- not a signature
++++ not a file header
+---
+ main.go | 1 +
+ 1 file changed, 1 insertion(+)
+ create mode 100644 main.go
+
+diff --git a/main.go b/main.go
+new file mode 100644
+index 0000000..06ab7d0
+--- /dev/null
++++ b/main.go
+@@ -0,0 +1 @@
++package main
+-- 
+2.39.5
+
+`
+
+func TestDiffLines(t *testing.T) {
+	a := []string{"one", "two", "three", "four", "five", "six", "seven"}
+	b := []string{"one", "two", "3", "four", "five", "six", "seven", "eight"}
+
+	lines := formatLineDiff(diffLines(a, b), 1)
+	expected := []string{"@@ ... @@", " two", "-three", "+3", " four", "@@ ... @@", " seven", "+eight"}
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected diff:\n%s\nexpected:\n%s", strings.Join(lines, "\n"), strings.Join(expected, "\n"))
+	}
+
+	if lines := formatLineDiff(diffLines(a, a), 1); lines != nil {
+		t.Errorf("Expected no diff for identical lines, got %v", lines)
+	}
+}
+
+func TestDiffPatches(t *testing.T) {
+	patchA := `diff --git a/README.md b/README.md
+--- a/README.md
++++ b/README.md
+@@ -1 +1 @@
+-old
++new
+diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -1 +1,2 @@
++// comment
+`
+	patchB := `diff --git a/README.md b/README.md
+--- a/README.md
++++ b/README.md
+@@ -1 +1 @@
+-old
++newer
+diff --git a/docs/guide.md b/docs/guide.md
+--- /dev/null
++++ b/docs/guide.md
+@@ -0,0 +1 @@
++guide
+`
+	diffs := diffPatches(patchA, patchB)
+	var got []string
+	for _, d := range diffs {
+		got = append(got, d.Path+" "+formatPatchFileStats(d.A)+" | "+formatPatchFileStats(d.B)+" "+d.Status)
+	}
+	expected := []string{
+		"README.md +1 -1 | +1 -1 different",
+		"docs/guide.md - | +1 -0 only in B",
+		"main.go +1 -0 | - only in A",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected patch comparison:\n%s", strings.Join(got, "\n"))
+	}
+
+	if diffs := diffPatches(patchA, patchA); diffs[0].Status != "identical" {
+		t.Errorf("Expected identical patches, got %+v", diffs[0])
+	}
+}
+
+func TestParsePatchFilesFormatPatch(t *testing.T) {
+	files := parsePatchFiles(testFormatPatch)
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 files, got %d", len(files))
+	}
+	expected := map[string]string{"README.md": "+1 -1", "main.go": "+1 -0"}
+	for path, stats := range expected {
+		if got := formatPatchFileStats(files[path]); got != stats {
+			t.Errorf("Expected %s for %s, got %s", stats, path, got)
+		}
+	}
+	if changes := strings.Join(files["README.md"].changes, "\n"); changes != "-line two\n+line 2" {
+		t.Errorf("Expected only the hunk lines as changes, got:\n%s", changes)
+	}
+}
+
+func TestRunDiffMetricDelta(t *testing.T) {
+	format := func(v float64) string { return formatNumber(int(v)) }
+	tests := []struct {
+		a, b     float64
+		expected string
+	}{
+		{1000, 1250, "+250 (+25%)"},
+		{1000, 500, "-500 (-50%)"},
+		{0, 300, "+300"},
+		{42, 42, "="},
+	}
+	for _, tt := range tests {
+		metric := runDiffMetric{Name: "Tokens", A: tt.a, B: tt.b, Format: format}
+		if delta := metric.Delta(); delta != tt.expected {
+			t.Errorf("Delta from %v to %v = %q, expected %q", tt.a, tt.b, delta, tt.expected)
+		}
+	}
+}
+
+func TestDiffRunsFromDownloadedRuns(t *testing.T) {
+	logsDir := t.TempDir()
+	writeRun := func(runID, model, prompt, toolName string, tokens int) {
+		runDir := filepath.Join(logsDir, "run-"+runID)
+		files := map[string]string{
+			"aw_info.json":          `{"engine_id": "claude", "model": "` + model + `", "workflow_name": "Triage"}`,
+			"prompt.txt/prompt.txt": prompt,
+			"agent_output.json":     `{"items":[{"type":"create-issue","title":"Triage report"}],"errors":[]}`,
+			"agent-stdio.log": `[
+  {"type":"system","subtype":"init","model":"` + model + `"},
+  {"type":"assistant","message":{"content":[{"type":"tool_use","id":"t1","name":"` + toolName + `","input":{}}]}},
+  {"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"t1","content":"ok"}]}},
+  {"type":"result","subtype":"success","num_turns":2,"total_cost_usd":0.01,"usage":{"input_tokens":` + strconv.Itoa(tokens) + `,"output_tokens":10}}
+]`,
+		}
+		for name, content := range files {
+			path := filepath.Join(runDir, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	writeRun("1", "claude-sonnet-4", "Triage the issue.\nLabel it.", "Bash", 100)
+	writeRun("2", "claude-opus-4", "Triage the issue.\nLabel and assign it.", "WebFetch", 200)
+
+	sideA, err := loadRunDiffSide(1, logsDir, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	sideB, err := loadRunDiffSide(2, logsDir, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var output strings.Builder
+	if err := writeRunDiffMarkdown(&output, buildRunDiff(sideA, sideB)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	markdown := output.String()
+
+	for _, expected := range []string{
+		"## Run Comparison: 1 vs 2",
+		"| **Configured Model** | claude-sonnet-4 | claude-opus-4 |",
+		"| Tokens | 110 | 210 | +100 (+91%) |",
+		"-Label it.\n+Label and assign it.",
+		"-Bash\n+WebFetch",
+		"### Safe Outputs\n\nIdentical",
+		"No changes in either patch",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("Expected markdown to contain %q, got:\n%s", expected, markdown)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/githubnext/gh-aw/pkg/console"
+)
+
+// runConclusions are the conclusions a workflow run can have on GitHub Actions
+var runConclusions = []string{"success", "failure", "cancelled", "skipped", "timed_out", "action_required", "neutral", "stale", "startup_failure"}
+
+// LogsFilter selects the runs analyzed by the logs command. The run metadata filters are
+// passed to the GitHub API, the others are applied to the downloaded artifacts of each run.
+type LogsFilter struct {
+	Conclusion      string  // Run conclusion, like failure
+	Event           string  // Event that triggered the run, like pull_request
+	Branch          string  // Head branch of the run
+	Actor           string  // User who triggered the run
+	MinCost         float64 // Minimum estimated cost of the run in USD
+	MinTokens       int     // Minimum token usage of the run
+	HasMissingTools bool    // Runs reporting missing tools
+	HasPatch        bool    // Runs with changes in aw.patch
+	SafeOutputType  string  // Runs with a safe output of this type, like create-pull-request
+}
+
+// Validate checks the filter values and normalizes the safe output type
+func (f *LogsFilter) Validate() error {
+	if f.Conclusion != "" && !contains(runConclusions, f.Conclusion) {
+		return fmt.Errorf("invalid conclusion value '%s'. Must be one of: %s", f.Conclusion, strings.Join(runConclusions, ", "))
+	}
+	if f.MinCost < 0 {
+		return fmt.Errorf("invalid min-cost value %g. Must not be negative", f.MinCost)
+	}
+	if f.MinTokens < 0 {
+		return fmt.Errorf("invalid min-tokens value %d. Must not be negative", f.MinTokens)
+	}
+	f.SafeOutputType = strings.ReplaceAll(f.SafeOutputType, "_", "-")
+	return nil
+}
+
+// ghRunListArgs returns the gh run list flags for the run metadata filters
+func (f LogsFilter) ghRunListArgs() []string {
+	var args []string
+	if f.Conclusion != "" {
+		args = append(args, "--status", f.Conclusion)
+	}
+	if f.Event != "" {
+		args = append(args, "--event", f.Event)
+	}
+	if f.Branch != "" {
+		args = append(args, "--branch", f.Branch)
+	}
+	if f.Actor != "" {
+		args = append(args, "--user", f.Actor)
+	}
+	return args
+}
+
+// matchesRun reports whether the metadata of a run matches the filter, for runs that were not
+// listed through the GitHub API like the ones analyzed offline
+func (f LogsFilter) matchesRun(run WorkflowRun) bool {
+	if f.Conclusion != "" && run.Conclusion != f.Conclusion {
+		return false
+	}
+	if f.Event != "" && run.Event != f.Event {
+		return false
+	}
+	if f.Branch != "" && run.HeadBranch != f.Branch {
+		return false
+	}
+	if f.Actor != "" {
+		// Runs listed through the GitHub API are filtered with --user, offline runs only record
+		// the actor in aw_info.json
+		if info, err := readAwInfo(run.LogsPath); err != nil || !strings.EqualFold(info.Actor, f.Actor) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesProcessedRun reports whether the downloaded artifacts of a run match the filter
+func (f LogsFilter) matchesProcessedRun(pr ProcessedRun, verbose bool) bool {
+	runDir := pr.Run.LogsPath
+	if f.MinCost > 0 && pr.Run.EstimatedCost < f.MinCost {
+		return false
+	}
+	if f.MinTokens > 0 && pr.Run.TokenUsage < f.MinTokens {
+		return false
+	}
+	if f.HasMissingTools && len(pr.MissingTools) == 0 {
+		return false
+	}
+	if f.HasPatch && !hasPatchChanges(runDir) {
+		return false
+	}
+	if f.SafeOutputType != "" && !contains(readSafeOutputTypes(runDir, verbose), f.SafeOutputType) {
+		return false
+	}
+	return true
+}
+
+// hasPatchChanges reports whether the aw.patch of a run holds changes
+func hasPatchChanges(runDir string) bool {
+	content, err := readRunArtifact(runDir, "aw.patch")
+	if err != nil {
+		return false
+	}
+	patch := strings.TrimSpace(string(content))
+	return patch != "" && !strings.Contains(patch, "Failed to generate patch")
+}
+
+// readSafeOutputTypes returns the types of the safe outputs in agent_output.json, like create-issue
+func readSafeOutputTypes(runDir string, verbose bool) []string {
+	content, err := readRunArtifact(runDir, "agent_output.json")
+	if err != nil {
+		return nil
+	}
+	var safeOutput struct {
+		Items []struct {
+			Type string `json:"type"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(content, &safeOutput); err != nil {
+		if verbose {
+			fmt.Fprintln(os.Stderr, console.FormatWarningMessage(fmt.Sprintf("Failed to parse safe output items from %s: %v", runDir, err)))
+		}
+		return nil
+	}
+
+	var types []string
+	for _, item := range safeOutput.Items {
+		if item.Type != "" && !contains(types, item.Type) {
+			types = append(types, item.Type)
+		}
+	}
+	return types
+}
@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLogsFilterValidate(t *testing.T) {
+	filter := LogsFilter{Conclusion: "failure", SafeOutputType: "create_pull_request"}
+	if err := filter.Validate(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if filter.SafeOutputType != "create-pull-request" {
+		t.Errorf("Expected the safe output type to be normalized, got %s", filter.SafeOutputType)
+	}
+
+	for _, invalid := range []LogsFilter{
+		{Conclusion: "failed"},
+		{MinCost: -1},
+		{MinTokens: -10},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("Expected an error for %+v", invalid)
+		}
+	}
+}
+
+func TestLogsFilterGhRunListArgs(t *testing.T) {
+	filter := LogsFilter{Conclusion: "failure", Event: "pull_request", Branch: "main", Actor: "octocat", MinCost: 1}
+	expected := []string{"--status", "failure", "--event", "pull_request", "--branch", "main", "--user", "octocat"}
+	if args := filter.ghRunListArgs(); !reflect.DeepEqual(args, expected) {
+		t.Errorf("Expected %q, got %q", expected, args)
+	}
+	if args := (LogsFilter{HasPatch: true}).ghRunListArgs(); len(args) != 0 {
+		t.Errorf("Expected no gh run list flags for artifact filters, got %q", args)
+	}
+}
+
+func TestLogsFilterMatchesRun(t *testing.T) {
+	runDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(runDir, "aw_info.json"), []byte(`{"actor": "octocat"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run := WorkflowRun{Conclusion: "failure", Event: "pull_request", HeadBranch: "feature", LogsPath: runDir}
+
+	tests := []struct {
+		filter   LogsFilter
+		expected bool
+	}{
+		{LogsFilter{}, true},
+		{LogsFilter{Conclusion: "failure", Event: "pull_request", Branch: "feature"}, true},
+		{LogsFilter{Conclusion: "success"}, false},
+		{LogsFilter{Event: "push"}, false},
+		{LogsFilter{Branch: "main"}, false},
+		{LogsFilter{Actor: "OctoCat"}, true},
+		{LogsFilter{Actor: "hubot"}, false},
+	}
+	for _, tt := range tests {
+		if matches := tt.filter.matchesRun(run); matches != tt.expected {
+			t.Errorf("Expected %v for %+v, got %v", tt.expected, tt.filter, matches)
+		}
+	}
+
+	// Offline runs without aw_info.json have no recorded actor
+	if (LogsFilter{Actor: "octocat"}).matchesRun(WorkflowRun{LogsPath: t.TempDir()}) {
+		t.Error("Expected a run without aw_info.json not to match an actor")
+	}
+}
+
+func TestLogsFilterMatchesProcessedRun(t *testing.T) {
+	runDir := t.TempDir()
+	files := map[string]string{
+		"aw_info.json":      `{"workflow_name": "Weekly Research", "actor": "octocat"}`,
+		"aw.patch":          "diff --git a/README.md b/README.md\n+hello\n",
+		"agent_output.json": `{"items": [{"type": "create-pull-request", "title": "Update README"}, {"type": "add-issue-comment", "body": "Done"}]}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(runDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	processedRun := ProcessedRun{
+		Run:          WorkflowRun{LogsPath: runDir, EstimatedCost: 2.5, TokenUsage: 120000},
+		MissingTools: []MissingToolReport{{Tool: "terraform"}},
+	}
+
+	tests := []struct {
+		filter   LogsFilter
+		expected bool
+	}{
+		{LogsFilter{}, true},
+		{LogsFilter{MinCost: 2.5, MinTokens: 100000}, true},
+		{LogsFilter{MinCost: 3}, false},
+		{LogsFilter{MinTokens: 200000}, false},
+		{LogsFilter{HasMissingTools: true, HasPatch: true}, true},
+		{LogsFilter{SafeOutputType: "create-pull-request"}, true},
+		{LogsFilter{SafeOutputType: "create-issue"}, false},
+	}
+	for _, tt := range tests {
+		if matches := tt.filter.matchesProcessedRun(processedRun, false); matches != tt.expected {
+			t.Errorf("Expected %v for %+v, got %v", tt.expected, tt.filter, matches)
+		}
+	}
+
+	emptyRun := ProcessedRun{Run: WorkflowRun{LogsPath: t.TempDir()}}
+	for _, filter := range []LogsFilter{{HasMissingTools: true}, {HasPatch: true}, {SafeOutputType: "create-pull-request"}} {
+		if filter.matchesProcessedRun(emptyRun, false) {
+			t.Errorf("Expected a run without artifacts not to match %+v", filter)
+		}
+	}
+
+	// The actor is filtered by gh run list, so runs that failed before uploading aw_info.json are kept
+	if !(LogsFilter{Actor: "octocat"}).matchesProcessedRun(emptyRun, false) {
+		t.Error("Expected the actor not to be checked against the downloaded artifacts")
+	}
+}
+
+func TestHasPatchChanges(t *testing.T) {
+	runDir := t.TempDir()
+	if hasPatchChanges(runDir) {
+		t.Error("Expected no changes without aw.patch")
+	}
+
+	for content, expected := range map[string]bool{
+		"":                                       false,
+		"Failed to generate patch: no commits\n": false,
+		"diff --git a/x b/x\n":                   true,
+	} {
+		if err := os.WriteFile(filepath.Join(runDir, "aw.patch"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if changes := hasPatchChanges(runDir); changes != expected {
+			t.Errorf("Expected %v for patch %q, got %v", expected, content, changes)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/githubnext/gh-aw/pkg/console"
+)
+
+// followPollInterval is how often the status of a followed run is polled
+var followPollInterval = 5 * time.Second
+
+// followRunFields are the fields of a followed run fetched with gh run view
+const followRunFields = "databaseId,number,url,status,conclusion,workflowName,createdAt,startedAt,updatedAt,event,headBranch,headSha,displayTitle,jobs"
+
+// followRunView is a workflow run with the status of its jobs and steps
+type followRunView struct {
+	WorkflowRun
+	Jobs []followJob `json:"jobs"`
+}
+
+// followJob is the status of a job of a followed run
+type followJob struct {
+	Name       string       `json:"name"`
+	Status     string       `json:"status"`
+	Conclusion string       `json:"conclusion"`
+	Steps      []followStep `json:"steps"`
+}
+
+// followStep is the status of a step of a followed run
+type followStep struct {
+	Number     int    `json:"number"`
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Conclusion string `json:"conclusion"`
+}
+
+// FollowRun polls a workflow run until it completes, streaming its job and step transitions
+// and the agent transcript as soon as the agent log artifact is uploaded. On completion the
+// artifacts are downloaded to outputDir and the metrics and safe outputs of the run are
+// displayed. It returns the conclusion of the run.
+func FollowRun(runID int64, outputDir string, verbose bool) (string, error) {
+	// Agent logs uploaded while the run is in progress are downloaded apart from the run
+	// folder, which is only complete once the run is
+	liveLogsDir, err := os.MkdirTemp("", fmt.Sprintf("gh-aw-follow-%d-", runID))
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(liveLogsDir)
+
+	var view followRunView
+	var previousJobs []followJob
+	downloadedLogs := make(map[string]bool)
+	printedLines := make(map[string]int)
+	for first := true; ; first = false {
+		view, err = fetchFollowRunView(runID, verbose)
+		if err != nil {
+			return "", err
+		}
+		if first {
+			fmt.Println(console.FormatInfoMessage(fmt.Sprintf("Following run %d of %s: %s", runID, view.WorkflowName, view.URL)))
+		}
+
+		for _, transition := range followTransitions(previousJobs, view.Jobs) {
+			fmt.Println(transition)
+		}
+		previousJobs = view.Jobs
+
+		if view.Status == "completed" {
+			break
+		}
+
+		if err := downloadLiveAgentLogs(runID, liveLogsDir, downloadedLogs, verbose); err != nil && verbose {
+			fmt.Fprintln(os.Stderr, console.FormatWarningMessage(err.Error()))
+		}
+		if transcripts, err := renderRunTranscripts(liveLogsDir, verbose); err == nil {
+			writeTranscriptUpdates(os.Stdout, transcripts, printedLines)
+		}
+
+		time.Sleep(followPollInterval)
+	}
+
+	runDir := filepath.Join(outputDir, fmt.Sprintf("run-%d", runID))
+	if err := downloadRunArtifacts(runID, runDir, verbose); err != nil {
+		if errors.Is(err, ErrNoArtifacts) {
+			fmt.Fprintln(os.Stderr, console.FormatWarningMessage(fmt.Sprintf("Run %d has no artifacts", runID)))
+			return view.Conclusion, nil
+		}
+		return view.Conclusion, err
+	}
+	if err := saveWorkflowRun(view.WorkflowRun, runDir); err != nil {
+		fmt.Fprintln(os.Stderr, console.FormatWarningMessage(err.Error()))
+	}
+
+	// Agent logs not uploaded before the run completed are rendered from the run folder
+	if transcripts, err := renderRunTranscripts(runDir, verbose); err == nil {
+		writeTranscriptUpdates(os.Stdout, transcripts, printedLines)
+	}
+
+	result := DownloadResult{Run: view.WorkflowRun, LogsPath: runDir}
+	analyzeRunArtifacts(&result, verbose)
+	processedRun, _ := processDownloadResult(result, "", "", verbose)
+
+	displayLogsOverview([]WorkflowRun{processedRun.Run})
+	displayFailureCauseAnalysis([]ProcessedRun{processedRun}, verbose)
+
+	fmt.Printf("\n%s\n", console.FormatListHeader("📤 Safe Outputs"))
+	fmt.Printf("%s\n\n", console.FormatListHeader("=============="))
+	if items := readSafeOutputItems(runDir, verbose); len(items) > 0 {
+		for _, item := range items {
+			fmt.Printf("  • %s\n", item)
+		}
+	} else {
+		fmt.Println("  No safe outputs")
+	}
+	fmt.Println()
+
+	absRunDir, _ := filepath.Abs(runDir)
+	message := fmt.Sprintf("Run %d completed with conclusion %s, artifacts downloaded to %s", runID, view.Conclusion, absRunDir)
+	if RunConclusionExitCode(view.Conclusion) == 0 {
+		fmt.Println(console.FormatSuccessMessage(message))
+	} else {
+		fmt.Println(console.FormatErrorMessage(message))
+	}
+	return view.Conclusion, nil
+}
+
+// RunConclusionExitCode returns the exit code for a followed run with the given conclusion,
+// 0 for runs that did not fail and 1 otherwise
+func RunConclusionExitCode(conclusion string) int {
+	switch conclusion {
+	case "success", "neutral", "skipped":
+		return 0
+	}
+	return 1
+}
+
+// fetchFollowRunView fetches the status of a run and of its jobs and steps
+func fetchFollowRunView(runID int64, verbose bool) (followRunView, error) {
+	args := []string{"run", "view", strconv.FormatInt(runID, 10), "--json", followRunFields}
+	if verbose {
+		fmt.Fprintln(os.Stderr, console.FormatInfoMessage(fmt.Sprintf("Executing: gh %s", strings.Join(args, " "))))
+	}
+
+	var view followRunView
+	output, err := exec.Command("gh", args...).CombinedOutput()
+	if err != nil {
+		if strings.Contains(err.Error(), "exit status 4") || strings.Contains(string(output), "gh auth login") {
+			return view, fmt.Errorf("GitHub CLI authentication required. Run 'gh auth login' first")
+		}
+		if len(output) > 0 {
+			return view, fmt.Errorf("failed to get status of run %d: %s", runID, strings.TrimSpace(string(output)))
+		}
+		return view, fmt.Errorf("failed to get status of run %d: %w", runID, err)
+	}
+	if err := json.Unmarshal(output, &view); err != nil {
+		return view, fmt.Errorf("failed to parse status of run %d: %w", runID, err)
+	}
+	return view, nil
+}
+
+// downloadLiveAgentLogs downloads the agent log artifacts uploaded so far by an in-progress
+// run that are not downloaded yet, each to its own folder like gh run download does
+func downloadLiveAgentLogs(runID int64, logsDir string, downloaded map[string]bool, verbose bool) error {
+	output, err := exec.Command("gh", "api", fmt.Sprintf("repos/{owner}/{repo}/actions/runs/%d/artifacts", runID), "--jq", ".artifacts[].name").Output()
+	if err != nil {
+		return fmt.Errorf("failed to list artifacts of run %d: %w", runID, err)
+	}
+
+	for _, name := range strings.Fields(string(output)) {
+		if !strings.HasSuffix(name, ".log") || downloaded[name] {
+			continue
+		}
+		args := []string{"run", "download", strconv.FormatInt(runID, 10), "--name", name, "--dir", filepath.Join(logsDir, name)}
+		if verbose {
+			fmt.Fprintln(os.Stderr, console.FormatInfoMessage(fmt.Sprintf("Executing: gh %s", strings.Join(args, " "))))
+		}
+		if output, err := exec.Command("gh", args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to download artifact %s of run %d: %s", name, runID, strings.TrimSpace(string(output)))
+		}
+		downloaded[name] = true
+	}
+	return nil
+}
+
+// followTransitions returns the job and step transitions between two polls of a run. Steps
+// that started and completed between the polls are only reported as completed.
+func followTransitions(previous, current []followJob) []string {
+	previousJobs := make(map[string]followJob)
+	for _, job := range previous {
+		previousJobs[job.Name] = job
+	}
+
+	var transitions []string
+	for _, job := range current {
+		previousJob := previousJobs[job.Name]
+		if job.Status != previousJob.Status {
+			if transition := formatFollowTransition(job.Status, job.Conclusion, "job "+job.Name, ""); transition != "" {
+				transitions = append(transitions, transition)
+			}
+		}
+
+		previousSteps := make(map[int]followStep)
+		for _, step := range previousJob.Steps {
+			previousSteps[step.Number] = step
+		}
+		for _, step := range job.Steps {
+			if step.Status == previousSteps[step.Number].Status {
+				continue
+			}
+			if transition := formatFollowTransition(step.Status, step.Conclusion, job.Name+" › "+step.Name, "  "); transition != "" {
+				transitions = append(transitions, transition)
+			}
+		}
+	}
+	return transitions
+}
+
+// formatFollowTransition formats a job or step reaching a status, or returns an empty string
+// for the statuses that are not reported, like queued
+func formatFollowTransition(status, conclusion, name, indent string) string {
+	switch status {
+	case "in_progress":
+		return fmt.Sprintf("%s▶ %s", indent, name)
+	case "completed":
+		switch conclusion {
+		case "success":
+			return fmt.Sprintf("%s✓ %s", indent, name)
+		case "skipped":
+			return fmt.Sprintf("%s- %s (skipped)", indent, name)
+		default:
+			return fmt.Sprintf("%s✗ %s (%s)", indent, name, conclusion)
+		}
+	}
+	return ""
+}
+
+// writeTranscriptUpdates writes the lines of the transcripts not written yet, as counted per
+// agent log in printedLines, with a header before the first lines of each agent log
+func writeTranscriptUpdates(w io.Writer, transcripts []runTranscript, printedLines map[string]int) {
+	for _, transcript := range transcripts {
+		lines := strings.Split(strings.TrimSpace(transcript.markdown), "\n")
+		printed := printedLines[transcript.path]
+		if len(lines) <= printed {
+			continue
+		}
+		if printed == 0 {
+			fmt.Fprintf(w, "\n%s\n\n", console.FormatInfoMessage(fmt.Sprintf("Agent log %s", transcript.path)))
+		}
+		fmt.Fprintln(w, strings.Join(lines[printed:], "\n"))
+		printedLines[transcript.path] = len(lines)
+	}
+}
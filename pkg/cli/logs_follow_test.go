@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFollowTransitions(t *testing.T) {
+	previous := []followJob{
+		{Name: "agent", Status: "in_progress", Steps: []followStep{
+			{Number: 1, Name: "Set up job", Status: "completed", Conclusion: "success"},
+			{Number: 2, Name: "Execute Claude Code CLI", Status: "in_progress"},
+			{Number: 3, Name: "Upload agent logs", Status: "queued"},
+		}},
+		{Name: "create_issue", Status: "queued"},
+	}
+	current := []followJob{
+		{Name: "agent", Status: "completed", Conclusion: "failure", Steps: []followStep{
+			{Number: 1, Name: "Set up job", Status: "completed", Conclusion: "success"},
+			{Number: 2, Name: "Execute Claude Code CLI", Status: "completed", Conclusion: "failure"},
+			{Number: 3, Name: "Upload agent logs", Status: "completed", Conclusion: "success"},
+		}},
+		{Name: "create_issue", Status: "completed", Conclusion: "skipped"},
+	}
+
+	expected := []string{
+		"✗ job agent (failure)",
+		"  ✗ agent › Execute Claude Code CLI (failure)",
+		"  ✓ agent › Upload agent logs",
+		"- job create_issue (skipped)",
+	}
+	if transitions := followTransitions(previous, current); !reflect.DeepEqual(transitions, expected) {
+		t.Errorf("Expected transitions %q, got %q", expected, transitions)
+	}
+
+	if transitions := followTransitions(current, current); len(transitions) != 0 {
+		t.Errorf("Expected no transitions between identical polls, got %q", transitions)
+	}
+}
+
+func TestFollowTransitionsFirstPoll(t *testing.T) {
+	current := []followJob{
+		{Name: "agent", Status: "in_progress", Steps: []followStep{
+			{Number: 1, Name: "Set up job", Status: "completed", Conclusion: "success"},
+			{Number: 2, Name: "Checkout repository", Status: "in_progress"},
+			{Number: 3, Name: "Setup MCPs", Status: "pending"},
+		}},
+		{Name: "create_issue", Status: "queued"},
+	}
+
+	expected := []string{
+		"▶ job agent",
+		"  ✓ agent › Set up job",
+		"  ▶ agent › Checkout repository",
+	}
+	if transitions := followTransitions(nil, current); !reflect.DeepEqual(transitions, expected) {
+		t.Errorf("Expected transitions %q, got %q", expected, transitions)
+	}
+}
+
+func TestRunConclusionExitCode(t *testing.T) {
+	tests := map[string]int{
+		"success":         0,
+		"neutral":         0,
+		"skipped":         0,
+		"failure":         1,
+		"cancelled":       1,
+		"timed_out":       1,
+		"startup_failure": 1,
+		"":                1,
+	}
+	for conclusion, expected := range tests {
+		if code := RunConclusionExitCode(conclusion); code != expected {
+			t.Errorf("Expected exit code %d for conclusion %q, got %d", expected, conclusion, code)
+		}
+	}
+}
+
+func TestWriteTranscriptUpdates(t *testing.T) {
+	printedLines := make(map[string]int)
+	var buf bytes.Buffer
+
+	writeTranscriptUpdates(&buf, []runTranscript{{path: "agent.log/agent.log", markdown: "## 🤖 Commands and Tools\n\n* ✅ `github::get_issue(...)`\n"}}, printedLines)
+	first := buf.String()
+	if !strings.Contains(first, "Agent log agent.log/agent.log") || !strings.Contains(first, "github::get_issue") {
+		t.Errorf("Expected the header and transcript, got:\n%s", first)
+	}
+
+	buf.Reset()
+	writeTranscriptUpdates(&buf, []runTranscript{{path: "agent.log/agent.log", markdown: "## 🤖 Commands and Tools\n\n* ✅ `github::get_issue(...)`\n* ✅ `github::add_comment(...)`\n"}}, printedLines)
+	second := buf.String()
+	if strings.Contains(second, "Agent log") || strings.Contains(second, "get_issue") {
+		t.Errorf("Expected only the new lines to be written, got:\n%s", second)
+	}
+	if !strings.Contains(second, "github::add_comment") {
+		t.Errorf("Expected the new line to be written, got:\n%s", second)
+	}
+
+	buf.Reset()
+	writeTranscriptUpdates(&buf, []runTranscript{{path: "agent.log/agent.log", markdown: "## 🤖 Commands and Tools\n\n* ✅ `github::get_issue(...)`\n* ✅ `github::add_comment(...)`\n"}}, printedLines)
+	if buf.Len() != 0 {
+		t.Errorf("Expected nothing to be written for an unchanged transcript, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,287 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Output formats of the logs --format flag
+const (
+	LogsFormatJSON   = "json"
+	LogsFormatCSV    = "csv"
+	LogsFormatNDJSON = "ndjson"
+)
+
+// LogsSchemaVersion is the version of the run records written by logs --format. It changes
+// when fields are renamed or removed, not when fields are added.
+const LogsSchemaVersion = 1
+
+// LogsRunRecord is the machine-readable record of a workflow run with its metrics,
+// missing tool reports and access log analysis
+type LogsRunRecord struct {
+	SchemaVersion    int                       `json:"schema_version"`
+	RunID            int64                     `json:"run_id"`
+	RunNumber        int                       `json:"run_number"`
+	WorkflowName     string                    `json:"workflow_name"`
+	Engine           string                    `json:"engine,omitempty"`
+	Model            string                    `json:"model,omitempty"`
+	URL              string                    `json:"url"`
+	Event            string                    `json:"event"`
+	HeadBranch       string                    `json:"head_branch"`
+	HeadSHA          string                    `json:"head_sha"`
+	DisplayTitle     string                    `json:"display_title"`
+	Status           string                    `json:"status"`
+	Conclusion       string                    `json:"conclusion"`
+	CreatedAt        time.Time                 `json:"created_at"`
+	StartedAt        time.Time                 `json:"started_at,omitzero"`
+	UpdatedAt        time.Time                 `json:"updated_at,omitzero"`
+	DurationSeconds  float64                   `json:"duration_seconds"`
+	TokenUsage       int                       `json:"token_usage"`
+	InputTokens      int                       `json:"input_tokens"`
+	OutputTokens     int                       `json:"output_tokens"`
+	CacheTokens      int                       `json:"cache_tokens"`
+	EstimatedCostUSD float64                   `json:"estimated_cost_usd"`
+	CostUnknown      bool                      `json:"cost_unknown,omitempty"`
+	Turns            int                       `json:"turns"`
+	ToolCalls        int                       `json:"tool_calls"`
+	ToolFailures     int                       `json:"tool_failures"`
+	StopReason       string                    `json:"stop_reason,omitempty"`
+	ErrorCount       int                       `json:"error_count"`
+	WarningCount     int                       `json:"warning_count"`
+	Tools            map[string]LogsToolRecord `json:"tools,omitempty"`          // Keyed by tool name, MCP tools as "server.tool"
+	FailureCauses    []FailureCause            `json:"failure_causes,omitempty"` // Causes of a failed or degraded run
+	FilesChanged     int                       `json:"files_changed"`            // Files changed by aw.patch
+	LinesAdded       int                       `json:"lines_added"`
+	LinesRemoved     int                       `json:"lines_removed"`
+	SafeOutputs      []SafeOutputCount         `json:"safe_outputs,omitempty"` // By type, sorted by type
+	MissingTools     []MissingToolReport       `json:"missing_tools,omitempty"`
+	AccessLog        *LogsAccessRecord         `json:"access_log,omitempty"` // Only for runs with a network firewall
+	LogsPath         string                    `json:"logs_path"`
+}
+
+// LogsToolRecord is the usage of a single tool in a run
+type LogsToolRecord struct {
+	Calls      int   `json:"calls"`
+	Failures   int   `json:"failures"`
+	DurationMs int64 `json:"duration_ms"`
+}
+
+// LogsAccessRecord is the network access log analysis of a run
+type LogsAccessRecord struct {
+	TotalRequests  int      `json:"total_requests"`
+	AllowedCount   int      `json:"allowed_count"`
+	DeniedCount    int      `json:"denied_count"`
+	AllowedDomains []string `json:"allowed_domains"`
+	DeniedDomains  []string `json:"denied_domains"`
+}
+
+// logsCSVHeader lists the CSV columns. Lists are joined with ";" and tool usage is left out.
+var logsCSVHeader = []string{
+	"schema_version", "run_id", "run_number", "workflow_name", "engine", "model", "url", "event",
+	"head_branch", "head_sha", "display_title", "status", "conclusion",
+	"created_at", "started_at", "updated_at", "duration_seconds",
+	"token_usage", "input_tokens", "output_tokens", "cache_tokens", "estimated_cost_usd",
+	"turns", "tool_calls", "tool_failures", "stop_reason", "error_count", "warning_count",
+	"failure_causes", "files_changed", "lines_added", "lines_removed", "safe_outputs_accepted", "safe_outputs_rejected", "safe_output_types",
+	"missing_tools", "total_requests", "allowed_domains", "denied_domains", "logs_path",
+}
+
+// isValidLogsFormat reports whether the format is one of the logs --format values
+func isValidLogsFormat(format string) bool {
+	return format == LogsFormatJSON || format == LogsFormatCSV || format == LogsFormatNDJSON
+}
+
+// buildLogsRunRecord builds the machine-readable record of a processed run
+func buildLogsRunRecord(processedRun ProcessedRun) LogsRunRecord {
+	run := processedRun.Run
+	metrics := processedRun.Metrics
+
+	record := LogsRunRecord{
+		SchemaVersion:    LogsSchemaVersion,
+		RunID:            run.DatabaseID,
+		RunNumber:        run.Number,
+		WorkflowName:     run.WorkflowName,
+		URL:              run.URL,
+		Event:            run.Event,
+		HeadBranch:       run.HeadBranch,
+		HeadSHA:          run.HeadSha,
+		DisplayTitle:     run.DisplayTitle,
+		Status:           run.Status,
+		Conclusion:       run.Conclusion,
+		CreatedAt:        run.CreatedAt,
+		StartedAt:        run.StartedAt,
+		UpdatedAt:        run.UpdatedAt,
+		DurationSeconds:  run.Duration.Seconds(),
+		TokenUsage:       run.TokenUsage,
+		Model:            metrics.Model,
+		InputTokens:      metrics.InputTokens,
+		OutputTokens:     metrics.OutputTokens,
+		CacheTokens:      metrics.CacheTokens,
+		EstimatedCostUSD: run.EstimatedCost,
+		CostUnknown:      run.CostUnknown,
+		Turns:            run.Turns,
+		ToolCalls:        run.ToolCalls,
+		ToolFailures:     run.ToolFailures,
+		StopReason:       run.StopReason,
+		ErrorCount:       metrics.ErrorCount,
+		WarningCount:     metrics.WarningCount,
+		FailureCauses:    run.FailureCauses,
+		FilesChanged:     run.FilesChanged,
+		LinesAdded:       run.LinesAdded,
+		LinesRemoved:     run.LinesRemoved,
+		SafeOutputs:      run.SafeOutputs,
+		MissingTools:     processedRun.MissingTools,
+		LogsPath:         run.LogsPath,
+	}
+
+	if run.LogsPath != "" {
+		if engine := extractEngineFromAwInfo(filepath.Join(run.LogsPath, "aw_info.json"), false); engine != nil {
+			record.Engine = engine.GetID()
+		}
+	}
+
+	if len(metrics.ToolCalls) > 0 {
+		record.Tools = make(map[string]LogsToolRecord, len(metrics.ToolCalls))
+		for name, tool := range metrics.ToolCalls {
+			record.Tools[name] = LogsToolRecord{Calls: tool.Calls, Failures: tool.Failures, DurationMs: tool.Duration.Milliseconds()}
+		}
+	}
+
+	if analysis := processedRun.AccessAnalysis; analysis != nil {
+		record.AccessLog = &LogsAccessRecord{
+			TotalRequests:  analysis.TotalRequests,
+			AllowedCount:   analysis.AllowedCount,
+			DeniedCount:    analysis.DeniedCount,
+			AllowedDomains: append([]string{}, analysis.AllowedDomains...),
+			DeniedDomains:  append([]string{}, analysis.DeniedDomains...),
+		}
+	}
+
+	return record
+}
+
+// writeLogsRecords writes the records of the processed runs to w in the given format
+func writeLogsRecords(w io.Writer, format string, processedRuns []ProcessedRun) error {
+	records := make([]LogsRunRecord, len(processedRuns))
+	for i, processedRun := range processedRuns {
+		records[i] = buildLogsRunRecord(processedRun)
+	}
+
+	switch format {
+	case LogsFormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	case LogsFormatNDJSON:
+		encoder := json.NewEncoder(w)
+		for _, record := range records {
+			if err := encoder.Encode(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	case LogsFormatCSV:
+		return writeLogsCSV(w, records)
+	}
+	return fmt.Errorf("unsupported format '%s'", format)
+}
+
+// writeLogsCSV writes the records as CSV rows under the logsCSVHeader columns
+func writeLogsCSV(w io.Writer, records []LogsRunRecord) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(logsCSVHeader); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		var failureCauses, missingTools, safeOutputTypes []string
+		for _, cause := range record.FailureCauses {
+			failureCauses = append(failureCauses, cause.Cause)
+		}
+		for _, report := range record.MissingTools {
+			missingTools = append(missingTools, report.Tool)
+		}
+		for _, c := range record.SafeOutputs {
+			safeOutputTypes = append(safeOutputTypes, c.Type)
+		}
+		accepted, rejected := sumSafeOutputCounts(record.SafeOutputs)
+		var totalRequests string
+		var allowedDomains, deniedDomains []string
+		if record.AccessLog != nil {
+			totalRequests = strconv.Itoa(record.AccessLog.TotalRequests)
+			allowedDomains = record.AccessLog.AllowedDomains
+			deniedDomains = record.AccessLog.DeniedDomains
+		}
+
+		row := []string{
+			strconv.Itoa(record.SchemaVersion),
+			strconv.FormatInt(record.RunID, 10),
+			strconv.Itoa(record.RunNumber),
+			record.WorkflowName,
+			record.Engine,
+			record.Model,
+			record.URL,
+			record.Event,
+			record.HeadBranch,
+			record.HeadSHA,
+			record.DisplayTitle,
+			record.Status,
+			record.Conclusion,
+			formatCSVTime(record.CreatedAt),
+			formatCSVTime(record.StartedAt),
+			formatCSVTime(record.UpdatedAt),
+			strconv.FormatFloat(record.DurationSeconds, 'f', -1, 64),
+			strconv.Itoa(record.TokenUsage),
+			strconv.Itoa(record.InputTokens),
+			strconv.Itoa(record.OutputTokens),
+			strconv.Itoa(record.CacheTokens),
+			formatCSVCost(record),
+			strconv.Itoa(record.Turns),
+			strconv.Itoa(record.ToolCalls),
+			strconv.Itoa(record.ToolFailures),
+			record.StopReason,
+			strconv.Itoa(record.ErrorCount),
+			strconv.Itoa(record.WarningCount),
+			strings.Join(failureCauses, ";"),
+			strconv.Itoa(record.FilesChanged),
+			strconv.Itoa(record.LinesAdded),
+			strconv.Itoa(record.LinesRemoved),
+			strconv.Itoa(accepted),
+			strconv.Itoa(rejected),
+			strings.Join(safeOutputTypes, ";"),
+			strings.Join(missingTools, ";"),
+			totalRequests,
+			strings.Join(allowedDomains, ";"),
+			strings.Join(deniedDomains, ";"),
+			record.LogsPath,
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// formatCSVCost formats the estimated cost of a run, or an empty cell when the cost is unknown
+func formatCSVCost(record LogsRunRecord) string {
+	if record.CostUnknown {
+		return ""
+	}
+	return strconv.FormatFloat(record.EstimatedCostUSD, 'f', -1, 64)
+}
+
+// formatCSVTime formats a timestamp as RFC 3339, or as an empty cell when it is not set
+func formatCSVTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/githubnext/gh-aw/pkg/workflow"
+)
+
+func testLogsProcessedRuns() []ProcessedRun {
+	return []ProcessedRun{
+		{
+			Run: WorkflowRun{
+				DatabaseID:    12345,
+				Number:        7,
+				WorkflowName:  "Weekly Research",
+				Status:        "completed",
+				Conclusion:    "success",
+				CreatedAt:     time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC),
+				Duration:      90 * time.Second,
+				TokenUsage:    1500,
+				EstimatedCost: 0.25,
+				Turns:         4,
+				ToolCalls:     3,
+				ToolFailures:  1,
+				StopReason:    workflow.StopReasonCompleted,
+				LogsPath:      "logs/run-12345",
+			},
+			Metrics: LogMetrics{
+				InputTokens:  1000,
+				OutputTokens: 500,
+				ToolCalls:    map[string]workflow.ToolCallMetrics{"github.get_issue": {Calls: 3, Failures: 1, Duration: 1500 * time.Millisecond}},
+			},
+			AccessAnalysis: &DomainAnalysis{AllowedDomains: []string{"api.github.com"}, DeniedDomains: []string{"evil.com", "example.com"}, TotalRequests: 5, AllowedCount: 3, DeniedCount: 2},
+			MissingTools:   []MissingToolReport{{Tool: "terraform", Reason: "Not installed"}},
+		},
+		{
+			Run: WorkflowRun{DatabaseID: 12346, WorkflowName: "Issue Triage", Status: "completed", Conclusion: "failure"},
+		},
+	}
+}
+
+func TestWriteLogsRecordsJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeLogsRecords(&buf, LogsFormatJSON, testLogsProcessedRuns()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var records []LogsRunRecord
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+		t.Fatalf("Invalid JSON output: %v\n%s", err, buf.String())
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+
+	record := records[0]
+	if record.SchemaVersion != LogsSchemaVersion || record.RunID != 12345 || record.DurationSeconds != 90 {
+		t.Errorf("Unexpected run fields: %+v", record)
+	}
+	if record.InputTokens != 1000 || record.OutputTokens != 500 || record.EstimatedCostUSD != 0.25 {
+		t.Errorf("Unexpected metrics: %+v", record)
+	}
+	if record.Tools["github.get_issue"] != (LogsToolRecord{Calls: 3, Failures: 1, DurationMs: 1500}) {
+		t.Errorf("Unexpected tool usage: %+v", record.Tools)
+	}
+	if record.AccessLog == nil || record.AccessLog.DeniedCount != 2 || len(record.MissingTools) != 1 {
+		t.Errorf("Expected access log and missing tools, got %+v, %+v", record.AccessLog, record.MissingTools)
+	}
+
+	if !strings.Contains(buf.String(), `"estimated_cost_usd": 0.25`) {
+		t.Errorf("Expected snake_case field names, got:\n%s", buf.String())
+	}
+	if strings.Count(buf.String(), `"access_log"`) != 1 {
+		t.Errorf("Expected access_log to be omitted for runs without a firewall, got:\n%s", buf.String())
+	}
+}
+
+func TestWriteLogsRecordsEmptyJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeLogsRecords(&buf, LogsFormatJSON, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("Expected an empty array, got %q", buf.String())
+	}
+}
+
+func TestWriteLogsRecordsNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeLogsRecords(&buf, LogsFormatNDJSON, testLogsProcessedRuns()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d:\n%s", len(lines), buf.String())
+	}
+	for _, line := range lines {
+		var record LogsRunRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil || record.SchemaVersion != LogsSchemaVersion {
+			t.Errorf("Invalid record line %s: %v", line, err)
+		}
+	}
+}
+
+func TestWriteLogsRecordsCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeLogsRecords(&buf, LogsFormatCSV, testLogsProcessedRuns()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Invalid CSV output: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("Expected a header and 2 rows, got %d", len(rows))
+	}
+
+	column := make(map[string]string)
+	for i, name := range rows[0] {
+		column[name] = rows[1][i]
+	}
+	expected := map[string]string{
+		"run_id":             "12345",
+		"created_at":         "2025-09-01T10:00:00Z",
+		"started_at":         "",
+		"estimated_cost_usd": "0.25",
+		"stop_reason":        "completed",
+		"missing_tools":      "terraform",
+		"total_requests":     "5",
+		"denied_domains":     "evil.com;example.com",
+	}
+	for name, value := range expected {
+		if column[name] != value {
+			t.Errorf("Expected %s to be %q, got %q", name, value, column[name])
+		}
+	}
+}
+
+func TestIsValidLogsFormat(t *testing.T) {
+	for _, format := range []string{"json", "csv", "ndjson"} {
+		if !isValidLogsFormat(format) {
+			t.Errorf("Expected %s to be valid", format)
+		}
+	}
+	if isValidLogsFormat("xml") || isValidLogsFormat("") {
+		t.Error("Expected xml and empty formats to be invalid")
+	}
+}
@@ -0,0 +1,413 @@
+package cli
+
+import (
+	_ "embed"
+	"fmt"
+	"html"
+	"html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/githubnext/gh-aw/pkg/console"
+	"github.com/githubnext/gh-aw/pkg/workflow"
+)
+
+//go:embed templates/logs_report.html
+var logsReportTemplateText string
+
+// logsReportTemplate renders the pages of the HTML report. Pages embed their styles and
+// have no scripts, so the report works offline and from any static file host.
+var logsReportTemplate = template.Must(template.New("logs_report").Funcs(template.FuncMap{
+	"join": strings.Join,
+	"inc":  func(i int) int { return i + 1 },
+	"percent": func(part, total int) float64 {
+		if total == 0 {
+			return 0
+		}
+		return float64(part) * 100 / float64(total)
+	},
+}).Parse(logsReportTemplateText))
+
+// htmlReport is the index page of the HTML report
+type htmlReport struct {
+	Title         string
+	Generated     string
+	Overview      console.TableConfig
+	FailureCauses []failureCauseSummary
+	Productivity  *console.TableConfig
+	ToolUsage     *console.TableConfig
+	Domains       []htmlDomainBar
+	MissingTools  []*MissingToolSummary
+}
+
+// htmlDomainBar is a domain of the network access chart with the runs allowed and denied access
+type htmlDomainBar struct {
+	Domain                string
+	AllowedRuns           int
+	DeniedRuns            int
+	WidthPct              float64 // Of the domain accessed by the most runs
+	AllowedPct, DeniedPct float64 // Of the bar
+}
+
+// htmlRunPage is the page of a run in the HTML report
+type htmlRunPage struct {
+	Title        string
+	Run          WorkflowRun
+	Fields       [][2]string
+	Timeline     []htmlTimelineEntry
+	Access       *DomainAnalysis
+	MissingTools []MissingToolReport
+	Patch        []htmlPatchLine
+	Transcripts  []htmlTranscript
+}
+
+// htmlTimelineEntry is a tool call of the run timeline
+type htmlTimelineEntry struct {
+	Turn     int
+	Offset   string // Since the start of the session, when the log has timestamps
+	Tool     string
+	Duration string
+	Status   string  // ok, error or pending
+	WidthPct float64 // Of the longest tool call
+}
+
+// htmlPatchLine is a line of the patch with its highlighting class
+type htmlPatchLine struct {
+	Class string // add, del, hunk, meta or ctx
+	Text  string
+}
+
+// htmlTranscript is a rendered agent transcript
+type htmlTranscript struct {
+	Path string
+	HTML template.HTML
+}
+
+// writeHTMLReport writes a static HTML report of the processed runs to reportDir: an index page
+// with the overview and analysis tables and a page per run with its transcripts, tool call
+// timeline, network access, missing tools and patch. It only reads the downloaded artifacts.
+func writeHTMLReport(reportDir string, processedRuns []ProcessedRun, verbose bool) error {
+	if err := os.MkdirAll(filepath.Join(reportDir, "runs"), 0755); err != nil {
+		return fmt.Errorf("failed to create report directory: %w", err)
+	}
+
+	workflowRuns := make([]WorkflowRun, len(processedRuns))
+	for i, pr := range processedRuns {
+		workflowRuns[i] = pr.Run
+	}
+
+	report := htmlReport{
+		Title:         "Agentic Workflow Runs",
+		Generated:     time.Now().UTC().Format("2006-01-02 15:04 UTC"),
+		Overview:      buildLogsOverviewTable(workflowRuns),
+		FailureCauses: buildFailureCauseSummaries(processedRuns),
+		Domains:       buildHTMLDomainBars(processedRuns),
+	}
+	if productivity, ok := buildWorkflowProductivityTable(processedRuns); ok {
+		report.Productivity = &productivity
+	}
+	if toolUsage, ok := buildToolUsageTable(processedRuns); ok {
+		report.ToolUsage = &toolUsage
+	}
+	report.MissingTools, _ = buildMissingToolSummaries(processedRuns)
+
+	if err := writeHTMLPage(filepath.Join(reportDir, "index.html"), "index", report); err != nil {
+		return err
+	}
+
+	for _, pr := range processedRuns {
+		page := buildHTMLRunPage(pr, verbose)
+		if err := writeHTMLPage(filepath.Join(reportDir, "runs", fmt.Sprintf("%d.html", pr.Run.DatabaseID)), "run", page); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeHTMLPage renders a page of the report template to path
+func writeHTMLPage(path, name string, data any) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to write report page: %w", err)
+	}
+	defer file.Close()
+	if err := logsReportTemplate.ExecuteTemplate(file, name, data); err != nil {
+		return fmt.Errorf("failed to render report page %s: %w", path, err)
+	}
+	return nil
+}
+
+// buildHTMLDomainBars counts the runs allowed and denied access to each domain, most accessed first
+func buildHTMLDomainBars(processedRuns []ProcessedRun) []htmlDomainBar {
+	bars := make(map[string]*htmlDomainBar)
+	bar := func(domain string) *htmlDomainBar {
+		if bars[domain] == nil {
+			bars[domain] = &htmlDomainBar{Domain: domain}
+		}
+		return bars[domain]
+	}
+	for _, pr := range processedRuns {
+		if pr.AccessAnalysis == nil {
+			continue
+		}
+		for _, domain := range pr.AccessAnalysis.AllowedDomains {
+			bar(domain).AllowedRuns++
+		}
+		for _, domain := range pr.AccessAnalysis.DeniedDomains {
+			bar(domain).DeniedRuns++
+		}
+	}
+
+	var result []htmlDomainBar
+	maxRuns := 0
+	for _, b := range bars {
+		maxRuns = max(maxRuns, b.AllowedRuns+b.DeniedRuns)
+		result = append(result, *b)
+	}
+	for i := range result {
+		runs := float64(result[i].AllowedRuns + result[i].DeniedRuns)
+		result[i].WidthPct = runs * 100 / float64(maxRuns)
+		result[i].AllowedPct = float64(result[i].AllowedRuns) * 100 / runs
+		result[i].DeniedPct = float64(result[i].DeniedRuns) * 100 / runs
+	}
+	sort.Slice(result, func(i, j int) bool {
+		ri, rj := result[i].AllowedRuns+result[i].DeniedRuns, result[j].AllowedRuns+result[j].DeniedRuns
+		if ri != rj {
+			return ri > rj
+		}
+		return result[i].Domain < result[j].Domain
+	})
+	return result
+}
+
+// buildHTMLRunPage builds the page of a run from its downloaded artifacts
+func buildHTMLRunPage(pr ProcessedRun, verbose bool) htmlRunPage {
+	run := pr.Run
+	page := htmlRunPage{
+		Title:        fmt.Sprintf("%s — Run %d", run.WorkflowName, run.DatabaseID),
+		Run:          run,
+		Access:       pr.AccessAnalysis,
+		MissingTools: pr.MissingTools,
+	}
+
+	cost := fmt.Sprintf("%.3f", run.EstimatedCost)
+	if run.CostUnknown {
+		cost = "unknown"
+	}
+	page.Fields = [][2]string{
+		{"Workflow", run.WorkflowName},
+		{"Status", strings.TrimSpace(run.Status + " " + run.Conclusion)},
+		{"Event", run.Event},
+		{"Branch", run.HeadBranch},
+		{"Created", run.CreatedAt.Format(time.RFC3339)},
+		{"Duration", formatDuration(run.Duration)},
+		{"Model", pr.Metrics.Model},
+		{"Turns", fmt.Sprintf("%d", run.Turns)},
+		{"Tokens", formatNumber(run.TokenUsage)},
+		{"Cost ($)", cost},
+		{"Tool Calls", formatToolCalls(run.ToolCalls, run.ToolFailures)},
+		{"Stop Reason", run.StopReason},
+	}
+
+	if run.LogsPath == "" {
+		return page
+	}
+
+	if transcripts, err := renderRunTranscripts(run.LogsPath, verbose); err == nil {
+		for _, transcript := range transcripts {
+			path := transcript.path
+			if len(transcripts) == 1 {
+				path = ""
+			}
+			page.Transcripts = append(page.Transcripts, htmlTranscript{Path: path, HTML: markdownToHTML(transcript.markdown)})
+		}
+	} else if verbose {
+		fmt.Fprintln(os.Stderr, console.FormatWarningMessage(fmt.Sprintf("Failed to render transcripts of run %d: %v", run.DatabaseID, err)))
+	}
+
+	_ = walkAgentLogs(run.LogsPath, verbose, func(path string, engine workflow.CodingAgentEngine, content string) {
+		if trace := engine.ParseLogTrace(content); !trace.IsEmpty() {
+			page.Timeline = append(page.Timeline, buildHTMLTimeline(trace)...)
+		}
+	})
+
+	if patch, err := readRunArtifact(run.LogsPath, "aw.patch"); err == nil {
+		page.Patch = highlightPatch(string(patch))
+	}
+	return page
+}
+
+// buildHTMLTimeline lists the tool calls of an agent trace with the status and duration of their results
+func buildHTMLTimeline(trace *workflow.AgentTrace) []htmlTimelineEntry {
+	results := make(map[string]workflow.TraceEvent)
+	for _, event := range trace.Events {
+		if event.Type == workflow.TraceEventToolResult && event.ToolCallID != "" {
+			results[event.ToolCallID] = event
+		}
+	}
+
+	start := trace.Session().Timestamp
+	var entries []htmlTimelineEntry
+	var longest int64
+	var durations []int64
+	for _, event := range trace.Events {
+		if event.Type != workflow.TraceEventToolCall {
+			continue
+		}
+		entry := htmlTimelineEntry{Turn: event.Turn, Tool: event.ToolName, Status: "pending"}
+		if !start.IsZero() && !event.Timestamp.IsZero() {
+			entry.Offset = "+" + event.Timestamp.Sub(start).Round(time.Second).String()
+		}
+		var durationMs int64
+		if result, ok := results[event.ToolCallID]; ok {
+			entry.Status = "ok"
+			if result.IsError {
+				entry.Status = "error"
+			}
+			durationMs = result.DurationMs
+			if durationMs > 0 {
+				entry.Duration = (time.Duration(durationMs) * time.Millisecond).Round(time.Millisecond).String()
+			}
+		}
+		longest = max(longest, durationMs)
+		durations = append(durations, durationMs)
+		entries = append(entries, entry)
+	}
+
+	// Without durations every call gets the same bar, colored by its status
+	for i := range entries {
+		entries[i].WidthPct = 100
+		if longest > 0 {
+			entries[i].WidthPct = max(1, float64(durations[i])*100/float64(longest))
+		}
+	}
+	return entries
+}
+
+// highlightPatch classifies the lines of a git patch for highlighting
+func highlightPatch(patch string) []htmlPatchLine {
+	patch = strings.TrimRight(patch, "\n")
+	if strings.TrimSpace(patch) == "" {
+		return nil
+	}
+
+	var lines []htmlPatchLine
+	for _, line := range strings.Split(patch, "\n") {
+		class := "ctx"
+		switch {
+		case strings.HasPrefix(line, "diff --git"), strings.HasPrefix(line, "+++ "), strings.HasPrefix(line, "--- "),
+			strings.HasPrefix(line, "index "), strings.HasPrefix(line, "From "), strings.HasPrefix(line, "Subject: "):
+			class = "meta"
+		case strings.HasPrefix(line, "@@"):
+			class = "hunk"
+		case strings.HasPrefix(line, "+"):
+			class = "add"
+		case strings.HasPrefix(line, "-"):
+			class = "del"
+		}
+		lines = append(lines, htmlPatchLine{Class: class, Text: line})
+	}
+	return lines
+}
+
+var (
+	markdownBoldPattern    = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	markdownHeadingPattern = regexp.MustCompile(`^(#{1,6}) (.*)$`)
+)
+
+// markdownToHTML converts the markdown of the log renderers to HTML: headings, bullet lists,
+// code fences, paragraphs, inline code and bold text. All text is escaped, so agent output
+// cannot inject markup into the report.
+func markdownToHTML(markdown string) template.HTML {
+	var b strings.Builder
+	inList, inParagraph, inCode := false, false, false
+	closeBlocks := func() {
+		if inList {
+			b.WriteString("</ul>\n")
+			inList = false
+		}
+		if inParagraph {
+			b.WriteString("</p>\n")
+			inParagraph = false
+		}
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			if inCode {
+				b.WriteString("</code></pre>\n")
+			} else {
+				closeBlocks()
+				b.WriteString("<pre><code>")
+			}
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			b.WriteString(html.EscapeString(line) + "\n")
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			closeBlocks()
+		case markdownHeadingPattern.MatchString(trimmed):
+			closeBlocks()
+			match := markdownHeadingPattern.FindStringSubmatch(trimmed)
+			level := min(len(match[1])+1, 6) // The page title is the only h1
+			fmt.Fprintf(&b, "<h%d>%s</h%d>\n", level, markdownInlineToHTML(match[2]), level)
+		case strings.HasPrefix(trimmed, "* ") || strings.HasPrefix(trimmed, "- "):
+			if inParagraph {
+				b.WriteString("</p>\n")
+				inParagraph = false
+			}
+			if !inList {
+				b.WriteString("<ul>\n")
+				inList = true
+			}
+			b.WriteString("<li>" + markdownInlineToHTML(trimmed[2:]) + "</li>\n")
+		default:
+			if inList {
+				b.WriteString("</ul>\n")
+				inList = false
+			}
+			if inParagraph {
+				b.WriteString("<br>\n")
+			} else {
+				b.WriteString("<p>")
+				inParagraph = true
+			}
+			b.WriteString(markdownInlineToHTML(trimmed))
+		}
+	}
+	if inCode {
+		b.WriteString("</code></pre>\n")
+	}
+	closeBlocks()
+
+	// The content was escaped while converting
+	return template.HTML(b.String())
+}
+
+// markdownInlineToHTML escapes a line of markdown, converting its inline code and bold text
+func markdownInlineToHTML(text string) string {
+	// Unbalanced backticks are left as they are
+	if strings.Count(text, "`")%2 == 1 {
+		return markdownBoldPattern.ReplaceAllString(html.EscapeString(text), "<strong>$1</strong>")
+	}
+
+	var b strings.Builder
+	for i, segment := range strings.Split(text, "`") {
+		if i%2 == 1 {
+			b.WriteString("<code>" + html.EscapeString(segment) + "</code>")
+			continue
+		}
+		b.WriteString(markdownBoldPattern.ReplaceAllString(html.EscapeString(segment), "<strong>$1</strong>"))
+	}
+	return b.String()
+}
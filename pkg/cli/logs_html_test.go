@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarkdownToHTML(t *testing.T) {
+	markdown := "## 🤖 Commands and Tools\n\n* ✅ `echo <b>`\n* ❌ `rm -rf`\n\n**Turns:** 3\nSecond <script>alert(1)</script> line\n\n```\n<raw> code\n```\nUnbalanced ` tick"
+
+	got := string(markdownToHTML(markdown))
+	expected := "<h3>🤖 Commands and Tools</h3>\n" +
+		"<ul>\n<li>✅ <code>echo &lt;b&gt;</code></li>\n<li>❌ <code>rm -rf</code></li>\n</ul>\n" +
+		"<p><strong>Turns:</strong> 3<br>\nSecond &lt;script&gt;alert(1)&lt;/script&gt; line</p>\n" +
+		"<pre><code>&lt;raw&gt; code\n</code></pre>\n" +
+		"<p>Unbalanced ` tick</p>\n"
+	if got != expected {
+		t.Errorf("Unexpected HTML:\n%s\nexpected:\n%s", got, expected)
+	}
+}
+
+func TestHighlightPatch(t *testing.T) {
+	patch := "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1,2 +1,2 @@\n context\n-old\n+new\n"
+	var classes []string
+	for _, line := range highlightPatch(patch) {
+		classes = append(classes, line.Class)
+	}
+	if got := strings.Join(classes, ","); got != "meta,meta,meta,hunk,ctx,del,add" {
+		t.Errorf("Unexpected classes: %s", got)
+	}
+
+	if lines := highlightPatch("\n"); lines != nil {
+		t.Errorf("Expected no lines for an empty patch, got %v", lines)
+	}
+}
+
+func TestBuildHTMLDomainBars(t *testing.T) {
+	processedRuns := []ProcessedRun{
+		{AccessAnalysis: &DomainAnalysis{AllowedDomains: []string{"api.github.com"}, DeniedDomains: []string{"example.com"}}},
+		{AccessAnalysis: &DomainAnalysis{AllowedDomains: []string{"api.github.com"}}},
+		{AccessAnalysis: &DomainAnalysis{DeniedDomains: []string{"api.github.com"}}},
+		{},
+	}
+
+	bars := buildHTMLDomainBars(processedRuns)
+	if len(bars) != 2 {
+		t.Fatalf("Expected 2 domains, got %d", len(bars))
+	}
+	github, example := bars[0], bars[1]
+	if github.Domain != "api.github.com" || github.AllowedRuns != 2 || github.DeniedRuns != 1 || github.WidthPct != 100 {
+		t.Errorf("Unexpected api.github.com bar: %+v", github)
+	}
+	if example.Domain != "example.com" || example.DeniedPct != 100 || example.WidthPct*3 != 100 {
+		t.Errorf("Unexpected example.com bar: %+v", example)
+	}
+}
+
+func TestWriteHTMLReport(t *testing.T) {
+	runDir := t.TempDir()
+	files := map[string]string{
+		"aw_info.json": `{"engine_id": "claude", "workflow_name": "Triage"}`,
+		"agent-stdio.log": `[
+  {"type":"system","subtype":"init","model":"claude-sonnet-4"},
+  {"type":"assistant","message":{"content":[{"type":"text","text":"Looking at <issue>"},{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"ls"}}]}},
+  {"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"t1","content":"ok","is_error":true}]}},
+  {"type":"result","subtype":"success","num_turns":2,"total_cost_usd":0.01,"usage":{"input_tokens":100,"output_tokens":10}}
+]`,
+		"aw.patch": "diff --git a/a.go b/a.go\n+new\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(runDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	processedRuns := []ProcessedRun{{
+		Run: WorkflowRun{DatabaseID: 42, WorkflowName: "Triage", Conclusion: "failure", CreatedAt: time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC), LogsPath: runDir,
+			FailureCauses: []FailureCause{{Cause: FailureCauseUnknown, Evidence: "run conclusion failure"}}},
+		MissingTools: []MissingToolReport{{Tool: "terraform", Reason: "needs <infra>", WorkflowName: "Triage", RunID: 42}},
+	}}
+
+	reportDir := filepath.Join(t.TempDir(), "report")
+	if err := writeHTMLReport(reportDir, processedRuns, false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	index, err := os.ReadFile(filepath.Join(reportDir, "index.html"))
+	if err != nil {
+		t.Fatalf("Expected index.html: %v", err)
+	}
+	runPage, err := os.ReadFile(filepath.Join(reportDir, "runs", "42.html"))
+	if err != nil {
+		t.Fatalf("Expected runs/42.html: %v", err)
+	}
+
+	for _, expected := range []string{`<a href="runs/42.html">42</a>`, "<h2>Failure Causes</h2>", "<code>terraform</code>", "needs &lt;infra&gt;"} {
+		if !strings.Contains(string(index), expected) {
+			t.Errorf("Expected index.html to contain %q", expected)
+		}
+	}
+	for _, expected := range []string{
+		`<a href="../index.html">`,
+		"<h2>Tool Call Timeline</h2>",
+		`<code>Bash</code>`,
+		`<span class="error"`,
+		`<div class="add">&#43;new</div>`,
+		"<h2>Transcript</h2>",
+		"Looking at &lt;issue&gt;",
+	} {
+		if !strings.Contains(string(runPage), expected) {
+			t.Errorf("Expected runs/42.html to contain %q", expected)
+		}
+	}
+	for _, page := range []string{string(index), string(runPage)} {
+		if strings.Contains(page, "<script") || strings.Contains(page, "http://") || strings.Contains(page, "https://") {
+			t.Errorf("Expected a self-contained page without scripts or external resources")
+		}
+	}
+}
@@ -0,0 +1,374 @@
+package cli
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/githubnext/gh-aw/pkg/console"
+	"github.com/githubnext/gh-aw/pkg/constants"
+	"github.com/githubnext/gh-aw/pkg/parser"
+	"github.com/githubnext/gh-aw/pkg/workflow"
+	"golang.org/x/net/publicsuffix"
+)
+
+// wildcardMinSubdomains is the number of subdomains of a domain accessed by the runs from which
+// they are collapsed into a wildcard
+const wildcardMinSubdomains = 2
+
+// domainRunStats counts the runs that accessed a domain through the network firewall
+type domainRunStats struct {
+	Domain           string
+	AllowedRuns      int
+	DeniedRuns       int
+	FailedDeniedRuns int // Failed runs in which the domain was denied
+}
+
+// deniedDomainWarning is a denied domain that looks required by the workflow
+type deniedDomainWarning struct {
+	Domain string
+	Reason string
+}
+
+// networkSuggestion is a proposed network.allowed list for a workflow
+type networkSuggestion struct {
+	Runs     int                 // Runs with access logs
+	Allowed  []string            // Ecosystem identifiers first, then wildcards and domains
+	Covers   map[string][]string // Accessed domains covered by each entry of Allowed
+	Required []deniedDomainWarning
+	Ignored  []string // Denied domains left out of the list
+	Unused   []string // Entries of the current list covering none of the accessed domains
+}
+
+// SuggestNetworkAllowList aggregates the domains accessed by the runs of a workflow into a
+// minimal network.allowed list and prints it. With apply, the list is written into the
+// frontmatter of the workflow instead of its current network configuration.
+func SuggestNetworkAllowList(workflowID string, processedRuns []ProcessedRun, apply, verbose bool) error {
+	stats, runs := buildDomainRunStats(processedRuns)
+	if runs == 0 {
+		return fmt.Errorf("no access logs found in %d runs of %s. Access logs are only recorded for workflows with a network firewall, see 'network:' in the frontmatter", len(processedRuns), workflowID)
+	}
+
+	var current []string
+	markdownPath, err := resolveWorkflowFile(workflowID, verbose)
+	if err == nil {
+		current, err = readNetworkAllowList(markdownPath)
+	}
+	if err != nil {
+		if apply {
+			return err
+		}
+		if verbose {
+			fmt.Fprintln(os.Stderr, console.FormatWarningMessage(err.Error()))
+		}
+	}
+
+	suggestion := suggestNetworkAllowList(stats, runs, current)
+	displayNetworkSuggestion(suggestion, current)
+
+	if !apply {
+		return nil
+	}
+	content, err := os.ReadFile(markdownPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", markdownPath, err)
+	}
+	updated, err := replaceFrontmatterNetwork(string(content), suggestion.Allowed)
+	if err != nil {
+		return fmt.Errorf("failed to update %s: %w", markdownPath, err)
+	}
+	if err := os.WriteFile(markdownPath, []byte(updated), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", markdownPath, err)
+	}
+	fmt.Println(console.FormatSuccessMessage(fmt.Sprintf("Updated network.allowed in %s. Run '%s compile' to update the lock file", markdownPath, constants.CLIExtensionPrefix)))
+	return nil
+}
+
+// buildDomainRunStats counts the runs accessing each domain, and returns the number of runs
+// with access logs
+func buildDomainRunStats(processedRuns []ProcessedRun) ([]domainRunStats, int) {
+	byDomain := make(map[string]*domainRunStats)
+	get := func(domain string) *domainRunStats {
+		if byDomain[domain] == nil {
+			byDomain[domain] = &domainRunStats{Domain: domain}
+		}
+		return byDomain[domain]
+	}
+
+	runs := 0
+	for _, pr := range processedRuns {
+		if pr.AccessAnalysis == nil {
+			continue
+		}
+		runs++
+		for _, domain := range pr.AccessAnalysis.AllowedDomains {
+			get(domain).AllowedRuns++
+		}
+		for _, domain := range pr.AccessAnalysis.DeniedDomains {
+			stats := get(domain)
+			stats.DeniedRuns++
+			if isFailedConclusion(pr.Run.Conclusion) {
+				stats.FailedDeniedRuns++
+			}
+		}
+	}
+
+	stats := make([]domainRunStats, 0, len(byDomain))
+	for _, s := range byDomain {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Domain < stats[j].Domain
+	})
+	return stats, runs
+}
+
+// suggestNetworkAllowList builds the allow-list covering the allowed domains and the denied
+// domains that look required. Domains of an ecosystem are replaced by its identifier, and
+// other domains sharing a parent domain by a wildcard.
+func suggestNetworkAllowList(stats []domainRunStats, runs int, current []string) networkSuggestion {
+	suggestion := networkSuggestion{Runs: runs, Covers: make(map[string][]string)}
+
+	var needed []string
+	for _, s := range stats {
+		if s.AllowedRuns > 0 {
+			needed = append(needed, s.Domain)
+			continue
+		}
+		if reason := deniedDomainRequiredReason(s, runs); reason != "" {
+			suggestion.Required = append(suggestion.Required, deniedDomainWarning{Domain: s.Domain, Reason: reason})
+			needed = append(needed, s.Domain)
+		} else {
+			suggestion.Ignored = append(suggestion.Ignored, s.Domain)
+		}
+	}
+
+	var ecosystems []string
+	byParent := make(map[string][]string)
+	for _, domain := range needed {
+		if ecosystem := workflow.GetDomainEcosystem(domain); ecosystem != "" {
+			if _, exists := suggestion.Covers[ecosystem]; !exists {
+				ecosystems = append(ecosystems, ecosystem)
+			}
+			suggestion.Covers[ecosystem] = append(suggestion.Covers[ecosystem], domain)
+			continue
+		}
+		parent := parentDomain(domain)
+		byParent[parent] = append(byParent[parent], domain)
+	}
+
+	var domains []string
+	for parent, group := range byParent {
+		subdomains := 0
+		for _, domain := range group {
+			if domain != parent {
+				subdomains++
+			}
+		}
+		if subdomains >= wildcardMinSubdomains {
+			wildcard := "*." + parent
+			domains = append(domains, wildcard)
+			suggestion.Covers[wildcard] = group
+			continue
+		}
+		for _, domain := range group {
+			domains = append(domains, domain)
+			suggestion.Covers[domain] = []string{domain}
+		}
+	}
+
+	// The defaults ecosystem goes first, as in the documented examples
+	sort.Slice(ecosystems, func(i, j int) bool {
+		if (ecosystems[i] == "defaults") != (ecosystems[j] == "defaults") {
+			return ecosystems[i] == "defaults"
+		}
+		return ecosystems[i] < ecosystems[j]
+	})
+	sort.Slice(domains, func(i, j int) bool {
+		return strings.TrimPrefix(domains[i], "*.") < strings.TrimPrefix(domains[j], "*.")
+	})
+	suggestion.Allowed = append(ecosystems, domains...)
+
+	for _, entry := range current {
+		used := false
+		for _, s := range stats {
+			if s.AllowedRuns > 0 && allowListEntryCovers(entry, s.Domain) {
+				used = true
+				break
+			}
+		}
+		if !used {
+			suggestion.Unused = append(suggestion.Unused, entry)
+		}
+	}
+	return suggestion
+}
+
+// deniedDomainRequiredReason returns why a domain denied by the firewall looks required, or an
+// empty string when it does not
+func deniedDomainRequiredReason(s domainRunStats, runs int) string {
+	if ecosystem := workflow.GetDomainEcosystem(s.Domain); ecosystem != "" {
+		return fmt.Sprintf("part of the %s ecosystem", ecosystem)
+	}
+	if s.FailedDeniedRuns > 0 {
+		return fmt.Sprintf("denied in %d failed runs", s.FailedDeniedRuns)
+	}
+	if runs >= 2 && s.DeniedRuns == runs {
+		return "denied in every run"
+	}
+	return ""
+}
+
+// parentDomain returns the registrable domain of the domain according to the public suffix list,
+// e.g. example.com for api.example.com or example.co.uk for api.example.co.uk, or the domain
+// itself for IP addresses and domains without a registrable parent. The subdomains of a public
+// suffix such as github.io or amazonaws.com are each their own parent, so a wildcard is never
+// suggested at or above the public suffix level
+func parentDomain(domain string) string {
+	if net.ParseIP(domain) != nil {
+		return domain
+	}
+	parent, err := publicsuffix.EffectiveTLDPlusOne(domain)
+	if err != nil {
+		return domain
+	}
+	return parent
+}
+
+// allowListEntryCovers reports whether an entry of network.allowed, an ecosystem identifier,
+// a wildcard or a domain, covers the domain
+func allowListEntryCovers(entry, domain string) bool {
+	for _, pattern := range workflow.GetAllowedDomains(&workflow.NetworkPermissions{Allowed: []string{entry}}) {
+		if pattern == domain {
+			return true
+		}
+		if suffix, ok := strings.CutPrefix(pattern, "*."); ok && (domain == suffix || strings.HasSuffix(domain, "."+suffix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// readNetworkAllowList returns the network.allowed list in the frontmatter of a workflow, with
+// "defaults" for the defaults mode, or nil when the network is not configured
+func readNetworkAllowList(markdownPath string) ([]string, error) {
+	content, err := os.ReadFile(markdownPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", markdownPath, err)
+	}
+	result, err := parser.ExtractFrontmatterFromContent(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse frontmatter of %s: %w", markdownPath, err)
+	}
+
+	switch network := result.Frontmatter["network"].(type) {
+	case string:
+		return []string{network}, nil
+	case map[string]any:
+		var allowed []string
+		if list, ok := network["allowed"].([]any); ok {
+			for _, entry := range list {
+				if s, ok := entry.(string); ok {
+					allowed = append(allowed, s)
+				}
+			}
+		}
+		return allowed, nil
+	}
+	return nil, nil
+}
+
+// replaceFrontmatterNetwork replaces the network configuration in the frontmatter of a workflow
+// with the allow-list, or adds it at the end of the frontmatter, keeping the other lines as is
+func replaceFrontmatterNetwork(content string, allowed []string) (string, error) {
+	lines := strings.Split(content, "\n")
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return "", fmt.Errorf("workflow has no frontmatter")
+	}
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "---" {
+			end = i
+			break
+		}
+	}
+	if end == -1 {
+		return "", fmt.Errorf("frontmatter not properly closed")
+	}
+
+	block := networkAllowListLines(allowed)
+	start, stop := end, end
+	for i := 1; i < end; i++ {
+		if strings.HasPrefix(lines[i], "network:") {
+			start = i
+			stop = i + 1
+			for stop < end && (strings.TrimSpace(lines[stop]) == "" || strings.HasPrefix(lines[stop], " ") || strings.HasPrefix(lines[stop], "\t")) {
+				stop++
+			}
+			// Blank lines after the network configuration separate it from the next key
+			for stop > start+1 && strings.TrimSpace(lines[stop-1]) == "" {
+				stop--
+			}
+			break
+		}
+	}
+
+	updated := append([]string{}, lines[:start]...)
+	updated = append(updated, block...)
+	updated = append(updated, lines[stop:]...)
+	return strings.Join(updated, "\n"), nil
+}
+
+// displayNetworkSuggestion prints the suggested allow-list with the domains it covers, the
+// denied domains that look required and the frontmatter to use
+func displayNetworkSuggestion(suggestion networkSuggestion, current []string) {
+	fmt.Printf("\n%s\n", console.FormatListHeader(fmt.Sprintf("🌐 Suggested Network Allow-List (%d runs with access logs)", suggestion.Runs)))
+	fmt.Printf("%s\n\n", console.FormatListHeader("======================================================"))
+
+	var rows [][]string
+	for _, entry := range suggestion.Allowed {
+		covers := suggestion.Covers[entry]
+		coverList := strings.Join(covers, ", ")
+		if len(coverList) > 60 {
+			coverList = fmt.Sprintf("%s (+%d)", covers[0], len(covers)-1)
+		}
+		rows = append(rows, []string{entry, coverList})
+	}
+	fmt.Print(console.RenderTable(console.TableConfig{
+		Headers: []string{"Entry", "Covers"},
+		Rows:    rows,
+	}))
+
+	for _, warning := range suggestion.Required {
+		fmt.Println(console.FormatWarningMessage(fmt.Sprintf("%s was denied but looks required (%s), it is included in the list", warning.Domain, warning.Reason)))
+	}
+	if len(suggestion.Ignored) > 0 {
+		fmt.Println(console.FormatInfoMessage(fmt.Sprintf("Denied domains left out: %s", strings.Join(suggestion.Ignored, ", "))))
+	}
+	if len(current) > 0 {
+		fmt.Println(console.FormatInfoMessage(fmt.Sprintf("Current allow-list: %s", strings.Join(current, ", "))))
+		if len(suggestion.Unused) > 0 {
+			fmt.Println(console.FormatInfoMessage(fmt.Sprintf("Not used by any run: %s", strings.Join(suggestion.Unused, ", "))))
+		}
+	}
+
+	fmt.Println()
+	fmt.Println("```yaml")
+	fmt.Println(strings.Join(networkAllowListLines(suggestion.Allowed), "\n"))
+	fmt.Println("```")
+}
+
+// networkAllowListLines returns the frontmatter lines of a network configuration with the
+// allow-list, quoting the wildcards that YAML would read as aliases
+func networkAllowListLines(allowed []string) []string {
+	lines := []string{"network:", "  allowed:"}
+	for _, entry := range allowed {
+		if strings.HasPrefix(entry, "*") {
+			entry = fmt.Sprintf("%q", entry)
+		}
+		lines = append(lines, "    - "+entry)
+	}
+	return lines
+}
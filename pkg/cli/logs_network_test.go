@@ -0,0 +1,215 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildDomainRunStats(t *testing.T) {
+	processedRuns := []ProcessedRun{
+		{Run: WorkflowRun{Conclusion: "success"}, AccessAnalysis: &DomainAnalysis{AllowedDomains: []string{"pypi.org"}, DeniedDomains: []string{"example.com"}}},
+		{Run: WorkflowRun{Conclusion: "failure"}, AccessAnalysis: &DomainAnalysis{AllowedDomains: []string{"pypi.org"}, DeniedDomains: []string{"example.com"}}},
+		{Run: WorkflowRun{Conclusion: "success"}},
+	}
+
+	stats, runs := buildDomainRunStats(processedRuns)
+	if runs != 2 {
+		t.Errorf("Expected 2 runs with access logs, got %d", runs)
+	}
+	expected := []domainRunStats{
+		{Domain: "example.com", DeniedRuns: 2, FailedDeniedRuns: 1},
+		{Domain: "pypi.org", AllowedRuns: 2},
+	}
+	if !reflect.DeepEqual(stats, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, stats)
+	}
+}
+
+func TestSuggestNetworkAllowList(t *testing.T) {
+	stats := []domainRunStats{
+		{Domain: "api.example.com", AllowedRuns: 3},
+		{Domain: "cdn.example.com", AllowedRuns: 1},
+		{Domain: "crl3.digicert.com", AllowedRuns: 3},
+		{Domain: "files.pythonhosted.org", DeniedRuns: 1},
+		{Domain: "internal.corp.net", DeniedRuns: 3},
+		{Domain: "pypi.org", AllowedRuns: 2},
+		{Domain: "status.other.io", AllowedRuns: 1},
+		{Domain: "telemetry.vendor.com", DeniedRuns: 1},
+		{Domain: "uploads.vendor.com", DeniedRuns: 1, FailedDeniedRuns: 1},
+	}
+
+	suggestion := suggestNetworkAllowList(stats, 3, []string{"defaults", "node", "api.example.com"})
+
+	expectedAllowed := []string{"defaults", "python", "*.example.com", "internal.corp.net", "status.other.io", "uploads.vendor.com"}
+	if !reflect.DeepEqual(suggestion.Allowed, expectedAllowed) {
+		t.Errorf("Expected allow-list %q, got %q", expectedAllowed, suggestion.Allowed)
+	}
+	if covers := suggestion.Covers["python"]; !reflect.DeepEqual(covers, []string{"files.pythonhosted.org", "pypi.org"}) {
+		t.Errorf("Expected python to cover the pypi domains, got %q", covers)
+	}
+	if covers := suggestion.Covers["*.example.com"]; !reflect.DeepEqual(covers, []string{"api.example.com", "cdn.example.com"}) {
+		t.Errorf("Expected the wildcard to cover the example.com subdomains, got %q", covers)
+	}
+
+	expectedRequired := []deniedDomainWarning{
+		{Domain: "files.pythonhosted.org", Reason: "part of the python ecosystem"},
+		{Domain: "internal.corp.net", Reason: "denied in every run"},
+		{Domain: "uploads.vendor.com", Reason: "denied in 1 failed runs"},
+	}
+	if !reflect.DeepEqual(suggestion.Required, expectedRequired) {
+		t.Errorf("Expected required denied domains %+v, got %+v", expectedRequired, suggestion.Required)
+	}
+	if !reflect.DeepEqual(suggestion.Ignored, []string{"telemetry.vendor.com"}) {
+		t.Errorf("Expected the other denied domain to be left out, got %q", suggestion.Ignored)
+	}
+	if !reflect.DeepEqual(suggestion.Unused, []string{"node"}) {
+		t.Errorf("Expected node to be unused in the current list, got %q", suggestion.Unused)
+	}
+}
+
+func TestSuggestNetworkAllowListPublicSuffixes(t *testing.T) {
+	stats := []domainRunStats{
+		{Domain: "a.example.co.uk", AllowedRuns: 1},
+		{Domain: "alice.github.io", AllowedRuns: 1},
+		{Domain: "app.azurewebsites.net", AllowedRuns: 1},
+		{Domain: "b.example.co.uk", AllowedRuns: 1},
+		{Domain: "bob.github.io", AllowedRuns: 1},
+		{Domain: "other.azurewebsites.net", AllowedRuns: 1},
+		{Domain: "shop.co.uk", AllowedRuns: 1},
+		{Domain: "store.co.uk", AllowedRuns: 1},
+	}
+
+	suggestion := suggestNetworkAllowList(stats, 1, nil)
+
+	expectedAllowed := []string{"alice.github.io", "app.azurewebsites.net", "bob.github.io", "*.example.co.uk", "other.azurewebsites.net", "shop.co.uk", "store.co.uk"}
+	if !reflect.DeepEqual(suggestion.Allowed, expectedAllowed) {
+		t.Errorf("Expected allow-list %q, got %q", expectedAllowed, suggestion.Allowed)
+	}
+}
+
+func TestParentDomain(t *testing.T) {
+	tests := map[string]string{
+		"api.example.com":           "example.com",
+		"a.b.example.com":           "example.com",
+		"example.com":               "example.com",
+		"localhost":                 "localhost",
+		"192.168.1.10":              "192.168.1.10",
+		"uploads.vendor.io":         "vendor.io",
+		"api.example.co.uk":         "example.co.uk",
+		"co.uk":                     "co.uk",
+		"alice.github.io":           "alice.github.io",
+		"github.io":                 "github.io",
+		"foo.s3.amazonaws.com":      "foo.s3.amazonaws.com",
+		"app.azurewebsites.net":     "app.azurewebsites.net",
+		"api.app.azurewebsites.net": "app.azurewebsites.net",
+	}
+	for domain, expected := range tests {
+		if parent := parentDomain(domain); parent != expected {
+			t.Errorf("Expected parent %s for %s, got %s", expected, domain, parent)
+		}
+	}
+}
+
+func TestAllowListEntryCovers(t *testing.T) {
+	tests := []struct {
+		entry, domain string
+		expected      bool
+	}{
+		{"python", "pypi.org", true},
+		{"python", "registry.npmjs.org", false},
+		{"*.example.com", "api.example.com", true},
+		{"*.example.com", "example.com", true},
+		{"*.example.com", "notexample.com", false},
+		{"api.example.com", "api.example.com", true},
+		{"api.example.com", "cdn.example.com", false},
+	}
+	for _, tt := range tests {
+		if covers := allowListEntryCovers(tt.entry, tt.domain); covers != tt.expected {
+			t.Errorf("Expected %v for %s covering %s, got %v", tt.expected, tt.entry, tt.domain, covers)
+		}
+	}
+}
+
+func TestReplaceFrontmatterNetwork(t *testing.T) {
+	allowed := []string{"defaults", "python", "*.example.com"}
+
+	content := `---
+on:
+  workflow_dispatch:
+network:
+  allowed:
+    - defaults
+    - node # for the build
+
+tools:
+  github:
+---
+# Research
+`
+	expected := `---
+on:
+  workflow_dispatch:
+network:
+  allowed:
+    - defaults
+    - python
+    - "*.example.com"
+
+tools:
+  github:
+---
+# Research
+`
+	updated, err := replaceFrontmatterNetwork(content, allowed)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if updated != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, updated)
+	}
+
+	updated, err = replaceFrontmatterNetwork("---\non: push\nnetwork: defaults\nengine: claude\n---\nBody\n", allowed)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(updated, "on: push\nnetwork:\n  allowed:\n    - defaults\n    - python\n    - \"*.example.com\"\nengine: claude\n---") {
+		t.Errorf("Expected the network mode to be replaced, got:\n%s", updated)
+	}
+
+	updated, err = replaceFrontmatterNetwork("---\non: push\n---\nBody\n", []string{"defaults"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if updated != "---\non: push\nnetwork:\n  allowed:\n    - defaults\n---\nBody\n" {
+		t.Errorf("Expected the network configuration to be added, got:\n%s", updated)
+	}
+
+	if _, err := replaceFrontmatterNetwork("# No frontmatter\n", allowed); err == nil {
+		t.Error("Expected an error for a workflow without frontmatter")
+	}
+}
+
+func TestReadNetworkAllowList(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string][]string{
+		"---\non: push\nnetwork:\n  allowed:\n    - defaults\n    - \"*.example.com\"\n---\n": {"defaults", "*.example.com"},
+		"---\non: push\nnetwork: defaults\n---\n":                                             {"defaults"},
+		"---\non: push\n---\n": nil,
+	}
+	for content, expected := range tests {
+		path := filepath.Join(dir, "workflow.md")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		allowed, err := readNetworkAllowList(path)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(allowed, expected) {
+			t.Errorf("Expected %q for:\n%s\ngot %q", expected, content, allowed)
+		}
+	}
+}
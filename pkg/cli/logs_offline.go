@@ -0,0 +1,221 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/githubnext/gh-aw/pkg/console"
+)
+
+// workflowRunFileName is the file in each run folder holding the run metadata from the GitHub API
+const workflowRunFileName = "workflow_run.json"
+
+// awInfoRun is the run metadata recorded in aw_info.json by the workflow itself
+type awInfoRun struct {
+	EngineID     string    `json:"engine_id"`
+	EngineName   string    `json:"engine_name"`
+	Model        string    `json:"model"`
+	Version      string    `json:"version"`
+	WorkflowName string    `json:"workflow_name"`
+	RunID        int64     `json:"run_id"`
+	RunNumber    int       `json:"run_number"`
+	Repository   string    `json:"repository"`
+	Ref          string    `json:"ref"`
+	SHA          string    `json:"sha"`
+	EventName    string    `json:"event_name"`
+	Actor        string    `json:"actor"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// saveWorkflowRun records the GitHub API metadata of the run in its folder, for offline analysis
+func saveWorkflowRun(run WorkflowRun, runDir string) error {
+	content, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode run %d: %w", run.DatabaseID, err)
+	}
+	if err := os.WriteFile(filepath.Join(runDir, workflowRunFileName), content, 0644); err != nil {
+		return fmt.Errorf("failed to save metadata of run %d: %w", run.DatabaseID, err)
+	}
+	return nil
+}
+
+// AnalyzeOfflineLogs rebuilds the runs previously downloaded to logsDir from their run folders
+// and analyzes them like DownloadWorkflowLogs does, without accessing the network
+func AnalyzeOfflineLogs(logsDir, workflowName string, count int, startDate, endDate, engine string, filter LogsFilter, traceFormat, format, htmlDir string, verbose bool) error {
+	processedRuns, err := loadOfflineProcessedRuns(logsDir, workflowName, count, startDate, endDate, engine, filter, traceFormat, verbose)
+	if err != nil {
+		return err
+	}
+
+	if err := recordRunHistory(logsDir, processedRuns); err != nil {
+		fmt.Fprintln(os.Stderr, console.FormatWarningMessage(err.Error()))
+	}
+
+	absLogsDir, _ := filepath.Abs(logsDir)
+	return reportProcessedRuns(processedRuns, format, htmlDir, fmt.Sprintf("Analyzed %d runs in %s", len(processedRuns), absLogsDir), verbose)
+}
+
+// loadOfflineProcessedRuns rebuilds and analyzes up to count runs previously downloaded to
+// logsDir that match the filters, most recent first
+func loadOfflineProcessedRuns(logsDir, workflowName string, count int, startDate, endDate, engine string, filter LogsFilter, traceFormat string, verbose bool) ([]ProcessedRun, error) {
+	runs, err := loadOfflineRuns(logsDir, verbose)
+	if err != nil {
+		return nil, err
+	}
+
+	runs, err = filterOfflineRuns(runs, workflowName, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	var processedRuns []ProcessedRun
+	for _, run := range runs {
+		if count > 0 && len(processedRuns) >= count {
+			break
+		}
+		if !filter.matchesRun(run) {
+			continue
+		}
+		result := DownloadResult{Run: run, LogsPath: run.LogsPath}
+		analyzeRunArtifacts(&result, verbose)
+		if processedRun, ok := processDownloadResult(result, engine, traceFormat, verbose); ok && filter.matchesProcessedRun(processedRun, verbose) {
+			processedRuns = append(processedRuns, processedRun)
+		}
+	}
+	return processedRuns, nil
+}
+
+// loadOfflineRuns loads the runs of the run-<id> folders in logsDir, most recent first
+func loadOfflineRuns(logsDir string, verbose bool) ([]WorkflowRun, error) {
+	entries, err := os.ReadDir(logsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read logs directory %s: %w", logsDir, err)
+	}
+
+	var runs []WorkflowRun
+	for _, entry := range entries {
+		idText, ok := strings.CutPrefix(entry.Name(), "run-")
+		if !entry.IsDir() || !ok {
+			continue
+		}
+		runID, err := strconv.ParseInt(idText, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		run, err := loadOfflineRun(filepath.Join(logsDir, entry.Name()), runID)
+		if err != nil {
+			if verbose {
+				fmt.Fprintln(os.Stderr, console.FormatWarningMessage(err.Error()))
+			}
+			continue
+		}
+		runs = append(runs, run)
+	}
+
+	sort.SliceStable(runs, func(i, j int) bool {
+		if !runs[i].CreatedAt.Equal(runs[j].CreatedAt) {
+			return runs[i].CreatedAt.After(runs[j].CreatedAt)
+		}
+		return runs[i].DatabaseID > runs[j].DatabaseID
+	})
+	return runs, nil
+}
+
+// loadOfflineRun rebuilds a run from the GitHub API metadata saved in its folder, or from its
+// aw_info.json for folders downloaded before the metadata was saved
+func loadOfflineRun(runDir string, runID int64) (WorkflowRun, error) {
+	run := WorkflowRun{DatabaseID: runID, LogsPath: runDir}
+
+	if content, err := os.ReadFile(filepath.Join(runDir, workflowRunFileName)); err == nil {
+		if err := json.Unmarshal(content, &run); err != nil {
+			return run, fmt.Errorf("invalid %s for run %d: %w", workflowRunFileName, runID, err)
+		}
+		run.LogsPath = runDir
+		return run, nil
+	}
+
+	info, err := readAwInfo(runDir)
+	if os.IsNotExist(err) {
+		return run, fmt.Errorf("skipping run %d: no %s or aw_info.json found in %s", runID, workflowRunFileName, runDir)
+	}
+	if err != nil {
+		return run, fmt.Errorf("invalid aw_info.json for run %d: %w", runID, err)
+	}
+
+	run.WorkflowName = info.WorkflowName
+	run.Number = info.RunNumber
+	run.Event = info.EventName
+	run.HeadSha = info.SHA
+	run.HeadBranch = strings.TrimPrefix(info.Ref, "refs/heads/")
+	run.CreatedAt = info.CreatedAt
+	if info.Repository != "" {
+		run.URL = fmt.Sprintf("https://github.com/%s/actions/runs/%d", info.Repository, runID)
+	}
+	return run, nil
+}
+
+// readAwInfo reads the aw_info.json of a run folder
+func readAwInfo(runDir string) (awInfoRun, error) {
+	var info awInfoRun
+	content, err := readRunArtifact(runDir, "aw_info.json")
+	if err != nil {
+		return info, err
+	}
+	err = json.Unmarshal(content, &info)
+	return info, err
+}
+
+// readRunArtifact reads a single-file artifact of a run folder, which is either the file
+// itself or a directory of the artifact name holding the file
+func readRunArtifact(runDir, name string) ([]byte, error) {
+	path := filepath.Join(runDir, name)
+	if stat, err := os.Stat(path); err == nil && stat.IsDir() {
+		// Artifacts uploaded from a file with another name, like the safe outputs file, hold
+		// that file only
+		entries, _ := os.ReadDir(path)
+		if len(entries) == 1 && !entries[0].IsDir() {
+			path = filepath.Join(path, entries[0].Name())
+		} else {
+			path = filepath.Join(path, name)
+		}
+	}
+	return os.ReadFile(path)
+}
+
+// filterOfflineRuns keeps the runs of the workflow created between the dates (YYYY-MM-DD, inclusive)
+func filterOfflineRuns(runs []WorkflowRun, workflowName, startDate, endDate string) ([]WorkflowRun, error) {
+	var start, end time.Time
+	var err error
+	if startDate != "" {
+		if start, err = time.Parse("2006-01-02", startDate); err != nil {
+			return nil, fmt.Errorf("invalid start-date '%s': %w", startDate, err)
+		}
+	}
+	if endDate != "" {
+		if end, err = time.Parse("2006-01-02", endDate); err != nil {
+			return nil, fmt.Errorf("invalid end-date '%s': %w", endDate, err)
+		}
+	}
+
+	var filtered []WorkflowRun
+	for _, run := range runs {
+		if workflowName != "" && run.WorkflowName != workflowName {
+			continue
+		}
+		if !start.IsZero() && run.CreatedAt.Before(start) {
+			continue
+		}
+		if !end.IsZero() && !run.CreatedAt.Before(end.AddDate(0, 0, 1)) {
+			continue
+		}
+		filtered = append(filtered, run)
+	}
+	return filtered, nil
+}
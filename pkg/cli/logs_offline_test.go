@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/githubnext/gh-aw/pkg/workflow"
+)
+
+func writeOfflineTestRun(t *testing.T, logsDir, name string, files map[string]string) {
+	t.Helper()
+	runDir := filepath.Join(logsDir, name)
+	if err := os.MkdirAll(runDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for file, content := range files {
+		if err := os.WriteFile(filepath.Join(runDir, file), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadOfflineRuns(t *testing.T) {
+	logsDir := t.TempDir()
+
+	run := WorkflowRun{
+		DatabaseID:   100,
+		WorkflowName: "Weekly Research",
+		Status:       "completed",
+		Conclusion:   "success",
+		CreatedAt:    time.Date(2025, 9, 1, 10, 0, 0, 0, time.UTC),
+		StartedAt:    time.Date(2025, 9, 1, 10, 0, 5, 0, time.UTC),
+		UpdatedAt:    time.Date(2025, 9, 1, 10, 5, 5, 0, time.UTC),
+		TokenUsage:   999, // Not saved, metrics are always recomputed
+	}
+	writeOfflineTestRun(t, logsDir, "run-100", map[string]string{"aw_info.json": `{"engine_id": "codex"}`})
+	if err := saveWorkflowRun(run, filepath.Join(logsDir, "run-100")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Older downloads only have aw_info.json
+	writeOfflineTestRun(t, logsDir, "run-200", map[string]string{
+		"aw_info.json": `{"engine_id": "claude", "workflow_name": "Issue Triage", "run_id": 200, "run_number": 12,
+			"repository": "octo/repo", "ref": "refs/heads/main", "sha": "abc123", "event_name": "issues",
+			"created_at": "2025-09-02T08:00:00.000Z"}`,
+	})
+
+	writeOfflineTestRun(t, logsDir, "run-300", map[string]string{"agent-stdio.log": "no metadata"})
+	writeOfflineTestRun(t, logsDir, "not-a-run", map[string]string{"aw_info.json": `{}`})
+
+	runs, err := loadOfflineRuns(logsDir, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(runs) != 2 {
+		t.Fatalf("Expected 2 runs, got %d: %+v", len(runs), runs)
+	}
+
+	triage := runs[0]
+	if triage.DatabaseID != 200 || triage.WorkflowName != "Issue Triage" || triage.Number != 12 {
+		t.Errorf("Expected the most recent run rebuilt from aw_info.json first, got %+v", triage)
+	}
+	if triage.HeadBranch != "main" || triage.Event != "issues" || triage.URL != "https://github.com/octo/repo/actions/runs/200" {
+		t.Errorf("Unexpected run fields from aw_info.json: %+v", triage)
+	}
+	if triage.LogsPath != filepath.Join(logsDir, "run-200") {
+		t.Errorf("Expected the run folder as logs path, got %s", triage.LogsPath)
+	}
+
+	research := runs[1]
+	if research.Conclusion != "success" || !research.UpdatedAt.Equal(run.UpdatedAt) || research.TokenUsage != 0 {
+		t.Errorf("Expected the saved GitHub API metadata only, got %+v", research)
+	}
+
+	filtered, err := filterOfflineRuns(runs, "Weekly Research", "2025-09-01", "2025-09-01")
+	if err != nil || len(filtered) != 1 || filtered[0].DatabaseID != 100 {
+		t.Errorf("Expected the Weekly Research run of 2025-09-01, got %+v (%v)", filtered, err)
+	}
+	filtered, _ = filterOfflineRuns(runs, "", "2025-09-02", "")
+	if len(filtered) != 1 || filtered[0].DatabaseID != 200 {
+		t.Errorf("Expected runs created from 2025-09-02, got %+v", filtered)
+	}
+	if _, err := filterOfflineRuns(runs, "", "yesterday", ""); err == nil {
+		t.Error("Expected an error for an invalid start date")
+	}
+}
+
+func TestLoadOfflineRunsMissingDirectory(t *testing.T) {
+	if _, err := loadOfflineRuns(filepath.Join(t.TempDir(), "missing"), false); err == nil {
+		t.Error("Expected an error for a missing logs directory")
+	}
+}
+
+func TestAnalyzeRunArtifactsOffline(t *testing.T) {
+	run := writeTraceTestRun(t, "codex", testCodexTraceLog)
+
+	result := DownloadResult{Run: run, LogsPath: run.LogsPath}
+	analyzeRunArtifacts(&result, false)
+
+	processedRun, ok := processDownloadResult(result, "codex", "", false)
+	if !ok {
+		t.Fatal("Expected the codex run to match the engine filter")
+	}
+	if processedRun.Run.TokenUsage != 1500 || processedRun.Run.Turns != 2 || processedRun.Run.ToolCalls != 2 {
+		t.Errorf("Expected metrics recomputed from the logs, got %+v", processedRun.Run)
+	}
+
+	if _, ok := processDownloadResult(result, "claude", "", false); ok {
+		t.Error("Expected the codex run to be filtered out by the claude engine filter")
+	}
+}
+
+func TestProcessDownloadResultManifestEngineFilter(t *testing.T) {
+	gitRoot := t.TempDir()
+	manifestDir := filepath.Join(gitRoot, workflow.EngineManifestDir)
+	if err := os.MkdirAll(manifestDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	manifest := "id: logs-filter-engine\ndisplay-name: Logs Filter Engine\nrun:\n  command: echo {{prompt-file}}\n"
+	if err := os.WriteFile(filepath.Join(manifestDir, "logs-filter-engine.yml"), []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := workflow.GetGlobalEngineRegistry().LoadManifestEngines(gitRoot); err != nil {
+		t.Fatal(err)
+	}
+
+	logsDir := t.TempDir()
+	writeOfflineTestRun(t, logsDir, "run-1", map[string]string{"aw_info.json": `{"engine_id": "logs-filter-engine"}`})
+	result := DownloadResult{Run: WorkflowRun{DatabaseID: 1}, LogsPath: filepath.Join(logsDir, "run-1")}
+
+	if _, ok := processDownloadResult(result, "logs-filter-engine", "", false); !ok {
+		t.Error("Expected the run to match the filter on its manifest engine")
+	}
+	if _, ok := processDownloadResult(result, "codex", "", false); ok {
+		t.Error("Expected the run to be filtered out by the codex engine filter")
+	}
+}
@@ -0,0 +1,236 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/githubnext/gh-aw/pkg/console"
+	"github.com/githubnext/gh-aw/pkg/workflow"
+)
+
+// SafeOutputCount is the number of safe outputs of a type written by the agent of a run
+type SafeOutputCount struct {
+	Type     string `json:"type"`
+	Accepted int    `json:"accepted"` // Passed validation, in agent_output.json
+	Rejected int    `json:"rejected"` // Written to safe_output.jsonl but not in agent_output.json
+}
+
+// extractPatchStats returns the number of files changed and the lines added and removed by
+// the aw.patch of a run
+func extractPatchStats(runDir string) (files, added, removed int) {
+	content, err := readRunArtifact(runDir, "aw.patch")
+	if err != nil {
+		return 0, 0, 0
+	}
+	for _, stats := range parsePatchFiles(string(content)) {
+		files++
+		added += stats.Added
+		removed += stats.Removed
+	}
+	return files, added, removed
+}
+
+// extractSafeOutputCounts counts the safe outputs of a run by type and outcome, comparing the
+// raw items in safe_output.jsonl with the validated items in agent_output.json
+func extractSafeOutputCounts(runDir string, verbose bool) []SafeOutputCount {
+	counts := make(map[string]*SafeOutputCount)
+	count := func(itemType string) *SafeOutputCount {
+		if counts[itemType] == nil {
+			counts[itemType] = &SafeOutputCount{Type: itemType}
+		}
+		return counts[itemType]
+	}
+
+	accepted := make(map[string]int)
+	if content, err := readRunArtifact(runDir, "agent_output.json"); err == nil {
+		var safeOutput struct {
+			Items []struct {
+				Type string `json:"type"`
+			} `json:"items"`
+		}
+		if err := json.Unmarshal(content, &safeOutput); err != nil {
+			if verbose {
+				fmt.Fprintln(os.Stderr, console.FormatWarningMessage(fmt.Sprintf("Failed to parse safe output items from %s: %v", runDir, err)))
+			}
+		}
+		for _, item := range safeOutput.Items {
+			if item.Type != "" {
+				count(item.Type).Accepted++
+				accepted[item.Type]++
+			}
+		}
+	}
+
+	written := make(map[string]int)
+	if content, err := readRunArtifact(runDir, workflow.OutputArtifactName); err == nil {
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+		for scanner.Scan() {
+			var item struct {
+				Type string `json:"type"`
+			}
+			if json.Unmarshal(scanner.Bytes(), &item) == nil && item.Type != "" {
+				written[strings.ReplaceAll(item.Type, "_", "-")]++
+			}
+		}
+	}
+	for itemType, n := range written {
+		if n > accepted[itemType] {
+			count(itemType).Rejected = n - accepted[itemType]
+		}
+	}
+
+	result := make([]SafeOutputCount, 0, len(counts))
+	for _, c := range counts {
+		result = append(result, *c)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Type < result[j].Type
+	})
+	return result
+}
+
+// sumSafeOutputCounts returns the accepted and rejected safe outputs of all types
+func sumSafeOutputCounts(counts []SafeOutputCount) (accepted, rejected int) {
+	for _, c := range counts {
+		accepted += c.Accepted
+		rejected += c.Rejected
+	}
+	return accepted, rejected
+}
+
+// formatPatchChanges formats the changes of a patch for the overview, e.g. "3 (+120/-4)"
+func formatPatchChanges(files, added, removed int) string {
+	if files == 0 {
+		return "N/A"
+	}
+	return fmt.Sprintf("%d (+%d/-%d)", files, added, removed)
+}
+
+// formatSafeOutputTotals formats the safe outputs of a run for the overview, e.g. "2 (1 rejected)"
+func formatSafeOutputTotals(accepted, rejected int) string {
+	switch {
+	case accepted == 0 && rejected == 0:
+		return "N/A"
+	case rejected == 0:
+		return fmt.Sprintf("%d", accepted)
+	}
+	return fmt.Sprintf("%d (%d rejected)", accepted, rejected)
+}
+
+// workflowProductivity aggregates what the runs of a workflow shipped for the tokens they used
+type workflowProductivity struct {
+	Workflow       string
+	Runs           int
+	ShippedRuns    int // Runs with a patch or an accepted safe output
+	FilesChanged   int
+	LinesAdded     int
+	LinesRemoved   int
+	OutputsByType  map[string]int // Accepted safe outputs
+	AcceptedOutput int
+	RejectedOutput int
+	Tokens         int
+	Cost           float64
+}
+
+// buildWorkflowProductivity groups the runs by workflow, the workflows shipping the most runs first
+func buildWorkflowProductivity(processedRuns []ProcessedRun) []*workflowProductivity {
+	byWorkflow := make(map[string]*workflowProductivity)
+	var result []*workflowProductivity
+	for _, pr := range processedRuns {
+		run := pr.Run
+		p := byWorkflow[run.WorkflowName]
+		if p == nil {
+			p = &workflowProductivity{Workflow: run.WorkflowName, OutputsByType: make(map[string]int)}
+			byWorkflow[run.WorkflowName] = p
+			result = append(result, p)
+		}
+
+		accepted, rejected := sumSafeOutputCounts(run.SafeOutputs)
+		p.Runs++
+		if run.FilesChanged > 0 || accepted > 0 {
+			p.ShippedRuns++
+		}
+		p.FilesChanged += run.FilesChanged
+		p.LinesAdded += run.LinesAdded
+		p.LinesRemoved += run.LinesRemoved
+		for _, c := range run.SafeOutputs {
+			if c.Accepted > 0 {
+				p.OutputsByType[c.Type] += c.Accepted
+			}
+		}
+		p.AcceptedOutput += accepted
+		p.RejectedOutput += rejected
+		p.Tokens += run.TokenUsage
+		p.Cost += run.EstimatedCost
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].ShippedRuns != result[j].ShippedRuns {
+			return result[i].ShippedRuns > result[j].ShippedRuns
+		}
+		return result[i].Workflow < result[j].Workflow
+	})
+	return result
+}
+
+// buildWorkflowProductivityTable builds the per-workflow productivity table, or returns false
+// when there are no runs
+func buildWorkflowProductivityTable(processedRuns []ProcessedRun) (console.TableConfig, bool) {
+	productivity := buildWorkflowProductivity(processedRuns)
+	if len(productivity) == 0 {
+		return console.TableConfig{}, false
+	}
+
+	headers := []string{"Workflow", "Shipped", "Changes", "Safe Outputs", "Tokens", "Cost ($)", "Tokens/Output"}
+	var rows [][]string
+	for _, p := range productivity {
+		var outputTypes []string
+		for itemType, n := range p.OutputsByType {
+			outputTypes = append(outputTypes, fmt.Sprintf("%s: %d", itemType, n))
+		}
+		sort.Strings(outputTypes)
+		outputs := formatSafeOutputTotals(p.AcceptedOutput, p.RejectedOutput)
+		if len(outputTypes) > 0 {
+			outputs += " - " + strings.Join(outputTypes, ", ")
+		}
+
+		tokensPerOutput := "N/A"
+		if p.AcceptedOutput > 0 && p.Tokens > 0 {
+			tokensPerOutput = formatNumber(p.Tokens / p.AcceptedOutput)
+		}
+
+		workflowName := p.Workflow
+		if len(workflowName) > 30 {
+			workflowName = workflowName[:27] + "..."
+		}
+
+		rows = append(rows, []string{
+			workflowName,
+			fmt.Sprintf("%d/%d", p.ShippedRuns, p.Runs),
+			formatPatchChanges(p.FilesChanged, p.LinesAdded, p.LinesRemoved),
+			outputs,
+			formatNumber(p.Tokens),
+			fmt.Sprintf("%.3f", p.Cost),
+			tokensPerOutput,
+		})
+	}
+
+	return console.TableConfig{
+		Title:   "Workflow Productivity",
+		Headers: headers,
+		Rows:    rows,
+	}, true
+}
+
+// displayWorkflowProductivity displays which workflows ship changes for the tokens they use
+func displayWorkflowProductivity(processedRuns []ProcessedRun) {
+	if tableConfig, ok := buildWorkflowProductivityTable(processedRuns); ok {
+		fmt.Print(console.RenderTable(tableConfig))
+	}
+}
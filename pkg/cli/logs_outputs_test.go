@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testOutputsPatch = `From 1234567 Mon Sep 17 00:00:00 2001
+Subject: [PATCH] Update docs
+
+---
+ README.md | 3 ++-
+ main.go   | 1 +
+diff --git a/README.md b/README.md
+--- a/README.md
++++ b/README.md
+@@ -1,2 +1,3 @@
+-old line
++new line
++another line
+diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -1 +1,2 @@
++// comment
+`
+
+func writeOutputsTestRun(t *testing.T, files map[string]string) string {
+	t.Helper()
+	runDir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(runDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return runDir
+}
+
+func TestExtractPatchStats(t *testing.T) {
+	runDir := writeOutputsTestRun(t, map[string]string{"aw.patch": testOutputsPatch})
+	files, added, removed := extractPatchStats(runDir)
+	if files != 2 || added != 3 || removed != 1 {
+		t.Errorf("Expected 2 files, 3 added and 1 removed, got %d, %d and %d", files, added, removed)
+	}
+
+	runDir = writeOutputsTestRun(t, map[string]string{"aw.patch": testFormatPatch})
+	if files, added, removed := extractPatchStats(runDir); files != 2 || added != 2 || removed != 1 {
+		t.Errorf("Expected 2 files, 2 added and 1 removed for format-patch output, got %d, %d and %d", files, added, removed)
+	}
+
+	if files, added, removed := extractPatchStats(t.TempDir()); files != 0 || added != 0 || removed != 0 {
+		t.Errorf("Expected no changes without aw.patch, got %d, %d and %d", files, added, removed)
+	}
+}
+
+func TestExtractSafeOutputCounts(t *testing.T) {
+	// The safe outputs artifact holds the file written by the agent under its own name
+	runDir := writeOutputsTestRun(t, map[string]string{
+		"safe_output.jsonl/aw_output_0123456789abcdef.txt": `{"type": "create-issue", "title": "Bug"}
+{"type": "create-issue", "title": ""}
+{"type": "add-issue-comment", "body": "Done"}
+not json
+{"type": "create_pull_request", "title": "Fix"}
+`,
+		"agent_output.json": `{"items": [{"type": "create-issue", "title": "Bug"}, {"type": "add-issue-comment", "body": "Done"}], "errors": ["Line 2: title is required", "Line 5: no changes"]}`,
+	})
+
+	expected := []SafeOutputCount{
+		{Type: "add-issue-comment", Accepted: 1},
+		{Type: "create-issue", Accepted: 1, Rejected: 1},
+		{Type: "create-pull-request", Rejected: 1},
+	}
+	counts := extractSafeOutputCounts(runDir, false)
+	if !reflect.DeepEqual(counts, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, counts)
+	}
+
+	if accepted, rejected := sumSafeOutputCounts(counts); accepted != 2 || rejected != 2 {
+		t.Errorf("Expected 2 accepted and 2 rejected, got %d and %d", accepted, rejected)
+	}
+
+	if counts := extractSafeOutputCounts(t.TempDir(), false); len(counts) != 0 {
+		t.Errorf("Expected no counts without artifacts, got %+v", counts)
+	}
+}
+
+func TestFormatOutputsStats(t *testing.T) {
+	tests := []struct {
+		actual, expected string
+	}{
+		{formatPatchChanges(0, 0, 0), "N/A"},
+		{formatPatchChanges(3, 120, 4), "3 (+120/-4)"},
+		{formatSafeOutputTotals(0, 0), "N/A"},
+		{formatSafeOutputTotals(2, 0), "2"},
+		{formatSafeOutputTotals(2, 1), "2 (1 rejected)"},
+		{formatSafeOutputTotals(0, 1), "0 (1 rejected)"},
+	}
+	for _, tt := range tests {
+		if tt.actual != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, tt.actual)
+		}
+	}
+}
+
+func TestBuildWorkflowProductivity(t *testing.T) {
+	processedRuns := []ProcessedRun{
+		{Run: WorkflowRun{WorkflowName: "Daily Triage", TokenUsage: 50000, EstimatedCost: 0.5}},
+		{Run: WorkflowRun{WorkflowName: "Daily Triage", TokenUsage: 70000, EstimatedCost: 0.7}},
+		{Run: WorkflowRun{WorkflowName: "Weekly Research", TokenUsage: 30000, EstimatedCost: 0.3,
+			FilesChanged: 2, LinesAdded: 10, LinesRemoved: 3,
+			SafeOutputs: []SafeOutputCount{{Type: "create-pull-request", Accepted: 1}, {Type: "create-issue", Accepted: 1, Rejected: 1}}}},
+		{Run: WorkflowRun{WorkflowName: "Weekly Research", TokenUsage: 10000, EstimatedCost: 0.1}},
+	}
+
+	productivity := buildWorkflowProductivity(processedRuns)
+	if len(productivity) != 2 {
+		t.Fatalf("Expected 2 workflows, got %d", len(productivity))
+	}
+
+	research := productivity[0]
+	if research.Workflow != "Weekly Research" || research.Runs != 2 || research.ShippedRuns != 1 {
+		t.Errorf("Expected the shipping workflow first with 1 of 2 runs shipped, got %+v", research)
+	}
+	if research.FilesChanged != 2 || research.LinesAdded != 10 || research.LinesRemoved != 3 || research.AcceptedOutput != 2 || research.RejectedOutput != 1 {
+		t.Errorf("Unexpected changes and outputs: %+v", research)
+	}
+	if research.Tokens != 40000 {
+		t.Errorf("Expected 40000 tokens, got %d", research.Tokens)
+	}
+
+	triage := productivity[1]
+	if triage.Workflow != "Daily Triage" || triage.ShippedRuns != 0 || triage.Tokens != 120000 {
+		t.Errorf("Expected the workflow that only used tokens last, got %+v", triage)
+	}
+
+	table, ok := buildWorkflowProductivityTable(processedRuns)
+	if !ok || len(table.Rows) != 2 {
+		t.Fatalf("Expected a row per workflow, got %+v", table)
+	}
+	row := strings.Join(table.Rows[0], " | ")
+	for _, expected := range []string{"1/2", "2 (+10/-3)", "2 (1 rejected) - create-issue: 1, create-pull-request: 1", "20.0k"} {
+		if !strings.Contains(row, expected) {
+			t.Errorf("Expected row to contain %q, got %s", expected, row)
+		}
+	}
+	if row := strings.Join(table.Rows[1], " | "); !strings.Contains(row, "0/2") || !strings.HasSuffix(row, "N/A") {
+		t.Errorf("Expected no tokens per output for a workflow without outputs, got %s", row)
+	}
+
+	if _, ok := buildWorkflowProductivityTable(nil); ok {
+		t.Error("Expected no table without runs")
+	}
+}
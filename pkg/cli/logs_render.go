@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/githubnext/gh-aw/pkg/console"
+	"github.com/githubnext/gh-aw/pkg/workflow"
+)
+
+// RenderRunLogs downloads the artifacts of a workflow run, unless they are already on disk,
+// and prints the agent transcript of each of its logs as rendered in the step summary
+func RenderRunLogs(runID int64, outputDir string, verbose bool) error {
+	runOutputDir := filepath.Join(outputDir, fmt.Sprintf("run-%d", runID))
+	if err := downloadRunArtifacts(runID, runOutputDir, verbose); err != nil {
+		return err
+	}
+
+	transcripts, err := renderRunTranscripts(runOutputDir, verbose)
+	if err != nil {
+		return fmt.Errorf("failed to render logs for run %d: %w", runID, err)
+	}
+	if len(transcripts) == 0 {
+		return fmt.Errorf("no agent logs with a supported engine found for run %d in %s", runID, runOutputDir)
+	}
+
+	for i, transcript := range transcripts {
+		if len(transcripts) > 1 {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Println(console.FormatInfoMessage(fmt.Sprintf("Agent log %s", transcript.path)))
+			fmt.Println()
+		}
+		fmt.Println(strings.TrimSpace(transcript.markdown))
+	}
+	return nil
+}
+
+// runTranscript is the rendered transcript of a single agent log file
+type runTranscript struct {
+	path     string // Relative to the run directory
+	markdown string
+}
+
+// renderRunTranscripts renders each agent log in the run directory with the renderer of its engine
+func renderRunTranscripts(logDir string, verbose bool) ([]runTranscript, error) {
+	var transcripts []runTranscript
+	err := walkAgentLogs(logDir, verbose, func(path string, engine workflow.CodingAgentEngine, content string) {
+		markdown := engine.RenderLog(content)
+		if markdown == "" {
+			if verbose {
+				fmt.Println(console.FormatWarningMessage(fmt.Sprintf("Engine %s has no log renderer, skipping %s", engine.GetID(), path)))
+			}
+			return
+		}
+		// Other files of the run, like the firewall logs, hold no agent session
+		if engine.ParseLogTrace(content).IsEmpty() {
+			return
+		}
+		relativePath, err := filepath.Rel(logDir, path)
+		if err != nil {
+			relativePath = path
+		}
+		transcripts = append(transcripts, runTranscript{path: relativePath, markdown: markdown})
+	})
+
+	return transcripts, err
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderRunTranscripts(t *testing.T) {
+	run := writeTraceTestRun(t, "codex", testCodexTraceLog)
+	if err := os.WriteFile(filepath.Join(run.LogsPath, "squid-access.log"), []byte("1693485428.123 TCP_DENIED/403 example.com\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	transcripts, err := renderRunTranscripts(run.LogsPath, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(transcripts) != 1 {
+		t.Fatalf("Expected only the agent log to be rendered, got %d transcripts", len(transcripts))
+	}
+	if transcripts[0].path != filepath.Join("agent.log", "agent.log") {
+		t.Errorf("Expected the path relative to the run directory, got %s", transcripts[0].path)
+	}
+
+	for _, expected := range []string{
+		"* ✅ `github::get_issue(...)`",
+		"* ❓ `bash -lc 'make test'`",
+		"**Total Tokens Used:** 1,500",
+		"## 🤖 Reasoning",
+	} {
+		if !strings.Contains(transcripts[0].markdown, expected) {
+			t.Errorf("Expected transcript to contain %q, got:\n%s", expected, transcripts[0].markdown)
+		}
+	}
+}
+
+func TestRenderRunTranscriptsWithoutRenderer(t *testing.T) {
+	run := writeTraceTestRun(t, "gemini", "some gemini output")
+
+	transcripts, err := renderRunTranscripts(run.LogsPath, false)
+	if err != nil || len(transcripts) != 0 {
+		t.Errorf("Expected no transcripts for an engine without a renderer, got %v, %v", transcripts, err)
+	}
+}
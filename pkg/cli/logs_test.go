@@ -17,7 +17,7 @@ func TestDownloadWorkflowLogs(t *testing.T) {
 	// Test the DownloadWorkflowLogs function
 	// This should either fail with auth error (if not authenticated)
 	// or succeed with no results (if authenticated but no workflows match)
-	err := DownloadWorkflowLogs("", 1, "", "", "./test-logs", "", false)
+	err := DownloadWorkflowLogs("", 1, "", "", "./test-logs", "", LogsFilter{}, "", "", "", false)
 
 	// If GitHub CLI is authenticated, the function may succeed but find no results
 	// If not authenticated, it should return an auth error
@@ -55,6 +55,25 @@ func TestFormatDuration(t *testing.T) {
 	}
 }
 
+func TestFormatToolCalls(t *testing.T) {
+	tests := []struct {
+		calls    int
+		failures int
+		expected string
+	}{
+		{0, 0, "N/A"},
+		{42, 0, "42"},
+		{42, 3, "42 (3 failed)"},
+	}
+
+	for _, tt := range tests {
+		result := formatToolCalls(tt.calls, tt.failures)
+		if result != tt.expected {
+			t.Errorf("formatToolCalls(%d, %d) = %q, expected %q", tt.calls, tt.failures, result, tt.expected)
+		}
+	}
+}
+
 func TestFormatNumber(t *testing.T) {
 	tests := []struct {
 		input    int
@@ -359,7 +378,7 @@ func TestListWorkflowRunsWithPagination(t *testing.T) {
 
 	// This should fail with authentication error (if not authenticated)
 	// or succeed with empty results (if authenticated but no workflows match)
-	runs, err := listWorkflowRunsWithPagination("nonexistent-workflow", 5, "", "", "2024-01-01T00:00:00Z", false)
+	runs, err := listWorkflowRunsWithPagination("nonexistent-workflow", 5, "", "", "2024-01-01T00:00:00Z", LogsFilter{}, false)
 
 	if err != nil {
 		// If there's an error, it should be an authentication error or workflow not found
@@ -777,7 +796,7 @@ func TestDownloadWorkflowLogsWithEngineFilter(t *testing.T) {
 			if !tt.expectError {
 				// For valid engines, test that the function can be called without panic
 				// It may still fail with auth errors, which is expected
-				err := DownloadWorkflowLogs("", 1, "", "", "./test-logs", tt.engine, false)
+				err := DownloadWorkflowLogs("", 1, "", "", "./test-logs", tt.engine, LogsFilter{}, "", "", "", false)
 
 				// Clean up any created directories
 				os.RemoveAll("./test-logs")
@@ -813,7 +832,7 @@ func TestLogsCommandFlags(t *testing.T) {
 		t.Fatal("Engine flag not found")
 	}
 
-	if engineFlag.Usage != "Filter logs by agentic engine type (claude, codex)" {
+	if engineFlag.Usage != "Filter logs by agentic engine type (claude, codex, gemini)" {
 		t.Errorf("Unexpected engine flag usage text: %s", engineFlag.Usage)
 	}
 
@@ -875,3 +894,70 @@ func TestExtractLogMetricsWithAwOutputFile(t *testing.T) {
 		t.Fatalf("extractLogMetrics in verbose mode failed: %v", err)
 	}
 }
+
+func TestExtractLogMetricsEngineMatrix(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Each matrix leg uploads its artifacts with a "-<leg>" suffix
+	files := map[string]string{
+		"aw_info.json-claude/aw_info.json": `{"engine_id": "claude"}`,
+		"aw_info.json-codex/aw_info.json":  `{"engine_id": "codex"}`,
+		"matrix.log-claude/matrix.log":     `{"type": "result", "total_cost_usd": 0.25, "usage": {"input_tokens": 100, "output_tokens": 50}}`,
+		"matrix.log-codex/matrix.log":      "[2025-08-13T00:24:50] tokens used: 1000",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	metrics, err := extractLogMetrics(tmpDir, false)
+	if err != nil {
+		t.Fatalf("extractLogMetrics failed: %v", err)
+	}
+
+	// Each leg's log is parsed with its own engine
+	if metrics.TokenUsage != 150+1000 {
+		t.Errorf("Expected token usage %d, got %d", 150+1000, metrics.TokenUsage)
+	}
+	if metrics.EstimatedCost != 0.25 {
+		t.Errorf("Expected cost 0.25, got %f", metrics.EstimatedCost)
+	}
+}
+
+func TestExtractLogMetricsSkipsPromptArtifact(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"aw_info.json":          `{"engine_id": "claude"}`,
+		"prompt.txt/prompt.txt": "ERROR: fix every error and warning\nWARNING: do not ignore warnings\n",
+		"agent-stdio.log":       `{"type": "result", "total_cost_usd": 0.25, "usage": {"input_tokens": 100, "output_tokens": 50}}`,
+		"workflow-complete.txt": "ERROR: workflow completed\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	metrics, err := extractLogMetrics(tmpDir, false)
+	if err != nil {
+		t.Fatalf("extractLogMetrics failed: %v", err)
+	}
+
+	// Only the agent log is parsed, so the prompt's words are not counted as errors
+	if metrics.ErrorCount != 0 || metrics.WarningCount != 0 {
+		t.Errorf("Expected no errors or warnings, got %d errors and %d warnings", metrics.ErrorCount, metrics.WarningCount)
+	}
+	if metrics.TokenUsage != 150 {
+		t.Errorf("Expected token usage 150, got %d", metrics.TokenUsage)
+	}
+}
@@ -0,0 +1,374 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/githubnext/gh-aw/pkg/workflow"
+)
+
+// Trace formats of the logs --trace-format flag
+const (
+	TraceFormatOTLPJSON = "otlp-json"
+	TraceFormatJSONL    = "jsonl"
+)
+
+// traceFileNames maps each trace format to the file written to the run directory
+var traceFileNames = map[string]string{
+	TraceFormatOTLPJSON: "trace.otlp.json",
+	TraceFormatJSONL:    "trace.jsonl",
+}
+
+// OTLP span kinds and status codes, see opentelemetry-proto trace/v1/trace.proto
+const (
+	otlpSpanKindInternal = 1
+	otlpStatusCodeError  = 2
+)
+
+// otlpTraceData is the OTLP/JSON encoding of an ExportTraceServiceRequest
+type otlpTraceData struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+// otlpAnyValue holds one of the values, OTLP/JSON encodes 64-bit integers as strings
+type otlpAnyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+}
+
+type otlpStatus struct {
+	Code int `json:"code,omitempty"`
+}
+
+// stringAttribute returns a string span attribute
+func stringAttribute(key, value string) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: &value}}
+}
+
+// intAttribute returns an integer span attribute
+func intAttribute(key string, value int) otlpKeyValue {
+	encoded := strconv.Itoa(value)
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{IntValue: &encoded}}
+}
+
+// isValidTraceFormat reports whether the format is supported by --trace-format
+func isValidTraceFormat(format string) bool {
+	_, ok := traceFileNames[format]
+	return ok
+}
+
+// extractRunTraces normalizes the agent logs of a run into one trace per agent session.
+// Engine matrix runs have one session per leg.
+func extractRunTraces(logDir string, verbose bool) ([]*workflow.AgentTrace, error) {
+	var traces []*workflow.AgentTrace
+	err := walkAgentLogs(logDir, verbose, func(path string, engine workflow.CodingAgentEngine, content string) {
+		if trace := engine.ParseLogTrace(content); !trace.IsEmpty() {
+			traces = append(traces, trace)
+		}
+	})
+
+	return traces, err
+}
+
+// writeRunTrace writes the agent traces of the run to its logs directory in the given format
+// and returns the path of the written file, or an empty path when the run has no traces
+func writeRunTrace(run WorkflowRun, format string, verbose bool) (string, error) {
+	traces, err := extractRunTraces(run.LogsPath, verbose)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract traces for run %d: %w", run.DatabaseID, err)
+	}
+	if len(traces) == 0 {
+		return "", nil
+	}
+
+	var content []byte
+	switch format {
+	case TraceFormatJSONL:
+		content, err = renderTraceJSONL(traces)
+	case TraceFormatOTLPJSON:
+		content, err = json.MarshalIndent(buildOTLPTrace(run, traces), "", "  ")
+	default:
+		return "", fmt.Errorf("unsupported trace format '%s'", format)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to render trace for run %d: %w", run.DatabaseID, err)
+	}
+
+	tracePath := filepath.Join(run.LogsPath, traceFileNames[format])
+	if err := os.WriteFile(tracePath, content, 0644); err != nil {
+		return "", fmt.Errorf("failed to write trace for run %d: %w", run.DatabaseID, err)
+	}
+	return tracePath, nil
+}
+
+// renderTraceJSONL renders the events of the traces as one JSON object per line
+func renderTraceJSONL(traces []*workflow.AgentTrace) ([]byte, error) {
+	var builder strings.Builder
+	for _, trace := range traces {
+		for _, event := range trace.Events {
+			line, err := json.Marshal(event)
+			if err != nil {
+				return nil, err
+			}
+			builder.Write(line)
+			builder.WriteString("\n")
+		}
+	}
+	return []byte(builder.String()), nil
+}
+
+// buildOTLPTrace converts the agent traces of a run into OTLP spans. Each run is one trace,
+// each agent session a root span with a child span per turn, and tool calls are children of
+// their turn. Attribute names follow the OpenTelemetry GenAI semantic conventions where
+// they apply.
+func buildOTLPTrace(run WorkflowRun, traces []*workflow.AgentTrace) otlpTraceData {
+	traceID := otlpID(16, strconv.FormatInt(run.DatabaseID, 10))
+
+	var spans []otlpSpan
+	for sessionIndex, trace := range traces {
+		spans = append(spans, buildSessionSpans(run, trace, traceID, sessionIndex)...)
+	}
+
+	return otlpTraceData{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpKeyValue{
+			stringAttribute("service.name", "gh-aw"),
+			stringAttribute("gh_aw.workflow", run.WorkflowName),
+			intAttribute("gh_aw.run_id", int(run.DatabaseID)),
+			stringAttribute("gh_aw.run_url", run.URL),
+		}},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: "gh-aw", Version: GetVersion()},
+			Spans: spans,
+		}},
+	}}}
+}
+
+// buildSessionSpans converts one agent session into its spans
+func buildSessionSpans(run WorkflowRun, trace *workflow.AgentTrace, traceID string, sessionIndex int) []otlpSpan {
+	times, end, estimated := traceEventTimes(run, trace)
+	session := trace.Session()
+	spanID := func(eventIndex int) string {
+		return otlpID(8, fmt.Sprintf("%d/%d/%d", run.DatabaseID, sessionIndex, eventIndex))
+	}
+
+	root := otlpSpan{
+		TraceID:           traceID,
+		SpanID:            spanID(0),
+		Name:              "invoke_agent " + session.Engine,
+		Kind:              otlpSpanKindInternal,
+		StartTimeUnixNano: unixNano(times[0]),
+		EndTimeUnixNano:   unixNano(end),
+		Attributes: []otlpKeyValue{
+			stringAttribute("gen_ai.operation.name", "invoke_agent"),
+			stringAttribute("gen_ai.agent.name", session.Engine),
+			intAttribute("gh_aw.turns", session.Turn),
+			intAttribute("gh_aw.usage.total_tokens", session.TotalTokens),
+		},
+	}
+	if session.Model != "" {
+		root.Attributes = append(root.Attributes, stringAttribute("gen_ai.request.model", session.Model))
+	}
+	if session.InputTokens > 0 || session.OutputTokens > 0 {
+		root.Attributes = append(root.Attributes,
+			intAttribute("gen_ai.usage.input_tokens", session.InputTokens),
+			intAttribute("gen_ai.usage.output_tokens", session.OutputTokens),
+		)
+	}
+	if session.StopReason != "" {
+		root.Attributes = append(root.Attributes, stringAttribute("gh_aw.stop_reason", session.StopReason))
+	}
+	if session.StopReason == workflow.StopReasonError {
+		root.Status.Code = otlpStatusCodeError
+	}
+	if estimated {
+		// The engine log has no timestamps, events are spread evenly over the session
+		root.Attributes = append(root.Attributes, stringAttribute("gh_aw.timing", "estimated"))
+	}
+
+	spans := []otlpSpan{root}
+	turnSpans := make(map[int]int)    // turn -> index in spans
+	toolSpans := make(map[string]int) // tool call id -> index in spans
+	toolStarts := make(map[string]time.Time)
+
+	for i, event := range trace.Events {
+		if i == 0 {
+			continue
+		}
+		switch event.Type {
+		case workflow.TraceEventTurn:
+			// A turn lasts until the next turn starts
+			turnEnd := end
+			for j := i + 1; j < len(trace.Events); j++ {
+				if trace.Events[j].Type == workflow.TraceEventTurn {
+					turnEnd = times[j]
+					break
+				}
+			}
+			turnSpans[event.Turn] = len(spans)
+			spans = append(spans, otlpSpan{
+				TraceID:           traceID,
+				SpanID:            spanID(i),
+				ParentSpanID:      root.SpanID,
+				Name:              fmt.Sprintf("turn %d", event.Turn),
+				Kind:              otlpSpanKindInternal,
+				StartTimeUnixNano: unixNano(times[i]),
+				EndTimeUnixNano:   unixNano(turnEnd),
+				Attributes:        []otlpKeyValue{intAttribute("gh_aw.turn", event.Turn)},
+			})
+		case workflow.TraceEventModelUsage:
+			if index, ok := turnSpans[event.Turn]; ok {
+				span := &spans[index]
+				if event.Model != "" {
+					span.Attributes = append(span.Attributes, stringAttribute("gen_ai.response.model", event.Model))
+				}
+				if event.InputTokens > 0 || event.OutputTokens > 0 {
+					span.Attributes = append(span.Attributes,
+						intAttribute("gen_ai.usage.input_tokens", event.InputTokens),
+						intAttribute("gen_ai.usage.output_tokens", event.OutputTokens),
+					)
+				}
+				span.Attributes = append(span.Attributes, intAttribute("gh_aw.usage.total_tokens", event.TotalTokens))
+			}
+		case workflow.TraceEventToolCall:
+			parent := root.SpanID
+			if index, ok := turnSpans[event.Turn]; ok {
+				parent = spans[index].SpanID
+			}
+			toolSpans[event.ToolCallID] = len(spans)
+			toolStarts[event.ToolCallID] = times[i]
+			spans = append(spans, otlpSpan{
+				TraceID:           traceID,
+				SpanID:            spanID(i),
+				ParentSpanID:      parent,
+				Name:              "execute_tool " + event.ToolName,
+				Kind:              otlpSpanKindInternal,
+				StartTimeUnixNano: unixNano(times[i]),
+				EndTimeUnixNano:   unixNano(times[i]),
+				Attributes: []otlpKeyValue{
+					stringAttribute("gen_ai.operation.name", "execute_tool"),
+					stringAttribute("gen_ai.tool.name", event.ToolName),
+					stringAttribute("gen_ai.tool.call.id", event.ToolCallID),
+				},
+			})
+		case workflow.TraceEventToolResult:
+			index, ok := toolSpans[event.ToolCallID]
+			if !ok || event.ToolCallID == "" {
+				continue
+			}
+			span := &spans[index]
+			resultEnd := times[i]
+			// Prefer the measured duration when the log timestamps are coarser
+			if measured := toolStarts[event.ToolCallID].Add(time.Duration(event.DurationMs) * time.Millisecond); measured.After(resultEnd) {
+				resultEnd = measured
+			}
+			span.EndTimeUnixNano = unixNano(resultEnd)
+			if event.IsError {
+				span.Status.Code = otlpStatusCodeError
+			}
+		}
+	}
+
+	return spans
+}
+
+// traceEventTimes returns the time of every event of the trace and the end of the session.
+// Engines without timestamps get their events spread evenly over the session, starting
+// when the run started.
+func traceEventTimes(run WorkflowRun, trace *workflow.AgentTrace) ([]time.Time, time.Time, bool) {
+	session := trace.Session()
+	times := make([]time.Time, len(trace.Events))
+
+	estimated := true
+	for _, event := range trace.Events {
+		if !event.Timestamp.IsZero() {
+			estimated = false
+			break
+		}
+	}
+
+	if !estimated {
+		current := session.Timestamp
+		for i, event := range trace.Events {
+			if !event.Timestamp.IsZero() {
+				current = event.Timestamp
+			}
+			times[i] = current
+		}
+		end := times[len(times)-1]
+		if sessionEnd := times[0].Add(time.Duration(session.DurationMs) * time.Millisecond); sessionEnd.After(end) {
+			end = sessionEnd
+		}
+		return times, end, false
+	}
+
+	start := run.StartedAt
+	if start.IsZero() {
+		start = run.CreatedAt
+	}
+	duration := time.Duration(session.DurationMs) * time.Millisecond
+	if duration == 0 {
+		duration = run.Duration
+	}
+	if duration == 0 {
+		duration = time.Duration(len(trace.Events)) * time.Millisecond
+	}
+	for i := range trace.Events {
+		times[i] = start.Add(duration * time.Duration(i) / time.Duration(len(trace.Events)))
+	}
+	return times, start.Add(duration), true
+}
+
+// otlpID derives a stable hex trace or span ID of the given byte length from the seed,
+// so exporting the same run twice yields the same IDs
+func otlpID(length int, seed string) string {
+	sum := sha256.Sum256([]byte(seed))
+	return hex.EncodeToString(sum[:length])
+}
+
+// unixNano formats a time as OTLP/JSON nanoseconds since the epoch
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/githubnext/gh-aw/pkg/workflow"
+)
+
+const testCodexTraceLog = `[2025-08-31T12:37:08] OpenAI Codex v0.27.0 (research preview)
+[2025-08-31T12:37:08] User instructions:
+Summarize the repository.
+[2025-08-31T12:37:20] tool github.get_issue({"number":1})
+[2025-08-31T12:37:20] github.get_issue({"number":1}) success in 450ms:
+[2025-08-31T12:37:22] tokens used: 1000
+[2025-08-31T12:37:30] exec bash -lc 'make test' in /home/runner/work/repo
+[2025-08-31T12:37:31] bash -lc 'make test' exited 2 in 1.2s:
+[2025-08-31T12:37:32] tokens used: 500`
+
+func writeTraceTestRun(t *testing.T, engineID, logContent string) WorkflowRun {
+	t.Helper()
+	runDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(runDir, "aw_info.json"), []byte(`{"engine_id": "`+engineID+`"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(runDir, "agent.log"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(runDir, "agent.log", "agent.log"), []byte(logContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return WorkflowRun{
+		DatabaseID:   12345,
+		WorkflowName: "Test Trace",
+		StartedAt:    time.Date(2025, 8, 31, 12, 37, 0, 0, time.UTC),
+		LogsPath:     runDir,
+	}
+}
+
+func TestWriteRunTraceOTLP(t *testing.T) {
+	run := writeTraceTestRun(t, "codex", testCodexTraceLog)
+
+	tracePath, err := writeRunTrace(run, TraceFormatOTLPJSON, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if filepath.Base(tracePath) != "trace.otlp.json" {
+		t.Errorf("Expected trace.otlp.json, got %s", tracePath)
+	}
+
+	content, err := os.ReadFile(tracePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data otlpTraceData
+	if err := json.Unmarshal(content, &data); err != nil {
+		t.Fatalf("Invalid OTLP JSON: %v", err)
+	}
+
+	spans := data.ResourceSpans[0].ScopeSpans[0].Spans
+	spansByName := make(map[string]otlpSpan)
+	for _, span := range spans {
+		spansByName[span.Name] = span
+		if span.TraceID != spans[0].TraceID || len(span.TraceID) != 32 || len(span.SpanID) != 16 {
+			t.Errorf("Invalid trace or span ID on span %q: %s/%s", span.Name, span.TraceID, span.SpanID)
+		}
+	}
+
+	root, ok := spansByName["invoke_agent codex"]
+	if !ok || root.ParentSpanID != "" {
+		t.Fatalf("Expected a codex root span, got %+v", spans)
+	}
+	turn := spansByName["turn 1"]
+	if turn.ParentSpanID != root.SpanID {
+		t.Errorf("Expected turn 1 to be a child of the session span")
+	}
+
+	tool := spansByName["execute_tool github.get_issue"]
+	if tool.ParentSpanID != turn.SpanID {
+		t.Errorf("Expected the tool call to be a child of turn 1")
+	}
+	// The log timestamps have second precision, the measured duration is used instead
+	start := time.Date(2025, 8, 31, 12, 37, 20, 0, time.UTC)
+	if tool.StartTimeUnixNano != unixNano(start) || tool.EndTimeUnixNano != unixNano(start.Add(450*time.Millisecond)) {
+		t.Errorf("Unexpected tool span timing: %s - %s", tool.StartTimeUnixNano, tool.EndTimeUnixNano)
+	}
+
+	if exec := spansByName["execute_tool exec"]; exec.Status.Code != otlpStatusCodeError {
+		t.Errorf("Expected the failed command to have an error status, got %+v", exec.Status)
+	}
+
+	// Exporting the same run again yields the same IDs
+	again := buildOTLPTrace(run, mustExtractRunTraces(t, run))
+	if again.ResourceSpans[0].ScopeSpans[0].Spans[0].SpanID != spans[0].SpanID {
+		t.Error("Expected stable span IDs across exports")
+	}
+}
+
+func TestWriteRunTraceEstimatedTiming(t *testing.T) {
+	run := writeTraceTestRun(t, "claude", `[
+  {"type": "assistant", "message": {"id": "msg_1", "content": [{"type": "tool_use", "id": "toolu_1", "name": "Bash", "input": {}}]}},
+  {"type": "user", "message": {"content": [{"type": "tool_result", "tool_use_id": "toolu_1", "content": "ok"}]}},
+  {"type": "result", "subtype": "success", "num_turns": 1, "duration_ms": 4000}
+]`)
+
+	data := buildOTLPTrace(run, mustExtractRunTraces(t, run))
+	root := data.ResourceSpans[0].ScopeSpans[0].Spans[0]
+	if root.StartTimeUnixNano != unixNano(run.StartedAt) || root.EndTimeUnixNano != unixNano(run.StartedAt.Add(4*time.Second)) {
+		t.Errorf("Expected the session to span the 4s from the run start, got %s - %s", root.StartTimeUnixNano, root.EndTimeUnixNano)
+	}
+
+	estimated := false
+	for _, attribute := range root.Attributes {
+		if attribute.Key == "gh_aw.timing" && *attribute.Value.StringValue == "estimated" {
+			estimated = true
+		}
+	}
+	if !estimated {
+		t.Error("Expected the session span to be marked as estimated timing")
+	}
+}
+
+func TestWriteRunTraceJSONL(t *testing.T) {
+	run := writeTraceTestRun(t, "codex", testCodexTraceLog)
+
+	tracePath, err := writeRunTrace(run, TraceFormatJSONL, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(tracePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 9 {
+		t.Fatalf("Expected 9 events, got %d:\n%s", len(lines), content)
+	}
+	if !strings.HasPrefix(lines[0], `{"type":"session","timestamp":"2025-08-31T12:37:08Z","engine":"codex"`) {
+		t.Errorf("Expected the session event first, got %s", lines[0])
+	}
+}
+
+func TestWriteRunTraceWithoutEngine(t *testing.T) {
+	tracePath, err := writeRunTrace(WorkflowRun{LogsPath: t.TempDir()}, TraceFormatJSONL, false)
+	if err != nil || tracePath != "" {
+		t.Errorf("Expected no trace without aw_info.json, got %q, %v", tracePath, err)
+	}
+}
+
+func mustExtractRunTraces(t *testing.T, run WorkflowRun) []*workflow.AgentTrace {
+	t.Helper()
+	traces, err := extractRunTraces(run.LogsPath, false)
+	if err != nil || len(traces) == 0 {
+		t.Fatalf("Expected traces, got %v (%v)", traces, err)
+	}
+	return traces
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// runHistoryFileName is the run history in the logs directory, one LogsRunRecord per line
+const runHistoryFileName = "run-history.jsonl"
+
+// recordRunHistory adds the processed runs to the run history of the logs directory, replacing
+// the previous records of runs that were analyzed again
+func recordRunHistory(logsDir string, processedRuns []ProcessedRun) error {
+	if len(processedRuns) == 0 {
+		return nil
+	}
+
+	records, err := loadRunHistory(logsDir)
+	if err != nil {
+		return err
+	}
+
+	byRunID := make(map[int64]int, len(records))
+	for i, record := range records {
+		byRunID[record.RunID] = i
+	}
+	for _, processedRun := range processedRuns {
+		record := buildLogsRunRecord(processedRun)
+		if i, exists := byRunID[record.RunID]; exists {
+			records[i] = record
+			continue
+		}
+		byRunID[record.RunID] = len(records)
+		records = append(records, record)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		if !records[i].CreatedAt.Equal(records[j].CreatedAt) {
+			return records[i].CreatedAt.Before(records[j].CreatedAt)
+		}
+		return records[i].RunID < records[j].RunID
+	})
+
+	return writeRunHistory(logsDir, records)
+}
+
+// loadRunHistory loads the run history of the logs directory, oldest run first
+func loadRunHistory(logsDir string) ([]LogsRunRecord, error) {
+	historyPath := filepath.Join(logsDir, runHistoryFileName)
+	file, err := os.Open(historyPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open run history: %w", err)
+	}
+	defer file.Close()
+
+	var records []LogsRunRecord
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record LogsRunRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("invalid run history record at %s:%d: %w", historyPath, lineNumber, err)
+		}
+		if record.SchemaVersion > LogsSchemaVersion {
+			return nil, fmt.Errorf("run history %s was written by a newer version of gh-aw (schema version %d)", historyPath, record.SchemaVersion)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read run history: %w", err)
+	}
+	return records, nil
+}
+
+// writeRunHistory replaces the run history of the logs directory with the records
+func writeRunHistory(logsDir string, records []LogsRunRecord) error {
+	if err := os.MkdirAll(logsDir, 0755); err != nil {
+		return fmt.Errorf("failed to create logs directory: %w", err)
+	}
+
+	// Write to a temporary file first so an interrupted write does not lose the history
+	historyPath := filepath.Join(logsDir, runHistoryFileName)
+	tempFile, err := os.CreateTemp(logsDir, runHistoryFileName+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write run history: %w", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	writer := bufio.NewWriter(tempFile)
+	encoder := json.NewEncoder(writer)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			tempFile.Close()
+			return fmt.Errorf("failed to write run history: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		tempFile.Close()
+		return fmt.Errorf("failed to write run history: %w", err)
+	}
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("failed to write run history: %w", err)
+	}
+	if err := os.Rename(tempFile.Name(), historyPath); err != nil {
+		return fmt.Errorf("failed to write run history: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordRunHistory(t *testing.T) {
+	logsDir := t.TempDir()
+
+	first := ProcessedRun{Run: WorkflowRun{DatabaseID: 2, WorkflowName: "Triage", CreatedAt: time.Date(2025, 9, 2, 0, 0, 0, 0, time.UTC), TokenUsage: 100}}
+	second := ProcessedRun{Run: WorkflowRun{DatabaseID: 1, WorkflowName: "Triage", CreatedAt: time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC), TokenUsage: 200}}
+	if err := recordRunHistory(logsDir, []ProcessedRun{first, second}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Analyzing a run again replaces its record
+	first.Run.TokenUsage = 150
+	if err := recordRunHistory(logsDir, []ProcessedRun{first}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	records, err := loadRunHistory(logsDir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+	if records[0].RunID != 1 || records[1].RunID != 2 {
+		t.Errorf("Expected records ordered by creation time, got runs %d and %d", records[0].RunID, records[1].RunID)
+	}
+	if records[1].TokenUsage != 150 {
+		t.Errorf("Expected the latest analysis of run 2, got %d tokens", records[1].TokenUsage)
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(logsDir, "*.tmp"))
+	if len(matches) != 0 {
+		t.Errorf("Expected no temporary files left, got %v", matches)
+	}
+}
+
+func TestLoadRunHistory(t *testing.T) {
+	logsDir := t.TempDir()
+	records, err := loadRunHistory(logsDir)
+	if err != nil || records != nil {
+		t.Errorf("Expected an empty history without a history file, got %v, %v", records, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(logsDir, runHistoryFileName), []byte(`{"schema_version": 99, "run_id": 1}`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadRunHistory(logsDir); err == nil || !strings.Contains(err.Error(), "newer version") {
+		t.Errorf("Expected an error for a newer schema version, got %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(logsDir, runHistoryFileName), []byte("{not json\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadRunHistory(logsDir); err == nil || !strings.Contains(err.Error(), ":1:") {
+		t.Errorf("Expected an error with the line number, got %v", err)
+	}
+}
@@ -0,0 +1,338 @@
+package cli
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/githubnext/gh-aw/pkg/console"
+	"github.com/githubnext/gh-aw/pkg/constants"
+	"github.com/githubnext/gh-aw/pkg/workflow"
+	"github.com/spf13/cobra"
+)
+
+// NewStatsCommand creates the stats command
+func NewStatsCommand() *cobra.Command {
+	statsCmd := &cobra.Command{
+		Use:   "stats [agentic-workflow-id]",
+		Short: "Report trends over the run history recorded by the logs command",
+		Long: `Report trends over the run history recorded by the logs command.
+
+Every run analyzed by '` + constants.CLIExtensionPrefix + ` logs', online or with --offline, is recorded in
+the run history of the logs directory. This command reports from that history:
+- Runs, failure rate, token usage and cost per workflow per week
+- Token usage percentiles per workflow
+- The most requested missing tools
+
+Examples:
+  ` + constants.CLIExtensionPrefix + ` stats                           # Trends of all workflows
+  ` + constants.CLIExtensionPrefix + ` stats weekly-research           # Trends of a specific workflow
+  ` + constants.CLIExtensionPrefix + ` stats --start-date -1mo         # Trends of the last month
+  ` + constants.CLIExtensionPrefix + ` stats -o ./my-logs              # Run history of another logs directory`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			logsDir, _ := cmd.Flags().GetString("output")
+			startDate, _ := cmd.Flags().GetString("start-date")
+
+			var workflowName string
+			if len(args) > 0 && args[0] != "" {
+				// Runs are recorded with their GitHub Actions workflow name
+				workflowName = args[0]
+				if resolvedName, err := workflow.ResolveWorkflowName(args[0]); err == nil {
+					workflowName = resolvedName
+				}
+			}
+
+			if startDate != "" {
+				resolvedStartDate, err := workflow.ResolveRelativeDate(startDate, time.Now())
+				if err != nil {
+					fmt.Fprintln(os.Stderr, console.FormatError(console.CompilerError{
+						Type:    "error",
+						Message: fmt.Sprintf("invalid start-date format '%s': %v", startDate, err),
+					}))
+					os.Exit(1)
+				}
+				startDate = resolvedStartDate
+			}
+
+			if err := ShowRunStats(logsDir, workflowName, startDate); err != nil {
+				fmt.Fprintln(os.Stderr, console.FormatError(console.CompilerError{
+					Type:    "error",
+					Message: err.Error(),
+				}))
+				os.Exit(1)
+			}
+		},
+	}
+
+	statsCmd.Flags().StringP("output", "o", "./logs", "Logs directory holding the run history")
+	statsCmd.Flags().String("start-date", "", "Only include runs created after this date (YYYY-MM-DD or delta like -1d, -1w, -1mo)")
+
+	return statsCmd
+}
+
+// ShowRunStats displays the trend reports of the run history of the logs directory
+func ShowRunStats(logsDir, workflowName, startDate string) error {
+	records, err := loadRunHistory(logsDir)
+	if err != nil {
+		return err
+	}
+
+	var start time.Time
+	if startDate != "" {
+		if start, err = time.Parse("2006-01-02", startDate); err != nil {
+			return fmt.Errorf("invalid start-date '%s': %w", startDate, err)
+		}
+	}
+	var selected []LogsRunRecord
+	for _, record := range records {
+		if workflowName != "" && record.WorkflowName != workflowName {
+			continue
+		}
+		if !start.IsZero() && record.CreatedAt.Before(start) {
+			continue
+		}
+		selected = append(selected, record)
+	}
+
+	if len(selected) == 0 {
+		fmt.Println(console.FormatWarningMessage(fmt.Sprintf("No runs recorded in the run history of %s. Run '%s logs' to record runs.", logsDir, constants.CLIExtensionPrefix)))
+		return nil
+	}
+
+	displayWeeklyStats(buildWeeklyStats(selected))
+	displayTokenPercentiles(buildTokenPercentiles(selected))
+	displayMissingToolStats(buildMissingToolStats(selected))
+
+	fmt.Println(console.FormatInfoMessage(fmt.Sprintf("%d runs from %s to %s", len(selected),
+		selected[0].CreatedAt.Format("2006-01-02"), selected[len(selected)-1].CreatedAt.Format("2006-01-02"))))
+	return nil
+}
+
+// weeklyStats aggregates the runs of a workflow in a week starting on Monday
+type weeklyStats struct {
+	Week         time.Time
+	WorkflowName string
+	Runs         int
+	Completed    int // Runs with a known conclusion
+	Failed       int
+	TokenUsage   int
+	Cost         float64
+}
+
+// FailureRate returns the share of failed runs among the runs with a known conclusion, or
+// -1 when no run has one
+func (s weeklyStats) FailureRate() float64 {
+	if s.Completed == 0 {
+		return -1
+	}
+	return float64(s.Failed) / float64(s.Completed)
+}
+
+// weekStart returns the Monday starting the week of the time, in UTC
+func weekStart(t time.Time) time.Time {
+	day := t.UTC().Truncate(24 * time.Hour)
+	offset := (int(day.Weekday()) + 6) % 7 // Days since Monday
+	return day.AddDate(0, 0, -offset)
+}
+
+// isFailedConclusion reports whether a run conclusion is a failure of the run
+func isFailedConclusion(conclusion string) bool {
+	return conclusion == "failure" || conclusion == "timed_out" || conclusion == "startup_failure"
+}
+
+// buildWeeklyStats aggregates the records per week and workflow, by week then workflow name
+func buildWeeklyStats(records []LogsRunRecord) []weeklyStats {
+	type key struct {
+		week     time.Time
+		workflow string
+	}
+	stats := make(map[key]*weeklyStats)
+	for _, record := range records {
+		k := key{weekStart(record.CreatedAt), record.WorkflowName}
+		s, exists := stats[k]
+		if !exists {
+			s = &weeklyStats{Week: k.week, WorkflowName: k.workflow}
+			stats[k] = s
+		}
+		s.Runs++
+		s.TokenUsage += record.TokenUsage
+		s.Cost += record.EstimatedCostUSD
+		if record.Conclusion != "" {
+			s.Completed++
+			if isFailedConclusion(record.Conclusion) {
+				s.Failed++
+			}
+		}
+	}
+
+	result := make([]weeklyStats, 0, len(stats))
+	for _, s := range stats {
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].Week.Equal(result[j].Week) {
+			return result[i].Week.Before(result[j].Week)
+		}
+		return result[i].WorkflowName < result[j].WorkflowName
+	})
+	return result
+}
+
+// displayWeeklyStats displays the per week and workflow table
+func displayWeeklyStats(stats []weeklyStats) {
+	headers := []string{"Week", "Workflow", "Runs", "Failure Rate", "Tokens", "Cost ($)", "Cost/Run ($)"}
+	var rows [][]string
+	for _, s := range stats {
+		failureRate := "N/A"
+		if rate := s.FailureRate(); rate >= 0 {
+			failureRate = fmt.Sprintf("%.0f%% (%d/%d)", rate*100, s.Failed, s.Completed)
+		}
+		rows = append(rows, []string{
+			s.Week.Format("2006-01-02"),
+			s.WorkflowName,
+			fmt.Sprintf("%d", s.Runs),
+			failureRate,
+			formatNumber(s.TokenUsage),
+			fmt.Sprintf("%.3f", s.Cost),
+			fmt.Sprintf("%.3f", s.Cost/float64(s.Runs)),
+		})
+	}
+
+	fmt.Print(console.RenderTable(console.TableConfig{
+		Title:   "Weekly Trends",
+		Headers: headers,
+		Rows:    rows,
+	}))
+}
+
+// tokenPercentiles are the token usage percentiles of the runs of a workflow
+type tokenPercentiles struct {
+	WorkflowName  string
+	Runs          int
+	P50, P90, P99 int
+	Max           int
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []int, p float64) int {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+// buildTokenPercentiles computes the token usage percentiles per workflow, by workflow name
+func buildTokenPercentiles(records []LogsRunRecord) []tokenPercentiles {
+	usage := make(map[string][]int)
+	for _, record := range records {
+		usage[record.WorkflowName] = append(usage[record.WorkflowName], record.TokenUsage)
+	}
+
+	result := make([]tokenPercentiles, 0, len(usage))
+	for name, tokens := range usage {
+		sort.Ints(tokens)
+		result = append(result, tokenPercentiles{
+			WorkflowName: name,
+			Runs:         len(tokens),
+			P50:          percentile(tokens, 50),
+			P90:          percentile(tokens, 90),
+			P99:          percentile(tokens, 99),
+			Max:          tokens[len(tokens)-1],
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].WorkflowName < result[j].WorkflowName })
+	return result
+}
+
+// displayTokenPercentiles displays the token usage percentiles table
+func displayTokenPercentiles(percentiles []tokenPercentiles) {
+	headers := []string{"Workflow", "Runs", "p50", "p90", "p99", "Max"}
+	var rows [][]string
+	for _, p := range percentiles {
+		rows = append(rows, []string{
+			p.WorkflowName,
+			fmt.Sprintf("%d", p.Runs),
+			formatNumber(p.P50),
+			formatNumber(p.P90),
+			formatNumber(p.P99),
+			formatNumber(p.Max),
+		})
+	}
+
+	fmt.Print(console.RenderTable(console.TableConfig{
+		Title:   "Token Usage Percentiles",
+		Headers: headers,
+		Rows:    rows,
+	}))
+}
+
+// missingToolStats counts the reports of a missing tool
+type missingToolStats struct {
+	Tool      string
+	Reports   int
+	Workflows []string
+	LastSeen  time.Time
+}
+
+// buildMissingToolStats counts the missing tool reports of the records, most reported first
+func buildMissingToolStats(records []LogsRunRecord) []missingToolStats {
+	stats := make(map[string]*missingToolStats)
+	for _, record := range records {
+		for _, report := range record.MissingTools {
+			s, exists := stats[report.Tool]
+			if !exists {
+				s = &missingToolStats{Tool: report.Tool}
+				stats[report.Tool] = s
+			}
+			s.Reports++
+			if !contains(s.Workflows, record.WorkflowName) {
+				s.Workflows = append(s.Workflows, record.WorkflowName)
+			}
+			if record.CreatedAt.After(s.LastSeen) {
+				s.LastSeen = record.CreatedAt
+			}
+		}
+	}
+
+	result := make([]missingToolStats, 0, len(stats))
+	for _, s := range stats {
+		sort.Strings(s.Workflows)
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Reports != result[j].Reports {
+			return result[i].Reports > result[j].Reports
+		}
+		return result[i].Tool < result[j].Tool
+	})
+	return result
+}
+
+// displayMissingToolStats displays the most requested missing tools
+func displayMissingToolStats(stats []missingToolStats) {
+	if len(stats) == 0 {
+		return
+	}
+
+	headers := []string{"Tool", "Reports", "Workflows", "Last Seen"}
+	var rows [][]string
+	for _, s := range stats {
+		rows = append(rows, []string{
+			s.Tool,
+			fmt.Sprintf("%d", s.Reports),
+			strings.Join(s.Workflows, ", "),
+			s.LastSeen.Format("2006-01-02"),
+		})
+	}
+
+	fmt.Print(console.RenderTable(console.TableConfig{
+		Title:   "Most Requested Missing Tools",
+		Headers: headers,
+		Rows:    rows,
+	}))
+}
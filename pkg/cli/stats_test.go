@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"testing"
+	"time"
+)
+
+func statsTestRecord(runID int64, workflowName string, created time.Time, conclusion string, tokens int, cost float64, missingTools ...string) LogsRunRecord {
+	record := LogsRunRecord{RunID: runID, WorkflowName: workflowName, CreatedAt: created, Conclusion: conclusion, TokenUsage: tokens, EstimatedCostUSD: cost}
+	for _, tool := range missingTools {
+		record.MissingTools = append(record.MissingTools, MissingToolReport{Tool: tool})
+	}
+	return record
+}
+
+func TestWeekStart(t *testing.T) {
+	tests := map[string]string{
+		"2025-09-01T10:00:00Z":      "2025-09-01", // Monday
+		"2025-09-07T23:59:00Z":      "2025-09-01", // Sunday
+		"2025-09-08T00:00:00Z":      "2025-09-08",
+		"2025-09-03T01:00:00+05:00": "2025-09-01",
+	}
+	for input, expected := range tests {
+		created, _ := time.Parse(time.RFC3339, input)
+		if week := weekStart(created).Format("2006-01-02"); week != expected {
+			t.Errorf("weekStart(%s) = %s, expected %s", input, week, expected)
+		}
+	}
+}
+
+func TestBuildWeeklyStats(t *testing.T) {
+	records := []LogsRunRecord{
+		statsTestRecord(1, "Triage", time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC), "success", 1000, 0.10),
+		statsTestRecord(2, "Triage", time.Date(2025, 9, 3, 0, 0, 0, 0, time.UTC), "failure", 3000, 0.30),
+		statsTestRecord(3, "Triage", time.Date(2025, 9, 9, 0, 0, 0, 0, time.UTC), "success", 500, 0.05),
+		statsTestRecord(4, "Research", time.Date(2025, 9, 2, 0, 0, 0, 0, time.UTC), "", 800, 0.08),
+	}
+
+	stats := buildWeeklyStats(records)
+	if len(stats) != 3 {
+		t.Fatalf("Expected 3 week and workflow groups, got %d: %+v", len(stats), stats)
+	}
+
+	research, triage, nextTriage := stats[0], stats[1], stats[2]
+	if research.WorkflowName != "Research" || research.FailureRate() != -1 {
+		t.Errorf("Expected the Research week first without a known failure rate, got %+v", research)
+	}
+	if triage.Runs != 2 || triage.TokenUsage != 4000 || triage.FailureRate() != 0.5 {
+		t.Errorf("Unexpected first Triage week: %+v", triage)
+	}
+	if nextTriage.Week.Format("2006-01-02") != "2025-09-08" || nextTriage.Runs != 1 {
+		t.Errorf("Unexpected second Triage week: %+v", nextTriage)
+	}
+}
+
+func TestBuildTokenPercentiles(t *testing.T) {
+	var records []LogsRunRecord
+	for i := 1; i <= 10; i++ {
+		records = append(records, statsTestRecord(int64(i), "Triage", time.Time{}, "success", i*100, 0))
+	}
+
+	percentiles := buildTokenPercentiles(records)
+	if len(percentiles) != 1 {
+		t.Fatalf("Expected 1 workflow, got %d", len(percentiles))
+	}
+	p := percentiles[0]
+	if p.Runs != 10 || p.P50 != 500 || p.P90 != 900 || p.P99 != 1000 || p.Max != 1000 {
+		t.Errorf("Unexpected percentiles: %+v", p)
+	}
+}
+
+func TestBuildMissingToolStats(t *testing.T) {
+	records := []LogsRunRecord{
+		statsTestRecord(1, "Triage", time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC), "success", 0, 0, "terraform"),
+		statsTestRecord(2, "Research", time.Date(2025, 9, 5, 0, 0, 0, 0, time.UTC), "success", 0, 0, "terraform", "docker"),
+		statsTestRecord(3, "Triage", time.Date(2025, 9, 3, 0, 0, 0, 0, time.UTC), "success", 0, 0, "terraform"),
+	}
+
+	stats := buildMissingToolStats(records)
+	if len(stats) != 2 {
+		t.Fatalf("Expected 2 tools, got %d", len(stats))
+	}
+	terraform := stats[0]
+	if terraform.Tool != "terraform" || terraform.Reports != 3 || len(terraform.Workflows) != 2 || terraform.Workflows[0] != "Research" {
+		t.Errorf("Unexpected terraform stats: %+v", terraform)
+	}
+	if terraform.LastSeen.Format("2006-01-02") != "2025-09-05" {
+		t.Errorf("Expected terraform last seen on 2025-09-05, got %s", terraform.LastSeen)
+	}
+}
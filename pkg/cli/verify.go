@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/githubnext/gh-aw/pkg/console"
+	"github.com/githubnext/gh-aw/pkg/workflow"
+)
+
+// VerifyWorkflows checks that lock files match the hash recorded in their header by the compiler.
+// If no workflows are given, every .lock.yml file in .github/workflows is verified.
+func VerifyWorkflows(workflows []string, verbose bool) error {
+	lockFiles, err := resolveLockFilesToVerify(workflows)
+	if err != nil {
+		return err
+	}
+
+	if len(lockFiles) == 0 {
+		fmt.Println(console.FormatWarningMessage("No lock files found to verify"))
+		return nil
+	}
+
+	var failed []string
+	for _, lockFile := range lockFiles {
+		if verbose {
+			fmt.Println(console.FormatInfoMessage(fmt.Sprintf("Verifying %s", console.ToRelativePath(lockFile))))
+		}
+
+		content, err := os.ReadFile(lockFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, console.FormatErrorMessage(fmt.Sprintf("%s: %v", console.ToRelativePath(lockFile), err)))
+			failed = append(failed, lockFile)
+			continue
+		}
+
+		if err := workflow.VerifyLockFileContent(string(content)); err != nil {
+			fmt.Fprintln(os.Stderr, console.FormatErrorMessage(fmt.Sprintf("%s: %v", console.ToRelativePath(lockFile), err)))
+			failed = append(failed, lockFile)
+			continue
+		}
+
+		fmt.Println(console.FormatSuccessMessage(console.ToRelativePath(lockFile)))
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d lock files failed verification", len(failed), len(lockFiles))
+	}
+	return nil
+}
+
+// resolveLockFilesToVerify maps workflow names, markdown files or lock files to lock file paths
+func resolveLockFilesToVerify(workflows []string) ([]string, error) {
+	workflowsDir := getWorkflowsDir()
+
+	if len(workflows) == 0 {
+		if _, err := os.Stat(workflowsDir); os.IsNotExist(err) {
+			return nil, fmt.Errorf("no .github/workflows directory found")
+		}
+		lockFiles, err := filepath.Glob(filepath.Join(workflowsDir, "*.lock.yml"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to find lock files: %w", err)
+		}
+		return lockFiles, nil
+	}
+
+	var lockFiles []string
+	for _, name := range workflows {
+		var lockFile string
+		switch {
+		case strings.HasSuffix(name, ".lock.yml"):
+			lockFile = name
+		case strings.HasSuffix(name, ".md"):
+			lockFile = strings.TrimSuffix(name, ".md") + ".lock.yml"
+		default:
+			lockFile = filepath.Join(workflowsDir, name+".lock.yml")
+		}
+		if _, err := os.Stat(lockFile); err != nil {
+			return nil, fmt.Errorf("lock file not found for '%s': %s", name, lockFile)
+		}
+		lockFiles = append(lockFiles, lockFile)
+	}
+	return lockFiles, nil
+}
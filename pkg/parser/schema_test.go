@@ -113,21 +113,37 @@ func TestValidateMainWorkflowFrontmatterWithSchema(t *testing.T) {
 			name: "invalid engine string format",
 			frontmatter: map[string]any{
 				"on":     "push",
-				"engine": "invalid-engine",
+				"engine": "Invalid_Engine",
 			},
 			wantErr:     true,
-			errContains: "value must be one of 'claude', 'codex'",
+			errContains: "does not match pattern",
 		},
 		{
 			name: "invalid engine object format - invalid id",
 			frontmatter: map[string]any{
 				"on": "push",
 				"engine": map[string]any{
-					"id": "invalid-engine",
+					"id": "Invalid_Engine",
 				},
 			},
 			wantErr:     true,
-			errContains: "value must be one of 'claude', 'codex'",
+			errContains: "does not match pattern",
+		},
+		{
+			name: "invalid engine provider region",
+			frontmatter: map[string]any{
+				"on": "push",
+				"engine": map[string]any{
+					"id": "claude",
+					"provider": map[string]any{
+						"type":               "bedrock",
+						"region":             "us-east-1\nrole-to-assume: attacker",
+						"credentials-secret": "AWS_ROLE_ARN",
+					},
+				},
+			},
+			wantErr:     true,
+			errContains: "does not match pattern",
 		},
 		{
 			name: "invalid engine object format - missing id",
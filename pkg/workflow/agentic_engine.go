@@ -2,6 +2,7 @@ package workflow
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
 )
@@ -32,6 +33,14 @@ type CodingAgentEngine interface {
 	// SupportsMaxTurns returns true if this engine supports the max-turns feature
 	SupportsMaxTurns() bool
 
+	// SupportsBashPolicy returns true if this engine enforces the tools.bash policy natively.
+	// Engines without native support get a bash/sh shim on PATH instead.
+	SupportsBashPolicy() bool
+
+	// SupportsProvider returns true if this engine can route model calls through the given
+	// enterprise model provider (engine.provider.type)
+	SupportsProvider(providerType string) bool
+
 	// GetDeclaredOutputFiles returns a list of output files that this engine may produce
 	// These files will be automatically uploaded as artifacts if they exist
 	GetDeclaredOutputFiles() []string
@@ -48,8 +57,21 @@ type CodingAgentEngine interface {
 	// ParseLogMetrics extracts metrics from engine-specific log content
 	ParseLogMetrics(logContent string, verbose bool) LogMetrics
 
+	// ParseLogTrace normalizes engine-specific log content into an agent trace,
+	// or returns nil when the engine has no trace support
+	ParseLogTrace(logContent string) *AgentTrace
+
+	// RenderLog renders engine-specific log content as the markdown transcript shown in
+	// the step summary, or returns an empty string when the engine has no renderer
+	RenderLog(logContent string) string
+
 	// GetLogParserScript returns the name of the JavaScript script to parse logs for this engine
 	GetLogParserScript() string
+
+	// GetInfrastructureErrorCheck returns a shell command that succeeds when the agent log shows
+	// that the engine itself failed (authentication, rate limits, outages) rather than the task,
+	// or an empty string when the engine cannot tell them apart
+	GetInfrastructureErrorCheck(logFile string) string
 }
 
 // BaseEngine provides common functionality for agentic engines
@@ -61,6 +83,8 @@ type BaseEngine struct {
 	supportsToolsWhitelist bool
 	supportsHTTPTransport  bool
 	supportsMaxTurns       bool
+	supportsBashPolicy     bool
+	supportedProviders     []string // Enterprise model provider types (engine.provider.type)
 }
 
 func (e *BaseEngine) GetID() string {
@@ -91,14 +115,38 @@ func (e *BaseEngine) SupportsMaxTurns() bool {
 	return e.supportsMaxTurns
 }
 
+func (e *BaseEngine) SupportsBashPolicy() bool {
+	return e.supportsBashPolicy
+}
+
+func (e *BaseEngine) SupportsProvider(providerType string) bool {
+	return slices.Contains(e.supportedProviders, providerType)
+}
+
 // GetDeclaredOutputFiles returns an empty list by default (engines can override)
 func (e *BaseEngine) GetDeclaredOutputFiles() []string {
 	return []string{}
 }
 
+// ParseLogTrace returns nil by default (engines can override)
+func (e *BaseEngine) ParseLogTrace(logContent string) *AgentTrace {
+	return nil
+}
+
+// RenderLog returns an empty string by default (engines can override)
+func (e *BaseEngine) RenderLog(logContent string) string {
+	return ""
+}
+
+// GetInfrastructureErrorCheck returns an empty string by default (engines can override)
+func (e *BaseEngine) GetInfrastructureErrorCheck(logFile string) string {
+	return ""
+}
+
 // EngineRegistry manages available agentic engines
 type EngineRegistry struct {
-	engines map[string]CodingAgentEngine
+	engines       map[string]CodingAgentEngine
+	manifestRoots map[string]bool // Repositories whose engine manifests were loaded
 }
 
 var (
@@ -106,15 +154,18 @@ var (
 	registryInitOnce sync.Once
 )
 
-// NewEngineRegistry creates a new engine registry with built-in engines
+// NewEngineRegistry creates a new engine registry with built-in engines. Engines declared in
+// manifests are registered with LoadManifestEngines.
 func NewEngineRegistry() *EngineRegistry {
 	registry := &EngineRegistry{
-		engines: make(map[string]CodingAgentEngine),
+		engines:       make(map[string]CodingAgentEngine),
+		manifestRoots: make(map[string]bool),
 	}
 
 	// Register built-in engines
 	registry.Register(NewClaudeEngine())
 	registry.Register(NewCodexEngine())
+	registry.Register(NewGeminiEngine())
 	registry.Register(NewCustomEngine())
 
 	return registry
@@ -9,8 +9,8 @@ func TestEngineRegistry(t *testing.T) {
 
 	// Test that built-in engines are registered
 	supportedEngines := registry.GetSupportedEngines()
-	if len(supportedEngines) != 3 {
-		t.Errorf("Expected 3 supported engines, got %d", len(supportedEngines))
+	if len(supportedEngines) != 4 {
+		t.Errorf("Expected 4 supported engines, got %d", len(supportedEngines))
 	}
 
 	// Test getting engines by ID
@@ -30,6 +30,14 @@ func TestEngineRegistry(t *testing.T) {
 		t.Errorf("Expected codex engine ID, got '%s'", codexEngine.GetID())
 	}
 
+	geminiEngine, err := registry.GetEngine("gemini")
+	if err != nil {
+		t.Errorf("Expected to find gemini engine, got error: %v", err)
+	}
+	if geminiEngine.GetID() != "gemini" {
+		t.Errorf("Expected gemini engine ID, got '%s'", geminiEngine.GetID())
+	}
+
 	customEngine, err := registry.GetEngine("custom")
 	if err != nil {
 		t.Errorf("Expected to find custom engine, got error: %v", err)
@@ -116,7 +124,7 @@ func TestEngineRegistryCustomEngine(t *testing.T) {
 
 	// Test that supported engines list is updated
 	supportedEngines := registry.GetSupportedEngines()
-	if len(supportedEngines) != 4 {
-		t.Errorf("Expected 4 supported engines after adding test-custom, got %d", len(supportedEngines))
+	if len(supportedEngines) != 5 {
+		t.Errorf("Expected 5 supported engines after adding test-custom, got %d", len(supportedEngines))
 	}
 }
@@ -0,0 +1,509 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// bashPolicyShimDir is where the bash policy shim is installed for engines without native support
+	bashPolicyShimDir = "/tmp/bash-policy"
+
+	// bashPolicyHookPath is where the bash policy hook is written for Claude Code
+	bashPolicyHookPath = ".claude/hooks/bash_policy.py"
+
+	// bashPolicyShimPath is the PATH of engine steps behind the bash policy shim, with the shim first.
+	// Only those steps set it, so the shim does not apply to the other steps of the job.
+	bashPolicyShimPath = "${{ steps.bash_policy_shim.outputs.path }}"
+)
+
+// defaultGitCommands are the bash commands added when safe outputs need to create commits
+var defaultGitCommands = []string{
+	"git checkout:*",
+	"git branch:*",
+	"git switch:*",
+	"git add:*",
+	"git rm:*",
+	"git commit:*",
+	"git merge:*",
+}
+
+// BashPolicy is the engine-neutral form of the tools.bash configuration.
+//
+// Patterns are matched against each simple command in a command line:
+//   - "git status" allows exactly that command
+//   - "git diff:*" and "git diff*" allow any command starting with "git diff"
+//   - "*" matches any run of characters and "?" matches a single character anywhere in the pattern
+//   - a bare "*" or ":*" allows every command
+//
+// Deny patterns always win over allow patterns.
+type BashPolicy struct {
+	AllowAll bool
+	Allow    []string
+	Deny     []string
+}
+
+// ParseBashPolicy parses the tools.bash value, which is either null (allow all commands),
+// a list of allowed command patterns, or an object with allow and deny lists
+func ParseBashPolicy(value any) (*BashPolicy, error) {
+	policy := &BashPolicy{}
+
+	switch v := value.(type) {
+	case nil:
+		policy.AllowAll = true
+	case []any:
+		allow, err := parseBashPatterns("bash", v)
+		if err != nil {
+			return nil, err
+		}
+		policy.setAllowed(allow)
+	case map[string]any:
+		for key := range v {
+			if key != "allow" && key != "deny" {
+				return nil, fmt.Errorf("unknown bash policy field '%s' (expected 'allow' or 'deny')", key)
+			}
+		}
+
+		// An omitted allow list allows every command that is not denied
+		policy.AllowAll = true
+		if allowValue, hasAllow := v["allow"]; hasAllow {
+			allowList, ok := allowValue.([]any)
+			if !ok {
+				return nil, fmt.Errorf("bash.allow must be a list of command patterns")
+			}
+			allow, err := parseBashPatterns("bash.allow", allowList)
+			if err != nil {
+				return nil, err
+			}
+			policy.AllowAll = false
+			policy.setAllowed(allow)
+		}
+
+		if denyValue, hasDeny := v["deny"]; hasDeny {
+			denyList, ok := denyValue.([]any)
+			if !ok {
+				return nil, fmt.Errorf("bash.deny must be a list of command patterns")
+			}
+			deny, err := parseBashPatterns("bash.deny", denyList)
+			if err != nil {
+				return nil, err
+			}
+			policy.Deny = deny
+		}
+	default:
+		return nil, fmt.Errorf("bash must be null, a list of command patterns, or an object with allow and deny lists")
+	}
+
+	return policy, nil
+}
+
+// parseBashPatterns converts a YAML list into command patterns, rejecting non-string and empty entries
+func parseBashPatterns(field string, items []any) ([]string, error) {
+	patterns := make([]string, 0, len(items))
+	for _, item := range items {
+		pattern, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s entries must be strings, got %T", field, item)
+		}
+		if strings.TrimSpace(pattern) == "" {
+			return nil, fmt.Errorf("%s entries must not be empty", field)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// setAllowed records allow patterns, collapsing the list when a pattern allows everything
+func (p *BashPolicy) setAllowed(patterns []string) {
+	for _, pattern := range patterns {
+		if pattern == "*" || pattern == ":*" {
+			p.AllowAll = true
+			p.Allow = nil
+			return
+		}
+	}
+	p.Allow = patterns
+}
+
+// allowCommands adds allow patterns that are not already present
+func (p *BashPolicy) allowCommands(patterns []string) {
+	if p.AllowAll {
+		return
+	}
+	for _, pattern := range patterns {
+		found := false
+		for _, existing := range p.Allow {
+			if existing == pattern {
+				found = true
+				break
+			}
+		}
+		if !found {
+			p.Allow = append(p.Allow, pattern)
+		}
+	}
+}
+
+// IsRestricted returns true if the policy blocks at least some commands
+func (p *BashPolicy) IsRestricted() bool {
+	return p != nil && (!p.AllowAll || len(p.Deny) > 0)
+}
+
+// claudeBashRule translates a pattern into a Claude Code Bash() rule body.
+// Claude only understands exact commands and trailing prefix matches, so patterns
+// with globs elsewhere return false.
+func claudeBashRule(pattern string) (string, bool) {
+	prefix := pattern
+	isPrefix := false
+	if strings.HasSuffix(prefix, ":*") {
+		prefix = strings.TrimSuffix(prefix, ":*")
+		isPrefix = true
+	} else if strings.HasSuffix(prefix, "*") {
+		prefix = strings.TrimSuffix(prefix, "*")
+		isPrefix = true
+	}
+
+	if strings.ContainsAny(prefix, "*?") {
+		return "", false
+	}
+	if isPrefix {
+		// "git push *" requires an argument, which a Claude prefix rule cannot express
+		if prefix == "" || strings.HasSuffix(prefix, " ") {
+			return "", false
+		}
+		return prefix + ":*", true
+	}
+	return prefix, true
+}
+
+// claudeRules translates all patterns, returning false if any cannot be expressed natively
+func claudeRules(patterns []string) ([]string, bool) {
+	rules := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		rule, ok := claudeBashRule(pattern)
+		if !ok {
+			return nil, false
+		}
+		rules = append(rules, rule)
+	}
+	return rules, true
+}
+
+// needsClaudeHook returns true if Claude Code permission rules cannot express the policy,
+// in which case a PreToolUse hook enforces it instead
+func (p *BashPolicy) needsClaudeHook() bool {
+	if !p.IsRestricted() {
+		return false
+	}
+	if _, ok := claudeRules(p.Allow); !ok {
+		return true
+	}
+	_, ok := claudeRules(p.Deny)
+	return !ok
+}
+
+// BashPolicyGenerator generates the script that enforces a bash policy at runtime
+type BashPolicyGenerator struct{}
+
+// GenerateBashPolicyScript generates a Python script that checks commands against the policy.
+// The script runs either as a Claude Code PreToolUse hook (with --claude-hook) or behind
+// the bash/sh shims placed first on PATH (with --shim).
+func (g *BashPolicyGenerator) GenerateBashPolicyScript(policy *BashPolicy) string {
+	allowJSON := []byte("[]")
+	if len(policy.Allow) > 0 {
+		allowJSON, _ = json.Marshal(policy.Allow)
+	}
+	denyJSON := []byte("[]")
+	if len(policy.Deny) > 0 {
+		denyJSON, _ = json.Marshal(policy.Deny)
+	}
+	allowAll := "False"
+	if policy.AllowAll {
+		allowAll = "True"
+	}
+
+	return fmt.Sprintf(`#!/usr/bin/env python3
+"""
+Bash policy enforcement for agentic workflows.
+Generated by gh-aw from the tools.bash configuration.
+
+Runs as a Claude Code PreToolUse hook (--claude-hook) or behind the
+bash/sh shims on PATH (--shim) for engines without native command
+allow-listing.
+"""
+
+import json
+import os
+import re
+import shlex
+import sys
+
+# Policy (populated during generation)
+ALLOW_ALL = %s
+ALLOWED = %s
+DENIED = %s
+
+SHIM_BIN_DIR = '%s'
+SHELLS = ('bash', 'sh')
+
+def pattern_regex(pattern):
+    """Convert a policy pattern into an anchored regular expression."""
+    if pattern.endswith(':*'):
+        pattern = pattern[:-2] + '*'
+    body = ''.join('.*' if ch == '*' else '.' if ch == '?' else re.escape(ch) for ch in pattern)
+    return re.compile('^' + body + '$', re.DOTALL)
+
+def split_commands(command):
+    """Split a command line into simple commands, including substitutions and subshells."""
+    # Treat substitutions as separate commands so their contents are checked too
+    command = command.replace('$(', ';(').replace('`+"`"+`', ';')
+    commands, current = [], []
+    for line in split_lines(command):
+        lexer = shlex.shlex(line, posix=True, punctuation_chars=';&|()')
+        lexer.whitespace_split = True
+        for token in lexer:
+            if token and all(ch in ';&|()' for ch in token):
+                commands.append(current)
+                current = []
+            else:
+                current.append(token)
+        commands.append(current)
+        current = []
+    result = []
+    for words in commands:
+        words = unwrap_command(words)
+        if not words:
+            continue
+        # Check nested shells and eval by their script
+        script = find_command_string(words[1:]) if words[0] in SHELLS else None
+        if script is not None:
+            result.extend(split_commands(script))
+        elif words[0] == 'eval':
+            result.extend(split_commands(' '.join(words[1:])))
+        else:
+            result.append(' '.join(words))
+    return result
+
+def split_lines(command):
+    """Split a script at unquoted newlines, joining line continuations and dropping comments
+    so a comment cannot swallow the command on the next line."""
+    lines, current, quote, i = [], [], None, 0
+    while i < len(command):
+        ch = command[i]
+        if ch == '\\' and quote != "'" and i + 1 < len(command):
+            if command[i + 1] != '\n':
+                current.append(command[i:i + 2])
+            i += 2
+            continue
+        if quote:
+            if ch == quote:
+                quote = None
+        elif ch in '"\'':
+            quote = ch
+        elif ch == '#' and (not current or current[-1] in ' \t;&|()'):
+            end = command.find('\n', i)
+            if end < 0:
+                break
+            i = end
+            continue
+        elif ch == '\n':
+            lines.append(''.join(current))
+            current = []
+            i += 1
+            continue
+        current.append(ch)
+        i += 1
+    lines.append(''.join(current))
+    return lines
+
+def unwrap_command(words):
+    """Return the command that runs, skipping environment assignments and the env, command and
+    exec wrappers, with the program reduced to its basename so /usr/bin/git matches git."""
+    while words:
+        name = os.path.basename(words[0])
+        if re.match(r'^[A-Za-z_][A-Za-z0-9_]*=', words[0]):
+            words = words[1:]
+        elif name == 'env':
+            words = words[1:]
+            while words and words[0].startswith('-'):
+                if words[0] in ('-S', '--split-string') and len(words) > 1:
+                    words = shlex.split(words[1]) + words[2:]
+                    break
+                words = words[2:] if words[0] in ('-u', '--unset', '-C', '--chdir') else words[1:]
+        elif name == 'command' and words[1:2] not in (['-v'], ['-V']):
+            words = words[1:]
+            while words and words[0] in ('-p', '--'):
+                words = words[1:]
+        elif name == 'exec':
+            words = words[1:]
+            while words and words[0].startswith('-'):
+                words = words[2:] if words[0] == '-a' else words[1:]
+        else:
+            return [name] + words[1:]
+    return words
+
+def check_command(command):
+    """Return None if the command line is allowed, otherwise the offending command."""
+    try:
+        commands = split_commands(command)
+    except ValueError:
+        return command
+    for cmd in commands:
+        if any(pattern_regex(p).match(cmd) for p in DENIED):
+            return cmd
+        if not ALLOW_ALL and not any(pattern_regex(p).match(cmd) for p in ALLOWED):
+            return cmd
+    return None
+
+def run_hook():
+    """Claude Code PreToolUse hook: exit 2 blocks the tool call with feedback."""
+    try:
+        data = json.load(sys.stdin)
+        if data.get('tool_name') != 'Bash':
+            sys.exit(0)
+        command = data.get('tool_input', {}).get('command', '')
+        blocked = check_command(command)
+    except Exception as e:
+        print(f"Bash policy validation error: {e}", file=sys.stderr)
+        sys.exit(2)
+    if blocked is not None:
+        print(f"Command blocked by bash policy: {blocked}", file=sys.stderr)
+        sys.exit(2)
+    sys.exit(0)
+
+def find_real_shell(name):
+    """Find the real shell on PATH, skipping the shim directory."""
+    for directory in os.environ.get('PATH', '').split(os.pathsep):
+        if not directory or os.path.abspath(directory) == SHIM_BIN_DIR:
+            continue
+        candidate = os.path.join(directory, name)
+        if os.path.isfile(candidate) and os.access(candidate, os.X_OK):
+            return candidate
+    return '/bin/' + name
+
+def find_command_string(args):
+    """Return the script passed with -c, or None for scripts read from files or stdin."""
+    i = 0
+    while i < len(args):
+        arg = args[i]
+        if arg in ('-o', '+o', '-O', '+O', '--rcfile', '--init-file'):
+            i += 2
+            continue
+        if arg == '--' or not arg.startswith(('-', '+')):
+            return None
+        if not arg.startswith('--') and 'c' in arg[1:]:
+            # The script is the first operand after the options
+            for rest in args[i + 1:]:
+                if not rest.startswith(('-', '+')):
+                    return rest
+            return None
+        i += 1
+    return None
+
+def run_shim(name, args):
+    """bash/sh shim: check scripts passed with -c, then run the real shell."""
+    command = find_command_string(args)
+    if command is not None:
+        blocked = check_command(command)
+        if blocked is not None:
+            print(f"Command blocked by bash policy: {blocked}", file=sys.stderr)
+            sys.exit(126)
+    real_shell = find_real_shell(name)
+    os.execv(real_shell, [real_shell] + args)
+
+if __name__ == '__main__':
+    if sys.argv[1:2] == ['--claude-hook']:
+        run_hook()
+    elif sys.argv[1:2] == ['--shim'] and len(sys.argv) > 2:
+        run_shim(sys.argv[2], sys.argv[3:])
+    else:
+        print("usage: bash_policy.py --claude-hook | --shim <shell> [args...]", file=sys.stderr)
+        sys.exit(2)
+`, allowAll, string(allowJSON), string(denyJSON), bashPolicyShimDir+"/bin")
+}
+
+// GenerateBashPolicyShimStep generates a step that installs bash and sh shims backed by the
+// policy script and outputs the PATH with the shims first for the engine steps
+func (g *BashPolicyGenerator) GenerateBashPolicyShimStep(policy *BashPolicy) GitHubActionStep {
+	script := g.GenerateBashPolicyScript(policy)
+
+	runContent := fmt.Sprintf(`mkdir -p %[1]s/bin
+cat > %[1]s/bash_policy.py << 'EOF'
+%[2]s
+EOF
+# The shims call python by absolute path so they never resolve themselves through PATH
+for shell in bash sh; do
+  printf '#!/bin/sh\nexec /usr/bin/python3 %[1]s/bash_policy.py --shim %%s "$@"\n' "$shell" > %[1]s/bin/$shell
+  chmod +x %[1]s/bin/$shell
+done
+echo "path=%[1]s/bin:$PATH" >> $GITHUB_OUTPUT`, bashPolicyShimDir, script)
+
+	var lines []string
+	lines = append(lines, "      - name: Setup Bash Policy Shim")
+	lines = append(lines, "        id: bash_policy_shim")
+	lines = append(lines, "        run: |")
+
+	// Split the run content into lines and properly indent
+	for _, line := range strings.Split(runContent, "\n") {
+		lines = append(lines, fmt.Sprintf("          %s", line))
+	}
+
+	return GitHubActionStep(lines)
+}
+
+// GenerateBashPolicyHookStep generates a step that writes the policy script as a Claude Code hook
+func (g *BashPolicyGenerator) GenerateBashPolicyHookStep(policy *BashPolicy) GitHubActionStep {
+	script := g.GenerateBashPolicyScript(policy)
+
+	runContent := fmt.Sprintf(`mkdir -p .claude/hooks
+cat > %[1]s << 'EOF'
+%[2]s
+EOF
+chmod +x %[1]s`, bashPolicyHookPath, script)
+
+	var lines []string
+	lines = append(lines, "      - name: Generate Bash Policy Hook")
+	lines = append(lines, "        run: |")
+
+	// Split the run content into lines and properly indent
+	for _, line := range strings.Split(runContent, "\n") {
+		lines = append(lines, fmt.Sprintf("          %s", line))
+	}
+
+	return GitHubActionStep(lines)
+}
+
+// usesBashPolicyShim reports whether the execution steps of the engine run behind the bash policy shim
+func usesBashPolicyShim(workflowData *WorkflowData, engine CodingAgentEngine) bool {
+	return workflowData.BashPolicy.IsRestricted() && !engine.SupportsBashPolicy()
+}
+
+// bashPolicyFromTools parses the bash entry of a tools map, returning nil if bash is not configured
+// or the configuration is invalid (invalid configurations are reported during parsing)
+func bashPolicyFromTools(tools map[string]any) *BashPolicy {
+	bashTool, hasBash := tools["bash"]
+	if !hasBash {
+		return nil
+	}
+	policy, err := ParseBashPolicy(bashTool)
+	if err != nil {
+		return nil
+	}
+	return policy
+}
+
+// sortedClaudeBashRules returns the Bash() rules for the given patterns in sorted order
+func sortedClaudeBashRules(patterns []string) []string {
+	rules, ok := claudeRules(patterns)
+	if !ok {
+		return nil
+	}
+	result := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		result = append(result, fmt.Sprintf("Bash(%s)", rule))
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,322 @@
+package workflow
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseBashPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       any
+		expected    *BashPolicy
+		expectError string
+	}{
+		{
+			name:     "null allows all commands",
+			value:    nil,
+			expected: &BashPolicy{AllowAll: true},
+		},
+		{
+			name:     "list of allowed commands",
+			value:    []any{"echo", "git diff:*"},
+			expected: &BashPolicy{Allow: []string{"echo", "git diff:*"}},
+		},
+		{
+			name:     "wildcard in list allows all commands",
+			value:    []any{"echo", ":*"},
+			expected: &BashPolicy{AllowAll: true},
+		},
+		{
+			name: "allow and deny lists",
+			value: map[string]any{
+				"allow": []any{"git *"},
+				"deny":  []any{"git push*"},
+			},
+			expected: &BashPolicy{Allow: []string{"git *"}, Deny: []string{"git push*"}},
+		},
+		{
+			name: "deny only allows everything else",
+			value: map[string]any{
+				"deny": []any{"curl*", "wget*"},
+			},
+			expected: &BashPolicy{AllowAll: true, Deny: []string{"curl*", "wget*"}},
+		},
+		{
+			name: "empty allow list allows nothing",
+			value: map[string]any{
+				"allow": []any{},
+			},
+			expected: &BashPolicy{Allow: []string{}},
+		},
+		{
+			name:        "unknown field",
+			value:       map[string]any{"block": []any{"curl"}},
+			expectError: "unknown bash policy field 'block'",
+		},
+		{
+			name:        "non-string entry",
+			value:       []any{"echo", 42},
+			expectError: "bash entries must be strings",
+		},
+		{
+			name:        "empty entry",
+			value:       map[string]any{"deny": []any{" "}},
+			expectError: "bash.deny entries must not be empty",
+		},
+		{
+			name:        "deny is not a list",
+			value:       map[string]any{"deny": "curl"},
+			expectError: "bash.deny must be a list",
+		},
+		{
+			name:        "invalid type",
+			value:       "echo",
+			expectError: "bash must be null, a list of command patterns, or an object",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := ParseBashPolicy(tt.value)
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Errorf("expected error containing %q, got: %v", tt.expectError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(policy, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, policy)
+			}
+		})
+	}
+}
+
+func TestClaudeBashRule(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		expected string
+		ok       bool
+	}{
+		{pattern: "git status", expected: "git status", ok: true},
+		{pattern: "git diff:*", expected: "git diff:*", ok: true},
+		{pattern: "git push*", expected: "git push:*", ok: true},
+		{pattern: "git push *", ok: false},
+		{pattern: "git log * --oneline", ok: false},
+		{pattern: "ls -?", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			rule, ok := claudeBashRule(tt.pattern)
+			if ok != tt.ok || rule != tt.expected {
+				t.Errorf("claudeBashRule(%q) = (%q, %v), expected (%q, %v)", tt.pattern, rule, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestClaudeEngineBashPolicy(t *testing.T) {
+	engine := NewClaudeEngine()
+
+	t.Run("prefix patterns become permission rules", func(t *testing.T) {
+		tools := map[string]any{
+			"bash": map[string]any{
+				"allow": []any{"git diff*", "ls"},
+				"deny":  []any{"git push*", "curl:*"},
+			},
+		}
+
+		allowed := engine.computeAllowedClaudeToolsString(tools, nil)
+		if !strings.Contains(allowed, "Bash(git diff:*)") || !strings.Contains(allowed, "Bash(ls)") {
+			t.Errorf("expected translated allow rules, got: %s", allowed)
+		}
+
+		disallowed := engine.computeDisallowedClaudeToolsString(tools)
+		if disallowed != "Bash(curl:*),Bash(git push:*)" {
+			t.Errorf("unexpected disallowed tools: %s", disallowed)
+		}
+
+		data := &WorkflowData{Tools: tools, EngineConfig: &EngineConfig{ID: "claude"}}
+		if steps := engine.GetInstallationSteps(data); len(steps) != 0 {
+			t.Errorf("expected no hook steps for natively expressible policy, got %d", len(steps))
+		}
+	})
+
+	t.Run("interior globs are enforced by a hook", func(t *testing.T) {
+		tools := map[string]any{
+			"bash": map[string]any{
+				"allow": []any{"git log * --oneline"},
+			},
+		}
+
+		allowed := engine.computeAllowedClaudeToolsString(tools, nil)
+		if !strings.Contains(allowed, "Bash,") {
+			t.Errorf("expected unrestricted Bash rule when the hook enforces the policy, got: %s", allowed)
+		}
+
+		data := &WorkflowData{Tools: tools, EngineConfig: &EngineConfig{ID: "claude"}}
+		var stepsContent strings.Builder
+		for _, step := range engine.GetInstallationSteps(data) {
+			stepsContent.WriteString(strings.Join(step, "\n"))
+		}
+		content := stepsContent.String()
+		if !strings.Contains(content, `"matcher": "Bash"`) {
+			t.Errorf("expected Bash hook in Claude settings, got:\n%s", content)
+		}
+		if !strings.Contains(content, "cat > .claude/hooks/bash_policy.py") {
+			t.Errorf("expected bash policy hook script, got:\n%s", content)
+		}
+		if !strings.Contains(content, `ALLOWED = ["git log * --oneline"]`) {
+			t.Errorf("expected policy embedded in hook script, got:\n%s", content)
+		}
+	})
+}
+
+func TestBashPolicyShimForCodex(t *testing.T) {
+	tmpDir := t.TempDir()
+	workflowPath := filepath.Join(tmpDir, "test-bash-policy.md")
+	content := `---
+on: push
+permissions:
+  contents: read
+engine: codex
+tools:
+  bash:
+    allow: ["git *", "ls"]
+    deny: ["git push*"]
+---
+
+# Bash Policy Test
+
+List the files.`
+	if err := os.WriteFile(workflowPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	compiler := NewCompiler(false, "", "test")
+	if err := compiler.CompileWorkflow(workflowPath); err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+
+	lockContent, err := os.ReadFile(strings.TrimSuffix(workflowPath, ".md") + ".lock.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock := string(lockContent)
+
+	shimIdx := strings.Index(lock, "- name: Setup Bash Policy Shim")
+	runIdx := strings.Index(lock, "- name: Run Codex")
+	if shimIdx < 0 || runIdx < 0 || shimIdx > runIdx {
+		t.Fatalf("expected bash policy shim before codex execution (shim=%d, run=%d)", shimIdx, runIdx)
+	}
+	if !strings.Contains(lock, `ALLOWED = ["git *","ls"]`) || !strings.Contains(lock, `DENIED = ["git push*"]`) {
+		t.Errorf("expected policy embedded in shim script")
+	}
+	if !strings.Contains(lock, `echo "path=/tmp/bash-policy/bin:$PATH" >> $GITHUB_OUTPUT`) || !strings.Contains(lock, "id: bash_policy_shim") {
+		t.Errorf("expected the shim step to output the PATH with the shim directory first")
+	}
+	if strings.Contains(lock, "GITHUB_PATH") {
+		t.Errorf("expected the shim not to be added to the PATH of the other steps")
+	}
+	if !strings.Contains(lock[runIdx:], "PATH: ${{ steps.bash_policy_shim.outputs.path }}") {
+		t.Errorf("expected the codex step to run with the shim first on PATH")
+	}
+}
+
+func TestBashPolicyScriptUnwrapsCommands(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not available")
+	}
+
+	policy := &BashPolicy{Allow: []string{"git *", "ls"}, Deny: []string{"git push*"}}
+	tests := map[string]bool{
+		"git status":                  true,
+		"/bin/ls":                     true,
+		"FOO=1 ls":                    true,
+		"git push origin":             false,
+		"/usr/bin/git push origin":    false,
+		"command git push":            false,
+		"command -p git push":         false,
+		"env git push":                false,
+		"env -i FOO=1 git push":       false,
+		"env -u HOME git push":        false,
+		"env -S 'git push origin'":    false,
+		"exec git push":               false,
+		"ls && /usr/bin/git push":     false,
+		"bash -c '/usr/bin/git push'": false,
+	}
+	checkBashPolicyCommands(t, policy, tests)
+}
+
+func TestBashPolicyScriptSplitsLines(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 not available")
+	}
+
+	policy := &BashPolicy{Allow: []string{"git status:*", "echo *"}, Deny: []string{"git push*", "curl*"}}
+	tests := map[string]bool{
+		"git status\necho hi":         true,
+		"echo 'a\ngit push'":          true,
+		"# setup\ngit status":         true,
+		"git status\ngit push origin": false,
+		"echo hi\ncurl evil":          false,
+		"echo hi # don't\ncurl evil":  false,
+		"echo hi \\\ncurl evil":       true,
+		"git status\n\ngit push":      false,
+	}
+	checkBashPolicyCommands(t, policy, tests)
+}
+
+// checkBashPolicyCommands runs the generated Claude hook for each command and compares the verdict
+func checkBashPolicyCommands(t *testing.T, policy *BashPolicy, tests map[string]bool) {
+	t.Helper()
+	scriptPath := filepath.Join(t.TempDir(), "bash_policy.py")
+	if err := os.WriteFile(scriptPath, []byte((&BashPolicyGenerator{}).GenerateBashPolicyScript(policy)), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for command, allowed := range tests {
+		input, _ := json.Marshal(map[string]any{"tool_name": "Bash", "tool_input": map[string]string{"command": command}})
+		cmd := exec.Command("python3", scriptPath, "--claude-hook")
+		cmd.Stdin = bytes.NewReader(input)
+		err := cmd.Run()
+		if allowed && err != nil {
+			t.Errorf("Expected %q to be allowed, got %v", command, err)
+		}
+		if !allowed && err == nil {
+			t.Errorf("Expected %q to be blocked", command)
+		}
+	}
+}
+
+func TestBashPolicyInvalidConfiguration(t *testing.T) {
+	tmpDir := t.TempDir()
+	workflowPath := filepath.Join(tmpDir, "test-bash-policy.md")
+	content := `---
+on: push
+engine: codex
+tools:
+  bash:
+    allow: ["ls", 1]
+---
+
+# Bash Policy Test`
+	if err := os.WriteFile(workflowPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	compiler := NewCompiler(false, "", "test")
+	err := compiler.CompileWorkflow(workflowPath)
+	if err == nil || !strings.Contains(err.Error(), "/tools/bash/allow/1") {
+		t.Errorf("expected invalid bash policy error, got: %v", err)
+	}
+}
@@ -28,6 +28,8 @@ func NewClaudeEngine() *ClaudeEngine {
 			supportsToolsWhitelist: true,
 			supportsHTTPTransport:  true, // Claude supports both stdio and HTTP transport
 			supportsMaxTurns:       true, // Claude supports max-turns feature
+			supportsBashPolicy:     true, // Claude translates the bash policy into permission rules and hooks
+			supportedProviders:     []string{"bedrock", "vertex"},
 		},
 	}
 }
@@ -35,26 +37,54 @@ func NewClaudeEngine() *ClaudeEngine {
 func (e *ClaudeEngine) GetInstallationSteps(workflowData *WorkflowData) []GitHubActionStep {
 	var steps []GitHubActionStep
 
+	// Authenticate to the cloud hosting the enterprise model provider
+	steps = append(steps, getProviderAuthSteps(getEngineProvider(workflowData))...)
+
+	hooks := e.getPreToolUseHooks(workflowData)
+	if len(hooks) == 0 {
+		return steps
+	}
+
+	// Add settings generation step registering all hooks
+	settingsGenerator := &ClaudeSettingsGenerator{}
+	steps = append(steps, settingsGenerator.GenerateSettingsWorkflowStepForHooks(hooks))
+
 	// Check if network permissions are configured (only for Claude engine)
-	if workflowData.EngineConfig != nil && workflowData.EngineConfig.ID == "claude" && ShouldEnforceNetworkPermissions(workflowData.NetworkPermissions) {
-		// Generate network hook generator and settings generator
+	if e.enforcesNetworkPermissions(workflowData) {
 		hookGenerator := &NetworkHookGenerator{}
-		settingsGenerator := &ClaudeSettingsGenerator{}
-
 		allowedDomains := GetAllowedDomains(workflowData.NetworkPermissions)
 
-		// Add settings generation step
-		settingsStep := settingsGenerator.GenerateSettingsWorkflowStep()
-		steps = append(steps, settingsStep)
-
 		// Add hook generation step
 		hookStep := hookGenerator.GenerateNetworkHookWorkflowStep(allowedDomains)
 		steps = append(steps, hookStep)
 	}
 
+	// Add bash policy hook when permission rules cannot express the policy
+	if bashPolicy := bashPolicyFromTools(workflowData.Tools); bashPolicy != nil && bashPolicy.needsClaudeHook() {
+		bashPolicyGenerator := &BashPolicyGenerator{}
+		steps = append(steps, bashPolicyGenerator.GenerateBashPolicyHookStep(bashPolicy))
+	}
+
 	return steps
 }
 
+// enforcesNetworkPermissions returns true if network permissions are enforced with a hook (only for Claude engine)
+func (e *ClaudeEngine) enforcesNetworkPermissions(workflowData *WorkflowData) bool {
+	return workflowData.EngineConfig != nil && workflowData.EngineConfig.ID == "claude" && ShouldEnforceNetworkPermissions(workflowData.NetworkPermissions)
+}
+
+// getPreToolUseHooks returns the hooks that must be registered in Claude settings
+func (e *ClaudeEngine) getPreToolUseHooks(workflowData *WorkflowData) []PreToolUseHook {
+	var hooks []PreToolUseHook
+	if e.enforcesNetworkPermissions(workflowData) {
+		hooks = append(hooks, networkPermissionsHook)
+	}
+	if bashPolicy := bashPolicyFromTools(workflowData.Tools); bashPolicy != nil && bashPolicy.needsClaudeHook() {
+		hooks = append(hooks, bashPolicyHook)
+	}
+	return hooks
+}
+
 // GetDeclaredOutputFiles returns the output files that Claude may produce
 func (e *ClaudeEngine) GetDeclaredOutputFiles() []string {
 	return []string{"output.txt"}
@@ -99,6 +129,15 @@ func (e *ClaudeEngine) GetExecutionSteps(workflowData *WorkflowData, logFile str
 		}
 	}
 
+	// Add the environment routing model calls through the enterprise model provider
+	providerEnv := e.getProviderEnv(getEngineProvider(workflowData))
+	for _, key := range sortedKeys(providerEnv) {
+		if claudeEnv != "" {
+			claudeEnv += "\n"
+		}
+		claudeEnv += "            " + key + ": " + providerEnv[key]
+	}
+
 	inputs := map[string]string{
 		"prompt_file":       "/tmp/aw-prompts/prompt.txt",
 		"anthropic_api_key": "${{ secrets.ANTHROPIC_API_KEY }}",
@@ -107,6 +146,17 @@ func (e *ClaudeEngine) GetExecutionSteps(workflowData *WorkflowData, logFile str
 		"timeout_minutes":   "", // Will be filled in during generation
 	}
 
+	// Enterprise model providers authenticate with cloud credentials instead of an Anthropic API key
+	if provider := getEngineProvider(workflowData); provider != nil {
+		delete(inputs, "anthropic_api_key")
+		switch provider.Type {
+		case ProviderBedrock:
+			inputs["use_bedrock"] = "\"true\""
+		case ProviderVertex:
+			inputs["use_vertex"] = "\"true\""
+		}
+	}
+
 	// Only add max_turns if it's actually specified
 	if workflowData.EngineConfig != nil && workflowData.EngineConfig.MaxTurns != "" {
 		inputs["max_turns"] = workflowData.EngineConfig.MaxTurns
@@ -120,11 +170,16 @@ func (e *ClaudeEngine) GetExecutionSteps(workflowData *WorkflowData, logFile str
 		inputs["model"] = workflowData.EngineConfig.Model
 	}
 
-	// Add settings parameter if network permissions are configured
-	if workflowData.EngineConfig != nil && workflowData.EngineConfig.ID == "claude" && ShouldEnforceNetworkPermissions(workflowData.NetworkPermissions) {
+	// Add settings parameter if network permissions or the bash policy hook are configured
+	if len(e.getPreToolUseHooks(workflowData)) > 0 {
 		inputs["settings"] = ".claude/settings.json"
 	}
 
+	// Deny bash commands through Claude permission rules
+	if disallowedTools := e.computeDisallowedClaudeToolsString(workflowData.Tools); disallowedTools != "" {
+		inputs["disallowed_tools"] = fmt.Sprintf("\"%s\"", disallowedTools)
+	}
+
 	// Apply default Claude tools
 	allowedTools := e.computeAllowedClaudeToolsString(workflowData.Tools, workflowData.SafeOutputs)
 
@@ -200,6 +255,10 @@ func (e *ClaudeEngine) GetExecutionSteps(workflowData *WorkflowData, logFile str
 		}
 	}
 
+	for _, key := range sortedKeys(providerEnv) {
+		stepLines = append(stepLines, fmt.Sprintf("          %s: %s", key, providerEnv[key]))
+	}
+
 	steps = append(steps, GitHubActionStep(stepLines))
 
 	// Add the log capture step
@@ -222,6 +281,29 @@ func (e *ClaudeEngine) GetExecutionSteps(workflowData *WorkflowData, logFile str
 	return steps
 }
 
+// getProviderEnv returns the environment variables that route Claude Code through an enterprise model provider
+func (e *ClaudeEngine) getProviderEnv(provider *EngineProviderConfig) map[string]string {
+	env := make(map[string]string)
+	if provider == nil {
+		return env
+	}
+
+	switch provider.Type {
+	case ProviderBedrock:
+		env["AWS_REGION"] = provider.Region
+		if provider.Endpoint != "" {
+			env["ANTHROPIC_BEDROCK_BASE_URL"] = provider.Endpoint
+		}
+	case ProviderVertex:
+		env["CLOUD_ML_REGION"] = provider.Region
+		env["ANTHROPIC_VERTEX_PROJECT_ID"] = fmt.Sprintf("${{ steps.%s.outputs.project_id }}", providerAuthStepID)
+		if provider.Endpoint != "" {
+			env["ANTHROPIC_VERTEX_BASE_URL"] = provider.Endpoint
+		}
+	}
+	return env
+}
+
 // convertStepToYAML converts a step map to YAML string - temporary helper
 func (e *ClaudeEngine) convertStepToYAML(stepMap map[string]any) (string, error) {
 	// Simple YAML generation for steps - this mirrors the compiler logic
@@ -313,13 +395,21 @@ func (e *ClaudeEngine) expandNeutralToolsToClaudeTools(tools map[string]any) map
 	}
 
 	// Convert neutral tools to Claude tools
-	if bashTool, hasBash := tools["bash"]; hasBash {
+	if bashPolicy := bashPolicyFromTools(tools); bashPolicy != nil {
 		// bash -> Bash, KillBash, BashOutput
-		if bashCommands, ok := bashTool.([]any); ok {
-			claudeAllowed["Bash"] = bashCommands
+		if bashPolicy.AllowAll || bashPolicy.needsClaudeHook() {
+			// Allow all bash commands; deny rules or the bash policy hook restrict them
+			claudeAllowed["Bash"] = nil
 		} else {
-			claudeAllowed["Bash"] = nil // Allow all bash commands
+			rules, _ := claudeRules(bashPolicy.Allow)
+			bashCommands := make([]any, 0, len(rules))
+			for _, rule := range rules {
+				bashCommands = append(bashCommands, rule)
+			}
+			claudeAllowed["Bash"] = bashCommands
 		}
+	} else if _, hasBash := tools["bash"]; hasBash {
+		claudeAllowed["Bash"] = nil // Allow all bash commands
 	}
 
 	if _, hasWebFetch := tools["web-fetch"]; hasWebFetch {
@@ -351,6 +441,17 @@ func (e *ClaudeEngine) expandNeutralToolsToClaudeTools(tools map[string]any) map
 	return result
 }
 
+// computeDisallowedClaudeToolsString generates the disallowed tools string for Claude from
+// the deny patterns of the bash policy
+func (e *ClaudeEngine) computeDisallowedClaudeToolsString(tools map[string]any) string {
+	bashPolicy := bashPolicyFromTools(tools)
+	if bashPolicy == nil || len(bashPolicy.Deny) == 0 {
+		return ""
+	}
+	// Patterns Claude cannot express are enforced by the bash policy hook instead
+	return strings.Join(sortedClaudeBashRules(bashPolicy.Deny), ",")
+}
+
 // computeAllowedClaudeToolsString
 // 1. validates that only neutral tools are provided (no claude section)
 // 2. converts neutral tools to Claude-specific tools format
@@ -709,9 +810,121 @@ func (e *ClaudeEngine) ParseLogMetrics(logContent string, verbose bool) LogMetri
 		metrics.TokenUsage = maxTokenUsage
 	}
 
+	e.ParseLogTrace(logContent).addToMetrics(&metrics)
+
 	return metrics
 }
 
+// parseClaudeLogEntries returns the entries of a Claude log, written either as a JSON array
+// or as one JSON object per line
+func parseClaudeLogEntries(logContent string) []map[string]any {
+	var entries []map[string]any
+	if err := json.Unmarshal([]byte(logContent), &entries); err == nil {
+		return entries
+	}
+
+	for _, line := range strings.Split(logContent, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "{") {
+			continue
+		}
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(trimmed), &entry); err == nil {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// ParseLogTrace normalizes a Claude stream-json log into an agent trace. Claude logs have
+// no timestamps, so the events are only ordered.
+func (e *ClaudeEngine) ParseLogTrace(logContent string) *AgentTrace {
+	trace := newAgentTrace(e.GetID())
+	session := *trace.Session()
+	toolNames := make(map[string]string) // tool_use id -> tool name
+	turn := 0
+	lastMessageID := ""
+
+	for _, entry := range parseClaudeLogEntries(logContent) {
+		switch entry["type"] {
+		case "system":
+			if model, ok := entry["model"].(string); ok {
+				session.Model = model
+			}
+		case "assistant", "user":
+			message, _ := entry["message"].(map[string]any)
+
+			// An assistant message is streamed as one entry per content block, all sharing the message id
+			if messageID, ok := message["id"].(string); ok && entry["type"] == "assistant" && messageID != lastMessageID {
+				lastMessageID = messageID
+				turn++
+				trace.add(TraceEvent{Type: TraceEventTurn, Turn: turn})
+
+				if usage, ok := message["usage"].(map[string]any); ok {
+					model, _ := message["model"].(string)
+					input := ConvertToInt(usage["input_tokens"])
+					output := ConvertToInt(usage["output_tokens"])
+					cache := ConvertToInt(usage["cache_creation_input_tokens"]) + ConvertToInt(usage["cache_read_input_tokens"])
+					trace.add(TraceEvent{
+						Type:         TraceEventModelUsage,
+						Turn:         turn,
+						Model:        model,
+						InputTokens:  input,
+						OutputTokens: output,
+						CacheTokens:  cache,
+						TotalTokens:  input + output + cache,
+					})
+				}
+			}
+
+			content, _ := message["content"].([]any)
+			for _, item := range content {
+				block, ok := item.(map[string]any)
+				if !ok {
+					continue
+				}
+				switch block["type"] {
+				case "tool_use":
+					name := NormalizeToolName(fmt.Sprintf("%v", block["name"]))
+					id, _ := block["id"].(string)
+					toolNames[id] = name
+					trace.add(TraceEvent{Type: TraceEventToolCall, Turn: turn, ToolName: name, ToolCallID: id})
+				case "tool_result":
+					id, _ := block["tool_use_id"].(string)
+					if name, ok := toolNames[id]; ok {
+						isError, _ := block["is_error"].(bool)
+						trace.add(TraceEvent{Type: TraceEventToolResult, Turn: turn, ToolName: name, ToolCallID: id, IsError: isError})
+					}
+				}
+			}
+		case "result":
+			session.Turn = ConvertToInt(entry["num_turns"])
+			session.DurationMs = int64(ConvertToInt(entry["duration_ms"]))
+			session.ModelDurationMs = int64(ConvertToInt(entry["duration_api_ms"]))
+
+			if usage, ok := entry["usage"].(map[string]any); ok {
+				session.InputTokens = ConvertToInt(usage["input_tokens"])
+				session.OutputTokens = ConvertToInt(usage["output_tokens"])
+				session.CacheTokens = ConvertToInt(usage["cache_creation_input_tokens"]) + ConvertToInt(usage["cache_read_input_tokens"])
+				session.TotalTokens = session.InputTokens + session.OutputTokens + session.CacheTokens
+			}
+
+			isError, _ := entry["is_error"].(bool)
+			switch {
+			case entry["subtype"] == "error_max_turns":
+				session.StopReason = StopReasonMaxTurns
+			case entry["subtype"] == "success" && !isError:
+				session.StopReason = StopReasonCompleted
+			default:
+				session.StopReason = StopReasonError
+			}
+		}
+	}
+
+	*trace.Session() = session
+	return trace
+}
+
 // isClaudeResultPayload checks if the JSON line is a Claude result payload with type: "result"
 func (e *ClaudeEngine) isClaudeResultPayload(line string) bool {
 	trimmed := strings.TrimSpace(line)
@@ -823,3 +1036,15 @@ func (e *ClaudeEngine) parseClaudeJSONLog(logContent string, verbose bool) LogMe
 func (e *ClaudeEngine) GetLogParserScript() string {
 	return "parse_claude_log"
 }
+
+// claudeInfrastructureErrorResult matches the result text Claude Code reports when an API call
+// fails: authentication, permission, rate limit and server errors, connection errors, and an
+// invalid key or exhausted credit balance
+const claudeInfrastructureErrorResult = `^(API Error: (40[13]|429|5[0-9][0-9])|API Error: (Connection error|Request timed out)|Invalid API key|Credit balance is too low)`
+
+// GetInfrastructureErrorCheck checks the error result of the execution log, a JSON array or JSON
+// lines, for an API error reported by Claude Code
+func (e *ClaudeEngine) GetInfrastructureErrorCheck(logFile string) string {
+	filter := fmt.Sprintf(`[flatten[] | objects | select(.type == "result" and .is_error == true) | .result | strings | test("%s")] | any`, claudeInfrastructureErrorResult)
+	return fmt.Sprintf("jq -es '%s' %s > /dev/null 2>&1", filter, logFile)
+}
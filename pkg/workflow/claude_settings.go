@@ -31,21 +31,38 @@ type HookEntry struct {
 	Command string `json:"command"`
 }
 
+// networkPermissionsHook validates WebFetch and WebSearch against the network permissions
+var networkPermissionsHook = PreToolUseHook{
+	Matcher: "WebFetch|WebSearch",
+	Hooks: []HookEntry{
+		{
+			Type:    "command",
+			Command: ".claude/hooks/network_permissions.py",
+		},
+	},
+}
+
+// bashPolicyHook validates Bash commands against the bash policy
+var bashPolicyHook = PreToolUseHook{
+	Matcher: "Bash",
+	Hooks: []HookEntry{
+		{
+			Type:    "command",
+			Command: bashPolicyHookPath + " --claude-hook",
+		},
+	},
+}
+
 // GenerateSettingsJSON generates Claude Code settings JSON for network permissions
 func (g *ClaudeSettingsGenerator) GenerateSettingsJSON() string {
+	return g.GenerateSettingsJSONForHooks([]PreToolUseHook{networkPermissionsHook})
+}
+
+// GenerateSettingsJSONForHooks generates Claude Code settings JSON registering the given hooks
+func (g *ClaudeSettingsGenerator) GenerateSettingsJSONForHooks(hooks []PreToolUseHook) string {
 	settings := ClaudeSettings{
 		Hooks: &HookConfiguration{
-			PreToolUse: []PreToolUseHook{
-				{
-					Matcher: "WebFetch|WebSearch",
-					Hooks: []HookEntry{
-						{
-							Type:    "command",
-							Command: ".claude/hooks/network_permissions.py",
-						},
-					},
-				},
-			},
+			PreToolUse: hooks,
 		},
 	}
 
@@ -55,7 +72,12 @@ func (g *ClaudeSettingsGenerator) GenerateSettingsJSON() string {
 
 // GenerateSettingsWorkflowStep generates a GitHub Actions workflow step that creates the settings file
 func (g *ClaudeSettingsGenerator) GenerateSettingsWorkflowStep() GitHubActionStep {
-	settingsJSON := g.GenerateSettingsJSON()
+	return g.GenerateSettingsWorkflowStepForHooks([]PreToolUseHook{networkPermissionsHook})
+}
+
+// GenerateSettingsWorkflowStepForHooks generates a workflow step that creates a settings file registering the given hooks
+func (g *ClaudeSettingsGenerator) GenerateSettingsWorkflowStepForHooks(hooks []PreToolUseHook) GitHubActionStep {
+	settingsJSON := g.GenerateSettingsJSONForHooks(hooks)
 
 	runContent := fmt.Sprintf(`cat > .claude/settings.json << 'EOF'
 %s
@@ -2,9 +2,11 @@ package workflow
 
 import (
 	"fmt"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // CodexEngine represents the Codex agentic engine (experimental)
@@ -22,6 +24,8 @@ func NewCodexEngine() *CodexEngine {
 			supportsToolsWhitelist: true,
 			supportsHTTPTransport:  false, // Codex only supports stdio transport
 			supportsMaxTurns:       false, // Codex does not support max-turns feature
+			supportsBashPolicy:     false, // Codex has no command allow-list, so the bash shim enforces it
+			supportedProviders:     []string{"azure-openai", "openai-compatible"},
 		},
 	}
 }
@@ -69,17 +73,30 @@ func (e *CodexEngine) GetExecutionSteps(workflowData *WorkflowData, logFile stri
 		model = workflowData.EngineConfig.Model
 	}
 
+	// Register the model provider serving the model in config.toml, if any
+	providerName, providerConfig := e.getModelProvider(workflowData)
+	providerSetup := ""
+	providerArgs := ""
+	if providerName != "" {
+		providerSetup = fmt.Sprintf(`
+# Register the %s model provider
+cat >> /tmp/mcp-config/config.toml << EOF
+%sEOF
+`, providerName, providerConfig)
+		providerArgs = fmt.Sprintf("  -c model_provider=%s \\\n", providerName)
+	}
+
 	command := fmt.Sprintf(`set -o pipefail
 INSTRUCTION=$(cat /tmp/aw-prompts/prompt.txt)
 export CODEX_HOME=/tmp/mcp-config
-
+%s
 # Create log directory outside git repo
 mkdir -p /tmp/aw-logs
 
 # Run codex with log capture - pipefail ensures codex exit code is preserved
 codex exec \
   -c model=%s \
-  --full-auto "$INSTRUCTION" 2>&1 | tee %s`, model, logFile)
+%s  --full-auto "$INSTRUCTION" 2>&1 | tee %s`, providerSetup, model, providerArgs, logFile)
 
 	env := map[string]string{
 		"OPENAI_API_KEY":      "${{ secrets.OPENAI_API_KEY }}",
@@ -93,6 +110,23 @@ codex exec \
 		env["GITHUB_AW_SAFE_OUTPUTS"] = "${{ env.GITHUB_AW_SAFE_OUTPUTS }}"
 	}
 
+	// Self-hosted servers need no OpenAI API key
+	if workflowData.EngineConfig != nil && workflowData.EngineConfig.BaseURL != "" {
+		delete(env, "OPENAI_API_KEY")
+	}
+
+	// Route model calls through the enterprise model provider
+	if provider := getEngineProvider(workflowData); provider != nil {
+		switch provider.Type {
+		case ProviderAzureOpenAI:
+			delete(env, "OPENAI_API_KEY")
+			env["AZURE_OPENAI_API_KEY"] = provider.credentials()
+		case ProviderOpenAICompatible:
+			env["OPENAI_API_KEY"] = provider.credentials()
+			env["OPENAI_BASE_URL"] = provider.Endpoint
+		}
+	}
+
 	// Add custom environment variables from engine config
 	if workflowData.EngineConfig != nil && len(workflowData.EngineConfig.Env) > 0 {
 		for key, value := range workflowData.EngineConfig.Env {
@@ -100,6 +134,11 @@ codex exec \
 		}
 	}
 
+	// Run behind the bash policy shim, which custom environment variables cannot override
+	if usesBashPolicyShim(workflowData, e) {
+		env["PATH"] = bashPolicyShimPath
+	}
+
 	// Generate the step for Codex execution
 	stepName := "Run Codex"
 	var stepLines []string
@@ -134,6 +173,36 @@ codex exec \
 	return steps
 }
 
+// getModelProvider returns the name and config.toml entry of the Codex model provider serving the
+// workflow's model: a self-hosted OpenAI-compatible server (engine.base-url) or Azure OpenAI.
+// The name is empty when Codex uses its default OpenAI provider.
+func (e *CodexEngine) getModelProvider(workflowData *WorkflowData) (string, string) {
+	if workflowData.EngineConfig == nil {
+		return "", ""
+	}
+
+	var config strings.Builder
+	if baseURL := workflowData.EngineConfig.BaseURL; baseURL != "" {
+		// Self-hosted servers (vLLM, Ollama, llama.cpp) implement the chat completions API without authentication
+		config.WriteString("\n[model_providers.self-hosted]\n")
+		config.WriteString("name = \"Self-hosted\"\n")
+		fmt.Fprintf(&config, "base_url = %q\n", baseURL)
+		config.WriteString("wire_api = \"chat\"\n")
+		return "self-hosted", config.String()
+	}
+
+	if provider := getEngineProvider(workflowData); provider != nil && provider.Type == ProviderAzureOpenAI {
+		config.WriteString("\n[model_providers.azure]\n")
+		config.WriteString("name = \"Azure OpenAI\"\n")
+		fmt.Fprintf(&config, "base_url = %q\n", provider.Endpoint)
+		config.WriteString("env_key = \"AZURE_OPENAI_API_KEY\"\n")
+		fmt.Fprintf(&config, "query_params = { api-version = %q }\n", DefaultAzureOpenAIAPIVersion)
+		return "azure", config.String()
+	}
+
+	return "", ""
+}
+
 // convertStepToYAML converts a step map to YAML string - temporary helper
 func (e *CodexEngine) convertStepToYAML(stepMap map[string]any) (string, error) {
 	// Simple YAML generation for steps - this mirrors the compiler logic
@@ -259,20 +328,180 @@ func (e *CodexEngine) ParseLogMetrics(logContent string, verbose bool) LogMetric
 	}
 
 	metrics.TokenUsage = totalTokenUsage
+	e.ParseLogTrace(logContent).addToMetrics(&metrics)
 
 	return metrics
 }
 
-// extractCodexTokenUsage extracts token usage from Codex-specific log lines
+var (
+	// codexLinePattern matches timestamped Codex log lines: "[2025-08-31T12:37:33] ..."
+	codexLinePattern = regexp.MustCompile(`^\[([^\]]+)\] (.*)$`)
+	// codexToolCallPattern matches MCP tool calls: "tool github.get_issue({...})"
+	codexToolCallPattern = regexp.MustCompile(`^tool ([^(\s]+)\(`)
+	// codexToolResultPattern matches MCP tool results: "github.get_issue({...}) success in 175ms:"
+	codexToolResultPattern = regexp.MustCompile(`^([^(\s]+)\(.*\) (success|failure|error|failed) in ([0-9.]+m?s):?$`)
+	// codexExecResultPattern matches shell command results: "bash -lc 'ls' succeeded in 12ms:"
+	codexExecResultPattern = regexp.MustCompile(`^.* (succeeded|failed|exited -?\d+) in ([0-9.]+m?s):?$`)
+	// codexModelPattern matches the model in the Codex log header: "model: o4-mini"
+	codexModelPattern = regexp.MustCompile(`^model: (\S+)$`)
+	// codexTokensUsedPattern matches the total tokens of a turn: "tokens used: 13934"
+	codexTokensUsedPattern = regexp.MustCompile(`tokens\s+used[:\s]+(\d+)`)
+	// codexTokenUsagePattern matches the token split of a turn, where input excludes the cached tokens:
+	// "Token usage: total=1500 input=400 (+ 800 cached) output=300 (reasoning 100)"
+	codexTokenUsagePattern = regexp.MustCompile(`(?i)token usage: total=([\d,]+) input=([\d,]+)(?: \(\+ ([\d,]+) cached\))? output=([\d,]+)`)
+)
+
+// codexExecToolName is the tool name Codex shell commands are traced under
+const codexExecToolName = "exec"
+
+// ParseLogTrace normalizes a Codex log into an agent trace. Codex reports the tokens
+// used at the end of every turn, a turn starts where the previous one ended.
+func (e *CodexEngine) ParseLogTrace(logContent string) *AgentTrace {
+	trace := newAgentTrace(e.GetID())
+	session := *trace.Session()
+	pendingCalls := make(map[string][]string) // tool name -> ids of calls without result
+	callCount := 0
+	turn := 0
+	inTurn := false
+	var turnBoundary, lastTimestamp time.Time
+
+	for _, line := range strings.Split(logContent, "\n") {
+		if match := codexModelPattern.FindStringSubmatch(strings.TrimSpace(line)); match != nil && session.Model == "" {
+			session.Model = match[1]
+			continue
+		}
+
+		match := codexLinePattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		// Tool output can contain lines starting with brackets too
+		timestamp := parseCodexTimestamp(match[1])
+		if timestamp.IsZero() {
+			continue
+		}
+		message := match[2]
+		if session.Timestamp.IsZero() {
+			session.Timestamp = timestamp
+			turnBoundary = timestamp
+		}
+		lastTimestamp = timestamp
+
+		// Header and prompt lines are not part of a turn
+		if strings.HasPrefix(message, "OpenAI Codex") || message == "User instructions:" {
+			turnBoundary = timestamp
+			continue
+		}
+		if strings.HasPrefix(message, "ERROR:") {
+			session.StopReason = StopReasonError
+			continue
+		}
+
+		if !inTurn {
+			inTurn = true
+			turn++
+			trace.add(TraceEvent{Type: TraceEventTurn, Timestamp: turnBoundary, Turn: turn})
+		}
+
+		if usage := e.extractCodexTokenSplit(message); usage.TotalTokens > 0 {
+			usage.Type, usage.Timestamp, usage.Turn, usage.Model = TraceEventModelUsage, timestamp, turn, session.Model
+			trace.add(usage)
+			session.InputTokens += usage.InputTokens
+			session.OutputTokens += usage.OutputTokens
+			session.CacheTokens += usage.CacheTokens
+			session.TotalTokens += usage.TotalTokens
+			inTurn = false
+			turnBoundary = timestamp
+			continue
+		}
+
+		name := ""
+		if toolMatch := codexToolCallPattern.FindStringSubmatch(message); toolMatch != nil {
+			name = toolMatch[1]
+		} else if strings.HasPrefix(message, "exec ") {
+			name = codexExecToolName
+		}
+		if name != "" {
+			callCount++
+			id := fmt.Sprintf("call_%d", callCount)
+			pendingCalls[name] = append(pendingCalls[name], id)
+			trace.add(TraceEvent{Type: TraceEventToolCall, Timestamp: timestamp, Turn: turn, ToolName: name, ToolCallID: id})
+			continue
+		}
+
+		var failed bool
+		var duration string
+		if resultMatch := codexToolResultPattern.FindStringSubmatch(message); resultMatch != nil {
+			name, failed, duration = resultMatch[1], resultMatch[2] != "success", resultMatch[3]
+		} else if resultMatch := codexExecResultPattern.FindStringSubmatch(message); resultMatch != nil {
+			name, failed, duration = codexExecToolName, resultMatch[1] != "succeeded", resultMatch[2]
+		}
+		if name != "" {
+			id := ""
+			if pending := pendingCalls[name]; len(pending) > 0 {
+				id, pendingCalls[name] = pending[0], pending[1:]
+			}
+			parsed, _ := time.ParseDuration(duration)
+			trace.add(TraceEvent{
+				Type:       TraceEventToolResult,
+				Timestamp:  timestamp,
+				Turn:       turn,
+				ToolName:   name,
+				ToolCallID: id,
+				IsError:    failed,
+				DurationMs: parsed.Milliseconds(),
+			})
+		}
+	}
+
+	session.Turn = turn
+	if !session.Timestamp.IsZero() {
+		session.DurationMs = lastTimestamp.Sub(session.Timestamp).Milliseconds()
+	}
+	// Codex has no turn limit, a session without errors ran to completion
+	if session.StopReason == "" && turn > 0 {
+		session.StopReason = StopReasonCompleted
+	}
+
+	*trace.Session() = session
+	return trace
+}
+
+// parseCodexTimestamp parses the timestamp of a Codex log line, which is UTC without a zone
+func parseCodexTimestamp(value string) time.Time {
+	if timestamp, err := time.Parse("2006-01-02T15:04:05", value); err == nil {
+		return timestamp
+	}
+	timestamp, _ := time.Parse(time.RFC3339Nano, value)
+	return timestamp
+}
+
+// extractCodexTokenUsage extracts the total token usage from Codex-specific log lines
 func (e *CodexEngine) extractCodexTokenUsage(line string) int {
-	// Codex format: "tokens used: 13934"
-	codexPattern := `tokens\s+used[:\s]+(\d+)`
-	if match := ExtractFirstMatch(line, codexPattern); match != "" {
-		if count, err := strconv.Atoi(match); err == nil {
-			return count
+	return e.extractCodexTokenSplit(line).TotalTokens
+}
+
+// extractCodexTokenSplit extracts the token usage of a Codex log line. Older Codex versions
+// only report the total tokens ("tokens used: 13934"), which leaves the split empty.
+func (e *CodexEngine) extractCodexTokenSplit(line string) TraceEvent {
+	if match := codexTokenUsagePattern.FindStringSubmatch(line); match != nil {
+		return TraceEvent{
+			TotalTokens:  parseCodexTokenCount(match[1]),
+			InputTokens:  parseCodexTokenCount(match[2]),
+			CacheTokens:  parseCodexTokenCount(match[3]),
+			OutputTokens: parseCodexTokenCount(match[4]),
 		}
 	}
-	return 0
+	if match := codexTokensUsedPattern.FindStringSubmatch(line); match != nil {
+		return TraceEvent{TotalTokens: parseCodexTokenCount(match[1])}
+	}
+	return TraceEvent{}
+}
+
+// parseCodexTokenCount parses a token count of a Codex log line, which may have thousands separators
+func parseCodexTokenCount(value string) int {
+	count, _ := strconv.Atoi(strings.ReplaceAll(value, ",", ""))
+	return count
 }
 
 // renderGitHubCodexMCPConfig generates GitHub MCP server configuration for codex config.toml
@@ -318,3 +547,12 @@ func (e *CodexEngine) renderCodexMCPConfig(yaml *strings.Builder, toolName strin
 func (e *CodexEngine) GetLogParserScript() string {
 	return "parse_codex_log"
 }
+
+// codexInfrastructureErrorLine matches the ERROR lines Codex prints when the model API rejects or
+// fails a request with an authentication, rate limit or server status, or cannot be reached
+const codexInfrastructureErrorLine = `^(\[[^]]*\] )?ERROR: (.*status:? (401|403|429|5[0-9][0-9])|stream disconnected before completion|error sending request)`
+
+// GetInfrastructureErrorCheck checks the Codex output for an ERROR line with an API failure
+func (e *CodexEngine) GetInfrastructureErrorCheck(logFile string) string {
+	return fmt.Sprintf("grep -qE '%s' %s 2>/dev/null", codexInfrastructureErrorLine, logFile)
+}
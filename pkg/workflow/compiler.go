@@ -80,7 +80,7 @@ func NewCompiler(verbose bool, engineOverride string, version string) *Compiler
 		version:        version,
 		skipValidation: true, // Skip validation by default for now since existing workflows don't fully comply
 		jobManager:     NewJobManager(),
-		engineRegistry: GetGlobalEngineRegistry(),
+		engineRegistry: NewEngineRegistry(),
 	}
 
 	return c
@@ -105,7 +105,7 @@ func NewCompilerWithCustomOutput(verbose bool, engineOverride string, customOutp
 		version:        version,
 		skipValidation: true, // Skip validation by default for now since existing workflows don't fully comply
 		jobManager:     NewJobManager(),
-		engineRegistry: GetGlobalEngineRegistry(),
+		engineRegistry: NewEngineRegistry(),
 	}
 
 	return c
@@ -139,6 +139,7 @@ type WorkflowData struct {
 	NeedsTextOutput    bool                // whether the workflow uses ${{ needs.task.outputs.text }}
 	NetworkPermissions *NetworkPermissions // parsed network permissions
 	SafeOutputs        *SafeOutputsConfig  // output configuration for automatic output routes
+	BashPolicy         *BashPolicy         // engine-neutral bash policy from tools.bash (nil if bash is not configured)
 }
 
 // SafeOutputsConfig holds configuration for automatic output routes
@@ -154,6 +155,8 @@ type SafeOutputsConfig struct {
 	PushToBranch                    *PushToBranchConfig                    `yaml:"push-to-branch,omitempty"`
 	MissingTool                     *MissingToolConfig                     `yaml:"missing-tool,omitempty"` // Optional for reporting missing functionality
 	AllowedDomains                  []string                               `yaml:"allowed-domains,omitempty"`
+	Sanitize                        *SanitizeConfig                        `yaml:"sanitize,omitempty"` // Default sanitize policy for all outputs
+	SanitizeOverrides               map[string]*SanitizeConfig             `yaml:"-"`                  // Per-output sanitize policies keyed by output type
 }
 
 // CreateIssuesConfig holds configuration for creating GitHub issues from agent output
@@ -284,6 +287,32 @@ func (c *Compiler) CompileWorkflow(markdownPath string) error {
 		fmt.Println(console.FormatSuccessMessage("Expression safety validation passed"))
 	}
 
+	// Enforce repository and organization policy files, if present
+	policy, err := LoadWorkflowPolicy(markdownPath)
+	if err != nil {
+		formattedErr := console.FormatError(console.CompilerError{
+			Position: console.ErrorPosition{
+				File:   markdownPath,
+				Line:   1,
+				Column: 1,
+			},
+			Type:    "error",
+			Message: err.Error(),
+		})
+		return errors.New(formattedErr)
+	}
+	if policy != nil {
+		if c.verbose {
+			fmt.Println(console.FormatInfoMessage(fmt.Sprintf("Validating against policy: %s", strings.Join(policy.Sources, ", "))))
+		}
+		if violations := c.validateWorkflowPolicy(policy, workflowData); len(violations) > 0 {
+			return formatPolicyViolations(markdownPath, policy, violations)
+		}
+		if c.verbose {
+			fmt.Println(console.FormatSuccessMessage("Policy validation passed"))
+		}
+	}
+
 	if c.verbose {
 		fmt.Println(console.FormatSuccessMessage("Successfully parsed frontmatter and markdown content"))
 		fmt.Println(console.FormatInfoMessage(fmt.Sprintf("Workflow name: %s", workflowData.Name)))
@@ -439,6 +468,18 @@ func (c *Compiler) validateWorkflowSchema(yamlContent string) error {
 	return nil
 }
 
+// loadEngineManifests registers the engines declared in the manifests of the repository containing
+// markdownPath, warning about invalid manifests
+func (c *Compiler) loadEngineManifests(markdownPath string) {
+	gitRoot := findRepositoryRoot(filepath.Dir(markdownPath))
+	if gitRoot == "" {
+		return
+	}
+	if err := c.engineRegistry.LoadManifestEngines(gitRoot); err != nil {
+		fmt.Fprintln(os.Stderr, console.FormatWarningMessage(err.Error()))
+	}
+}
+
 // parseWorkflowFile parses a markdown workflow file and extracts all necessary data
 func (c *Compiler) parseWorkflowFile(markdownPath string) (*WorkflowData, error) {
 	if c.verbose {
@@ -529,6 +570,8 @@ func (c *Compiler) parseWorkflowFile(markdownPath string) (*WorkflowData, error)
 		engineSetting = finalEngineSetting
 	}
 
+	c.loadEngineManifests(markdownPath)
+
 	// Apply the default AI engine setting if not specified
 	if engineSetting == "" {
 		defaultEngine := c.engineRegistry.GetDefaultEngine()
@@ -588,6 +631,18 @@ func (c *Compiler) parseWorkflowFile(markdownPath string) (*WorkflowData, error)
 		return nil, fmt.Errorf("HTTP transport not supported: %w", err)
 	}
 
+	// Resolve the engine-neutral bash policy before engine-specific tool handling
+	var bashPolicy *BashPolicy
+	if bashTool, hasBash := tools["bash"]; hasBash {
+		bashPolicy, err = ParseBashPolicy(bashTool)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bash tool configuration: %w", err)
+		}
+		if needsGitCommands(safeOutputs) {
+			bashPolicy.allowCommands(defaultGitCommands)
+		}
+	}
+
 	if !agenticEngine.SupportsToolsWhitelist() {
 		// For engines that don't support tool whitelists (like codex), ignore tools section and provide warnings
 		fmt.Println(console.FormatWarningMessage(fmt.Sprintf("Using experimental %s support (engine: %s)", agenticEngine.GetDisplayName(), engineSetting)))
@@ -606,6 +661,29 @@ func (c *Compiler) parseWorkflowFile(markdownPath string) (*WorkflowData, error)
 		return nil, fmt.Errorf("max-turns not supported: %w", err)
 	}
 
+	// Validate the engine fallback chain
+	if err := c.validateEngineFallbacks(engineConfig, agenticEngine, tools); err != nil {
+		return nil, fmt.Errorf("invalid engine fallback: %w", err)
+	}
+
+	// Validate the engine comparison matrix
+	if err := c.validateEngineMatrix(engineConfig, tools); err != nil {
+		return nil, fmt.Errorf("invalid engine matrix: %w", err)
+	}
+
+	// Validate the enterprise model provider
+	if err := c.validateEngineProvider(engineConfig, agenticEngine); err != nil {
+		return nil, fmt.Errorf("invalid engine provider: %w", err)
+	}
+
+	// Validate the self-hosted model server
+	if err := c.validateEngineBaseURL(engineConfig, agenticEngine); err != nil {
+		return nil, fmt.Errorf("invalid engine base-url: %w", err)
+	}
+
+	// Allow the engine to reach its self-hosted model server
+	networkPermissions = allowEngineBaseURL(networkPermissions, engineConfig)
+
 	// Process @include directives in markdown content
 	markdownContent, err := parser.ExpandIncludes(result.Markdown, markdownDir, false)
 	if err != nil {
@@ -639,12 +717,18 @@ func (c *Compiler) parseWorkflowFile(markdownPath string) (*WorkflowData, error)
 		EngineConfig:       engineConfig,
 		NetworkPermissions: networkPermissions,
 		NeedsTextOutput:    needsTextOutput,
+		BashPolicy:         bashPolicy,
 	}
 
 	// Extract YAML sections from frontmatter - use direct frontmatter map extraction
 	// to avoid issues with nested keys (e.g., tools.mcps.*.env being confused with top-level env)
 	workflowData.On = c.extractTopLevelYAMLSection(result.Frontmatter, "on")
 	workflowData.Permissions = c.extractTopLevelYAMLSection(result.Frontmatter, "permissions")
+
+	// Amazon Bedrock credentials are obtained through GitHub OIDC, which needs id-token: write
+	if providerNeedsIDToken(getEngineProvider(workflowData)) && !hasIDTokenWritePermission(workflowData.Permissions) {
+		fmt.Println(console.FormatWarningMessage("The bedrock provider authenticates with GitHub OIDC: add 'id-token: write' to permissions"))
+	}
 	workflowData.Network = c.extractTopLevelYAMLSection(result.Frontmatter, "network")
 	workflowData.Concurrency = c.extractTopLevelYAMLSection(result.Frontmatter, "concurrency")
 	workflowData.RunName = c.extractTopLevelYAMLSection(result.Frontmatter, "run-name")
@@ -1487,14 +1571,9 @@ func (c *Compiler) applyDefaultTools(tools map[string]any, safeOutputs *SafeOutp
 		if _, exists := tools["edit"]; !exists {
 			tools["edit"] = nil
 		}
-		gitCommands := []any{
-			"git checkout:*",
-			"git branch:*",
-			"git switch:*",
-			"git add:*",
-			"git rm:*",
-			"git commit:*",
-			"git merge:*",
+		gitCommands := make([]any, 0, len(defaultGitCommands))
+		for _, gitCmd := range defaultGitCommands {
+			gitCommands = append(gitCommands, gitCmd)
 		}
 
 		// Add bash tool with Git commands if not already present
@@ -1503,41 +1582,54 @@ func (c *Compiler) applyDefaultTools(tools map[string]any, safeOutputs *SafeOutp
 			tools["bash"] = gitCommands
 		} else {
 			// bash tool exists, merge Git commands with existing commands
-			existingBash := tools["bash"]
-			if existingCommands, ok := existingBash.([]any); ok {
-				// Convert existing commands to strings for comparison
-				existingSet := make(map[string]bool)
-				for _, cmd := range existingCommands {
-					if cmdStr, ok := cmd.(string); ok {
-						existingSet[cmdStr] = true
-						// If we see :* or *, all bash commands are already allowed
-						if cmdStr == ":*" || cmdStr == "*" {
-							// Don't add specific Git commands since all are already allowed
-							goto bashComplete
-						}
-					}
-				}
-
-				// Add Git commands that aren't already present
-				newCommands := make([]any, len(existingCommands))
-				copy(newCommands, existingCommands)
-				for _, gitCmd := range gitCommands {
-					if gitCmdStr, ok := gitCmd.(string); ok {
-						if !existingSet[gitCmdStr] {
-							newCommands = append(newCommands, gitCmd)
-						}
+			switch existingBash := tools["bash"].(type) {
+			case []any:
+				tools["bash"] = mergeGitCommands(existingBash, gitCommands)
+			case map[string]any:
+				// Policy form: merge into the allow list; an omitted allow list already allows everything
+				if allowList, ok := existingBash["allow"].([]any); ok {
+					merged := make(map[string]any, len(existingBash))
+					for key, value := range existingBash {
+						merged[key] = value
 					}
+					merged["allow"] = mergeGitCommands(allowList, gitCommands)
+					tools["bash"] = merged
 				}
-				tools["bash"] = newCommands
-			} else if existingBash == nil {
-				_ = existingBash // Keep the nil value as-is
 			}
+			// A nil value already allows all commands and is kept as-is
 		}
-	bashComplete:
 	}
 	return tools
 }
 
+// mergeGitCommands adds Git commands that aren't already present to a list of bash commands
+func mergeGitCommands(existingCommands []any, gitCommands []any) []any {
+	// Convert existing commands to strings for comparison
+	existingSet := make(map[string]bool)
+	for _, cmd := range existingCommands {
+		if cmdStr, ok := cmd.(string); ok {
+			existingSet[cmdStr] = true
+			// If we see :* or *, all bash commands are already allowed
+			if cmdStr == ":*" || cmdStr == "*" {
+				// Don't add specific Git commands since all are already allowed
+				return existingCommands
+			}
+		}
+	}
+
+	// Add Git commands that aren't already present
+	newCommands := make([]any, len(existingCommands))
+	copy(newCommands, existingCommands)
+	for _, gitCmd := range gitCommands {
+		if gitCmdStr, ok := gitCmd.(string); ok {
+			if !existingSet[gitCmdStr] {
+				newCommands = append(newCommands, gitCmd)
+			}
+		}
+	}
+	return newCommands
+}
+
 // needsGitCommands checks if safe outputs configuration requires Git commands
 func needsGitCommands(safeOutputs *SafeOutputsConfig) bool {
 	if safeOutputs == nil {
@@ -1634,7 +1726,8 @@ func (c *Compiler) generateYAML(data *WorkflowData, markdownPath string) (string
 	// Generate jobs section using JobManager
 	yaml.WriteString(c.jobManager.RenderToYAML())
 
-	return yaml.String(), nil
+	// Record the content hash in the header so the workflow can verify itself at runtime
+	return stampLockFileHash(yaml.String()), nil
 }
 
 // isTaskJobNeeded determines if the task job is required
@@ -1651,6 +1744,11 @@ func (c *Compiler) buildJobs(data *WorkflowData, markdownPath string) error {
 	// Generate job name from workflow name
 	jobName := c.generateJobName(data.Name)
 
+	// Verify the lock file before any other job runs so tampered permissions or tools never take effect
+	if err := c.jobManager.AddJob(c.buildLockFileVerificationJob(data)); err != nil {
+		return fmt.Errorf("failed to add %s job: %w", lockFileVerificationJobName, err)
+	}
+
 	// Build task job only if actually needed (preamble job that handles runtime conditions)
 	var taskJobCreated bool
 	if c.isTaskJobNeeded(data) {
@@ -1684,10 +1782,26 @@ func (c *Compiler) buildJobs(data *WorkflowData, markdownPath string) error {
 		return fmt.Errorf("failed to add main job: %w", err)
 	}
 
-	if data.SafeOutputs != nil {
+	// Safe output jobs read the agent output from the main job, or from the compare job in an engine matrix
+	outputJobName := jobName
+	applySafeOutputs := true
+	if isEngineMatrix(data) {
+		compareJob, err := c.buildCompareJob(data, jobName)
+		if err != nil {
+			return fmt.Errorf("failed to build compare job: %w", err)
+		}
+		if err := c.jobManager.AddJob(compareJob); err != nil {
+			return fmt.Errorf("failed to add compare job: %w", err)
+		}
+		// Without a winner the compare job only previews the outputs of each engine
+		outputJobName = compareJobName
+		applySafeOutputs = data.EngineConfig.Winner != ""
+	}
+
+	if data.SafeOutputs != nil && applySafeOutputs {
 		// Build create_issue job if output.create_issue is configured
 		if data.SafeOutputs.CreateIssues != nil {
-			createIssueJob, err := c.buildCreateOutputIssueJob(data, jobName)
+			createIssueJob, err := c.buildCreateOutputIssueJob(data, outputJobName)
 			if err != nil {
 				return fmt.Errorf("failed to build create_issue job: %w", err)
 			}
@@ -1698,7 +1812,7 @@ func (c *Compiler) buildJobs(data *WorkflowData, markdownPath string) error {
 
 		// Build create_discussion job if output.create_discussion is configured
 		if data.SafeOutputs.CreateDiscussions != nil {
-			createDiscussionJob, err := c.buildCreateOutputDiscussionJob(data, jobName)
+			createDiscussionJob, err := c.buildCreateOutputDiscussionJob(data, outputJobName)
 			if err != nil {
 				return fmt.Errorf("failed to build create_discussion job: %w", err)
 			}
@@ -1709,7 +1823,7 @@ func (c *Compiler) buildJobs(data *WorkflowData, markdownPath string) error {
 
 		// Build create_issue_comment job if output.add-issue-comment is configured
 		if data.SafeOutputs.AddIssueComments != nil {
-			createCommentJob, err := c.buildCreateOutputAddIssueCommentJob(data, jobName)
+			createCommentJob, err := c.buildCreateOutputAddIssueCommentJob(data, outputJobName)
 			if err != nil {
 				return fmt.Errorf("failed to build create_issue_comment job: %w", err)
 			}
@@ -1720,7 +1834,7 @@ func (c *Compiler) buildJobs(data *WorkflowData, markdownPath string) error {
 
 		// Build create_pr_review_comment job if output.create-pull-request-review-comment is configured
 		if data.SafeOutputs.CreatePullRequestReviewComments != nil {
-			createPRReviewCommentJob, err := c.buildCreateOutputPullRequestReviewCommentJob(data, jobName)
+			createPRReviewCommentJob, err := c.buildCreateOutputPullRequestReviewCommentJob(data, outputJobName)
 			if err != nil {
 				return fmt.Errorf("failed to build create_pr_review_comment job: %w", err)
 			}
@@ -1733,7 +1847,7 @@ func (c *Compiler) buildJobs(data *WorkflowData, markdownPath string) error {
 		if data.SafeOutputs.CreateSecurityReports != nil {
 			// Extract the workflow filename without extension for rule ID prefix
 			workflowFilename := strings.TrimSuffix(filepath.Base(markdownPath), ".md")
-			createSecurityReportJob, err := c.buildCreateOutputSecurityReportJob(data, jobName, workflowFilename)
+			createSecurityReportJob, err := c.buildCreateOutputSecurityReportJob(data, outputJobName, workflowFilename)
 			if err != nil {
 				return fmt.Errorf("failed to build create_security_report job: %w", err)
 			}
@@ -1744,7 +1858,7 @@ func (c *Compiler) buildJobs(data *WorkflowData, markdownPath string) error {
 
 		// Build create_pull_request job if output.create-pull-request is configured
 		if data.SafeOutputs.CreatePullRequests != nil {
-			createPullRequestJob, err := c.buildCreateOutputPullRequestJob(data, jobName)
+			createPullRequestJob, err := c.buildCreateOutputPullRequestJob(data, outputJobName)
 			if err != nil {
 				return fmt.Errorf("failed to build create_pull_request job: %w", err)
 			}
@@ -1755,7 +1869,7 @@ func (c *Compiler) buildJobs(data *WorkflowData, markdownPath string) error {
 
 		// Build add_labels job if output.add-issue-label is configured (including null/empty)
 		if data.SafeOutputs.AddIssueLabels != nil {
-			addLabelsJob, err := c.buildCreateOutputLabelJob(data, jobName)
+			addLabelsJob, err := c.buildCreateOutputLabelJob(data, outputJobName)
 			if err != nil {
 				return fmt.Errorf("failed to build add_labels job: %w", err)
 			}
@@ -1766,7 +1880,7 @@ func (c *Compiler) buildJobs(data *WorkflowData, markdownPath string) error {
 
 		// Build update_issue job if output.update-issue is configured
 		if data.SafeOutputs.UpdateIssues != nil {
-			updateIssueJob, err := c.buildCreateOutputUpdateIssueJob(data, jobName)
+			updateIssueJob, err := c.buildCreateOutputUpdateIssueJob(data, outputJobName)
 			if err != nil {
 				return fmt.Errorf("failed to build update_issue job: %w", err)
 			}
@@ -1777,7 +1891,7 @@ func (c *Compiler) buildJobs(data *WorkflowData, markdownPath string) error {
 
 		// Build push_to_branch job if output.push-to-branch is configured
 		if data.SafeOutputs.PushToBranch != nil {
-			pushToBranchJob, err := c.buildCreateOutputPushToBranchJob(data, jobName)
+			pushToBranchJob, err := c.buildCreateOutputPushToBranchJob(data, outputJobName)
 			if err != nil {
 				return fmt.Errorf("failed to build push_to_branch job: %w", err)
 			}
@@ -1788,7 +1902,7 @@ func (c *Compiler) buildJobs(data *WorkflowData, markdownPath string) error {
 
 		// Build missing_tool job (always enabled when SafeOutputs exists)
 		if data.SafeOutputs.MissingTool != nil {
-			missingToolJob, err := c.buildCreateOutputMissingToolJob(data, jobName)
+			missingToolJob, err := c.buildCreateOutputMissingToolJob(data, outputJobName)
 			if err != nil {
 				return fmt.Errorf("failed to build missing_tool job: %w", err)
 			}
@@ -1876,6 +1990,7 @@ func (c *Compiler) buildTaskJob(data *WorkflowData) (*Job, error) {
 		Permissions: "", // No permissions needed - task job does not require content access
 		Steps:       steps,
 		Outputs:     outputs,
+		Depends:     []string{lockFileVerificationJobName},
 	}
 
 	return job, nil
@@ -1908,7 +2023,7 @@ func (c *Compiler) buildAddReactionJob(data *WorkflowData, taskJobCreated bool)
 		"reaction_id": "${{ steps.react.outputs.reaction-id }}",
 	}
 
-	var depends []string
+	depends := []string{lockFileVerificationJobName}
 	if taskJobCreated {
 		depends = []string{"task"} // Depend on the task job only if it exists
 	}
@@ -2307,7 +2422,7 @@ func (c *Compiler) buildCreateOutputPullRequestJob(data *WorkflowData, mainJobNa
 	// Pass the agent output content from the main job
 	steps = append(steps, fmt.Sprintf("          GITHUB_AW_AGENT_OUTPUT: ${{ needs.%s.outputs.output }}\n", mainJobName))
 	// Pass the workflow ID for branch naming
-	steps = append(steps, fmt.Sprintf("          GITHUB_AW_WORKFLOW_ID: %q\n", mainJobName))
+	steps = append(steps, fmt.Sprintf("          GITHUB_AW_WORKFLOW_ID: %q\n", c.generateJobName(data.Name)))
 	// Pass the base branch from GitHub context
 	steps = append(steps, "          GITHUB_AW_BASE_BRANCH: ${{ github.ref_name }}\n")
 	if data.SafeOutputs.CreatePullRequests.TitlePrefix != "" {
@@ -2385,25 +2500,31 @@ func (c *Compiler) buildMainJob(data *WorkflowData, jobName string, taskJobCreat
 		steps = append(steps, stepsContent)
 	}
 
-	var depends []string
+	depends := []string{lockFileVerificationJobName}
 	if taskJobCreated {
 		depends = []string{"task"} // Depend on the task job only if it exists
 	}
 
 	// Build outputs for all engines (GITHUB_AW_SAFE_OUTPUTS functionality)
 	// Only include output if the workflow actually uses the safe-outputs feature
+	// Matrix legs cannot share job outputs, so the compare job reads their output artifacts instead
 	var outputs map[string]string
-	if data.SafeOutputs != nil {
+	var strategy string
+	if data.SafeOutputs != nil && !isEngineMatrix(data) {
 		outputs = map[string]string{
 			"output": "${{ steps.collect_output.outputs.output }}",
 		}
 	}
+	if isEngineMatrix(data) {
+		strategy = engineMatrixStrategy(data)
+	}
 
 	job := &Job{
 		Name:        jobName,
 		If:          "", // Remove the If condition since task job handles alias checks
 		RunsOn:      c.indentYAMLLines(data.RunsOn, "    "),
 		Permissions: c.indentYAMLLines(data.Permissions, "    "),
+		Strategy:    strategy,
 		Steps:       steps,
 		Depends:     depends,
 		Outputs:     outputs,
@@ -2459,7 +2580,7 @@ func (c *Compiler) generateSafetyChecks(yaml *strings.Builder, data *WorkflowDat
 }
 
 // generateMCPSetup generates the MCP server configuration setup
-func (c *Compiler) generateMCPSetup(yaml *strings.Builder, tools map[string]any, engine CodingAgentEngine) {
+func (c *Compiler) generateMCPSetup(yaml *strings.Builder, tools map[string]any, engines ...CodingAgentEngine) {
 	// Collect tools that need MCP server configuration
 	var mcpTools []string
 	var proxyTools []string
@@ -2548,8 +2669,10 @@ func (c *Compiler) generateMCPSetup(yaml *strings.Builder, tools map[string]any,
 	yaml.WriteString("        run: |\n")
 	yaml.WriteString("          mkdir -p /tmp/mcp-config\n")
 
-	// Use the engine's RenderMCPConfig method
-	engine.RenderMCPConfig(yaml, tools, mcpTools)
+	// Use each engine's RenderMCPConfig method so fallback engines are configured up front
+	for _, engine := range engines {
+		engine.RenderMCPConfig(yaml, tools, mcpTools)
+	}
 }
 
 func getGitHubDockerImageVersion(githubTool any) string {
@@ -2598,11 +2721,29 @@ func (c *Compiler) generateMainJobSteps(yaml *strings.Builder, data *WorkflowDat
 		return
 	}
 
+	// Engines to retry the prompt with when the primary engine fails for infrastructure reasons,
+	// or the engines compared in a matrix
+	chain := c.getEngineChain(data, engine)
+	matrix := c.getEngineMatrixLegs(data)
+	attempts := chain
+	if len(matrix) > 0 {
+		attempts = matrix
+	}
+	engines := make([]CodingAgentEngine, len(attempts))
+	for i, attempt := range attempts {
+		engines[i] = attempt.engine
+	}
+
 	// Add engine-specific installation steps
-	installSteps := engine.GetInstallationSteps(data)
-	for _, step := range installSteps {
-		for _, line := range step {
-			yaml.WriteString(line + "\n")
+	if len(matrix) > 0 {
+		c.generateEngineMatrixInstallSteps(yaml, matrix)
+	} else {
+		// Fallback engines are installed before they run, only when the chain falls back to them
+		installSteps := chain[0].engine.GetInstallationSteps(chain[0].data)
+		for _, step := range installSteps {
+			for _, line := range step {
+				yaml.WriteString(line + "\n")
+			}
 		}
 	}
 
@@ -2612,28 +2753,50 @@ func (c *Compiler) generateMainJobSteps(yaml *strings.Builder, data *WorkflowDat
 	}
 
 	// Add MCP setup
-	c.generateMCPSetup(yaml, data.Tools, engine)
+	c.generateMCPSetup(yaml, data.Tools, engines...)
 
 	// Add safety checks before executing agentic tools
 	c.generateSafetyChecks(yaml, data)
 
 	// Add prompt creation step
 	c.generatePrompt(yaml, data)
+	c.generateUploadPrompt(yaml, data)
 
 	logFile := generateSafeFileName(data.Name)
 	logFileFull := fmt.Sprintf("/tmp/%s.log", logFile)
 
 	// Generate aw_info.json with agentic run metadata
-	c.generateCreateAwInfo(yaml, data, engine)
+	if len(matrix) > 0 {
+		c.generateEngineMatrixAwInfo(yaml, matrix)
+	} else {
+		c.generateCreateAwInfo(yaml, data, engine)
+	}
+
+	// Upload info to artifact, after the engine that produced the output is recorded when falling back
+	if len(chain) == 1 {
+		c.generateUploadAwInfo(yaml, data)
+	}
 
-	// Upload info to artifact
-	c.generateUploadAwInfo(yaml)
+	// Enforce the bash policy with a shim for engines that cannot enforce it natively
+	if data.BashPolicy.IsRestricted() && !allEnginesSupportBashPolicy(engines) {
+		generator := &BashPolicyGenerator{}
+		for _, line := range generator.GenerateBashPolicyShimStep(data.BashPolicy) {
+			yaml.WriteString(line + "\n")
+		}
+	}
 
 	// Add AI execution step using the agentic engine
-	c.generateEngineExecutionSteps(yaml, data, engine, logFileFull)
+	if len(matrix) > 0 {
+		c.generateEngineMatrixExecutionSteps(yaml, matrix, logFileFull)
+	} else if len(chain) > 1 {
+		c.generateEngineChainExecutionSteps(yaml, chain, logFileFull)
+		c.generateUploadAwInfo(yaml, data)
+	} else {
+		c.generateEngineExecutionSteps(yaml, data, engine, logFileFull)
+	}
 
 	// add workflow_complete.txt
-	c.generateWorkflowComplete(yaml)
+	c.generateWorkflowComplete(yaml, data)
 
 	// Add output collection step only if safe-outputs feature is used (GITHUB_AW_SAFE_OUTPUTS functionality)
 	if data.SafeOutputs != nil {
@@ -2641,19 +2804,25 @@ func (c *Compiler) generateMainJobSteps(yaml *strings.Builder, data *WorkflowDat
 	}
 
 	// Add engine-declared output files collection (if any)
-	if len(engine.GetDeclaredOutputFiles()) > 0 {
-		c.generateEngineOutputCollection(yaml, engine)
+	if outputFiles := engineChainOutputFiles(attempts); len(outputFiles) > 0 {
+		c.generateEngineOutputCollection(yaml, data, outputFiles)
 	}
 
 	// Extract and upload squid access logs (if any proxy tools were used)
 	c.generateExtractAccessLogs(yaml, data.Tools)
-	c.generateUploadAccessLogs(yaml, data.Tools)
+	c.generateUploadAccessLogs(yaml, data)
 
 	// parse agent logs for GITHUB_STEP_SUMMARY
-	c.generateLogParsing(yaml, engine, logFileFull)
+	if len(matrix) > 0 {
+		c.generateEngineMatrixLogParsing(yaml, matrix, logFileFull)
+	} else if len(chain) > 1 {
+		c.generateEngineChainLogParsing(yaml, chain, logFileFull)
+	} else {
+		c.generateLogParsing(yaml, engine, logFileFull)
+	}
 
 	// upload agent logs
-	c.generateUploadAgentLogs(yaml, logFile, logFileFull)
+	c.generateUploadAgentLogs(yaml, data, logFile, logFileFull)
 
 	// Add git patch generation step only if safe-outputs create-pull-request feature is used
 	if data.SafeOutputs != nil && (data.SafeOutputs.CreatePullRequests != nil || data.SafeOutputs.PushToBranch != nil) {
@@ -2664,7 +2833,7 @@ func (c *Compiler) generateMainJobSteps(yaml *strings.Builder, data *WorkflowDat
 	c.generatePostSteps(yaml, data)
 }
 
-func (c *Compiler) generateWorkflowComplete(yaml *strings.Builder) {
+func (c *Compiler) generateWorkflowComplete(yaml *strings.Builder, data *WorkflowData) {
 	yaml.WriteString("      - name: Check if workflow-complete.txt exists, if so upload it\n")
 	yaml.WriteString("        id: check_file\n")
 	yaml.WriteString("        run: |\n")
@@ -2679,21 +2848,26 @@ func (c *Compiler) generateWorkflowComplete(yaml *strings.Builder) {
 	yaml.WriteString("        if: steps.check_file.outputs.upload == 'true'\n")
 	yaml.WriteString("        uses: actions/upload-artifact@v4\n")
 	yaml.WriteString("        with:\n")
-	yaml.WriteString("          name: workflow-complete\n")
+	fmt.Fprintf(yaml, "          name: %s\n", artifactName(data, "workflow-complete"))
 	yaml.WriteString("          path: workflow-complete.txt\n")
 }
 
-func (c *Compiler) generateUploadAgentLogs(yaml *strings.Builder, logFile string, logFileFull string) {
+func (c *Compiler) generateUploadAgentLogs(yaml *strings.Builder, data *WorkflowData, logFile string, logFileFull string) {
 	yaml.WriteString("      - name: Upload agent logs\n")
 	yaml.WriteString("        if: always()\n")
 	yaml.WriteString("        uses: actions/upload-artifact@v4\n")
 	yaml.WriteString("        with:\n")
-	fmt.Fprintf(yaml, "          name: %s.log\n", logFile)
+	fmt.Fprintf(yaml, "          name: %s\n", artifactName(data, logFile+".log"))
 	fmt.Fprintf(yaml, "          path: %s\n", logFileFull)
 	yaml.WriteString("          if-no-files-found: warn\n")
 }
 
 func (c *Compiler) generateLogParsing(yaml *strings.Builder, engine CodingAgentEngine, logFileFull string) {
+	c.generateLogParsingStep(yaml, engine, logFileFull, "always()")
+}
+
+// generateLogParsingStep generates the engine's log parsing step, running it under the given condition
+func (c *Compiler) generateLogParsingStep(yaml *strings.Builder, engine CodingAgentEngine, logFileFull string, condition string) {
 	parserScriptName := engine.GetLogParserScript()
 	if parserScriptName == "" {
 		// Skip log parsing if engine doesn't provide a parser
@@ -2707,7 +2881,7 @@ func (c *Compiler) generateLogParsing(yaml *strings.Builder, engine CodingAgentE
 	}
 
 	yaml.WriteString("      - name: Parse agent logs for step summary\n")
-	yaml.WriteString("        if: always()\n")
+	fmt.Fprintf(yaml, "        if: %s\n", condition)
 	yaml.WriteString("        uses: actions/github-script@v7\n")
 	yaml.WriteString("        env:\n")
 	fmt.Fprintf(yaml, "          AGENT_LOG_FILE: %s\n", logFileFull)
@@ -2721,12 +2895,23 @@ func (c *Compiler) generateLogParsing(yaml *strings.Builder, engine CodingAgentE
 	}
 }
 
-func (c *Compiler) generateUploadAwInfo(yaml *strings.Builder) {
+// generateUploadPrompt uploads the prompt given to the agent, so runs can be compared with 'logs diff'
+func (c *Compiler) generateUploadPrompt(yaml *strings.Builder, data *WorkflowData) {
+	yaml.WriteString("      - name: Upload prompt\n")
+	yaml.WriteString("        if: always()\n")
+	yaml.WriteString("        uses: actions/upload-artifact@v4\n")
+	yaml.WriteString("        with:\n")
+	fmt.Fprintf(yaml, "          name: %s\n", artifactName(data, "prompt.txt"))
+	yaml.WriteString("          path: /tmp/aw-prompts/prompt.txt\n")
+	yaml.WriteString("          if-no-files-found: warn\n")
+}
+
+func (c *Compiler) generateUploadAwInfo(yaml *strings.Builder, data *WorkflowData) {
 	yaml.WriteString("      - name: Upload agentic run info\n")
 	yaml.WriteString("        if: always()\n")
 	yaml.WriteString("        uses: actions/upload-artifact@v4\n")
 	yaml.WriteString("        with:\n")
-	yaml.WriteString("          name: aw_info.json\n")
+	fmt.Fprintf(yaml, "          name: %s\n", artifactName(data, "aw_info.json"))
 	yaml.WriteString("          path: /tmp/aw_info.json\n")
 	yaml.WriteString("          if-no-files-found: warn\n")
 }
@@ -2763,10 +2948,10 @@ func (c *Compiler) generateExtractAccessLogs(yaml *strings.Builder, tools map[st
 	}
 }
 
-func (c *Compiler) generateUploadAccessLogs(yaml *strings.Builder, tools map[string]any) {
+func (c *Compiler) generateUploadAccessLogs(yaml *strings.Builder, data *WorkflowData) {
 	// Check if any tools require proxy setup
 	var proxyTools []string
-	for toolName, toolConfig := range tools {
+	for toolName, toolConfig := range data.Tools {
 		if toolConfigMap, ok := toolConfig.(map[string]any); ok {
 			needsProxySetup, _ := needsProxy(toolConfigMap)
 			if needsProxySetup {
@@ -2784,7 +2969,7 @@ func (c *Compiler) generateUploadAccessLogs(yaml *strings.Builder, tools map[str
 	yaml.WriteString("        if: always()\n")
 	yaml.WriteString("        uses: actions/upload-artifact@v4\n")
 	yaml.WriteString("        with:\n")
-	yaml.WriteString("          name: access.log\n")
+	fmt.Fprintf(yaml, "          name: %s\n", artifactName(data, "access.log"))
 	yaml.WriteString("          path: /tmp/access-logs/\n")
 	yaml.WriteString("          if-no-files-found: warn\n")
 }
@@ -3197,6 +3382,9 @@ func (c *Compiler) extractSafeOutputsConfig(frontmatter map[string]any) *SafeOut
 			if missingToolConfig != nil {
 				config.MissingTool = missingToolConfig
 			}
+
+			// Parse sanitize policies (top-level default and per-output overrides)
+			c.parseSanitizeConfigs(outputMap, config)
 		}
 	}
 
@@ -3590,6 +3778,10 @@ func (c *Compiler) buildCustomJobs(data *WorkflowData) error {
 					job.Depends = append(job.Depends, depStr)
 				}
 			}
+			// Jobs without dependencies would start before the lock file is verified
+			if len(job.Depends) == 0 {
+				job.Depends = []string{lockFileVerificationJobName}
+			}
 
 			// Extract other job properties
 			if runsOn, hasRunsOn := configMap["runs-on"]; hasRunsOn {
@@ -3845,6 +4037,22 @@ func (c *Compiler) generateOutputCollectionStep(yaml *strings.Builder, data *Wor
 			safeOutputsConfig["missing-tool"] = missingToolConfig
 		}
 
+		// Attach the effective sanitize policy to each enabled output type
+		for outputType, entry := range safeOutputsConfig {
+			sanitizeConfig := data.SafeOutputs.effectiveSanitizeConfig(outputType)
+			if sanitizeConfig == nil {
+				continue
+			}
+			entryConfig, ok := entry.(map[string]interface{})
+			if !ok {
+				entryConfig = map[string]interface{}{
+					"enabled": true,
+				}
+			}
+			entryConfig["sanitize"] = sanitizeConfig
+			safeOutputsConfig[outputType] = entryConfig
+		}
+
 		// Convert to JSON string for environment variable
 		configJSON, _ := json.Marshal(safeOutputsConfig)
 		fmt.Fprintf(yaml, "          GITHUB_AW_SAFE_OUTPUTS_CONFIG: %q\n", string(configJSON))
@@ -3885,14 +4093,14 @@ func (c *Compiler) generateOutputCollectionStep(yaml *strings.Builder, data *Wor
 	yaml.WriteString("        if: always() && steps.collect_output.outputs.output != ''\n")
 	yaml.WriteString("        uses: actions/upload-artifact@v4\n")
 	yaml.WriteString("        with:\n")
-	fmt.Fprintf(yaml, "          name: %s\n", OutputArtifactName)
+	fmt.Fprintf(yaml, "          name: %s\n", artifactName(data, OutputArtifactName))
 	yaml.WriteString("          path: ${{ env.GITHUB_AW_SAFE_OUTPUTS }}\n")
 	yaml.WriteString("          if-no-files-found: warn\n")
 	yaml.WriteString("      - name: Upload agent output JSON\n")
 	yaml.WriteString("        if: always() && env.GITHUB_AW_AGENT_OUTPUT\n")
 	yaml.WriteString("        uses: actions/upload-artifact@v4\n")
 	yaml.WriteString("        with:\n")
-	yaml.WriteString("          name: agent_output.json\n")
+	fmt.Fprintf(yaml, "          name: %s\n", artifactName(data, "agent_output.json"))
 	yaml.WriteString("          path: ${{ env.GITHUB_AW_AGENT_OUTPUT }}\n")
 	yaml.WriteString("          if-no-files-found: warn\n")
 
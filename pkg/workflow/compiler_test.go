@@ -1949,21 +1949,26 @@ This is a test workflow.
 		t.Fatalf("Generated file too short, expected at least 3 lines")
 	}
 
-	// Check that the first 3 lines are comment lines (disclaimer)
-	for i := 0; i < 3; i++ {
+	// Check that the first 5 lines are comment lines (disclaimer and lock file hash)
+	for i := 0; i < 5; i++ {
 		if !strings.HasPrefix(lines[i], "#") {
 			t.Errorf("Line %d should be a comment (disclaimer), but got: %s", i+1, lines[i])
 		}
 	}
 
-	// Check that line 4 is empty (separator after disclaimer)
-	if lines[3] != "" {
-		t.Errorf("Line 4 should be empty (separator), but got: %s", lines[3])
+	// Check that line 5 records the lock file hash
+	if !strings.HasPrefix(lines[4], LockFileHashPrefix) {
+		t.Errorf("Line 5 should record the lock file hash, but got: %s", lines[4])
 	}
 
-	// Check that line 5 starts the actual workflow content
-	if !strings.HasPrefix(lines[4], "name:") {
-		t.Errorf("Line 5 should start with 'name:', but got: %s", lines[4])
+	// Check that line 6 is empty (separator after disclaimer)
+	if lines[5] != "" {
+		t.Errorf("Line 6 should be empty (separator), but got: %s", lines[5])
+	}
+
+	// Check that line 7 starts the actual workflow content
+	if !strings.HasPrefix(lines[6], "name:") {
+		t.Errorf("Line 7 should start with 'name:', but got: %s", lines[6])
 	}
 }
 
@@ -2755,10 +2760,10 @@ Test workflow with reaction.
 		}
 	}
 
-	// Verify two jobs are created (add_reaction, main) - missing_tool is not auto-created
+	// Verify three jobs are created (verify_lock_file, add_reaction, main) - missing_tool is not auto-created
 	jobCount := strings.Count(yamlContent, "runs-on: ubuntu-latest")
-	if jobCount != 2 {
-		t.Errorf("Expected 2 jobs (add_reaction, main), found %d", jobCount)
+	if jobCount != 3 {
+		t.Errorf("Expected 3 jobs (verify_lock_file, add_reaction, main), found %d", jobCount)
 	}
 }
 
@@ -2827,10 +2832,10 @@ Test workflow without explicit reaction (should not create reaction action).
 		}
 	}
 
-	// Verify only one job is created (main) - missing_tool is not auto-created
+	// Verify only two jobs are created (verify_lock_file, main) - missing_tool is not auto-created
 	jobCount := strings.Count(yamlContent, "runs-on: ubuntu-latest")
-	if jobCount != 1 {
-		t.Errorf("Expected 1 job (main), found %d", jobCount)
+	if jobCount != 2 {
+		t.Errorf("Expected 2 jobs (verify_lock_file, main), found %d", jobCount)
 	}
 }
 
@@ -4870,7 +4875,7 @@ func TestAccessLogUploadConditional(t *testing.T) {
 
 			// Test generateUploadAccessLogs
 			yaml.Reset()
-			compiler.generateUploadAccessLogs(&yaml, tt.tools)
+			compiler.generateUploadAccessLogs(&yaml, &WorkflowData{Tools: tt.tools})
 			uploadContent := yaml.String()
 
 			hasExtractStep := strings.Contains(extractContent, "name: Extract squid access logs")
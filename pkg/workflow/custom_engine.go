@@ -22,6 +22,7 @@ func NewCustomEngine() *CustomEngine {
 			supportsToolsWhitelist: false,
 			supportsHTTPTransport:  false,
 			supportsMaxTurns:       true, // Custom engine supports max-turns for consistency
+			supportsBashPolicy:     false,
 		},
 	}
 }
@@ -70,9 +71,17 @@ func (e *CustomEngine) GetExecutionSteps(workflowData *WorkflowData, logFile str
 				// Add custom environment variables from engine config
 				if workflowData.EngineConfig != nil && len(workflowData.EngineConfig.Env) > 0 {
 					for key, value := range workflowData.EngineConfig.Env {
+						if key == "PATH" && usesBashPolicyShim(workflowData, e) {
+							continue
+						}
 						stepStr += fmt.Sprintf("          %s: %s\n", key, value)
 					}
 				}
+
+				// Run behind the bash policy shim, which custom environment variables cannot override
+				if usesBashPolicyShim(workflowData, e) {
+					stepStr += fmt.Sprintf("          PATH: %s\n", bashPolicyShimPath)
+				}
 			}
 
 			// Split the step YAML into lines to create a GitHubActionStep
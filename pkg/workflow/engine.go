@@ -13,6 +13,12 @@ type EngineConfig struct {
 	MaxTurns string
 	Env      map[string]string
 	Steps    []map[string]any
+	Fallback []*EngineConfig       // Engines that retry the prompt when this engine fails for infrastructure reasons
+	Matrix   []*EngineMatrixLeg    // Engines that each run the prompt in a matrix leg for comparison
+	Compare  bool                  // Compare the outputs of the matrix legs
+	Winner   string                // Matrix leg whose output is applied by the safe output jobs
+	Provider *EngineProviderConfig // Enterprise model provider the engine calls instead of the vendor API
+	BaseURL  string                // Self-hosted OpenAI-compatible server the engine calls instead of the vendor API
 }
 
 // NetworkPermissions represents network access permissions
@@ -95,6 +101,36 @@ func (c *Compiler) extractEngineConfig(frontmatter map[string]any) (string, *Eng
 				}
 			}
 
+			// Extract optional 'fallback' field (array of engine IDs or engine objects)
+			if fallback, hasFallback := engineObj["fallback"]; hasFallback {
+				config.Fallback = extractEngineFallback(fallback)
+			}
+
+			// Extract optional 'matrix', 'compare' and 'winner' fields
+			if matrix, hasMatrix := engineObj["matrix"]; hasMatrix {
+				config.Matrix = extractEngineMatrix(matrix)
+				// The first matrix engine is the workflow's engine for everything outside the matrix legs
+				if config.ID == "" && len(config.Matrix) > 0 {
+					config.ID = config.Matrix[0].ID
+				}
+			}
+			if compare, ok := engineObj["compare"].(bool); ok {
+				config.Compare = compare
+			}
+			if winner, ok := engineObj["winner"].(string); ok {
+				config.Winner = winner
+			}
+
+			// Extract optional 'base-url' field (self-hosted OpenAI-compatible server)
+			if baseURL, ok := engineObj["base-url"].(string); ok {
+				config.BaseURL = baseURL
+			}
+
+			// Extract optional 'provider' field (enterprise model provider)
+			if provider, hasProvider := engineObj["provider"]; hasProvider {
+				config.Provider = extractEngineProvider(provider)
+			}
+
 			// Return the ID as the engineSetting for backwards compatibility
 			return config.ID, config
 		}
@@ -0,0 +1,354 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// engineAttempt is one engine in a fallback chain together with the workflow data it runs with
+type engineAttempt struct {
+	engine    CodingAgentEngine
+	data      *WorkflowData
+	stepCount int      // Number of steps generated for the engine, used to name steps without an ID
+	runIDs    []string // Step IDs of the execution steps
+	matrixKey string   // Matrix leg name when the engine runs in an engine matrix
+}
+
+// extractEngineFallback parses the engine 'fallback' field, a list of engine IDs or {id, model, version} objects
+func extractEngineFallback(fallback any) []*EngineConfig {
+	fallbackArray, ok := fallback.([]any)
+	if !ok {
+		return nil
+	}
+
+	var configs []*EngineConfig
+	for _, item := range fallbackArray {
+		switch value := item.(type) {
+		case string:
+			configs = append(configs, &EngineConfig{ID: value})
+		case map[string]any:
+			config := &EngineConfig{}
+			if id, ok := value["id"].(string); ok {
+				config.ID = id
+			}
+			if model, ok := value["model"].(string); ok {
+				config.Model = model
+			}
+			if version, ok := value["version"].(string); ok {
+				config.Version = version
+			}
+			configs = append(configs, config)
+		}
+	}
+	return configs
+}
+
+// validateEngineFallbacks validates that the fallback engines exist, are not repeated, and support
+// the features the workflow uses
+func (c *Compiler) validateEngineFallbacks(engineConfig *EngineConfig, primary CodingAgentEngine, tools map[string]any) error {
+	if engineConfig == nil || len(engineConfig.Fallback) == 0 {
+		return nil
+	}
+
+	if primary.GetID() == "custom" {
+		return fmt.Errorf("engine fallback is not supported with the custom engine")
+	}
+
+	seen := map[string]bool{primary.GetID(): true}
+	for _, fallback := range engineConfig.Fallback {
+		if fallback.ID == "" {
+			return fmt.Errorf("engine fallback entries must specify an engine id")
+		}
+		if err := c.validateEngine(fallback.ID); err != nil {
+			return fmt.Errorf("invalid fallback engine '%s': %w", fallback.ID, err)
+		}
+		engine, err := c.getAgenticEngine(fallback.ID)
+		if err != nil {
+			return fmt.Errorf("invalid fallback engine '%s': %w", fallback.ID, err)
+		}
+		if engine.GetID() == "custom" {
+			return fmt.Errorf("the custom engine cannot be used as a fallback engine")
+		}
+		if seen[engine.GetID()] {
+			return fmt.Errorf("engine '%s' appears more than once in the fallback chain", engine.GetID())
+		}
+		seen[engine.GetID()] = true
+
+		if err := c.validateHTTPTransportSupport(tools, engine); err != nil {
+			return err
+		}
+		if engineConfig.MaxTurns != "" && !engine.SupportsMaxTurns() {
+			return fmt.Errorf("fallback engine '%s' does not support the max-turns feature", engine.GetID())
+		}
+	}
+	return nil
+}
+
+// getEngineChain returns the primary engine followed by its fallback engines. Fallback engines
+// inherit max-turns and env from the primary engine configuration but not its model or version.
+func (c *Compiler) getEngineChain(data *WorkflowData, primary CodingAgentEngine) []*engineAttempt {
+	chain := []*engineAttempt{{engine: primary, data: data}}
+	if data.EngineConfig == nil {
+		return chain
+	}
+
+	for _, fallback := range data.EngineConfig.Fallback {
+		engine, err := c.getAgenticEngine(fallback.ID)
+		if err != nil {
+			continue
+		}
+		fallbackData := *data
+		fallbackData.EngineConfig = &EngineConfig{
+			ID:       engine.GetID(),
+			Model:    fallback.Model,
+			Version:  fallback.Version,
+			MaxTurns: data.EngineConfig.MaxTurns,
+			Env:      data.EngineConfig.Env,
+		}
+		chain = append(chain, &engineAttempt{engine: engine, data: &fallbackData})
+	}
+	return chain
+}
+
+// engineChainCondition returns the condition under which the engine at index i runs: the
+// previous engine's result check requested a fallback. The primary engine always runs.
+func engineChainCondition(i int) string {
+	if i == 0 {
+		return ""
+	}
+	return fmt.Sprintf("steps.engine_fallback_%d.outputs.fallback == 'true'", i-1)
+}
+
+// prepareEngineChainStep gives the n-th step of the engine at index i an ID, its chain condition,
+// and continue-on-error when requested. It returns the step and its ID.
+func prepareEngineChainStep(step GitHubActionStep, i int, n int, continueOnError bool) (GitHubActionStep, string) {
+	condition := engineChainCondition(i)
+
+	var stepID string
+	hasCondition := false
+	hasContinueOnError := false
+	lines := make([]string, 0, len(step)+3)
+	for j, line := range step {
+		switch {
+		case j > 0 && strings.HasPrefix(line, "        id: "):
+			stepID = strings.TrimPrefix(line, "        id: ")
+		case j > 0 && strings.HasPrefix(line, "        if: "):
+			hasCondition = true
+			if condition != "" {
+				line = fmt.Sprintf("        if: %s && %s", strings.TrimPrefix(line, "        if: "), condition)
+			}
+		case j > 0 && strings.HasPrefix(line, "        continue-on-error:"):
+			hasContinueOnError = true
+		}
+		lines = append(lines, line)
+	}
+
+	var attributes []string
+	if stepID == "" {
+		stepID = fmt.Sprintf("engine_%d_step_%d", i, n)
+		attributes = append(attributes, "        id: "+stepID)
+	}
+	if !hasCondition && condition != "" {
+		attributes = append(attributes, "        if: "+condition)
+	}
+	if continueOnError && !hasContinueOnError {
+		attributes = append(attributes, "        continue-on-error: true")
+	}
+
+	result := append([]string{lines[0]}, attributes...)
+	result = append(result, lines[1:]...)
+	return GitHubActionStep(result), stepID
+}
+
+// generateEngineChainExecutionSteps generates the execution steps of every engine in the chain. After each
+// engine but the last, a check step requests a fallback when the engine failed for infrastructure reasons
+// and fails the job when it failed for any other reason. Before a fallback engine runs, the safe outputs
+// and the workspace changes of the failed engine are discarded, then the fallback engine is installed.
+// The primary engine is installed with the other setup steps. Installation steps are always fatal, so
+// an engine never runs without the settings and hooks enforcing the workflow's restrictions.
+func (c *Compiler) generateEngineChainExecutionSteps(yaml *strings.Builder, chain []*engineAttempt, logFile string) {
+	c.generateRecordWorkspaceState(yaml)
+
+	for i, attempt := range chain {
+		last := i == len(chain)-1
+		if i > 0 {
+			c.generateResetForFallback(yaml, attempt, i)
+			for _, step := range attempt.engine.GetInstallationSteps(attempt.data) {
+				prepared, _ := prepareEngineChainStep(step, i, attempt.stepCount, false)
+				attempt.stepCount++
+				for _, line := range prepared {
+					yaml.WriteString(line + "\n")
+				}
+			}
+		}
+		for _, step := range attempt.engine.GetExecutionSteps(attempt.data, logFile) {
+			prepared, stepID := prepareEngineChainStep(step, i, attempt.stepCount, !last)
+			attempt.stepCount++
+			attempt.runIDs = append(attempt.runIDs, stepID)
+			for _, line := range prepared {
+				yaml.WriteString(line + "\n")
+			}
+		}
+
+		if !last {
+			c.generateEngineFallbackCheck(yaml, attempt, chain[i+1], i, logFile)
+		}
+	}
+
+	c.generateRecordAgenticEngine(yaml, chain)
+}
+
+// generateEngineFallbackCheck generates the step deciding whether to fall back to the next engine
+func (c *Compiler) generateEngineFallbackCheck(yaml *strings.Builder, attempt *engineAttempt, next *engineAttempt, i int, logFile string) {
+	name := attempt.engine.GetDisplayName()
+	nextName := next.engine.GetDisplayName()
+
+	fmt.Fprintf(yaml, "      - name: Check %s result\n", name)
+	fmt.Fprintf(yaml, "        id: engine_fallback_%d\n", i)
+	if condition := engineChainCondition(i); condition != "" {
+		fmt.Fprintf(yaml, "        if: %s\n", condition)
+	}
+	yaml.WriteString("        run: |\n")
+	yaml.WriteString("          if [[ \" $GITHUB_AW_RUN_OUTCOMES \" != *\" failure \"* ]]; then\n")
+	yaml.WriteString("            echo \"fallback=false\" >> $GITHUB_OUTPUT\n")
+	if check := attempt.engine.GetInfrastructureErrorCheck(logFile); check != "" {
+		fmt.Fprintf(yaml, "          elif %s; then\n", check)
+		fmt.Fprintf(yaml, "            echo \"::warning::%s failed for infrastructure reasons, falling back to %s\"\n", name, nextName)
+		yaml.WriteString("            echo \"fallback=true\" >> $GITHUB_OUTPUT\n")
+	}
+	yaml.WriteString("          else\n")
+	fmt.Fprintf(yaml, "            echo \"::error::%s failed\"\n", name)
+	yaml.WriteString("            exit 1\n")
+	yaml.WriteString("          fi\n")
+	yaml.WriteString("        env:\n")
+	fmt.Fprintf(yaml, "          GITHUB_AW_RUN_OUTCOMES: \"%s\"\n", stepOutcomes(attempt.runIDs))
+}
+
+// generateRecordWorkspaceState generates the step recording the commit and branch checked out before
+// the first engine runs, so that a fallback engine starts from the same workspace
+func (c *Compiler) generateRecordWorkspaceState(yaml *strings.Builder) {
+	yaml.WriteString("      - name: Record workspace state\n")
+	yaml.WriteString("        id: workspace_state\n")
+	yaml.WriteString("        run: |\n")
+	yaml.WriteString("          if git rev-parse --git-dir > /dev/null 2>&1; then\n")
+	yaml.WriteString("            echo \"head=$(git rev-parse HEAD)\" >> $GITHUB_OUTPUT\n")
+	yaml.WriteString("            echo \"branch=$(git symbolic-ref --quiet --short HEAD)\" >> $GITHUB_OUTPUT\n")
+	yaml.WriteString("          fi\n")
+}
+
+// generateResetForFallback generates the step that discards the safe outputs written by the failed
+// engine and restores the workspace before the fallback engine at index i reruns the prompt
+func (c *Compiler) generateResetForFallback(yaml *strings.Builder, attempt *engineAttempt, i int) {
+	fmt.Fprintf(yaml, "      - name: Reset workspace for %s\n", attempt.engine.GetDisplayName())
+	fmt.Fprintf(yaml, "        if: %s\n", engineChainCondition(i))
+	yaml.WriteString("        run: |\n")
+	if attempt.data.SafeOutputs != nil {
+		yaml.WriteString("          # Discard the safe outputs of the failed engine\n")
+		yaml.WriteString("          : > \"$GITHUB_AW_SAFE_OUTPUTS\"\n")
+	}
+	yaml.WriteString("          if [ -n \"$INITIAL_HEAD\" ]; then\n")
+	yaml.WriteString("            if [ -n \"$INITIAL_BRANCH\" ]; then\n")
+	yaml.WriteString("              git checkout --force \"$INITIAL_BRANCH\"\n")
+	yaml.WriteString("            else\n")
+	yaml.WriteString("              git checkout --force --detach \"$INITIAL_HEAD\"\n")
+	yaml.WriteString("            fi\n")
+	yaml.WriteString("            git reset --hard \"$INITIAL_HEAD\"\n")
+	yaml.WriteString("            git clean -fd\n")
+	yaml.WriteString("          fi\n")
+	yaml.WriteString("        env:\n")
+	yaml.WriteString("          INITIAL_HEAD: ${{ steps.workspace_state.outputs.head }}\n")
+	yaml.WriteString("          INITIAL_BRANCH: ${{ steps.workspace_state.outputs.branch }}\n")
+}
+
+// stepOutcomes returns a space-separated list of step outcome expressions
+func stepOutcomes(stepIDs []string) string {
+	outcomes := make([]string, len(stepIDs))
+	for i, stepID := range stepIDs {
+		outcomes[i] = fmt.Sprintf("${{ steps.%s.outcome }}", stepID)
+	}
+	return strings.Join(outcomes, " ")
+}
+
+// generateRecordAgenticEngine generates a step that records the engine that produced the output in
+// aw_info.json, so that log parsing and 'gh aw logs' use the matching parser
+func (c *Compiler) generateRecordAgenticEngine(yaml *strings.Builder, chain []*engineAttempt) {
+	type engineInfo struct {
+		ID    string `json:"id"`
+		Name  string `json:"name"`
+		Model string `json:"model"`
+	}
+	engines := make([]engineInfo, len(chain))
+	fallbacks := make([]string, len(chain)-1)
+	for i, attempt := range chain {
+		engines[i] = engineInfo{ID: attempt.engine.GetID(), Name: attempt.engine.GetDisplayName()}
+		if attempt.data.EngineConfig != nil {
+			engines[i].Model = attempt.data.EngineConfig.Model
+		}
+		if i < len(chain)-1 {
+			fallbacks[i] = fmt.Sprintf("${{ steps.engine_fallback_%d.outputs.fallback }}", i)
+		}
+	}
+	enginesJSON, _ := json.Marshal(engines)
+
+	yaml.WriteString("      - name: Record agentic engine\n")
+	yaml.WriteString("        id: agentic_engine\n")
+	yaml.WriteString("        if: always()\n")
+	yaml.WriteString("        uses: actions/github-script@v7\n")
+	yaml.WriteString("        env:\n")
+	fmt.Fprintf(yaml, "          GITHUB_AW_ENGINE_FALLBACKS: \"%s\"\n", strings.Join(fallbacks, ","))
+	yaml.WriteString("        with:\n")
+	yaml.WriteString("          script: |\n")
+	yaml.WriteString("            const fs = require('fs');\n")
+	fmt.Fprintf(yaml, "            const engines = %s;\n", enginesJSON)
+	yaml.WriteString("            const fallbacks = process.env.GITHUB_AW_ENGINE_FALLBACKS.split(',');\n")
+	yaml.WriteString("            // The engine that ran last is the first one that did not request a fallback\n")
+	yaml.WriteString("            let index = 0;\n")
+	yaml.WriteString("            while (index < fallbacks.length && fallbacks[index] === 'true') {\n")
+	yaml.WriteString("              index++;\n")
+	yaml.WriteString("            }\n")
+	yaml.WriteString("            const engine = engines[index];\n")
+	yaml.WriteString("            const tmpPath = '/tmp/aw_info.json';\n")
+	yaml.WriteString("            const awInfo = JSON.parse(fs.readFileSync(tmpPath, 'utf8'));\n")
+	yaml.WriteString("            awInfo.engine_id = engine.id;\n")
+	yaml.WriteString("            awInfo.engine_name = engine.name;\n")
+	yaml.WriteString("            awInfo.model = engine.model;\n")
+	yaml.WriteString("            awInfo.engine_attempts = engines.slice(0, index + 1).map(e => e.id);\n")
+	yaml.WriteString("            fs.writeFileSync(tmpPath, JSON.stringify(awInfo, null, 2));\n")
+	yaml.WriteString("            core.setOutput('engine', engine.id);\n")
+	yaml.WriteString("            console.log(`Agentic engine: ${engine.name}`);\n")
+}
+
+// generateEngineChainLogParsing generates a log parsing step for each engine in the chain that
+// only runs when that engine produced the agent log
+func (c *Compiler) generateEngineChainLogParsing(yaml *strings.Builder, chain []*engineAttempt, logFile string) {
+	for _, attempt := range chain {
+		condition := fmt.Sprintf("always() && steps.agentic_engine.outputs.engine == '%s'", attempt.engine.GetID())
+		c.generateLogParsingStep(yaml, attempt.engine, logFile, condition)
+	}
+}
+
+// engineChainOutputFiles returns the output files declared by any engine in the chain
+func engineChainOutputFiles(chain []*engineAttempt) []string {
+	var outputFiles []string
+	seen := make(map[string]bool)
+	for _, attempt := range chain {
+		for _, file := range attempt.engine.GetDeclaredOutputFiles() {
+			if !seen[file] {
+				seen[file] = true
+				outputFiles = append(outputFiles, file)
+			}
+		}
+	}
+	return outputFiles
+}
+
+// allEnginesSupportBashPolicy reports whether every engine enforces the bash policy natively
+func allEnginesSupportBashPolicy(engines []CodingAgentEngine) bool {
+	for _, engine := range engines {
+		if !engine.SupportsBashPolicy() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,331 @@
+package workflow
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExtractEngineFallback(t *testing.T) {
+	compiler := NewCompiler(false, "", "test")
+	_, config := compiler.extractEngineConfig(map[string]any{
+		"engine": map[string]any{
+			"id": "claude",
+			"fallback": []any{
+				"codex",
+				map[string]any{"id": "gemini", "model": "gemini-2.5-flash", "version": "0.5.0"},
+			},
+		},
+	})
+
+	if config == nil || len(config.Fallback) != 2 {
+		t.Fatalf("Expected 2 fallback engines, got %+v", config)
+	}
+	if config.Fallback[0].ID != "codex" {
+		t.Errorf("Expected first fallback 'codex', got '%s'", config.Fallback[0].ID)
+	}
+	if config.Fallback[1].ID != "gemini" || config.Fallback[1].Model != "gemini-2.5-flash" || config.Fallback[1].Version != "0.5.0" {
+		t.Errorf("Unexpected second fallback: %+v", config.Fallback[1])
+	}
+}
+
+func TestValidateEngineFallbacks(t *testing.T) {
+	compiler := NewCompiler(false, "", "test")
+	claude := NewClaudeEngine()
+
+	tests := []struct {
+		name        string
+		primary     CodingAgentEngine
+		config      *EngineConfig
+		tools       map[string]any
+		errContains string
+	}{
+		{
+			name:    "valid chain",
+			primary: claude,
+			config:  &EngineConfig{ID: "claude", Fallback: []*EngineConfig{{ID: "codex"}, {ID: "gemini"}}},
+		},
+		{
+			name:    "no fallback",
+			primary: claude,
+			config:  &EngineConfig{ID: "claude"},
+		},
+		{
+			name:        "unknown engine",
+			primary:     claude,
+			config:      &EngineConfig{ID: "claude", Fallback: []*EngineConfig{{ID: "gpt"}}},
+			errContains: "invalid fallback engine 'gpt'",
+		},
+		{
+			name:        "primary repeated",
+			primary:     claude,
+			config:      &EngineConfig{ID: "claude", Fallback: []*EngineConfig{{ID: "claude"}}},
+			errContains: "appears more than once",
+		},
+		{
+			name:        "custom fallback",
+			primary:     claude,
+			config:      &EngineConfig{ID: "claude", Fallback: []*EngineConfig{{ID: "custom"}}},
+			errContains: "custom engine cannot be used as a fallback",
+		},
+		{
+			name:        "custom primary",
+			primary:     NewCustomEngine(),
+			config:      &EngineConfig{ID: "custom", Fallback: []*EngineConfig{{ID: "claude"}}},
+			errContains: "not supported with the custom engine",
+		},
+		{
+			name:        "max-turns unsupported by fallback",
+			primary:     claude,
+			config:      &EngineConfig{ID: "claude", MaxTurns: "5", Fallback: []*EngineConfig{{ID: "codex"}}},
+			errContains: "fallback engine 'codex' does not support the max-turns feature",
+		},
+		{
+			name:    "http transport unsupported by fallback",
+			primary: claude,
+			config:  &EngineConfig{ID: "claude", Fallback: []*EngineConfig{{ID: "codex"}}},
+			tools: map[string]any{
+				"remote": map[string]any{"mcp": map[string]any{"type": "http", "url": "https://mcp.example.com"}},
+			},
+			errContains: "not supported by engine 'codex'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := compiler.validateEngineFallbacks(tt.config, tt.primary, tt.tools)
+			if tt.errContains == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("Expected error containing %q, got %v", tt.errContains, err)
+			}
+		})
+	}
+}
+
+func TestPrepareEngineChainStep(t *testing.T) {
+	step := GitHubActionStep{
+		"      - name: Capture logs",
+		"        if: always()",
+		"        run: touch /tmp/test.log",
+	}
+
+	prepared, stepID := prepareEngineChainStep(step, 1, 2, true)
+	content := strings.Join(prepared, "\n")
+	if stepID != "engine_1_step_2" {
+		t.Errorf("Expected generated step id 'engine_1_step_2', got '%s'", stepID)
+	}
+	expected := []string{
+		"        id: engine_1_step_2",
+		"        if: always() && steps.engine_fallback_0.outputs.fallback == 'true'",
+		"        continue-on-error: true",
+	}
+	for _, want := range expected {
+		if !strings.Contains(content, want) {
+			t.Errorf("Expected %q in step:\n%s", want, content)
+		}
+	}
+
+	// Existing IDs are kept and the primary engine runs unconditionally
+	step = GitHubActionStep{
+		"      - name: Execute",
+		"        id: agentic_execution",
+		"        run: agent",
+	}
+	prepared, stepID = prepareEngineChainStep(step, 0, 0, false)
+	content = strings.Join(prepared, "\n")
+	if stepID != "agentic_execution" {
+		t.Errorf("Expected existing step id 'agentic_execution', got '%s'", stepID)
+	}
+	if strings.Contains(content, "if:") || strings.Contains(content, "continue-on-error") || strings.Count(content, "id:") != 1 {
+		t.Errorf("Expected step to be unchanged:\n%s", content)
+	}
+}
+
+func TestEngineFallbackCompile(t *testing.T) {
+	tmpDir := t.TempDir()
+	workflowPath := filepath.Join(tmpDir, "test-fallback.md")
+	content := `---
+on: push
+permissions:
+  contents: read
+engine:
+  id: claude
+  fallback:
+    - codex
+    - id: gemini
+      model: gemini-2.5-flash
+tools:
+  github:
+    allowed: [get_issue]
+safe-outputs:
+  create-issue:
+---
+
+# Fallback Test
+
+Summarize the repository.`
+	if err := os.WriteFile(workflowPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	compiler := NewCompiler(false, "", "test")
+	if err := compiler.CompileWorkflow(workflowPath); err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+
+	lockContent, err := os.ReadFile(strings.TrimSuffix(workflowPath, ".md") + ".lock.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock := string(lockContent)
+
+	expected := []string{
+		// MCP configurations of every engine are rendered up front
+		"cat > /tmp/mcp-config/mcp-servers.json",
+		"cat > /tmp/mcp-config/config.toml",
+		"cat > /tmp/mcp-config/settings.json",
+		"- name: Check Claude Code result\n        id: engine_fallback_0\n",
+		"- name: Check Codex result\n        id: engine_fallback_1\n        if: steps.engine_fallback_0.outputs.fallback == 'true'\n",
+		"- name: Install Codex\n        id: engine_1_step_1\n        if: steps.engine_fallback_0.outputs.fallback == 'true'\n        run:",
+		"--model gemini-2.5-flash",
+		`elif jq -es '[flatten[] | objects | select(.type == "result" and .is_error == true)`,
+		"elif grep -qE '^(\\[[^]]*\\] )?ERROR: ",
+		"- name: Record workspace state\n        id: workspace_state\n",
+		"- name: Reset workspace for Codex\n        if: steps.engine_fallback_0.outputs.fallback == 'true'\n",
+		"- name: Reset workspace for Gemini CLI\n        if: steps.engine_fallback_1.outputs.fallback == 'true'\n",
+		": > \"$GITHUB_AW_SAFE_OUTPUTS\"",
+		"git reset --hard \"$INITIAL_HEAD\"\n            git clean -fd\n",
+		`GITHUB_AW_RUN_OUTCOMES: "${{ steps.agentic_execution.outcome }}`,
+		"- name: Record agentic engine\n        id: agentic_engine\n",
+		"if: always() && steps.agentic_engine.outputs.engine == 'codex'",
+		"if: always() && steps.agentic_engine.outputs.engine == 'gemini'",
+	}
+	for _, want := range expected {
+		if !strings.Contains(lock, want) {
+			t.Errorf("Expected %q in lock file", want)
+		}
+	}
+
+	// The last engine's failure fails the job
+	geminiRun := lock[strings.Index(lock, "- name: Run Gemini"):]
+	geminiRun = geminiRun[:strings.Index(geminiRun, "- name: Record agentic engine")]
+	if strings.Contains(geminiRun, "continue-on-error") {
+		t.Errorf("Expected the last engine to fail the job:\n%s", geminiRun)
+	}
+
+	// The workspace is reset after the failed engine's result check and before the fallback engine runs
+	reset := strings.Index(lock, "- name: Reset workspace for Codex")
+	if reset < strings.Index(lock, "- name: Check Claude Code result") || reset > strings.Index(lock, "- name: Run Codex") {
+		t.Error("Expected the workspace to be reset between the Claude Code result check and the Codex run")
+	}
+
+	// aw_info.json is uploaded after the engine that produced the output is recorded
+	if strings.Index(lock, "- name: Upload agentic run info") < strings.Index(lock, "- name: Record agentic engine") {
+		t.Error("Expected aw_info.json to be uploaded after the engine is recorded")
+	}
+}
+
+func TestEngineFallbackStepOrder(t *testing.T) {
+	tmpDir := t.TempDir()
+	workflowPath := filepath.Join(tmpDir, "test-fallback-order.md")
+	content := `---
+on: push
+permissions:
+  contents: read
+engine:
+  id: claude
+  fallback: [codex]
+network:
+  allowed: [example.com]
+---
+
+# Fallback Order Test
+
+Summarize the repository.`
+	if err := os.WriteFile(workflowPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	compiler := NewCompiler(false, "", "test")
+	if err := compiler.CompileWorkflow(workflowPath); err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	lockContent, err := os.ReadFile(strings.TrimSuffix(workflowPath, ".md") + ".lock.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock := string(lockContent)
+
+	// The fallback engine is installed after the result check that requests the fallback
+	order := []string{
+		"- name: Generate Network Permissions Hook",
+		"- name: Execute Claude Code",
+		"- name: Check Claude Code result",
+		"- name: Reset workspace for Codex",
+		"- name: Install Codex",
+		"- name: Run Codex",
+	}
+	last := -1
+	for _, name := range order {
+		index := strings.Index(lock, name)
+		if index < 0 {
+			t.Fatalf("Expected %q in lock file", name)
+		}
+		if index < last {
+			t.Errorf("Expected %q after %q", name, order[slices.Index(order, name)-1])
+		}
+		last = index
+	}
+
+	// Installation and enforcement steps of the primary engine are fatal
+	hook := lock[strings.Index(lock, "- name: Generate Network Permissions Hook"):]
+	hook = hook[:strings.Index(hook[1:], "- name:")+1]
+	if strings.Contains(hook, "continue-on-error") || strings.Contains(hook, "if:") {
+		t.Errorf("Expected the network hook of the primary engine to always run and be fatal:\n%s", hook)
+	}
+	install := lock[strings.Index(lock, "- name: Install Codex"):]
+	install = install[:strings.Index(install[1:], "- name:")+1]
+	if strings.Contains(install, "continue-on-error") {
+		t.Errorf("Expected the fallback installation to be fatal:\n%s", install)
+	}
+}
+
+func TestEngineInfrastructureErrorPatterns(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		line     string
+		expected bool
+	}{
+		{"claude overloaded", claudeInfrastructureErrorResult, `API Error: 529 {"type":"error","error":{"type":"overloaded_error"}}`, true},
+		{"claude invalid key", claudeInfrastructureErrorResult, "Invalid API key · Please run /login", true},
+		{"claude connection error", claudeInfrastructureErrorResult, "API Error: Connection error.", true},
+		{"claude invalid request", claudeInfrastructureErrorResult, "API Error: 400 prompt is too long", false},
+		{"claude task failure mentioning auth", claudeInfrastructureErrorResult, "The authentication tests fail with 401 unauthorized after the rate limit change", false},
+		{"codex unauthorized", codexInfrastructureErrorLine, "[2025-08-01T10:00:00] ERROR: unexpected status 401 Unauthorized: invalid key", true},
+		{"codex rate limit", codexInfrastructureErrorLine, "ERROR: exceeded retry limit, last status: 429 Too Many Requests", true},
+		{"codex tool output", codexInfrastructureErrorLine, "FAIL auth.test.js: ECONNREFUSED, expected status 401", false},
+		{"codex indented tool output", codexInfrastructureErrorLine, "  ERROR: status 500 from mock server", false},
+		{"gemini api error", geminiInfrastructureErrorLine, "[API Error: got status: 429 Too Many Requests.]", true},
+		{"gemini agent text", geminiInfrastructureErrorLine, "The quota check returns unauthorized for expired tokens", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if matched := regexp.MustCompile(tt.pattern).MatchString(tt.line); matched != tt.expected {
+				t.Errorf("Expected %v for %q, got %v", tt.expected, tt.line, matched)
+			}
+		})
+	}
+
+	if check := NewCustomEngine().GetInfrastructureErrorCheck("/tmp/test.log"); check != "" {
+		t.Errorf("Expected no infrastructure error check for the custom engine, got %q", check)
+	}
+}
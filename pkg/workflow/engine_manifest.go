@@ -0,0 +1,722 @@
+package workflow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// EngineManifestDir is the repository directory holding engine manifests
+const EngineManifestDir = ".github/aw-engines"
+
+// engineManifestIDPattern restricts manifest engine IDs to lowercase identifiers
+var engineManifestIDPattern = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// EngineManifest declares an agentic engine in YAML instead of Go code
+type EngineManifest struct {
+	ID           string                     `yaml:"id"`
+	DisplayName  string                     `yaml:"display-name,omitempty"`
+	Description  string                     `yaml:"description,omitempty"`
+	Experimental bool                       `yaml:"experimental,omitempty"`
+	Capabilities EngineManifestCapabilities `yaml:"capabilities,omitempty"`
+	Install      []EngineManifestStep       `yaml:"install,omitempty"` // Steps run before the MCP setup
+	Run          EngineManifestRun          `yaml:"run"`
+	MCP          *EngineManifestMCP         `yaml:"mcp,omitempty"`
+	Logs         EngineManifestLogs         `yaml:"logs,omitempty"`
+	OutputFiles  []string                   `yaml:"output-files,omitempty"` // Files uploaded as artifacts if they exist
+
+	Source string `yaml:"-"` // Path of the manifest file
+}
+
+// EngineManifestCapabilities declares the features a manifest engine supports
+type EngineManifestCapabilities struct {
+	ToolsWhitelist bool `yaml:"tools-whitelist,omitempty"`
+	HTTPTransport  bool `yaml:"http-transport,omitempty"`
+	MaxTurns       bool `yaml:"max-turns,omitempty"`
+}
+
+// EngineManifestStep is a GitHub Actions step declared in a manifest
+type EngineManifestStep struct {
+	Name string            `yaml:"name"`
+	Uses string            `yaml:"uses,omitempty"`
+	With map[string]string `yaml:"with,omitempty"`
+	Run  string            `yaml:"run,omitempty"`
+	Env  map[string]string `yaml:"env,omitempty"`
+}
+
+// EngineManifestRun declares how the engine is executed
+type EngineManifestRun struct {
+	Command string            `yaml:"command"`           // Shell command template
+	Model   string            `yaml:"model,omitempty"`   // Default model when the workflow does not set one
+	Version string            `yaml:"version,omitempty"` // Default version when the workflow does not set one
+	Env     map[string]string `yaml:"env,omitempty"`
+}
+
+// EngineManifestMCP declares how MCP servers are rendered for the engine
+type EngineManifestMCP struct {
+	Path    string `yaml:"path"`               // File the MCP configuration is written to
+	Format  string `yaml:"format"`             // "json" or "toml"
+	RootKey string `yaml:"root-key,omitempty"` // Key holding the servers (default: mcpServers for JSON, mcp_servers for TOML)
+}
+
+// EngineManifestLogs declares the log format and how metrics are extracted from it
+type EngineManifestLogs struct {
+	Format         string                `yaml:"format,omitempty"`          // "text" (default), "json" or "jsonl"
+	Parser         string                `yaml:"parser,omitempty"`          // Built-in step summary renderer, e.g. parse_codex_log
+	TokenUsage     *EngineManifestMetric `yaml:"token-usage,omitempty"`     // Token usage extraction rule
+	Cost           *EngineManifestMetric `yaml:"cost,omitempty"`            // Estimated cost extraction rule
+	ErrorPattern   string                `yaml:"error-pattern,omitempty"`   // Regex for error lines (default: lines containing "error")
+	WarningPattern string                `yaml:"warning-pattern,omitempty"` // Regex for warning lines (default: lines containing "warning")
+}
+
+// EngineManifestMetric extracts a numeric metric from the log
+type EngineManifestMetric struct {
+	Pattern   string   `yaml:"pattern,omitempty"`   // Text logs: regex whose first capture group is the value
+	Fields    []string `yaml:"fields,omitempty"`    // JSON logs: dotted paths whose values are added together
+	Aggregate string   `yaml:"aggregate,omitempty"` // "sum" (default), "max" or "last"
+}
+
+// ParseEngineManifest parses and validates an engine manifest, rejecting unknown keys
+func ParseEngineManifest(content []byte) (*EngineManifest, error) {
+	manifest := &EngineManifest{}
+	if err := yaml.UnmarshalWithOptions(content, manifest, yaml.DisallowUnknownField()); err != nil {
+		return nil, err
+	}
+	if err := manifest.validate(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// validate checks the manifest for missing or invalid fields
+func (m *EngineManifest) validate() error {
+	if m.ID == "" {
+		return fmt.Errorf("engine manifest must declare an id")
+	}
+	if !engineManifestIDPattern.MatchString(m.ID) {
+		return fmt.Errorf("invalid engine id '%s': must start with a lowercase letter and contain only lowercase letters, digits and dashes", m.ID)
+	}
+	if strings.TrimSpace(m.Run.Command) == "" {
+		return fmt.Errorf("engine '%s' must declare run.command", m.ID)
+	}
+	for i, step := range m.Install {
+		if step.Name == "" {
+			return fmt.Errorf("engine '%s' install step %d must have a name", m.ID, i+1)
+		}
+		if (step.Uses == "") == (step.Run == "") {
+			return fmt.Errorf("engine '%s' install step '%s' must declare exactly one of uses or run", m.ID, step.Name)
+		}
+	}
+	if m.MCP != nil {
+		if m.MCP.Path == "" {
+			return fmt.Errorf("engine '%s' must declare mcp.path", m.ID)
+		}
+		if m.MCP.Format != "json" && m.MCP.Format != "toml" {
+			return fmt.Errorf("engine '%s' has invalid mcp.format '%s': must be json or toml", m.ID, m.MCP.Format)
+		}
+	}
+
+	switch m.Logs.Format {
+	case "", "text", "json", "jsonl":
+	default:
+		return fmt.Errorf("engine '%s' has invalid logs.format '%s': must be text, json or jsonl", m.ID, m.Logs.Format)
+	}
+	if m.Logs.Parser != "" && GetLogParserScript(m.Logs.Parser) == "" {
+		return fmt.Errorf("engine '%s' references unknown log parser '%s'", m.ID, m.Logs.Parser)
+	}
+	for _, pattern := range []string{m.Logs.ErrorPattern, m.Logs.WarningPattern} {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("engine '%s' has invalid log pattern '%s': %w", m.ID, pattern, err)
+		}
+	}
+	for name, metric := range map[string]*EngineManifestMetric{"token-usage": m.Logs.TokenUsage, "cost": m.Logs.Cost} {
+		if metric == nil {
+			continue
+		}
+		if err := metric.validate(m.logFormat()); err != nil {
+			return fmt.Errorf("engine '%s' has invalid logs.%s: %w", m.ID, name, err)
+		}
+	}
+	return nil
+}
+
+// validate checks that the metric rule matches the log format
+func (r *EngineManifestMetric) validate(format string) error {
+	switch r.Aggregate {
+	case "", "sum", "max", "last":
+	default:
+		return fmt.Errorf("invalid aggregate '%s': must be sum, max or last", r.Aggregate)
+	}
+	if format == "text" {
+		if r.Pattern == "" {
+			return fmt.Errorf("text logs require a pattern")
+		}
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return err
+		}
+		if re.NumSubexp() < 1 {
+			return fmt.Errorf("pattern '%s' must contain a capture group", r.Pattern)
+		}
+		return nil
+	}
+	if len(r.Fields) == 0 {
+		return fmt.Errorf("%s logs require fields", format)
+	}
+	return nil
+}
+
+// logFormat returns the declared log format, defaulting to text
+func (m *EngineManifest) logFormat() string {
+	if m.Logs.Format == "" {
+		return "text"
+	}
+	return m.Logs.Format
+}
+
+// LoadEngineManifests loads every *.yml and *.yaml manifest in dir. A missing directory yields no manifests.
+func LoadEngineManifests(dir string) ([]*EngineManifest, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read engine manifest directory %s: %w", dir, err)
+	}
+
+	var manifests []*EngineManifest
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		ext := filepath.Ext(entry.Name())
+		if ext != ".yml" && ext != ".yaml" {
+			continue
+		}
+		manifestPath := filepath.Join(dir, entry.Name())
+		content, err := os.ReadFile(manifestPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read engine manifest %s: %w", manifestPath, err)
+		}
+		manifest, err := ParseEngineManifest(content)
+		if err != nil {
+			return nil, fmt.Errorf("invalid engine manifest %s: %w", manifestPath, err)
+		}
+		manifest.Source = manifestPath
+		manifests = append(manifests, manifest)
+	}
+	return manifests, nil
+}
+
+// engineManifestDirs returns the directories searched for engine manifests: the engines directory
+// of the repository at gitRoot followed by the engines directories of its locally installed packages.
+// Globally installed packages are not searched, so compilation only depends on the repository.
+func engineManifestDirs(gitRoot string) []string {
+	dirs := []string{filepath.Join(gitRoot, EngineManifestDir)}
+
+	matches, _ := filepath.Glob(filepath.Join(gitRoot, ".aw", "packages", "*", "*", "engines"))
+	sort.Strings(matches)
+	return append(dirs, matches...)
+}
+
+// findRepositoryRoot walks up from dir to the nearest directory that contains a .git entry,
+// or returns an empty string outside a repository
+func findRepositoryRoot(dir string) string {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(absDir, ".git")); err == nil {
+			return absDir
+		}
+		parent := filepath.Dir(absDir)
+		if parent == absDir {
+			return ""
+		}
+		absDir = parent
+	}
+}
+
+// RegisterManifests registers manifest engines. The first manifest registered for an ID wins,
+// and manifests cannot replace built-in engines.
+func (r *EngineRegistry) RegisterManifests(manifests []*EngineManifest) error {
+	for _, manifest := range manifests {
+		if existing, exists := r.engines[manifest.ID]; exists {
+			if _, isManifest := existing.(*ManifestEngine); isManifest {
+				continue
+			}
+			return fmt.Errorf("engine manifest %s cannot replace built-in engine '%s'", manifest.Source, manifest.ID)
+		}
+		r.Register(NewManifestEngine(manifest))
+	}
+	return nil
+}
+
+// LoadManifestEngines registers the engines declared in the manifest directories of the repository
+// at gitRoot. Each repository is loaded once. Invalid manifests are skipped and returned as an
+// error once the valid ones are registered.
+func (r *EngineRegistry) LoadManifestEngines(gitRoot string) error {
+	if r.manifestRoots[gitRoot] {
+		return nil
+	}
+	r.manifestRoots[gitRoot] = true
+
+	var errs []error
+	for _, dir := range engineManifestDirs(gitRoot) {
+		manifests, err := LoadEngineManifests(dir)
+		if err == nil {
+			err = r.RegisterManifests(manifests)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ManifestEngine is an agentic engine declared by an EngineManifest
+type ManifestEngine struct {
+	BaseEngine
+	manifest *EngineManifest
+}
+
+// NewManifestEngine creates an engine from a validated manifest
+func NewManifestEngine(manifest *EngineManifest) *ManifestEngine {
+	displayName := manifest.DisplayName
+	if displayName == "" {
+		displayName = manifest.ID
+	}
+	return &ManifestEngine{
+		BaseEngine: BaseEngine{
+			id:                     manifest.ID,
+			displayName:            displayName,
+			description:            manifest.Description,
+			experimental:           manifest.Experimental,
+			supportsToolsWhitelist: manifest.Capabilities.ToolsWhitelist,
+			supportsHTTPTransport:  manifest.Capabilities.HTTPTransport,
+			supportsMaxTurns:       manifest.Capabilities.MaxTurns,
+			supportsBashPolicy:     false, // Manifest engines rely on the bash policy shim
+		},
+		manifest: manifest,
+	}
+}
+
+// Manifest returns the manifest the engine was created from
+func (e *ManifestEngine) Manifest() *EngineManifest {
+	return e.manifest
+}
+
+// GetDeclaredOutputFiles returns the output files declared in the manifest
+func (e *ManifestEngine) GetDeclaredOutputFiles() []string {
+	return e.manifest.OutputFiles
+}
+
+// templateReplacer substitutes the {{...}} placeholders available in manifest commands
+func (e *ManifestEngine) templateReplacer(workflowData *WorkflowData, logFile string) *strings.Replacer {
+	model := e.manifest.Run.Model
+	version := e.manifest.Run.Version
+	maxTurns := ""
+	if workflowData.EngineConfig != nil {
+		if workflowData.EngineConfig.Model != "" {
+			model = workflowData.EngineConfig.Model
+		}
+		if workflowData.EngineConfig.Version != "" {
+			version = workflowData.EngineConfig.Version
+		}
+		maxTurns = workflowData.EngineConfig.MaxTurns
+	}
+	mcpConfig := ""
+	if e.manifest.MCP != nil {
+		mcpConfig = e.manifest.MCP.Path
+	}
+
+	return strings.NewReplacer(
+		"{{prompt-file}}", "/tmp/aw-prompts/prompt.txt",
+		"{{log-file}}", logFile,
+		"{{model}}", model,
+		"{{version}}", version,
+		"{{max-turns}}", maxTurns,
+		"{{mcp-config}}", mcpConfig,
+	)
+}
+
+func (e *ManifestEngine) GetInstallationSteps(workflowData *WorkflowData) []GitHubActionStep {
+	replacer := e.templateReplacer(workflowData, "")
+
+	var steps []GitHubActionStep
+	for _, step := range e.manifest.Install {
+		steps = append(steps, renderManifestStep(step, replacer, nil))
+	}
+	return steps
+}
+
+// GetExecutionSteps returns the GitHub Actions steps for executing the manifest engine
+func (e *ManifestEngine) GetExecutionSteps(workflowData *WorkflowData, logFile string) []GitHubActionStep {
+	replacer := e.templateReplacer(workflowData, logFile)
+
+	env := map[string]string{
+		"GITHUB_AW_PROMPT": "/tmp/aw-prompts/prompt.txt",
+	}
+	for key, value := range e.manifest.Run.Env {
+		env[key] = value
+	}
+
+	// Add GITHUB_AW_SAFE_OUTPUTS if output is needed
+	if workflowData.SafeOutputs != nil {
+		env["GITHUB_AW_SAFE_OUTPUTS"] = "${{ env.GITHUB_AW_SAFE_OUTPUTS }}"
+	}
+
+	if workflowData.EngineConfig != nil && workflowData.EngineConfig.MaxTurns != "" {
+		env["GITHUB_AW_MAX_TURNS"] = workflowData.EngineConfig.MaxTurns
+	}
+
+	// Add custom environment variables from engine config
+	if workflowData.EngineConfig != nil && len(workflowData.EngineConfig.Env) > 0 {
+		for key, value := range workflowData.EngineConfig.Env {
+			env[key] = value
+		}
+	}
+
+	// Run behind the bash policy shim, which custom environment variables cannot override
+	if usesBashPolicyShim(workflowData, e) {
+		env["PATH"] = bashPolicyShimPath
+	}
+
+	step := EngineManifestStep{
+		Name: "Run " + e.GetDisplayName(),
+		Run:  "set -o pipefail\nmkdir -p /tmp/aw-logs\n" + strings.TrimRight(e.manifest.Run.Command, "\n"),
+	}
+	return []GitHubActionStep{renderManifestStep(step, replacer, env)}
+}
+
+// renderManifestStep renders a manifest step, substituting placeholders in run commands and inputs.
+// Inputs are quoted so values such as versions keep their string type.
+func renderManifestStep(step EngineManifestStep, replacer *strings.Replacer, extraEnv map[string]string) GitHubActionStep {
+	var lines []string
+	lines = append(lines, fmt.Sprintf("      - name: %s", step.Name))
+
+	if step.Uses != "" {
+		lines = append(lines, fmt.Sprintf("        uses: %s", step.Uses))
+	}
+	if len(step.With) > 0 {
+		lines = append(lines, "        with:")
+		for _, key := range sortedKeys(step.With) {
+			lines = append(lines, fmt.Sprintf("          %s: %q", key, replacer.Replace(step.With[key])))
+		}
+	}
+	if step.Run != "" {
+		run := strings.TrimRight(replacer.Replace(step.Run), "\n")
+		if strings.Contains(run, "\n") {
+			lines = append(lines, "        run: |")
+			for _, line := range strings.Split(run, "\n") {
+				lines = append(lines, "          "+line)
+			}
+		} else {
+			lines = append(lines, fmt.Sprintf("        run: %s", run))
+		}
+	}
+
+	env := make(map[string]string)
+	for key, value := range step.Env {
+		env[key] = value
+	}
+	for key, value := range extraEnv {
+		env[key] = value
+	}
+	if len(env) > 0 {
+		lines = append(lines, "        env:")
+		for _, key := range sortedKeys(env) {
+			lines = append(lines, fmt.Sprintf("          %s: %s", key, env[key]))
+		}
+	}
+
+	return GitHubActionStep(lines)
+}
+
+// sortedKeys returns the keys of a string map in sorted order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (e *ManifestEngine) RenderMCPConfig(yaml *strings.Builder, tools map[string]any, mcpTools []string) {
+	mcp := e.manifest.MCP
+	if mcp == nil {
+		return
+	}
+
+	fmt.Fprintf(yaml, "          mkdir -p %s\n", filepath.Dir(mcp.Path))
+	if mcp.Format == "toml" {
+		e.renderTOMLMCPConfig(yaml, tools, mcpTools)
+	} else {
+		e.renderJSONMCPConfig(yaml, tools, mcpTools)
+	}
+}
+
+// renderJSONMCPConfig writes the MCP servers as a JSON object under the root key
+func (e *ManifestEngine) renderJSONMCPConfig(yaml *strings.Builder, tools map[string]any, mcpTools []string) {
+	rootKey := e.manifest.MCP.RootKey
+	if rootKey == "" {
+		rootKey = "mcpServers"
+	}
+
+	servers := make(map[string]any)
+	for _, toolName := range mcpTools {
+		switch toolName {
+		case "github":
+			servers["github"] = map[string]any{
+				"command": "docker",
+				"args": []string{
+					"run",
+					"-i",
+					"--rm",
+					"-e",
+					"GITHUB_PERSONAL_ACCESS_TOKEN",
+					"ghcr.io/github/github-mcp-server:" + getGitHubDockerImageVersion(tools["github"]),
+				},
+				"env": map[string]string{
+					"GITHUB_PERSONAL_ACCESS_TOKEN": "${{ secrets.GITHUB_TOKEN }}",
+				},
+			}
+		default:
+			// Handle custom MCP tools (those with MCP-compatible type)
+			if toolConfig, ok := tools[toolName].(map[string]any); ok {
+				if hasMcp, _ := hasMCPConfig(toolConfig); hasMcp {
+					mcpConfig, err := getMCPConfig(toolConfig, toolName)
+					if err != nil {
+						fmt.Printf("Error generating custom MCP configuration for %s: %v\n", toolName, err)
+						continue
+					}
+					server := make(map[string]any)
+					for _, property := range []string{"command", "args", "env", "url", "headers"} {
+						if value, exists := mcpConfig[property]; exists {
+							server[property] = value
+						}
+					}
+					servers[toolName] = server
+				}
+			}
+		}
+	}
+
+	configJSON, _ := json.MarshalIndent(map[string]any{rootKey: servers}, "", "  ")
+
+	fmt.Fprintf(yaml, "          cat > %s << 'EOF'\n", e.manifest.MCP.Path)
+	for _, line := range strings.Split(string(configJSON), "\n") {
+		yaml.WriteString("          " + line + "\n")
+	}
+	yaml.WriteString("          EOF\n")
+}
+
+// renderTOMLMCPConfig writes the MCP servers as TOML tables under the root key
+func (e *ManifestEngine) renderTOMLMCPConfig(yaml *strings.Builder, tools map[string]any, mcpTools []string) {
+	rootKey := e.manifest.MCP.RootKey
+	if rootKey == "" {
+		rootKey = "mcp_servers"
+	}
+
+	fmt.Fprintf(yaml, "          cat > %s << EOF\n", e.manifest.MCP.Path)
+	for _, toolName := range mcpTools {
+		switch toolName {
+		case "github":
+			yaml.WriteString("          \n")
+			fmt.Fprintf(yaml, "          [%s.github]\n", rootKey)
+			yaml.WriteString("          command = \"docker\"\n")
+			yaml.WriteString("          args = [\"run\", \"-i\", \"--rm\", \"-e\", \"GITHUB_PERSONAL_ACCESS_TOKEN\", \"ghcr.io/github/github-mcp-server:" + getGitHubDockerImageVersion(tools["github"]) + "\"]\n")
+			yaml.WriteString("          env = { \"GITHUB_PERSONAL_ACCESS_TOKEN\" = \"${{ secrets.GITHUB_TOKEN }}\" }\n")
+		default:
+			// Handle custom MCP tools (those with MCP-compatible type)
+			if toolConfig, ok := tools[toolName].(map[string]any); ok {
+				if hasMcp, _ := hasMCPConfig(toolConfig); hasMcp {
+					yaml.WriteString("          \n")
+					fmt.Fprintf(yaml, "          [%s.%s]\n", rootKey, toolName)
+					renderer := MCPConfigRenderer{
+						IndentLevel: "          ",
+						Format:      "toml",
+					}
+					if err := renderSharedMCPConfig(yaml, toolName, toolConfig, renderer); err != nil {
+						fmt.Printf("Error generating custom MCP configuration for %s: %v\n", toolName, err)
+					}
+				}
+			}
+		}
+	}
+	yaml.WriteString("          EOF\n")
+}
+
+// ParseLogMetrics extracts metrics from the log using the rules declared in the manifest
+func (e *ManifestEngine) ParseLogMetrics(logContent string, verbose bool) LogMetrics {
+	var metrics LogMetrics
+	logs := e.manifest.Logs
+	format := e.manifest.logFormat()
+
+	// Collect metric samples in log order
+	var tokenSamples, costSamples []float64
+	if format == "json" {
+		var document any
+		if err := json.Unmarshal([]byte(logContent), &document); err != nil {
+			if verbose {
+				fmt.Printf("Failed to parse %s log as JSON: %v\n", e.GetID(), err)
+			}
+		} else {
+			entries, isArray := document.([]any)
+			if !isArray {
+				entries = []any{document}
+			}
+			for _, entry := range entries {
+				tokenSamples = appendJSONSample(tokenSamples, logs.TokenUsage, entry)
+				costSamples = appendJSONSample(costSamples, logs.Cost, entry)
+			}
+		}
+	}
+
+	errorPattern := compileOptionalPattern(logs.ErrorPattern)
+	warningPattern := compileOptionalPattern(logs.WarningPattern)
+
+	for _, line := range strings.Split(logContent, "\n") {
+		// Skip empty lines
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		switch format {
+		case "text":
+			tokenSamples = appendTextSample(tokenSamples, logs.TokenUsage, line)
+			costSamples = appendTextSample(costSamples, logs.Cost, line)
+		case "jsonl":
+			var entry any
+			if err := json.Unmarshal([]byte(strings.TrimSpace(line)), &entry); err == nil {
+				tokenSamples = appendJSONSample(tokenSamples, logs.TokenUsage, entry)
+				costSamples = appendJSONSample(costSamples, logs.Cost, entry)
+				continue
+			}
+		}
+
+		// Count errors and warnings
+		if errorPattern != nil {
+			if errorPattern.MatchString(line) {
+				metrics.ErrorCount++
+			}
+		} else if strings.Contains(strings.ToLower(line), "error") {
+			metrics.ErrorCount++
+		}
+		if warningPattern != nil {
+			if warningPattern.MatchString(line) {
+				metrics.WarningCount++
+			}
+		} else if strings.Contains(strings.ToLower(line), "warning") {
+			metrics.WarningCount++
+		}
+	}
+
+	if logs.TokenUsage != nil {
+		metrics.TokenUsage = int(aggregateSamples(tokenSamples, logs.TokenUsage.Aggregate))
+	}
+	if logs.Cost != nil {
+		metrics.EstimatedCost = aggregateSamples(costSamples, logs.Cost.Aggregate)
+	}
+
+	return metrics
+}
+
+// compileOptionalPattern compiles a validated pattern, returning nil when it is empty
+func compileOptionalPattern(pattern string) *regexp.Regexp {
+	if pattern == "" {
+		return nil
+	}
+	return regexp.MustCompile(pattern)
+}
+
+// appendTextSample appends the value captured by the rule's pattern from a log line
+func appendTextSample(samples []float64, rule *EngineManifestMetric, line string) []float64 {
+	if rule == nil {
+		return samples
+	}
+	match := compileOptionalPattern(rule.Pattern).FindStringSubmatch(line)
+	if len(match) < 2 {
+		return samples
+	}
+	value, err := strconv.ParseFloat(strings.ReplaceAll(match[1], ",", ""), 64)
+	if err != nil {
+		return samples
+	}
+	return append(samples, value)
+}
+
+// appendJSONSample appends the sum of the rule's fields in a JSON log entry
+func appendJSONSample(samples []float64, rule *EngineManifestMetric, entry any) []float64 {
+	if rule == nil {
+		return samples
+	}
+	found := false
+	total := 0.0
+	for _, field := range rule.Fields {
+		if value, ok := lookupJSONPath(entry, field); ok {
+			total += ConvertToFloat(value)
+			found = true
+		}
+	}
+	if !found {
+		return samples
+	}
+	return append(samples, total)
+}
+
+// lookupJSONPath resolves a dotted path such as usage.input_tokens in a decoded JSON value
+func lookupJSONPath(value any, path string) (any, bool) {
+	current := value
+	for _, key := range strings.Split(path, ".") {
+		object, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		current, ok = object[key]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// aggregateSamples combines metric samples using sum (default), max or last
+func aggregateSamples(samples []float64, aggregate string) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	switch aggregate {
+	case "max":
+		result := samples[0]
+		for _, sample := range samples[1:] {
+			if sample > result {
+				result = sample
+			}
+		}
+		return result
+	case "last":
+		return samples[len(samples)-1]
+	default:
+		total := 0.0
+		for _, sample := range samples {
+			total += sample
+		}
+		return total
+	}
+}
+
+// GetLogParserScript returns the built-in step summary renderer declared in the manifest
+func (e *ManifestEngine) GetLogParserScript() string {
+	return e.manifest.Logs.Parser
+}
@@ -0,0 +1,521 @@
+package workflow
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testAiderManifest = `id: aider
+display-name: Aider
+description: Uses Aider as the coding agent
+experimental: true
+capabilities:
+  tools-whitelist: true
+  max-turns: false
+install:
+  - name: Setup Python
+    uses: actions/setup-python@v5
+    with:
+      python-version: "3.12"
+  - name: Install Aider
+    run: pip install aider-chat{{version}}
+run:
+  command: |
+    aider --model {{model}} --yes --message-file {{prompt-file}} 2>&1 | tee {{log-file}}
+  model: gpt-4o
+  env:
+    OPENAI_API_KEY: ${{ secrets.OPENAI_API_KEY }}
+mcp:
+  path: /tmp/mcp-config/aider.json
+  format: json
+logs:
+  format: text
+  parser: parse_codex_log
+  token-usage:
+    pattern: 'Tokens: ([\d,]+) sent'
+  cost:
+    pattern: '\$([\d.]+) session'
+    aggregate: last
+output-files:
+  - .aider.chat.history.md
+`
+
+func TestParseEngineManifest(t *testing.T) {
+	manifest, err := ParseEngineManifest([]byte(testAiderManifest))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if manifest.ID != "aider" || manifest.DisplayName != "Aider" || !manifest.Experimental {
+		t.Errorf("Unexpected manifest metadata: %+v", manifest)
+	}
+	if len(manifest.Install) != 2 || manifest.Install[0].With["python-version"] != "3.12" {
+		t.Errorf("Unexpected install steps: %+v", manifest.Install)
+	}
+	if manifest.MCP == nil || manifest.MCP.Format != "json" {
+		t.Errorf("Unexpected MCP config: %+v", manifest.MCP)
+	}
+
+	tests := []struct {
+		name        string
+		content     string
+		errContains string
+	}{
+		{
+			name:        "missing id",
+			content:     "run:\n  command: agent\n",
+			errContains: "must declare an id",
+		},
+		{
+			name:        "invalid id",
+			content:     "id: My_Engine\nrun:\n  command: agent\n",
+			errContains: "invalid engine id 'My_Engine'",
+		},
+		{
+			name:        "missing run command",
+			content:     "id: agent\n",
+			errContains: "must declare run.command",
+		},
+		{
+			name:        "unknown field",
+			content:     "id: agent\nrun:\n  command: agent\nunknown: true\n",
+			errContains: "unknown",
+		},
+		{
+			name:        "install step with uses and run",
+			content:     "id: agent\ninstall:\n  - name: Both\n    uses: actions/checkout@v5\n    run: echo hi\nrun:\n  command: agent\n",
+			errContains: "exactly one of uses or run",
+		},
+		{
+			name:        "invalid mcp format",
+			content:     "id: agent\nrun:\n  command: agent\nmcp:\n  path: /tmp/mcp.yml\n  format: yaml\n",
+			errContains: "invalid mcp.format 'yaml'",
+		},
+		{
+			name:        "unknown log parser",
+			content:     "id: agent\nrun:\n  command: agent\nlogs:\n  parser: parse_agent_log\n",
+			errContains: "unknown log parser 'parse_agent_log'",
+		},
+		{
+			name:        "text metric without capture group",
+			content:     "id: agent\nrun:\n  command: agent\nlogs:\n  token-usage:\n    pattern: 'tokens'\n",
+			errContains: "must contain a capture group",
+		},
+		{
+			name:        "json metric without fields",
+			content:     "id: agent\nrun:\n  command: agent\nlogs:\n  format: jsonl\n  token-usage:\n    pattern: '(\\d+)'\n",
+			errContains: "jsonl logs require fields",
+		},
+		{
+			name:        "invalid aggregate",
+			content:     "id: agent\nrun:\n  command: agent\nlogs:\n  cost:\n    pattern: '(\\d+)'\n    aggregate: avg\n",
+			errContains: "invalid aggregate 'avg'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseEngineManifest([]byte(tt.content))
+			if err == nil {
+				t.Fatalf("Expected error containing %q, got none", tt.errContains)
+			}
+			if !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("Expected error containing %q, got %v", tt.errContains, err)
+			}
+		})
+	}
+}
+
+func TestManifestEngineSteps(t *testing.T) {
+	manifest, err := ParseEngineManifest([]byte(testAiderManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := NewManifestEngine(manifest)
+
+	if engine.GetID() != "aider" || engine.GetDisplayName() != "Aider" {
+		t.Errorf("Unexpected engine identity: %s / %s", engine.GetID(), engine.GetDisplayName())
+	}
+	if !engine.SupportsToolsWhitelist() || engine.SupportsMaxTurns() || engine.SupportsHTTPTransport() {
+		t.Error("Expected capabilities to follow the manifest")
+	}
+	if engine.GetLogParserScript() != "parse_codex_log" {
+		t.Errorf("Expected log parser 'parse_codex_log', got '%s'", engine.GetLogParserScript())
+	}
+	if files := engine.GetDeclaredOutputFiles(); len(files) != 1 || files[0] != ".aider.chat.history.md" {
+		t.Errorf("Unexpected output files: %v", files)
+	}
+
+	workflowData := &WorkflowData{
+		SafeOutputs: &SafeOutputsConfig{},
+		EngineConfig: &EngineConfig{
+			ID:      "aider",
+			Version: "==0.86.0",
+			Env:     map[string]string{"CUSTOM_VAR": "value"},
+		},
+	}
+
+	installSteps := engine.GetInstallationSteps(workflowData)
+	if len(installSteps) != 2 {
+		t.Fatalf("Expected 2 installation steps, got %d", len(installSteps))
+	}
+	setupContent := strings.Join(installSteps[0], "\n")
+	if !strings.Contains(setupContent, "uses: actions/setup-python@v5") || !strings.Contains(setupContent, `python-version: "3.12"`) {
+		t.Errorf("Unexpected setup step:\n%s", setupContent)
+	}
+	if installContent := strings.Join(installSteps[1], "\n"); !strings.Contains(installContent, "run: pip install aider-chat==0.86.0") {
+		t.Errorf("Unexpected install step:\n%s", installContent)
+	}
+
+	execSteps := engine.GetExecutionSteps(workflowData, "/tmp/test.log")
+	if len(execSteps) != 1 {
+		t.Fatalf("Expected 1 execution step, got %d", len(execSteps))
+	}
+	stepContent := strings.Join(execSteps[0], "\n")
+	expected := []string{
+		"- name: Run Aider",
+		"run: |",
+		"set -o pipefail",
+		"aider --model gpt-4o --yes --message-file /tmp/aw-prompts/prompt.txt 2>&1 | tee /tmp/test.log",
+		"CUSTOM_VAR: value",
+		"GITHUB_AW_PROMPT: /tmp/aw-prompts/prompt.txt",
+		"GITHUB_AW_SAFE_OUTPUTS: ${{ env.GITHUB_AW_SAFE_OUTPUTS }}",
+		"OPENAI_API_KEY: ${{ secrets.OPENAI_API_KEY }}",
+	}
+	for _, want := range expected {
+		if !strings.Contains(stepContent, want) {
+			t.Errorf("Expected %q in step content:\n%s", want, stepContent)
+		}
+	}
+	if strings.Index(stepContent, "CUSTOM_VAR") > strings.Index(stepContent, "OPENAI_API_KEY") {
+		t.Errorf("Expected environment variables to be sorted:\n%s", stepContent)
+	}
+}
+
+func TestManifestEngineRenderMCPConfig(t *testing.T) {
+	tools := map[string]any{
+		"github": map[string]any{},
+		"remote": map[string]any{
+			"mcp": map[string]any{
+				"type": "http",
+				"url":  "https://mcp.example.com/mcp",
+			},
+		},
+	}
+
+	t.Run("json", func(t *testing.T) {
+		engine := NewManifestEngine(&EngineManifest{
+			ID:  "agent",
+			Run: EngineManifestRun{Command: "agent"},
+			MCP: &EngineManifestMCP{Path: "/tmp/agent/mcp.json", Format: "json", RootKey: "servers"},
+		})
+		var yaml strings.Builder
+		engine.RenderMCPConfig(&yaml, tools, []string{"github", "remote"})
+		output := yaml.String()
+
+		expected := []string{
+			"mkdir -p /tmp/agent",
+			"cat > /tmp/agent/mcp.json << 'EOF'",
+			`"servers": {`,
+			`"ghcr.io/github/github-mcp-server:sha-09deac4"`,
+			`"url": "https://mcp.example.com/mcp"`,
+			"          EOF",
+		}
+		for _, want := range expected {
+			if !strings.Contains(output, want) {
+				t.Errorf("Expected %q in MCP config:\n%s", want, output)
+			}
+		}
+	})
+
+	t.Run("toml", func(t *testing.T) {
+		engine := NewManifestEngine(&EngineManifest{
+			ID:  "agent",
+			Run: EngineManifestRun{Command: "agent"},
+			MCP: &EngineManifestMCP{Path: "/tmp/mcp-config/agent.toml", Format: "toml"},
+		})
+		var yaml strings.Builder
+		engine.RenderMCPConfig(&yaml, tools, []string{"github"})
+		output := yaml.String()
+
+		expected := []string{
+			"cat > /tmp/mcp-config/agent.toml << EOF",
+			"[mcp_servers.github]",
+			`command = "docker"`,
+		}
+		for _, want := range expected {
+			if !strings.Contains(output, want) {
+				t.Errorf("Expected %q in MCP config:\n%s", want, output)
+			}
+		}
+	})
+
+	t.Run("no mcp section", func(t *testing.T) {
+		engine := NewManifestEngine(&EngineManifest{ID: "agent", Run: EngineManifestRun{Command: "agent"}})
+		var yaml strings.Builder
+		engine.RenderMCPConfig(&yaml, tools, []string{"github"})
+		if yaml.Len() != 0 {
+			t.Errorf("Expected no MCP config, got:\n%s", yaml.String())
+		}
+	})
+}
+
+func TestManifestEngineParseLogMetrics(t *testing.T) {
+	t.Run("text", func(t *testing.T) {
+		manifest, err := ParseEngineManifest([]byte(testAiderManifest))
+		if err != nil {
+			t.Fatal(err)
+		}
+		logContent := `Aider v0.86.0
+Tokens: 1,200 sent, 300 received. Cost: $0.01 message, $0.01 session.
+Warning: file is large
+Tokens: 800 sent, 100 received. Cost: $0.02 message, $0.03 session.
+Error: command failed`
+
+		metrics := NewManifestEngine(manifest).ParseLogMetrics(logContent, false)
+		if metrics.TokenUsage != 2000 {
+			t.Errorf("Expected token usage 2000, got %d", metrics.TokenUsage)
+		}
+		if metrics.EstimatedCost != 0.03 {
+			t.Errorf("Expected cost 0.03, got %f", metrics.EstimatedCost)
+		}
+		if metrics.ErrorCount != 1 || metrics.WarningCount != 1 {
+			t.Errorf("Expected 1 error and 1 warning, got %d and %d", metrics.ErrorCount, metrics.WarningCount)
+		}
+	})
+
+	t.Run("jsonl", func(t *testing.T) {
+		manifest, err := ParseEngineManifest([]byte(`id: agent
+run:
+  command: agent
+logs:
+  format: jsonl
+  token-usage:
+    fields: [usage.input_tokens, usage.output_tokens]
+  cost:
+    fields: [total_cost_usd]
+    aggregate: max
+  error-pattern: '^FATAL'
+`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		logContent := `{"type":"turn","usage":{"input_tokens":100,"output_tokens":20},"total_cost_usd":0.1}
+{"type":"turn","usage":{"input_tokens":50,"output_tokens":5},"total_cost_usd":0.2,"message":"error in tool"}
+FATAL: agent crashed
+plain error line`
+
+		metrics := NewManifestEngine(manifest).ParseLogMetrics(logContent, false)
+		if metrics.TokenUsage != 175 {
+			t.Errorf("Expected token usage 175, got %d", metrics.TokenUsage)
+		}
+		if metrics.EstimatedCost != 0.2 {
+			t.Errorf("Expected cost 0.2, got %f", metrics.EstimatedCost)
+		}
+		if metrics.ErrorCount != 1 {
+			t.Errorf("Expected 1 error from the error pattern, got %d", metrics.ErrorCount)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		manifest, err := ParseEngineManifest([]byte(`id: agent
+run:
+  command: agent
+logs:
+  format: json
+  token-usage:
+    fields: [stats.tokens]
+`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		metrics := NewManifestEngine(manifest).ParseLogMetrics(`[{"stats":{"tokens":10}},{"stats":{"tokens":32}}]`, false)
+		if metrics.TokenUsage != 42 {
+			t.Errorf("Expected token usage 42, got %d", metrics.TokenUsage)
+		}
+	})
+}
+
+func TestLoadEngineManifests(t *testing.T) {
+	dir := t.TempDir()
+
+	manifests, err := LoadEngineManifests(filepath.Join(dir, "missing"))
+	if err != nil || len(manifests) != 0 {
+		t.Fatalf("Expected no manifests for missing directory, got %v (%v)", manifests, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "aider.yml"), []byte(testAiderManifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.md"), []byte("# Notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	manifests, err = LoadEngineManifests(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(manifests) != 1 || manifests[0].Source != filepath.Join(dir, "aider.yml") {
+		t.Fatalf("Expected aider manifest, got %v", manifests)
+	}
+
+	registry := NewEngineRegistry()
+	if err := registry.RegisterManifests(manifests); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	engine, err := registry.GetEngine("aider")
+	if err != nil {
+		t.Fatalf("Expected aider engine to be registered: %v", err)
+	}
+	if _, ok := engine.(*ManifestEngine); !ok {
+		t.Errorf("Expected a manifest engine, got %T", engine)
+	}
+
+	// Manifests cannot replace built-in engines
+	builtin := &EngineManifest{ID: "claude", Run: EngineManifestRun{Command: "claude"}, Source: "claude.yml"}
+	if err := registry.RegisterManifests([]*EngineManifest{builtin}); err == nil || !strings.Contains(err.Error(), "cannot replace built-in engine 'claude'") {
+		t.Errorf("Expected built-in collision error, got %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "broken.yaml"), []byte("id: broken\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadEngineManifests(dir); err == nil || !strings.Contains(err.Error(), "broken.yaml") {
+		t.Errorf("Expected error naming the invalid manifest, got %v", err)
+	}
+}
+
+func TestManifestEngineCompile(t *testing.T) {
+	manifest, err := ParseEngineManifest([]byte(testAiderManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpDir := t.TempDir()
+	workflowPath := filepath.Join(tmpDir, "test-aider.md")
+	content := `---
+on: push
+permissions:
+  contents: read
+engine: aider
+tools:
+  github:
+    allowed: [get_issue]
+---
+
+# Aider Test
+
+Summarize the repository.`
+	if err := os.WriteFile(workflowPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	compiler := NewCompiler(false, "", "test")
+	compiler.engineRegistry = NewEngineRegistry()
+	if err := compiler.engineRegistry.RegisterManifests([]*EngineManifest{manifest}); err != nil {
+		t.Fatal(err)
+	}
+	if err := compiler.CompileWorkflow(workflowPath); err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+
+	lockContent, err := os.ReadFile(strings.TrimSuffix(workflowPath, ".md") + ".lock.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock := string(lockContent)
+
+	expected := []string{
+		"- name: Install Aider",
+		"cat > /tmp/mcp-config/aider.json << 'EOF'",
+		"- name: Run Aider",
+		`engine_id: "aider"`,
+		".aider.chat.history.md",
+	}
+	for _, want := range expected {
+		if !strings.Contains(lock, want) {
+			t.Errorf("Expected %q in lock file", want)
+		}
+	}
+}
+
+func TestManifestEnginesLoadedFromWorkflowRepository(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	gitRoot := t.TempDir()
+	workflowsDir := filepath.Join(gitRoot, ".github", "workflows")
+	for _, dir := range []string{filepath.Join(gitRoot, ".git"), filepath.Join(gitRoot, EngineManifestDir), workflowsDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(gitRoot, EngineManifestDir, "aider.yml"), []byte(testAiderManifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+	workflowPath := filepath.Join(workflowsDir, "test-aider.md")
+	content := `---
+on: push
+engine: aider
+---
+
+# Aider Test
+
+Summarize the repository.`
+	if err := os.WriteFile(workflowPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Creating a registry does not read manifests
+	if NewEngineRegistry().IsValidEngine("aider") {
+		t.Fatal("Expected a new registry to only hold built-in engines")
+	}
+
+	compiler := NewCompiler(false, "", "test")
+	if err := compiler.CompileWorkflow(workflowPath); err != nil {
+		t.Fatalf("Expected the manifest engine of the workflow repository to be loaded: %v", err)
+	}
+	if !compiler.engineRegistry.IsValidEngine("aider") {
+		t.Error("Expected aider to be registered from the repository manifests")
+	}
+
+	// A repository is loaded once, so a broken manifest added later is not read
+	if err := os.WriteFile(filepath.Join(gitRoot, EngineManifestDir, "broken.yaml"), []byte("id: broken\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := compiler.engineRegistry.LoadManifestEngines(gitRoot); err != nil {
+		t.Errorf("Expected the repository to be loaded once, got %v", err)
+	}
+	if err := NewEngineRegistry().LoadManifestEngines(gitRoot); err == nil || !strings.Contains(err.Error(), "broken.yaml") {
+		t.Errorf("Expected error naming the invalid manifest, got %v", err)
+	}
+}
+
+func TestEngineManifestDirsSkipGlobalPackages(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	gitRoot := t.TempDir()
+	localEngines := filepath.Join(gitRoot, ".aw", "packages", "org", "local", "engines")
+	globalEngines := filepath.Join(homeDir, ".aw", "packages", "org", "global", "engines")
+	for _, dir := range []string{localEngines, globalEngines} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(globalEngines, "aider.yml"), []byte(testAiderManifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dirs := engineManifestDirs(gitRoot)
+	expected := []string{filepath.Join(gitRoot, EngineManifestDir), localEngines}
+	if strings.Join(dirs, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected manifest directories %v, got %v", expected, dirs)
+	}
+
+	registry := NewEngineRegistry()
+	if err := registry.LoadManifestEngines(gitRoot); err != nil {
+		t.Fatal(err)
+	}
+	if registry.IsValidEngine("aider") {
+		t.Error("Expected manifests of globally installed packages to be ignored")
+	}
+}
@@ -0,0 +1,314 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// compareJobName is the job that compares the outputs of an engine matrix
+const compareJobName = "compare"
+
+// EngineMatrixLeg is one engine configuration in an engine comparison matrix
+type EngineMatrixLeg struct {
+	Name    string // Leg name used in the matrix and artifact names (defaults to the engine ID)
+	ID      string
+	Model   string
+	Version string
+}
+
+// Key returns the name identifying the leg in the matrix
+func (l *EngineMatrixLeg) Key() string {
+	if l.Name != "" {
+		return l.Name
+	}
+	return l.ID
+}
+
+// extractEngineMatrix parses the engine 'matrix' field, a list of engine IDs or {id, model, version, name} objects
+func extractEngineMatrix(matrix any) []*EngineMatrixLeg {
+	matrixArray, ok := matrix.([]any)
+	if !ok {
+		return nil
+	}
+
+	var legs []*EngineMatrixLeg
+	for _, item := range matrixArray {
+		switch value := item.(type) {
+		case string:
+			legs = append(legs, &EngineMatrixLeg{ID: value})
+		case map[string]any:
+			leg := &EngineMatrixLeg{}
+			if id, ok := value["id"].(string); ok {
+				leg.ID = id
+			}
+			if model, ok := value["model"].(string); ok {
+				leg.Model = model
+			}
+			if version, ok := value["version"].(string); ok {
+				leg.Version = version
+			}
+			if name, ok := value["name"].(string); ok {
+				leg.Name = name
+			}
+			legs = append(legs, leg)
+		}
+	}
+	return legs
+}
+
+// isEngineMatrix reports whether the workflow runs the main job once per engine in a matrix
+func isEngineMatrix(data *WorkflowData) bool {
+	return data != nil && data.EngineConfig != nil && len(data.EngineConfig.Matrix) > 0
+}
+
+// artifactName returns the name of an artifact uploaded by the main job. Matrix legs add their
+// leg name so that every leg uploads distinct artifacts.
+func artifactName(data *WorkflowData, name string) string {
+	if isEngineMatrix(data) {
+		return name + "-${{ matrix.engine }}"
+	}
+	return name
+}
+
+// validateEngineMatrix validates the engines of a comparison matrix and the designated winner
+func (c *Compiler) validateEngineMatrix(engineConfig *EngineConfig, tools map[string]any) error {
+	if engineConfig == nil || len(engineConfig.Matrix) == 0 {
+		if engineConfig != nil && engineConfig.Winner != "" {
+			return fmt.Errorf("engine winner requires an engine matrix")
+		}
+		return nil
+	}
+
+	if !engineConfig.Compare {
+		return fmt.Errorf("engine matrix requires 'compare: true'")
+	}
+	if len(engineConfig.Fallback) > 0 {
+		return fmt.Errorf("engine matrix cannot be combined with engine fallback")
+	}
+	if len(engineConfig.Matrix) < 2 {
+		return fmt.Errorf("engine matrix must contain at least two engines")
+	}
+
+	seen := make(map[string]bool)
+	for _, leg := range engineConfig.Matrix {
+		if leg.ID == "" {
+			return fmt.Errorf("engine matrix entries must specify an engine id")
+		}
+		if err := c.validateEngine(leg.ID); err != nil {
+			return fmt.Errorf("invalid matrix engine '%s': %w", leg.ID, err)
+		}
+		engine, err := c.getAgenticEngine(leg.ID)
+		if err != nil {
+			return fmt.Errorf("invalid matrix engine '%s': %w", leg.ID, err)
+		}
+		if engine.GetID() == "custom" {
+			return fmt.Errorf("the custom engine cannot be used in an engine matrix")
+		}
+		if !engineManifestIDPattern.MatchString(leg.Key()) {
+			return fmt.Errorf("invalid engine matrix name '%s': must start with a lowercase letter and contain only lowercase letters, digits and dashes", leg.Key())
+		}
+		if seen[leg.Key()] {
+			return fmt.Errorf("engine matrix name '%s' is used more than once, set 'name' to tell the entries apart", leg.Key())
+		}
+		seen[leg.Key()] = true
+
+		if err := c.validateHTTPTransportSupport(tools, engine); err != nil {
+			return err
+		}
+		if engineConfig.MaxTurns != "" && !engine.SupportsMaxTurns() {
+			return fmt.Errorf("matrix engine '%s' does not support the max-turns feature", engine.GetID())
+		}
+	}
+
+	if engineConfig.Winner != "" && !seen[engineConfig.Winner] {
+		return fmt.Errorf("engine winner '%s' is not in the engine matrix", engineConfig.Winner)
+	}
+	return nil
+}
+
+// getEngineMatrixLegs returns an engine attempt for each matrix leg. Legs inherit max-turns and env
+// from the engine configuration.
+func (c *Compiler) getEngineMatrixLegs(data *WorkflowData) []*engineAttempt {
+	if !isEngineMatrix(data) {
+		return nil
+	}
+
+	var legs []*engineAttempt
+	for _, leg := range data.EngineConfig.Matrix {
+		engine, err := c.getAgenticEngine(leg.ID)
+		if err != nil {
+			continue
+		}
+		legData := *data
+		legData.EngineConfig = &EngineConfig{
+			ID:       engine.GetID(),
+			Model:    leg.Model,
+			Version:  leg.Version,
+			MaxTurns: data.EngineConfig.MaxTurns,
+			Env:      data.EngineConfig.Env,
+			Matrix:   data.EngineConfig.Matrix,
+		}
+		legs = append(legs, &engineAttempt{engine: engine, data: &legData, matrixKey: leg.Key()})
+	}
+	return legs
+}
+
+// engineMatrixStrategy returns the strategy section running the main job once per matrix leg
+func engineMatrixStrategy(data *WorkflowData) string {
+	var strategy strings.Builder
+	strategy.WriteString("strategy:\n")
+	strategy.WriteString("      fail-fast: false\n")
+	strategy.WriteString("      matrix:\n")
+	strategy.WriteString("        engine:\n")
+	for i, leg := range data.EngineConfig.Matrix {
+		fmt.Fprintf(&strategy, "          - %s", leg.Key())
+		if i < len(data.EngineConfig.Matrix)-1 {
+			strategy.WriteString("\n")
+		}
+	}
+	return strategy.String()
+}
+
+// engineMatrixCondition returns the condition selecting the steps of a matrix leg
+func engineMatrixCondition(key string) string {
+	return fmt.Sprintf("matrix.engine == '%s'", key)
+}
+
+// withStepCondition adds a condition to a step, combining it with any condition the step already has
+func withStepCondition(step GitHubActionStep, condition string) GitHubActionStep {
+	result := make([]string, 0, len(step)+1)
+	hasCondition := false
+	for j, line := range step {
+		if j > 0 && strings.HasPrefix(line, "        if: ") {
+			hasCondition = true
+			line = fmt.Sprintf("        if: %s && %s", strings.TrimPrefix(line, "        if: "), condition)
+		}
+		result = append(result, line)
+	}
+	if !hasCondition {
+		result = append([]string{result[0], "        if: " + condition}, result[1:]...)
+	}
+	return GitHubActionStep(result)
+}
+
+// writeStepsWithCondition writes steps to the YAML, each running only under the given condition
+func writeStepsWithCondition(yaml *strings.Builder, steps []GitHubActionStep, condition string) {
+	for _, step := range steps {
+		for _, line := range withStepCondition(step, condition) {
+			yaml.WriteString(line + "\n")
+		}
+	}
+}
+
+// generateEngineMatrixInstallSteps generates the installation steps of every matrix leg, each running only in its leg
+func (c *Compiler) generateEngineMatrixInstallSteps(yaml *strings.Builder, legs []*engineAttempt) {
+	for _, leg := range legs {
+		writeStepsWithCondition(yaml, leg.engine.GetInstallationSteps(leg.data), engineMatrixCondition(leg.matrixKey))
+	}
+}
+
+// generateEngineMatrixExecutionSteps generates the execution steps of every matrix leg, each running only in its leg
+func (c *Compiler) generateEngineMatrixExecutionSteps(yaml *strings.Builder, legs []*engineAttempt, logFile string) {
+	for _, leg := range legs {
+		writeStepsWithCondition(yaml, leg.engine.GetExecutionSteps(leg.data, logFile), engineMatrixCondition(leg.matrixKey))
+	}
+}
+
+// generateEngineMatrixAwInfo generates the aw_info.json step of every matrix leg, each running only in its leg
+func (c *Compiler) generateEngineMatrixAwInfo(yaml *strings.Builder, legs []*engineAttempt) {
+	for _, leg := range legs {
+		var stepBuilder strings.Builder
+		c.generateCreateAwInfo(&stepBuilder, leg.data, leg.engine)
+		step := GitHubActionStep(strings.Split(strings.TrimSuffix(stepBuilder.String(), "\n"), "\n"))
+		writeStepsWithCondition(yaml, []GitHubActionStep{step}, engineMatrixCondition(leg.matrixKey))
+	}
+}
+
+// generateEngineMatrixLogParsing generates the log parsing step of every matrix leg, each running only in its leg
+func (c *Compiler) generateEngineMatrixLogParsing(yaml *strings.Builder, legs []*engineAttempt, logFile string) {
+	for _, leg := range legs {
+		condition := fmt.Sprintf("always() && %s", engineMatrixCondition(leg.matrixKey))
+		c.generateLogParsingStep(yaml, leg.engine, logFile, condition)
+	}
+}
+
+// buildCompareJob creates the job that downloads the artifacts of every matrix leg, shows token usage,
+// cost and outputs side by side, and exposes the winner's output to the safe output jobs
+func (c *Compiler) buildCompareJob(data *WorkflowData, mainJobName string) (*Job, error) {
+	if !isEngineMatrix(data) {
+		return nil, fmt.Errorf("engine matrix configuration is required")
+	}
+
+	type compareLeg struct {
+		Key    string `json:"key"`
+		Engine string `json:"engine"`
+		Name   string `json:"name"`
+		Model  string `json:"model"`
+	}
+	var legs []compareLeg
+	for _, leg := range c.getEngineMatrixLegs(data) {
+		legs = append(legs, compareLeg{
+			Key:    leg.matrixKey,
+			Engine: leg.engine.GetID(),
+			Name:   leg.engine.GetDisplayName(),
+			Model:  leg.data.EngineConfig.Model,
+		})
+	}
+	legsJSON, err := json.Marshal(legs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal engine matrix: %w", err)
+	}
+
+	var steps []string
+	steps = append(steps, "      - name: Download engine outputs\n")
+	steps = append(steps, "        uses: actions/download-artifact@v5\n")
+	steps = append(steps, "        with:\n")
+	steps = append(steps, "          path: /tmp/aw-compare/\n")
+	steps = append(steps, "      - name: Compare engine outputs\n")
+	steps = append(steps, "        id: compare\n")
+	steps = append(steps, "        uses: actions/github-script@v7\n")
+	steps = append(steps, "        env:\n")
+	steps = append(steps, fmt.Sprintf("          GITHUB_AW_COMPARE_LEGS: %q\n", legsJSON))
+	steps = append(steps, "          GITHUB_AW_COMPARE_DIR: /tmp/aw-compare\n")
+	steps = append(steps, fmt.Sprintf("          GITHUB_AW_AGENT_LOG: %s.log\n", generateSafeFileName(data.Name)))
+	if data.EngineConfig.Winner != "" {
+		steps = append(steps, fmt.Sprintf("          GITHUB_AW_COMPARE_WINNER: %s\n", data.EngineConfig.Winner))
+	}
+	steps = append(steps, "        with:\n")
+	steps = append(steps, "          script: |\n")
+	steps = append(steps, FormatJavaScriptForYAML(compareEngineOutputsScript)...)
+
+	var outputs map[string]string
+	if data.EngineConfig.Winner != "" && data.SafeOutputs != nil {
+		outputs = map[string]string{
+			"output": "${{ steps.compare.outputs.output }}",
+		}
+
+		// Republish the winner's patch under the name the pull request and push jobs download
+		if data.SafeOutputs.CreatePullRequests != nil || data.SafeOutputs.PushToBranch != nil {
+			steps = append(steps, "      - name: Upload winner git patch\n")
+			steps = append(steps, "        if: steps.compare.outputs.patch != ''\n")
+			steps = append(steps, "        uses: actions/upload-artifact@v4\n")
+			steps = append(steps, "        with:\n")
+			steps = append(steps, "          name: aw.patch\n")
+			steps = append(steps, "          path: ${{ steps.compare.outputs.patch }}\n")
+			steps = append(steps, "          if-no-files-found: ignore\n")
+		}
+	}
+
+	job := &Job{
+		Name:   compareJobName,
+		RunsOn: "runs-on: ubuntu-latest",
+		// Compare the legs that completed even if others failed, unless the run was cancelled
+		// or the workflow condition skipped the matrix job
+		If:             fmt.Sprintf("if: ${{ !cancelled() && needs.%s.result != 'skipped' }}", mainJobName),
+		Permissions:    "permissions:\n      contents: read", // Only reads artifacts of the current run
+		TimeoutMinutes: 10,
+		Steps:          steps,
+		Outputs:        outputs,
+		Depends:        []string{mainJobName},
+	}
+
+	return job, nil
+}
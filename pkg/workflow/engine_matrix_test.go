@@ -0,0 +1,272 @@
+package workflow
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+)
+
+func TestExtractEngineMatrix(t *testing.T) {
+	compiler := NewCompiler(false, "", "test")
+	engineSetting, config := compiler.extractEngineConfig(map[string]any{
+		"engine": map[string]any{
+			"matrix": []any{
+				"claude",
+				map[string]any{"id": "codex", "model": "o4-mini", "name": "codex-mini"},
+			},
+			"compare": true,
+			"winner":  "codex-mini",
+		},
+	})
+
+	if config == nil || len(config.Matrix) != 2 {
+		t.Fatalf("Expected 2 matrix engines, got %+v", config)
+	}
+	if engineSetting != "claude" {
+		t.Errorf("Expected the first matrix engine as engine setting, got '%s'", engineSetting)
+	}
+	if config.Matrix[0].Key() != "claude" {
+		t.Errorf("Expected first leg 'claude', got '%s'", config.Matrix[0].Key())
+	}
+	if config.Matrix[1].ID != "codex" || config.Matrix[1].Model != "o4-mini" || config.Matrix[1].Key() != "codex-mini" {
+		t.Errorf("Unexpected second leg: %+v", config.Matrix[1])
+	}
+	if !config.Compare || config.Winner != "codex-mini" {
+		t.Errorf("Expected compare with winner 'codex-mini', got compare=%v winner=%q", config.Compare, config.Winner)
+	}
+}
+
+func TestValidateEngineMatrix(t *testing.T) {
+	compiler := NewCompiler(false, "", "test")
+	legs := func(ids ...string) []*EngineMatrixLeg {
+		var result []*EngineMatrixLeg
+		for _, id := range ids {
+			result = append(result, &EngineMatrixLeg{ID: id})
+		}
+		return result
+	}
+
+	tests := []struct {
+		name        string
+		config      *EngineConfig
+		errContains string
+	}{
+		{
+			name:   "valid matrix",
+			config: &EngineConfig{ID: "claude", Matrix: legs("claude", "codex"), Compare: true, Winner: "codex"},
+		},
+		{
+			name:   "no matrix",
+			config: &EngineConfig{ID: "claude"},
+		},
+		{
+			name:        "compare required",
+			config:      &EngineConfig{ID: "claude", Matrix: legs("claude", "codex")},
+			errContains: "requires 'compare: true'",
+		},
+		{
+			name:        "single engine",
+			config:      &EngineConfig{ID: "claude", Matrix: legs("claude"), Compare: true},
+			errContains: "at least two engines",
+		},
+		{
+			name:        "duplicate engine",
+			config:      &EngineConfig{ID: "claude", Matrix: legs("claude", "claude"), Compare: true},
+			errContains: "used more than once",
+		},
+		{
+			name: "duplicate engine with names",
+			config: &EngineConfig{ID: "claude", Compare: true, Matrix: []*EngineMatrixLeg{
+				{ID: "claude", Name: "sonnet", Model: "claude-sonnet-4"},
+				{ID: "claude", Name: "opus", Model: "claude-opus-4"},
+			}},
+		},
+		{
+			name:        "custom engine",
+			config:      &EngineConfig{ID: "claude", Matrix: legs("claude", "custom"), Compare: true},
+			errContains: "custom engine cannot be used",
+		},
+		{
+			name:        "unknown winner",
+			config:      &EngineConfig{ID: "claude", Matrix: legs("claude", "codex"), Compare: true, Winner: "gemini"},
+			errContains: "engine winner 'gemini' is not in the engine matrix",
+		},
+		{
+			name:        "combined with fallback",
+			config:      &EngineConfig{ID: "claude", Matrix: legs("claude", "codex"), Compare: true, Fallback: []*EngineConfig{{ID: "gemini"}}},
+			errContains: "cannot be combined with engine fallback",
+		},
+		{
+			name:        "max-turns unsupported",
+			config:      &EngineConfig{ID: "claude", Matrix: legs("claude", "codex"), Compare: true, MaxTurns: "5"},
+			errContains: "matrix engine 'codex' does not support the max-turns feature",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := compiler.validateEngineMatrix(tt.config, nil)
+			if tt.errContains == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("Expected error containing %q, got %v", tt.errContains, err)
+			}
+		})
+	}
+}
+
+func TestWithStepCondition(t *testing.T) {
+	step := GitHubActionStep{
+		"      - name: Capture logs",
+		"        if: always()",
+		"        run: touch /tmp/test.log",
+	}
+	content := strings.Join(withStepCondition(step, engineMatrixCondition("codex")), "\n")
+	if !strings.Contains(content, "        if: always() && matrix.engine == 'codex'") {
+		t.Errorf("Expected combined condition in step:\n%s", content)
+	}
+
+	step = GitHubActionStep{
+		"      - name: Install",
+		"        run: npm install",
+	}
+	result := withStepCondition(step, engineMatrixCondition("claude"))
+	if len(result) != 3 || result[1] != "        if: matrix.engine == 'claude'" {
+		t.Errorf("Expected condition after the step name, got:\n%s", strings.Join(result, "\n"))
+	}
+}
+
+func TestEngineMatrixCompile(t *testing.T) {
+	tests := []struct {
+		name        string
+		winner      string
+		expected    []string
+		notExpected []string
+	}{
+		{
+			name:   "with winner",
+			winner: "\n  winner: codex",
+			expected: []string{
+				"  create_issue:\n    needs: compare\n",
+				"GITHUB_AW_AGENT_OUTPUT: ${{ needs.compare.outputs.output }}",
+				"GITHUB_AW_COMPARE_WINNER: codex",
+				"      output: ${{ steps.compare.outputs.output }}",
+			},
+		},
+		{
+			name:        "preview only",
+			expected:    []string{"GITHUB_AW_COMPARE_LEGS"},
+			notExpected: []string{"create_issue:", "GITHUB_AW_COMPARE_WINNER:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			workflowPath := filepath.Join(tmpDir, "test-matrix.md")
+			content := `---
+on: push
+permissions:
+  contents: read
+engine:
+  matrix:
+    - claude
+    - id: codex
+      model: o4-mini
+  compare: true` + tt.winner + `
+safe-outputs:
+  create-issue:
+---
+
+# Matrix Test
+
+Summarize the repository.`
+			if err := os.WriteFile(workflowPath, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			compiler := NewCompiler(false, "", "test")
+			if err := compiler.CompileWorkflow(workflowPath); err != nil {
+				t.Fatalf("unexpected compile error: %v", err)
+			}
+
+			lockContent, err := os.ReadFile(strings.TrimSuffix(workflowPath, ".md") + ".lock.yml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			lock := string(lockContent)
+
+			expected := append([]string{
+				"    strategy:\n      fail-fast: false\n      matrix:\n        engine:\n          - claude\n          - codex\n",
+				"if: matrix.engine == 'claude'",
+				"if: matrix.engine == 'codex'",
+				"if: always() && matrix.engine == 'codex'",
+				"name: agent_output.json-${{ matrix.engine }}",
+				"name: aw_info.json-${{ matrix.engine }}",
+				"  compare:\n    needs: matrix-test\n    if: ${{ !cancelled() && needs.matrix-test.result != 'skipped' }}\n",
+				"-c model=o4-mini",
+			}, tt.expected...)
+			for _, want := range expected {
+				if !strings.Contains(lock, want) {
+					t.Errorf("Expected %q in lock file", want)
+				}
+			}
+			for _, unwanted := range tt.notExpected {
+				if strings.Contains(lock, unwanted) {
+					t.Errorf("Did not expect %q in lock file", unwanted)
+				}
+			}
+
+			// Matrix legs cannot share job outputs, the compare job reads their artifacts instead
+			mainJob := lock[strings.Index(lock, "  matrix-test:"):strings.Index(lock, "  compare:")]
+			if strings.Contains(mainJob, "outputs:\n      output:") {
+				t.Error("Expected the matrix job not to declare the output job output")
+			}
+		})
+	}
+}
+
+func TestCompareJobQuotesLegs(t *testing.T) {
+	model := `o4-mini 'preview' "beta" \ test`
+	data := &WorkflowData{
+		Name: "Matrix Test",
+		EngineConfig: &EngineConfig{
+			Compare: true,
+			Matrix:  []*EngineMatrixLeg{{ID: "claude"}, {ID: "codex", Model: model}},
+		},
+	}
+
+	job, err := NewCompiler(false, "", "test").buildCompareJob(data, "matrix-test")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var envLine string
+	for _, line := range job.Steps {
+		if strings.Contains(line, "GITHUB_AW_COMPARE_LEGS:") {
+			envLine = strings.TrimSpace(line)
+		}
+	}
+	var env map[string]string
+	if err := yaml.Unmarshal([]byte(envLine), &env); err != nil {
+		t.Fatalf("Expected a valid YAML scalar, got %q: %v", envLine, err)
+	}
+	var legs []struct {
+		Key   string `json:"key"`
+		Model string `json:"model"`
+	}
+	if err := json.Unmarshal([]byte(env["GITHUB_AW_COMPARE_LEGS"]), &legs); err != nil {
+		t.Fatalf("Expected the legs as JSON, got %q: %v", env["GITHUB_AW_COMPARE_LEGS"], err)
+	}
+	if len(legs) != 2 || legs[1].Model != model {
+		t.Errorf("Expected the model %q to round-trip, got %+v", model, legs)
+	}
+}
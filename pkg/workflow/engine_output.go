@@ -1,12 +1,12 @@
 package workflow
 
 import (
+	"fmt"
 	"strings"
 )
 
 // generateEngineOutputCollection generates a step that collects engine-declared output files as artifacts
-func (c *Compiler) generateEngineOutputCollection(yaml *strings.Builder, engine CodingAgentEngine) {
-	outputFiles := engine.GetDeclaredOutputFiles()
+func (c *Compiler) generateEngineOutputCollection(yaml *strings.Builder, data *WorkflowData, outputFiles []string) {
 	if len(outputFiles) == 0 {
 		return
 	}
@@ -16,7 +16,7 @@ func (c *Compiler) generateEngineOutputCollection(yaml *strings.Builder, engine
 	yaml.WriteString("      - name: Upload engine output files\n")
 	yaml.WriteString("        uses: actions/upload-artifact@v4\n")
 	yaml.WriteString("        with:\n")
-	yaml.WriteString("          name: agent_outputs\n")
+	fmt.Fprintf(yaml, "          name: %s\n", artifactName(data, "agent_outputs"))
 
 	// Create the path list for all declared output files
 	yaml.WriteString("          path: |\n")
@@ -0,0 +1,233 @@
+package workflow
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Enterprise model provider types (engine.provider.type)
+const (
+	ProviderBedrock          = "bedrock"
+	ProviderVertex           = "vertex"
+	ProviderAzureOpenAI      = "azure-openai"
+	ProviderOpenAICompatible = "openai-compatible"
+)
+
+// DefaultAzureOpenAIAPIVersion is the Azure OpenAI API version Codex requests
+const DefaultAzureOpenAIAPIVersion = "2025-04-01-preview"
+
+// providerAuthStepID is the ID of the step authenticating to the provider's cloud
+const providerAuthStepID = "provider_auth"
+
+// secretNamePattern matches valid GitHub Actions secret names
+var secretNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// regionPattern matches cloud region names, which are written unquoted into the generated YAML
+var regionPattern = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+// EngineProviderConfig routes the engine's model calls through an enterprise model provider
+// instead of the engine vendor's public API
+type EngineProviderConfig struct {
+	Type              string
+	Region            string
+	Endpoint          string
+	CredentialsSecret string // Name of the secret holding the provider credentials
+}
+
+// extractEngineProvider parses the engine 'provider' field
+func extractEngineProvider(provider any) *EngineProviderConfig {
+	providerMap, ok := provider.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	config := &EngineProviderConfig{}
+	if providerType, ok := providerMap["type"].(string); ok {
+		config.Type = providerType
+	}
+	if region, ok := providerMap["region"].(string); ok {
+		config.Region = region
+	}
+	if endpoint, ok := providerMap["endpoint"].(string); ok {
+		config.Endpoint = endpoint
+	}
+	if secret, ok := providerMap["credentials-secret"].(string); ok {
+		config.CredentialsSecret = secret
+	}
+	return config
+}
+
+// getEngineProvider returns the enterprise model provider of the workflow's engine, or nil
+func getEngineProvider(workflowData *WorkflowData) *EngineProviderConfig {
+	if workflowData == nil || workflowData.EngineConfig == nil {
+		return nil
+	}
+	return workflowData.EngineConfig.Provider
+}
+
+// credentials returns the expression reading the provider credentials secret
+func (p *EngineProviderConfig) credentials() string {
+	return fmt.Sprintf("${{ secrets.%s }}", p.CredentialsSecret)
+}
+
+// validateEngineProvider validates the enterprise model provider against the engine
+func (c *Compiler) validateEngineProvider(engineConfig *EngineConfig, engine CodingAgentEngine) error {
+	if engineConfig == nil || engineConfig.Provider == nil {
+		return nil
+	}
+	provider := engineConfig.Provider
+
+	switch provider.Type {
+	case ProviderBedrock, ProviderVertex, ProviderAzureOpenAI, ProviderOpenAICompatible:
+	case "":
+		return fmt.Errorf("engine provider requires a 'type'")
+	default:
+		return fmt.Errorf("unknown engine provider type '%s'. Must be one of: %s, %s, %s, %s", provider.Type, ProviderBedrock, ProviderVertex, ProviderAzureOpenAI, ProviderOpenAICompatible)
+	}
+
+	if !engine.SupportsProvider(provider.Type) {
+		return fmt.Errorf("engine '%s' does not support the '%s' provider", engine.GetID(), provider.Type)
+	}
+	if len(engineConfig.Matrix) > 0 {
+		return fmt.Errorf("engine provider cannot be combined with an engine matrix")
+	}
+	// Fallback engines would call their vendor's public API, bypassing the provider
+	if len(engineConfig.Fallback) > 0 {
+		return fmt.Errorf("engine provider cannot be combined with engine fallback")
+	}
+
+	if !secretNamePattern.MatchString(provider.CredentialsSecret) {
+		if provider.CredentialsSecret == "" {
+			return fmt.Errorf("the '%s' provider requires 'credentials-secret'", provider.Type)
+		}
+		return fmt.Errorf("invalid credentials-secret '%s': must be a secret name, not an expression", provider.CredentialsSecret)
+	}
+
+	switch provider.Type {
+	case ProviderBedrock, ProviderVertex:
+		if provider.Region == "" {
+			return fmt.Errorf("the '%s' provider requires 'region'", provider.Type)
+		}
+	case ProviderAzureOpenAI, ProviderOpenAICompatible:
+		if provider.Endpoint == "" {
+			return fmt.Errorf("the '%s' provider requires 'endpoint'", provider.Type)
+		}
+	}
+
+	if provider.Region != "" && !regionPattern.MatchString(provider.Region) {
+		return fmt.Errorf("invalid provider region '%s': must only contain lowercase letters, digits and hyphens", provider.Region)
+	}
+	if provider.Endpoint != "" {
+		if _, err := parseEndpointURL(provider.Endpoint); err != nil {
+			return fmt.Errorf("invalid provider endpoint '%s': %w", provider.Endpoint, err)
+		}
+	}
+
+	return nil
+}
+
+// validateEngineBaseURL validates the self-hosted OpenAI-compatible server the engine calls
+func (c *Compiler) validateEngineBaseURL(engineConfig *EngineConfig, engine CodingAgentEngine) error {
+	if engineConfig == nil || engineConfig.BaseURL == "" {
+		return nil
+	}
+
+	// A self-hosted server is an OpenAI-compatible endpoint that needs no credentials
+	if !engine.SupportsProvider(ProviderOpenAICompatible) {
+		return fmt.Errorf("engine '%s' does not support base-url", engine.GetID())
+	}
+	if engineConfig.Provider != nil {
+		return fmt.Errorf("base-url cannot be combined with an engine provider")
+	}
+	if len(engineConfig.Matrix) > 0 {
+		return fmt.Errorf("base-url cannot be combined with an engine matrix")
+	}
+	if len(engineConfig.Fallback) > 0 {
+		return fmt.Errorf("base-url cannot be combined with engine fallback")
+	}
+	if engineConfig.Model == "" {
+		return fmt.Errorf("base-url requires 'model', the name the server serves the model under")
+	}
+	if _, err := parseEndpointURL(engineConfig.BaseURL); err != nil {
+		return fmt.Errorf("invalid base-url '%s': %w", engineConfig.BaseURL, err)
+	}
+	return nil
+}
+
+// parseEndpointURL parses a model endpoint, which must be an http or https URL with a host
+func parseEndpointURL(endpoint string) (*url.URL, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return nil, fmt.Errorf("must be an http or https URL")
+	}
+	return parsed, nil
+}
+
+// allowEngineBaseURL adds the host of the engine's self-hosted server to the network allow-list
+func allowEngineBaseURL(network *NetworkPermissions, engineConfig *EngineConfig) *NetworkPermissions {
+	if engineConfig == nil || engineConfig.BaseURL == "" {
+		return network
+	}
+	parsed, err := parseEndpointURL(engineConfig.BaseURL)
+	if err != nil {
+		return network
+	}
+	host := parsed.Hostname()
+
+	// The defaults mode has no explicit allow-list, so spell out the defaults next to the host
+	if network == nil || network.Mode == "defaults" {
+		return &NetworkPermissions{Allowed: []string{"defaults", host}}
+	}
+	if slices.Contains(network.Allowed, host) {
+		return network
+	}
+	return &NetworkPermissions{Allowed: append(slices.Clone(network.Allowed), host)}
+}
+
+// providerNeedsIDToken reports whether the provider authenticates with GitHub OIDC
+func providerNeedsIDToken(provider *EngineProviderConfig) bool {
+	return provider != nil && provider.Type == ProviderBedrock
+}
+
+// getProviderAuthSteps returns the steps authenticating to the cloud hosting the provider.
+// Bedrock assumes the IAM role in the credentials secret through GitHub OIDC, Vertex AI signs in
+// with the service account key in the credentials secret. API key providers need no step.
+func getProviderAuthSteps(provider *EngineProviderConfig) []GitHubActionStep {
+	if provider == nil {
+		return nil
+	}
+
+	switch provider.Type {
+	case ProviderBedrock:
+		return []GitHubActionStep{{
+			"      - name: Configure AWS credentials for Amazon Bedrock",
+			"        id: " + providerAuthStepID,
+			"        uses: aws-actions/configure-aws-credentials@v4",
+			"        with:",
+			"          role-to-assume: " + provider.credentials(),
+			"          aws-region: " + provider.Region,
+		}}
+	case ProviderVertex:
+		return []GitHubActionStep{{
+			"      - name: Authenticate to Google Cloud for Vertex AI",
+			"        id: " + providerAuthStepID,
+			"        uses: google-github-actions/auth@v2",
+			"        with:",
+			"          credentials_json: " + provider.credentials(),
+		}}
+	}
+	return nil
+}
+
+// hasIDTokenWritePermission reports whether the workflow permissions grant id-token: write
+func hasIDTokenWritePermission(permissions string) bool {
+	for _, line := range strings.Split(permissions, "\n") {
+		if strings.ReplaceAll(strings.TrimSpace(line), " ", "") == "id-token:write" {
+			return true
+		}
+	}
+	return strings.Contains(permissions, "write-all")
+}
@@ -0,0 +1,394 @@
+package workflow
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractEngineProvider(t *testing.T) {
+	compiler := NewCompiler(false, "", "test")
+	_, config := compiler.extractEngineConfig(map[string]any{
+		"engine": map[string]any{
+			"id": "claude",
+			"provider": map[string]any{
+				"type":               "vertex",
+				"region":             "us-east5",
+				"endpoint":           "https://vertex.example.com",
+				"credentials-secret": "GCP_SA_KEY",
+			},
+		},
+	})
+
+	if config == nil || config.Provider == nil {
+		t.Fatalf("Expected provider to be parsed, got %+v", config)
+	}
+	expected := EngineProviderConfig{Type: "vertex", Region: "us-east5", Endpoint: "https://vertex.example.com", CredentialsSecret: "GCP_SA_KEY"}
+	if *config.Provider != expected {
+		t.Errorf("Expected provider %+v, got %+v", expected, *config.Provider)
+	}
+}
+
+func TestValidateEngineProvider(t *testing.T) {
+	compiler := NewCompiler(false, "", "test")
+
+	tests := []struct {
+		name        string
+		engine      CodingAgentEngine
+		provider    *EngineProviderConfig
+		fallback    []*EngineConfig
+		errContains string
+	}{
+		{
+			name:     "claude on bedrock",
+			engine:   NewClaudeEngine(),
+			provider: &EngineProviderConfig{Type: ProviderBedrock, Region: "us-east-1", CredentialsSecret: "AWS_ROLE_ARN"},
+		},
+		{
+			name:     "codex on azure openai",
+			engine:   NewCodexEngine(),
+			provider: &EngineProviderConfig{Type: ProviderAzureOpenAI, Endpoint: "https://contoso.openai.azure.com/openai", CredentialsSecret: "AZURE_OPENAI_KEY"},
+		},
+		{
+			name:        "unknown type",
+			engine:      NewClaudeEngine(),
+			provider:    &EngineProviderConfig{Type: "watsonx", CredentialsSecret: "KEY"},
+			errContains: "unknown engine provider type 'watsonx'",
+		},
+		{
+			name:        "unsupported by engine",
+			engine:      NewCodexEngine(),
+			provider:    &EngineProviderConfig{Type: ProviderBedrock, Region: "us-east-1", CredentialsSecret: "AWS_ROLE_ARN"},
+			errContains: "engine 'codex' does not support the 'bedrock' provider",
+		},
+		{
+			name:        "missing region",
+			engine:      NewClaudeEngine(),
+			provider:    &EngineProviderConfig{Type: ProviderVertex, CredentialsSecret: "GCP_SA_KEY"},
+			errContains: "requires 'region'",
+		},
+		{
+			name:        "region with YAML syntax",
+			engine:      NewClaudeEngine(),
+			provider:    &EngineProviderConfig{Type: ProviderBedrock, Region: "us-east-1\n          role-to-assume: attacker", CredentialsSecret: "AWS_ROLE_ARN"},
+			errContains: "invalid provider region",
+		},
+		{
+			name:        "region with expression",
+			engine:      NewClaudeEngine(),
+			provider:    &EngineProviderConfig{Type: ProviderVertex, Region: "${{ github.event.issue.title }}", CredentialsSecret: "GCP_SA_KEY"},
+			errContains: "invalid provider region",
+		},
+		{
+			name:        "missing endpoint",
+			engine:      NewCodexEngine(),
+			provider:    &EngineProviderConfig{Type: ProviderOpenAICompatible, CredentialsSecret: "KEY"},
+			errContains: "requires 'endpoint'",
+		},
+		{
+			name:        "missing credentials",
+			engine:      NewClaudeEngine(),
+			provider:    &EngineProviderConfig{Type: ProviderBedrock, Region: "us-east-1"},
+			errContains: "requires 'credentials-secret'",
+		},
+		{
+			name:        "credentials expression",
+			engine:      NewClaudeEngine(),
+			provider:    &EngineProviderConfig{Type: ProviderBedrock, Region: "us-east-1", CredentialsSecret: "${{ secrets.AWS_ROLE_ARN }}"},
+			errContains: "must be a secret name",
+		},
+		{
+			name:        "invalid endpoint",
+			engine:      NewCodexEngine(),
+			provider:    &EngineProviderConfig{Type: ProviderAzureOpenAI, Endpoint: "contoso.openai.azure.com", CredentialsSecret: "KEY"},
+			errContains: "must be an http or https URL",
+		},
+		{
+			name:        "combined with fallback",
+			engine:      NewClaudeEngine(),
+			provider:    &EngineProviderConfig{Type: ProviderBedrock, Region: "us-east-1", CredentialsSecret: "AWS_ROLE_ARN"},
+			fallback:    []*EngineConfig{{ID: "codex"}},
+			errContains: "engine provider cannot be combined with engine fallback",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &EngineConfig{ID: tt.engine.GetID(), Provider: tt.provider, Fallback: tt.fallback}
+			err := compiler.validateEngineProvider(config, tt.engine)
+			if tt.errContains == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("Expected error containing %q, got %v", tt.errContains, err)
+			}
+		})
+	}
+}
+
+func TestClaudeEngineProviderSteps(t *testing.T) {
+	engine := NewClaudeEngine()
+
+	tests := []struct {
+		name        string
+		provider    *EngineProviderConfig
+		install     []string
+		execution   []string
+		notExpected []string
+	}{
+		{
+			name:     "bedrock",
+			provider: &EngineProviderConfig{Type: ProviderBedrock, Region: "us-east-1", CredentialsSecret: "AWS_ROLE_ARN"},
+			install: []string{
+				"uses: aws-actions/configure-aws-credentials@v4",
+				"role-to-assume: ${{ secrets.AWS_ROLE_ARN }}",
+				"aws-region: us-east-1",
+			},
+			execution:   []string{"use_bedrock: \"true\"", "AWS_REGION: us-east-1"},
+			notExpected: []string{"anthropic_api_key", "use_vertex"},
+		},
+		{
+			name:     "vertex",
+			provider: &EngineProviderConfig{Type: ProviderVertex, Region: "us-east5", Endpoint: "https://vertex.example.com", CredentialsSecret: "GCP_SA_KEY"},
+			install: []string{
+				"uses: google-github-actions/auth@v2",
+				"credentials_json: ${{ secrets.GCP_SA_KEY }}",
+			},
+			execution: []string{
+				"use_vertex: \"true\"",
+				"CLOUD_ML_REGION: us-east5",
+				"ANTHROPIC_VERTEX_PROJECT_ID: ${{ steps.provider_auth.outputs.project_id }}",
+				"ANTHROPIC_VERTEX_BASE_URL: https://vertex.example.com",
+			},
+			notExpected: []string{"anthropic_api_key", "use_bedrock"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workflowData := &WorkflowData{
+				Name:         "test-workflow",
+				EngineConfig: &EngineConfig{ID: "claude", Provider: tt.provider},
+			}
+
+			var install strings.Builder
+			for _, step := range engine.GetInstallationSteps(workflowData) {
+				install.WriteString(strings.Join(step, "\n") + "\n")
+			}
+			for _, want := range tt.install {
+				if !strings.Contains(install.String(), want) {
+					t.Errorf("Expected %q in installation steps:\n%s", want, install.String())
+				}
+			}
+
+			execution := strings.Join(engine.GetExecutionSteps(workflowData, "/tmp/test.log")[0], "\n")
+			for _, want := range tt.execution {
+				if !strings.Contains(execution, want) {
+					t.Errorf("Expected %q in execution step:\n%s", want, execution)
+				}
+			}
+			for _, unwanted := range tt.notExpected {
+				if strings.Contains(execution, unwanted) {
+					t.Errorf("Did not expect %q in execution step:\n%s", unwanted, execution)
+				}
+			}
+		})
+	}
+}
+
+func TestCodexEngineProviderSteps(t *testing.T) {
+	engine := NewCodexEngine()
+
+	workflowData := &WorkflowData{
+		Name: "test-workflow",
+		EngineConfig: &EngineConfig{ID: "codex", Model: "gpt-4o", Provider: &EngineProviderConfig{
+			Type:              ProviderAzureOpenAI,
+			Endpoint:          "https://contoso.openai.azure.com/openai",
+			CredentialsSecret: "AZURE_OPENAI_KEY",
+		}},
+	}
+	execution := strings.Join(engine.GetExecutionSteps(workflowData, "/tmp/test.log")[0], "\n")
+	expected := []string{
+		"cat >> /tmp/mcp-config/config.toml << EOF",
+		"[model_providers.azure]",
+		`base_url = "https://contoso.openai.azure.com/openai"`,
+		`env_key = "AZURE_OPENAI_API_KEY"`,
+		`query_params = { api-version = "2025-04-01-preview" }`,
+		"-c model=gpt-4o \\\n            -c model_provider=azure \\\n            --full-auto",
+		"AZURE_OPENAI_API_KEY: ${{ secrets.AZURE_OPENAI_KEY }}",
+	}
+	for _, want := range expected {
+		if !strings.Contains(execution, want) {
+			t.Errorf("Expected %q in execution step:\n%s", want, execution)
+		}
+	}
+	if strings.Contains(execution, "OPENAI_API_KEY: ${{ secrets.OPENAI_API_KEY }}") {
+		t.Errorf("Expected the OpenAI API key to be replaced:\n%s", execution)
+	}
+
+	workflowData.EngineConfig.Provider = &EngineProviderConfig{
+		Type:              ProviderOpenAICompatible,
+		Endpoint:          "https://llm.internal.example.com/v1",
+		CredentialsSecret: "LLM_GATEWAY_KEY",
+	}
+	execution = strings.Join(engine.GetExecutionSteps(workflowData, "/tmp/test.log")[0], "\n")
+	for _, want := range []string{
+		"OPENAI_API_KEY: ${{ secrets.LLM_GATEWAY_KEY }}",
+		"OPENAI_BASE_URL: https://llm.internal.example.com/v1",
+	} {
+		if !strings.Contains(execution, want) {
+			t.Errorf("Expected %q in execution step:\n%s", want, execution)
+		}
+	}
+	if strings.Contains(execution, "model_provider=") {
+		t.Errorf("Did not expect a model provider override:\n%s", execution)
+	}
+}
+
+func TestHasIDTokenWritePermission(t *testing.T) {
+	tests := []struct {
+		permissions string
+		expected    bool
+	}{
+		{"permissions:\n  contents: read\n  id-token: write", true},
+		{"permissions: write-all", true},
+		{"permissions:\n  contents: read", false},
+		{"permissions:\n  id-token: read", false},
+	}
+	for _, tt := range tests {
+		if got := hasIDTokenWritePermission(tt.permissions); got != tt.expected {
+			t.Errorf("hasIDTokenWritePermission(%q) = %v, expected %v", tt.permissions, got, tt.expected)
+		}
+	}
+}
+
+func TestValidateEngineBaseURL(t *testing.T) {
+	compiler := NewCompiler(false, "", "test")
+
+	tests := []struct {
+		name        string
+		engine      CodingAgentEngine
+		config      *EngineConfig
+		errContains string
+	}{
+		{
+			name:   "codex with local server",
+			engine: NewCodexEngine(),
+			config: &EngineConfig{ID: "codex", Model: "qwen2.5-coder:32b", BaseURL: "http://localhost:11434/v1"},
+		},
+		{
+			name:        "unsupported engine",
+			engine:      NewClaudeEngine(),
+			config:      &EngineConfig{ID: "claude", Model: "llama3", BaseURL: "http://localhost:11434/v1"},
+			errContains: "engine 'claude' does not support base-url",
+		},
+		{
+			name:        "model required",
+			engine:      NewCodexEngine(),
+			config:      &EngineConfig{ID: "codex", BaseURL: "http://localhost:11434/v1"},
+			errContains: "base-url requires 'model'",
+		},
+		{
+			name:   "combined with provider",
+			engine: NewCodexEngine(),
+			config: &EngineConfig{ID: "codex", Model: "gpt-4o", BaseURL: "http://localhost:8000/v1", Provider: &EngineProviderConfig{
+				Type: ProviderAzureOpenAI, Endpoint: "https://contoso.openai.azure.com/openai", CredentialsSecret: "KEY",
+			}},
+			errContains: "cannot be combined with an engine provider",
+		},
+		{
+			name:        "combined with fallback",
+			engine:      NewCodexEngine(),
+			config:      &EngineConfig{ID: "codex", Model: "llama3", BaseURL: "http://localhost:11434/v1", Fallback: []*EngineConfig{{ID: "claude"}}},
+			errContains: "base-url cannot be combined with engine fallback",
+		},
+		{
+			name:        "invalid url",
+			engine:      NewCodexEngine(),
+			config:      &EngineConfig{ID: "codex", Model: "llama3", BaseURL: "localhost:11434"},
+			errContains: "must be an http or https URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := compiler.validateEngineBaseURL(tt.config, tt.engine)
+			if tt.errContains == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("Expected error containing %q, got %v", tt.errContains, err)
+			}
+		})
+	}
+}
+
+func TestCodexEngineBaseURLSteps(t *testing.T) {
+	engine := NewCodexEngine()
+	workflowData := &WorkflowData{
+		Name:         "test-workflow",
+		EngineConfig: &EngineConfig{ID: "codex", Model: "qwen2.5-coder:32b", BaseURL: "http://vllm:8000/v1"},
+	}
+
+	execution := strings.Join(engine.GetExecutionSteps(workflowData, "/tmp/test.log")[0], "\n")
+	expected := []string{
+		"[model_providers.self-hosted]",
+		`base_url = "http://vllm:8000/v1"`,
+		`wire_api = "chat"`,
+		"-c model=qwen2.5-coder:32b \\\n            -c model_provider=self-hosted \\",
+	}
+	for _, want := range expected {
+		if !strings.Contains(execution, want) {
+			t.Errorf("Expected %q in execution step:\n%s", want, execution)
+		}
+	}
+	if strings.Contains(execution, "OPENAI_API_KEY") {
+		t.Errorf("Expected no OpenAI API key for a self-hosted server:\n%s", execution)
+	}
+}
+
+func TestAllowEngineBaseURL(t *testing.T) {
+	config := &EngineConfig{ID: "codex", Model: "llama3", BaseURL: "http://vllm.internal:8000/v1"}
+
+	tests := []struct {
+		name     string
+		network  *NetworkPermissions
+		expected []string
+	}{
+		{
+			name:     "defaults mode",
+			network:  &NetworkPermissions{Mode: "defaults"},
+			expected: []string{"defaults", "vllm.internal"},
+		},
+		{
+			name:     "explicit allow-list",
+			network:  &NetworkPermissions{Allowed: []string{"python"}},
+			expected: []string{"python", "vllm.internal"},
+		},
+		{
+			name:     "host already allowed",
+			network:  &NetworkPermissions{Allowed: []string{"vllm.internal"}},
+			expected: []string{"vllm.internal"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := allowEngineBaseURL(tt.network, config)
+			if result.Mode != "" || strings.Join(result.Allowed, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Expected allow-list %v, got %+v", tt.expected, result)
+			}
+		})
+	}
+
+	// Engines without a base-url keep their network permissions
+	network := &NetworkPermissions{Mode: "defaults"}
+	if result := allowEngineBaseURL(network, &EngineConfig{ID: "codex"}); result != network {
+		t.Errorf("Expected network permissions to be unchanged, got %+v", result)
+	}
+}
@@ -0,0 +1,385 @@
+package workflow
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// expressionTokenKind identifies the lexical class of a token in a GitHub Actions expression
+type expressionTokenKind int
+
+const (
+	tokenEOF expressionTokenKind = iota
+	tokenIdentifier
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenLeftParen
+	tokenRightParen
+	tokenLeftBracket
+	tokenRightBracket
+	tokenDot
+	tokenComma
+	tokenStar
+)
+
+// expressionToken is a single lexical token with its byte offset in the source expression
+type expressionToken struct {
+	kind  expressionTokenKind
+	value string
+	pos   int
+}
+
+// tokenizeExpression splits a GitHub Actions expression (the text between ${{ and }}) into tokens
+func tokenizeExpression(expr string) ([]expressionToken, error) {
+	var tokens []expressionToken
+	i := 0
+	for i < len(expr) {
+		ch := expr[i]
+
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\r' || ch == '\n':
+			i++
+		case ch == '(':
+			tokens = append(tokens, expressionToken{kind: tokenLeftParen, value: "(", pos: i})
+			i++
+		case ch == ')':
+			tokens = append(tokens, expressionToken{kind: tokenRightParen, value: ")", pos: i})
+			i++
+		case ch == '[':
+			tokens = append(tokens, expressionToken{kind: tokenLeftBracket, value: "[", pos: i})
+			i++
+		case ch == ']':
+			tokens = append(tokens, expressionToken{kind: tokenRightBracket, value: "]", pos: i})
+			i++
+		case ch == ',':
+			tokens = append(tokens, expressionToken{kind: tokenComma, value: ",", pos: i})
+			i++
+		case ch == '*':
+			tokens = append(tokens, expressionToken{kind: tokenStar, value: "*", pos: i})
+			i++
+		case ch == '.' && (i+1 >= len(expr) || !isDigit(expr[i+1])):
+			tokens = append(tokens, expressionToken{kind: tokenDot, value: ".", pos: i})
+			i++
+		case ch == '\'':
+			// String literals are single-quoted; a doubled quote ('') escapes a literal quote
+			start := i
+			i++
+			var sb strings.Builder
+			closed := false
+			for i < len(expr) {
+				if expr[i] == '\'' {
+					if i+1 < len(expr) && expr[i+1] == '\'' {
+						sb.WriteByte('\'')
+						i += 2
+						continue
+					}
+					closed = true
+					i++
+					break
+				}
+				sb.WriteByte(expr[i])
+				i++
+			}
+			if !closed {
+				return nil, fmt.Errorf("unterminated string literal at position %d", start)
+			}
+			tokens = append(tokens, expressionToken{kind: tokenString, value: sb.String(), pos: start})
+		case isDigit(ch) || (ch == '.' && i+1 < len(expr) && isDigit(expr[i+1])) ||
+			(ch == '-' && i+1 < len(expr) && (isDigit(expr[i+1]) || expr[i+1] == '.')):
+			start := i
+			i++
+			for i < len(expr) && (isDigit(expr[i]) || isHexLetter(expr[i]) || expr[i] == '.' || expr[i] == 'x' ||
+				((expr[i] == '+' || expr[i] == '-') && (expr[i-1] == 'e' || expr[i-1] == 'E'))) {
+				i++
+			}
+			tokens = append(tokens, expressionToken{kind: tokenNumber, value: expr[start:i], pos: start})
+		case isIdentifierStart(ch):
+			start := i
+			for i < len(expr) && isIdentifierPart(expr[i]) {
+				i++
+			}
+			tokens = append(tokens, expressionToken{kind: tokenIdentifier, value: expr[start:i], pos: start})
+		default:
+			// Operators: ! != == < <= > >= && ||
+			start := i
+			var op string
+			if i+1 < len(expr) {
+				two := expr[i : i+2]
+				switch two {
+				case "!=", "==", "<=", ">=", "&&", "||":
+					op = two
+				}
+			}
+			if op == "" {
+				switch ch {
+				case '!', '<', '>':
+					op = string(ch)
+				default:
+					return nil, fmt.Errorf("unexpected character '%c' at position %d", ch, i)
+				}
+			}
+			tokens = append(tokens, expressionToken{kind: tokenOperator, value: op, pos: start})
+			i += len(op)
+		}
+	}
+	tokens = append(tokens, expressionToken{kind: tokenEOF, pos: len(expr)})
+	return tokens, nil
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
+
+func isHexLetter(ch byte) bool {
+	return (ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F')
+}
+
+func isIdentifierStart(ch byte) bool {
+	return ch == '_' || unicode.IsLetter(rune(ch))
+}
+
+func isIdentifierPart(ch byte) bool {
+	return isIdentifierStart(ch) || isDigit(ch) || ch == '-'
+}
+
+// expressionParser is a recursive descent parser over a token stream.
+// Operator precedence follows the GitHub Actions documentation, from lowest to highest:
+// ||, &&, == !=, < <= > >=, !, then property dereference and function calls.
+type expressionParser struct {
+	tokens []expressionToken
+	pos    int
+}
+
+// ParseExpression parses the body of a GitHub Actions expression (without the surrounding ${{ }})
+// into a ConditionNode tree built from the same node types used to generate job conditions
+func ParseExpression(expr string) (ConditionNode, error) {
+	tokens, err := tokenizeExpression(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &expressionParser{tokens: tokens}
+	if p.peek().kind == tokenEOF {
+		return nil, fmt.Errorf("empty expression")
+	}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected token '%s' at position %d", tok.value, tok.pos)
+	}
+	return node, nil
+}
+
+func (p *expressionParser) peek() expressionToken {
+	return p.tokens[p.pos]
+}
+
+func (p *expressionParser) next() expressionToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *expressionParser) isOperator(ops ...string) bool {
+	tok := p.peek()
+	if tok.kind != tokenOperator {
+		return false
+	}
+	for _, op := range ops {
+		if tok.value == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *expressionParser) expect(kind expressionTokenKind, what string) (expressionToken, error) {
+	tok := p.next()
+	if tok.kind != kind {
+		if tok.kind == tokenEOF {
+			return tok, fmt.Errorf("expected %s but reached end of expression", what)
+		}
+		return tok, fmt.Errorf("expected %s but found '%s' at position %d", what, tok.value, tok.pos)
+	}
+	return tok, nil
+}
+
+func (p *expressionParser) parseOr() (ConditionNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("||") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &OrNode{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *expressionParser) parseAnd() (ConditionNode, error) {
+	left, err := p.parseEquality()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("&&") {
+		p.next()
+		right, err := p.parseEquality()
+		if err != nil {
+			return nil, err
+		}
+		left = &AndNode{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *expressionParser) parseEquality() (ConditionNode, error) {
+	left, err := p.parseRelational()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("==", "!=") {
+		op := p.next().value
+		right, err := p.parseRelational()
+		if err != nil {
+			return nil, err
+		}
+		left = BuildComparison(left, op, right)
+	}
+	return left, nil
+}
+
+func (p *expressionParser) parseRelational() (ConditionNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("<", "<=", ">", ">=") {
+		op := p.next().value
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = BuildComparison(left, op, right)
+	}
+	return left, nil
+}
+
+func (p *expressionParser) parseUnary() (ConditionNode, error) {
+	if p.isOperator("!") {
+		p.next()
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &NotNode{Child: child}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *expressionParser) parsePrimary() (ConditionNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenLeftParen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenRightParen, "')'"); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case tokenString:
+		return BuildStringLiteral(tok.value), nil
+	case tokenNumber:
+		return BuildNumberLiteral(tok.value), nil
+	case tokenIdentifier:
+		switch tok.value {
+		case "true":
+			return BuildBooleanLiteral(true), nil
+		case "false":
+			return BuildBooleanLiteral(false), nil
+		case "null":
+			return &NullLiteralNode{}, nil
+		}
+		if p.peek().kind == tokenLeftParen {
+			return p.parseFunctionCall(tok.value)
+		}
+		return p.parsePropertyAccess(tok.value)
+	case tokenEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	default:
+		return nil, fmt.Errorf("unexpected token '%s' at position %d", tok.value, tok.pos)
+	}
+}
+
+func (p *expressionParser) parseFunctionCall(name string) (ConditionNode, error) {
+	p.next() // consume '('
+	var args []ConditionNode
+	if p.peek().kind != tokenRightParen {
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.peek().kind != tokenComma {
+				break
+			}
+			p.next()
+		}
+	}
+	if _, err := p.expect(tokenRightParen, "')' to close call to "+name); err != nil {
+		return nil, err
+	}
+	return BuildFunctionCall(name, args...), nil
+}
+
+// parsePropertyAccess reads a dereference chain such as github.event['issue'].labels.*.name.
+// Bracketed string indexes are normalized to dot notation so that allow-list checks see one canonical path;
+// dynamic indexes (e.g. github.event[env.FIELD]) are rejected because their target cannot be known statically.
+func (p *expressionParser) parsePropertyAccess(root string) (ConditionNode, error) {
+	var sb strings.Builder
+	sb.WriteString(root)
+	for {
+		switch p.peek().kind {
+		case tokenDot:
+			p.next()
+			tok := p.next()
+			switch tok.kind {
+			case tokenIdentifier:
+				sb.WriteString("." + tok.value)
+			case tokenStar:
+				sb.WriteString(".*")
+			default:
+				return nil, fmt.Errorf("expected property name after '.' at position %d", tok.pos)
+			}
+		case tokenLeftBracket:
+			p.next()
+			tok := p.next()
+			switch tok.kind {
+			case tokenString:
+				sb.WriteString("." + tok.value)
+			case tokenNumber:
+				sb.WriteString("[" + tok.value + "]")
+			case tokenStar:
+				sb.WriteString(".*")
+			default:
+				return nil, fmt.Errorf("dynamic property index at position %d is not supported", tok.pos)
+			}
+			if _, err := p.expect(tokenRightBracket, "']'"); err != nil {
+				return nil, err
+			}
+		default:
+			return BuildPropertyAccess(sb.String()), nil
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package workflow
+
+import (
+	"testing"
+)
+
+func TestParseExpression(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "property access",
+			input:    "github.event.issue.number",
+			expected: "github.event.issue.number",
+		},
+		{
+			name:     "or of properties",
+			input:    "github.event.issue.number || github.event.pull_request.number",
+			expected: "(github.event.issue.number) || (github.event.pull_request.number)",
+		},
+		{
+			name:     "and binds tighter than or",
+			input:    "a || b && c",
+			expected: "(a) || ((b) && (c))",
+		},
+		{
+			name:     "parentheses override precedence",
+			input:    "(a || b) && c",
+			expected: "((a) || (b)) && (c)",
+		},
+		{
+			name:     "negation",
+			input:    "!github.event.pull_request.draft",
+			expected: "!(github.event.pull_request.draft)",
+		},
+		{
+			name:     "comparison with string literal",
+			input:    "github.event_name == 'issues'",
+			expected: "github.event_name == 'issues'",
+		},
+		{
+			name:     "relational binds tighter than equality",
+			input:    "a < 1 == true",
+			expected: "a < 1 == true",
+		},
+		{
+			name:     "function call with arguments",
+			input:    "contains(github.event.issue.labels.*.name, 'bug')",
+			expected: "contains(github.event.issue.labels.*.name, 'bug')",
+		},
+		{
+			name:     "function call without arguments",
+			input:    "always()",
+			expected: "always()",
+		},
+		{
+			name:     "bracket string index normalized",
+			input:    "github.event['issue'].number",
+			expected: "github.event.issue.number",
+		},
+		{
+			name:     "bracket number index preserved",
+			input:    "github.event.release.assets[0].id",
+			expected: "github.event.release.assets[0].id",
+		},
+		{
+			name:     "hyphenated property names",
+			input:    "steps.my-step.outputs.result",
+			expected: "steps.my-step.outputs.result",
+		},
+		{
+			name:     "literals",
+			input:    "null != false && 1.5 >= -2",
+			expected: "(null != false) && (1.5 >= -2)",
+		},
+		{
+			name:     "escaped quote in string",
+			input:    "format('it''s {0}', github.actor)",
+			expected: "format('it's {0}', github.actor)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := ParseExpression(tt.input)
+			if err != nil {
+				t.Fatalf("ParseExpression(%q) returned error: %v", tt.input, err)
+			}
+			if got := node.Render(); got != tt.expected {
+				t.Errorf("ParseExpression(%q).Render() = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseExpressionNodeTypes(t *testing.T) {
+	node, err := ParseExpression("github.event.issue.number || startsWith(github.ref, 'refs/tags/')")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	or, ok := node.(*OrNode)
+	if !ok {
+		t.Fatalf("expected *OrNode, got %T", node)
+	}
+	if prop, ok := or.Left.(*PropertyAccessNode); !ok || prop.PropertyPath != "github.event.issue.number" {
+		t.Errorf("expected left to be PropertyAccessNode for github.event.issue.number, got %#v", or.Left)
+	}
+	call, ok := or.Right.(*FunctionCallNode)
+	if !ok {
+		t.Fatalf("expected right to be *FunctionCallNode, got %T", or.Right)
+	}
+	if call.FunctionName != "startsWith" || len(call.Arguments) != 2 {
+		t.Errorf("unexpected function call: %#v", call)
+	}
+	if lit, ok := call.Arguments[1].(*StringLiteralNode); !ok || lit.Value != "refs/tags/" {
+		t.Errorf("expected second argument to be string literal 'refs/tags/', got %#v", call.Arguments[1])
+	}
+}
+
+func TestParseExpressionErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "empty", input: ""},
+		{name: "whitespace only", input: "   "},
+		{name: "unterminated string", input: "'abc"},
+		{name: "unbalanced parenthesis", input: "(a || b"},
+		{name: "dangling operator", input: "a ||"},
+		{name: "trailing token", input: "a b"},
+		{name: "dynamic index", input: "github.event[env.FIELD]"},
+		{name: "unclosed call", input: "contains(a, b"},
+		{name: "unknown character", input: "a $ b"},
+		{name: "nested expression marker", input: "${{ github.workflow"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseExpression(tt.input); err == nil {
+				t.Errorf("ParseExpression(%q) expected error, got none", tt.input)
+			}
+		})
+	}
+}
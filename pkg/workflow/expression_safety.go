@@ -8,17 +8,30 @@ import (
 	"github.com/githubnext/gh-aw/pkg/constants"
 )
 
-// validateExpressionSafety checks that all GitHub Actions expressions in the markdown content
-// are in the allowed list and returns an error if any unauthorized expressions are found
-func validateExpressionSafety(markdownContent string) error {
-	// Regular expression to match GitHub Actions expressions: ${{ ... }}
+var (
+	// expressionRegex matches GitHub Actions expressions: ${{ ... }}
 	// Use (?s) flag to enable dotall mode so . matches newlines to capture multiline expressions
 	// Use non-greedy matching with .*? to handle nested braces properly
-	expressionRegex := regexp.MustCompile(`(?s)\$\{\{(.*?)\}\}`)
-	needsStepsRegex := regexp.MustCompile(`^(needs|steps)\.[a-zA-Z0-9_-]+(\.[a-zA-Z0-9_-]+)*$`)
-	inputsRegex := regexp.MustCompile(`^github\.event\.inputs\.[a-zA-Z0-9_-]+$`)
-	envRegex := regexp.MustCompile(`^env\.[a-zA-Z0-9_-]+$`)
+	expressionRegex = regexp.MustCompile(`(?s)\$\{\{(.*?)\}\}`)
+	needsStepsRegex = regexp.MustCompile(`^(needs|steps)\.[a-zA-Z0-9_-]+(\.[a-zA-Z0-9_-]+)*$`)
+	inputsRegex     = regexp.MustCompile(`^github\.event\.inputs\.[a-zA-Z0-9_-]+$`)
+	envRegex        = regexp.MustCompile(`^env\.[a-zA-Z0-9_-]+$`)
+)
+
+// safeExpressionFunctions lists the expression functions that may appear in markdown content.
+// These only compare or combine their arguments, so they are safe as long as every argument is.
+// toJSON/fromJSON are deliberately excluded since they can serialize whole context objects.
+var safeExpressionFunctions = map[string]bool{
+	"contains":   true,
+	"startswith": true,
+	"endswith":   true,
+	"format":     true,
+	"join":       true,
+}
 
+// validateExpressionSafety checks that all GitHub Actions expressions in the markdown content
+// are in the allowed list and returns an error if any unauthorized expressions are found
+func validateExpressionSafety(markdownContent string) error {
 	// Find all expressions in the markdown content
 	matches := expressionRegex.FindAllStringSubmatch(markdownContent, -1)
 
@@ -38,28 +51,9 @@ func validateExpressionSafety(markdownContent string) error {
 			continue
 		}
 
-		// Check if this expression is in the allowed list
-		allowed := false
-
-		// Check if this expression starts with "needs." or "steps." and is a simple property access
-		if needsStepsRegex.MatchString(expression) {
-			allowed = true
-		} else if inputsRegex.MatchString(expression) {
-			// Check if this expression matches github.event.inputs.* pattern
-			allowed = true
-		} else if envRegex.MatchString(expression) {
-			// check if this expression matches env.* pattern
-			allowed = true
-		} else {
-			for _, allowedExpr := range constants.AllowedExpressions {
-				if expression == allowedExpr {
-					allowed = true
-					break
-				}
-			}
-		}
-
-		if !allowed {
+		// Parse the expression and walk the tree; anything that fails to parse is unauthorized
+		node, err := ParseExpression(expression)
+		if err != nil || !isSafeExpressionNode(node) {
 			unauthorizedExpressions = append(unauthorizedExpressions, expression)
 		}
 	}
@@ -72,3 +66,48 @@ func validateExpressionSafety(markdownContent string) error {
 
 	return nil
 }
+
+// isSafeExpressionNode reports whether every property access reachable from node is allow-listed
+// and every function call is one of the safe expression functions
+func isSafeExpressionNode(node ConditionNode) bool {
+	switch n := node.(type) {
+	case *PropertyAccessNode:
+		return isAllowedPropertyPath(n.PropertyPath)
+	case *StringLiteralNode, *NumberLiteralNode, *BooleanLiteralNode, *NullLiteralNode:
+		return true
+	case *OrNode:
+		return isSafeExpressionNode(n.Left) && isSafeExpressionNode(n.Right)
+	case *AndNode:
+		return isSafeExpressionNode(n.Left) && isSafeExpressionNode(n.Right)
+	case *NotNode:
+		return isSafeExpressionNode(n.Child)
+	case *ComparisonNode:
+		return isSafeExpressionNode(n.Left) && isSafeExpressionNode(n.Right)
+	case *FunctionCallNode:
+		if !safeExpressionFunctions[strings.ToLower(n.FunctionName)] {
+			return false
+		}
+		for _, arg := range n.Arguments {
+			if !isSafeExpressionNode(arg) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// isAllowedPropertyPath checks a single context property path against the allowed list
+// and the needs.*, steps.*, github.event.inputs.* and env.* patterns
+func isAllowedPropertyPath(path string) bool {
+	if needsStepsRegex.MatchString(path) || inputsRegex.MatchString(path) || envRegex.MatchString(path) {
+		return true
+	}
+	for _, allowedExpr := range constants.AllowedExpressions {
+		if path == allowedExpr {
+			return true
+		}
+	}
+	return false
+}
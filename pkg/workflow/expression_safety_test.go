@@ -143,6 +143,56 @@ func TestValidateExpressionSafety(t *testing.T) {
 			content:     "Code example: `${{ github.workflow }}` and ```${{ github.repository }}```",
 			expectError: false,
 		},
+		{
+			name:        "allowed_or_of_allowed_properties",
+			content:     "Number: ${{ github.event.issue.number || github.event.pull_request.number }}",
+			expectError: false,
+		},
+		{
+			name:        "allowed_comparison_and_negation",
+			content:     "Success: ${{ !(github.event.workflow_run.conclusion == 'failure') && github.run_number > 1 }}",
+			expectError: false,
+		},
+		{
+			name:        "allowed_safe_function_over_allowed_context",
+			content:     "Release: ${{ startsWith(github.event.release.tag_name, 'v') }}",
+			expectError: false,
+		},
+		{
+			name:        "allowed_bracket_index_normalized",
+			content:     "Issue: ${{ github.event['issue'].number }}",
+			expectError: false,
+		},
+		{
+			name:           "unauthorized_attacker_controlled_in_or",
+			content:        "Body: ${{ github.event.issue.number || github.event.issue.body }}",
+			expectError:    true,
+			expectedErrors: []string{"github.event.issue.body"},
+		},
+		{
+			name:           "unauthorized_attacker_controlled_in_function",
+			content:        "Title: ${{ format('{0}', github.event.pull_request.title) }}",
+			expectError:    true,
+			expectedErrors: []string{"github.event.pull_request.title"},
+		},
+		{
+			name:           "unauthorized_bracket_index_to_body",
+			content:        "Body: ${{ github.event['issue']['body'] }}",
+			expectError:    true,
+			expectedErrors: []string{"github.event['issue']['body']"},
+		},
+		{
+			name:           "unauthorized_dynamic_index",
+			content:        "Dynamic: ${{ github.event[env.FIELD] }}",
+			expectError:    true,
+			expectedErrors: []string{"github.event[env.FIELD]"},
+		},
+		{
+			name:           "unauthorized_function",
+			content:        "Json: ${{ toJSON(github.event) }}",
+			expectError:    true,
+			expectedErrors: []string{"toJSON(github.event)"},
+		},
 		{
 			name:           "unauthorized_in_code_blocks",
 			content:        "Code example: `${{ secrets.TOKEN }}` should still be caught",
@@ -158,6 +158,13 @@ func (n *NumberLiteralNode) Render() string {
 	return n.Value
 }
 
+// NullLiteralNode represents the null literal
+type NullLiteralNode struct{}
+
+func (n *NullLiteralNode) Render() string {
+	return "null"
+}
+
 // ComparisonNode represents comparison operations like ==, !=, <, >, <=, >=
 type ComparisonNode struct {
 	Left     ConditionNode
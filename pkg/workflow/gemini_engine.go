@@ -0,0 +1,548 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultGeminiModel is the model used when no model is specified in the engine config
+	DefaultGeminiModel = "gemini-2.5-pro"
+
+	// geminiSystemSettingsPath is the Gemini CLI system settings file holding the MCP servers
+	geminiSystemSettingsPath = "/tmp/mcp-config/settings.json"
+)
+
+// defaultGeminiTools are the read-only Gemini CLI tools that are always available
+var defaultGeminiTools = []string{
+	"glob",
+	"list_directory",
+	"read_file",
+	"read_many_files",
+	"search_file_content",
+}
+
+// geminiWebTools are the Gemini CLI tools that reach the network without going through MCP servers
+var geminiWebTools = []string{
+	"google_web_search",
+	"web_fetch",
+}
+
+// GeminiEngine represents the Google Gemini CLI agentic engine (experimental)
+type GeminiEngine struct {
+	BaseEngine
+}
+
+// GeminiSettings represents the structure of the Gemini CLI settings.json
+type GeminiSettings struct {
+	MCPServers      map[string]GeminiMCPServer `json:"mcpServers,omitempty"`
+	CoreTools       []string                   `json:"coreTools,omitempty"`
+	ExcludeTools    []string                   `json:"excludeTools,omitempty"`
+	MaxSessionTurns int                        `json:"maxSessionTurns,omitempty"`
+}
+
+// GeminiMCPServer represents a single MCP server entry in the Gemini CLI settings
+type GeminiMCPServer struct {
+	Command      string            `json:"command,omitempty"`
+	Args         []string          `json:"args,omitempty"`
+	Env          map[string]string `json:"env,omitempty"`
+	HTTPURL      string            `json:"httpUrl,omitempty"`
+	Headers      map[string]string `json:"headers,omitempty"`
+	IncludeTools []string          `json:"includeTools,omitempty"`
+	Trust        bool              `json:"trust,omitempty"`
+}
+
+func NewGeminiEngine() *GeminiEngine {
+	return &GeminiEngine{
+		BaseEngine: BaseEngine{
+			id:                     "gemini",
+			displayName:            "Gemini CLI",
+			description:            "Uses Google Gemini CLI with MCP server support",
+			experimental:           true,
+			supportsToolsWhitelist: true,
+			supportsHTTPTransport:  true,  // Gemini supports both stdio and streamable HTTP transport
+			supportsMaxTurns:       true,  // Gemini supports maxSessionTurns
+			supportsBashPolicy:     false, // Gemini cannot express glob patterns, so the bash shim enforces it
+		},
+	}
+}
+
+func (e *GeminiEngine) GetInstallationSteps(workflowData *WorkflowData) []GitHubActionStep {
+	// Build the npm install command, optionally with version
+	installCmd := "npm install -g @google/gemini-cli"
+	if workflowData.EngineConfig != nil && workflowData.EngineConfig.Version != "" {
+		installCmd = fmt.Sprintf("npm install -g @google/gemini-cli@%s", workflowData.EngineConfig.Version)
+	}
+
+	return []GitHubActionStep{
+		{
+			"      - name: Setup Node.js",
+			"        uses: actions/setup-node@v4",
+			"        with:",
+			"          node-version: '24'",
+		},
+		{
+			"      - name: Install Gemini CLI",
+			fmt.Sprintf("        run: %s", installCmd),
+		},
+	}
+}
+
+// GetExecutionSteps returns the GitHub Actions steps for executing Gemini CLI
+func (e *GeminiEngine) GetExecutionSteps(workflowData *WorkflowData, logFile string) []GitHubActionStep {
+	var steps []GitHubActionStep
+
+	// Handle custom steps if they exist in engine config
+	if workflowData.EngineConfig != nil && len(workflowData.EngineConfig.Steps) > 0 {
+		for _, step := range workflowData.EngineConfig.Steps {
+			stepYAML, err := e.convertStepToYAML(step)
+			if err != nil {
+				// Log error but continue with other steps
+				continue
+			}
+			steps = append(steps, GitHubActionStep{stepYAML})
+		}
+	}
+
+	// Use model from engineConfig if available, otherwise default to gemini-2.5-pro
+	model := DefaultGeminiModel
+	if workflowData.EngineConfig != nil && workflowData.EngineConfig.Model != "" {
+		model = workflowData.EngineConfig.Model
+	}
+
+	settingsJSON := e.generateUserSettingsJSON(workflowData)
+
+	command := fmt.Sprintf(`set -o pipefail
+INSTRUCTION=$(cat /tmp/aw-prompts/prompt.txt)
+
+# Write tool restrictions to the Gemini user settings
+mkdir -p ~/.gemini
+cat > ~/.gemini/settings.json << 'EOF'
+%s
+EOF
+
+# Create log directory outside git repo
+mkdir -p /tmp/aw-logs
+
+# Run gemini with log capture - pipefail ensures gemini exit code is preserved
+gemini \
+  --model %s \
+  --yolo \
+  --output-format json \
+  --prompt "$INSTRUCTION" 2>&1 | tee %s`, settingsJSON, model, logFile)
+
+	env := map[string]string{
+		"GEMINI_API_KEY":                  "${{ secrets.GEMINI_API_KEY }}",
+		"GEMINI_CLI_SYSTEM_SETTINGS_PATH": geminiSystemSettingsPath,
+		"GITHUB_STEP_SUMMARY":             "${{ env.GITHUB_STEP_SUMMARY }}",
+		"GITHUB_AW_PROMPT":                "/tmp/aw-prompts/prompt.txt",
+	}
+
+	// Add GITHUB_AW_SAFE_OUTPUTS if output is needed
+	hasOutput := workflowData.SafeOutputs != nil
+	if hasOutput {
+		env["GITHUB_AW_SAFE_OUTPUTS"] = "${{ env.GITHUB_AW_SAFE_OUTPUTS }}"
+	}
+
+	if workflowData.EngineConfig != nil && workflowData.EngineConfig.MaxTurns != "" {
+		env["GITHUB_AW_MAX_TURNS"] = workflowData.EngineConfig.MaxTurns
+	}
+
+	// Add custom environment variables from engine config
+	if workflowData.EngineConfig != nil && len(workflowData.EngineConfig.Env) > 0 {
+		for key, value := range workflowData.EngineConfig.Env {
+			env[key] = value
+		}
+	}
+
+	// Run behind the bash policy shim, which custom environment variables cannot override
+	if usesBashPolicyShim(workflowData, e) {
+		env["PATH"] = bashPolicyShimPath
+	}
+
+	// Generate the step for Gemini execution
+	stepName := "Run Gemini"
+	var stepLines []string
+
+	stepLines = append(stepLines, fmt.Sprintf("      - name: %s", stepName))
+	stepLines = append(stepLines, "        run: |")
+
+	// Split command into lines and indent them properly
+	commandLines := strings.Split(command, "\n")
+	for _, line := range commandLines {
+		stepLines = append(stepLines, "          "+line)
+	}
+
+	// Add environment variables
+	stepLines = append(stepLines, "        env:")
+	// Sort environment keys for consistent output
+	envKeys := make([]string, 0, len(env))
+	for key := range env {
+		envKeys = append(envKeys, key)
+	}
+	sort.Strings(envKeys)
+
+	for _, key := range envKeys {
+		value := env[key]
+		stepLines = append(stepLines, fmt.Sprintf("          %s: %s", key, value))
+	}
+
+	steps = append(steps, GitHubActionStep(stepLines))
+
+	return steps
+}
+
+// convertStepToYAML converts a step map to YAML string - temporary helper
+func (e *GeminiEngine) convertStepToYAML(stepMap map[string]any) (string, error) {
+	// Simple YAML generation for steps - this mirrors the compiler logic
+	var stepYAML []string
+
+	// Add step name
+	if name, hasName := stepMap["name"]; hasName {
+		if nameStr, ok := name.(string); ok {
+			stepYAML = append(stepYAML, fmt.Sprintf("      - name: %s", nameStr))
+		}
+	}
+
+	// Add id field if present
+	if id, hasID := stepMap["id"]; hasID {
+		if idStr, ok := id.(string); ok {
+			stepYAML = append(stepYAML, fmt.Sprintf("        id: %s", idStr))
+		}
+	}
+
+	// Add continue-on-error field if present
+	if continueOnError, hasContinueOnError := stepMap["continue-on-error"]; hasContinueOnError {
+		// Handle both string and boolean values for continue-on-error
+		switch v := continueOnError.(type) {
+		case bool:
+			stepYAML = append(stepYAML, fmt.Sprintf("        continue-on-error: %t", v))
+		case string:
+			stepYAML = append(stepYAML, fmt.Sprintf("        continue-on-error: %s", v))
+		}
+	}
+
+	// Add uses action
+	if uses, hasUses := stepMap["uses"]; hasUses {
+		if usesStr, ok := uses.(string); ok {
+			stepYAML = append(stepYAML, fmt.Sprintf("        uses: %s", usesStr))
+		}
+	}
+
+	// Add run command
+	if run, hasRun := stepMap["run"]; hasRun {
+		if runStr, ok := run.(string); ok {
+			stepYAML = append(stepYAML, "        run: |")
+			// Split command into lines and indent them properly
+			runLines := strings.Split(runStr, "\n")
+			for _, line := range runLines {
+				stepYAML = append(stepYAML, "          "+line)
+			}
+		}
+	}
+
+	// Add with parameters
+	if with, hasWith := stepMap["with"]; hasWith {
+		if withMap, ok := with.(map[string]any); ok {
+			stepYAML = append(stepYAML, "        with:")
+			// Sort keys for stable output
+			keys := make([]string, 0, len(withMap))
+			for key := range withMap {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				stepYAML = append(stepYAML, fmt.Sprintf("          %s: %v", key, withMap[key]))
+			}
+		}
+	}
+
+	return strings.Join(stepYAML, "\n"), nil
+}
+
+// generateUserSettingsJSON generates the Gemini user settings restricting the built-in tools
+func (e *GeminiEngine) generateUserSettingsJSON(workflowData *WorkflowData) string {
+	settings := GeminiSettings{
+		CoreTools: e.computeGeminiCoreTools(workflowData.Tools, workflowData.SafeOutputs, workflowData.NetworkPermissions),
+	}
+
+	// Gemini cannot restrict its web tools to a domain allow-list, so they are disabled
+	// whenever network permissions are enforced
+	if ShouldEnforceNetworkPermissions(workflowData.NetworkPermissions) {
+		settings.ExcludeTools = geminiWebTools
+	}
+
+	if workflowData.EngineConfig != nil && workflowData.EngineConfig.MaxTurns != "" {
+		if maxTurns, err := strconv.Atoi(workflowData.EngineConfig.MaxTurns); err == nil {
+			settings.MaxSessionTurns = maxTurns
+		}
+	}
+
+	settingsJSON, _ := json.MarshalIndent(settings, "", "  ")
+	return string(settingsJSON)
+}
+
+// computeGeminiCoreTools converts neutral tools to the list of enabled Gemini CLI built-in tools
+func (e *GeminiEngine) computeGeminiCoreTools(tools map[string]any, safeOutputs *SafeOutputsConfig, network *NetworkPermissions) []string {
+	coreTools := make(map[string]bool)
+	for _, tool := range defaultGeminiTools {
+		coreTools[tool] = true
+	}
+
+	// bash -> run_shell_command (command restrictions are enforced by the bash policy shim)
+	if _, hasBash := tools["bash"]; hasBash {
+		coreTools["run_shell_command"] = true
+	}
+
+	// edit -> replace, write_file
+	if _, hasEdit := tools["edit"]; hasEdit {
+		coreTools["replace"] = true
+		coreTools["write_file"] = true
+	}
+
+	// web-fetch -> web_fetch, web-search -> google_web_search (unless network permissions are enforced)
+	if !ShouldEnforceNetworkPermissions(network) {
+		if _, hasWebFetch := tools["web-fetch"]; hasWebFetch {
+			coreTools["web_fetch"] = true
+		}
+		if _, hasWebSearch := tools["web-search"]; hasWebSearch {
+			coreTools["google_web_search"] = true
+		}
+	}
+
+	// Safe outputs are written to the GITHUB_AW_SAFE_OUTPUTS file
+	if safeOutputs != nil {
+		coreTools["write_file"] = true
+	}
+
+	var result []string
+	for tool := range coreTools {
+		result = append(result, tool)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func (e *GeminiEngine) RenderMCPConfig(yaml *strings.Builder, tools map[string]any, mcpTools []string) {
+	settings := GeminiSettings{
+		MCPServers: make(map[string]GeminiMCPServer),
+	}
+
+	for _, toolName := range mcpTools {
+		switch toolName {
+		case "github":
+			githubTool := tools["github"]
+			settings.MCPServers["github"] = e.buildGitHubGeminiMCPServer(githubTool)
+		default:
+			// Handle custom MCP tools (those with MCP-compatible type)
+			if toolConfig, ok := tools[toolName].(map[string]any); ok {
+				if hasMcp, _ := hasMCPConfig(toolConfig); hasMcp {
+					server, err := e.buildGeminiMCPServer(toolName, toolConfig)
+					if err != nil {
+						fmt.Printf("Error generating custom MCP configuration for %s: %v\n", toolName, err)
+						continue
+					}
+					settings.MCPServers[toolName] = server
+				}
+			}
+		}
+	}
+
+	settingsJSON, _ := json.MarshalIndent(settings, "", "  ")
+
+	fmt.Fprintf(yaml, "          cat > %s << 'EOF'\n", geminiSystemSettingsPath)
+	for _, line := range strings.Split(string(settingsJSON), "\n") {
+		yaml.WriteString("          " + line + "\n")
+	}
+	yaml.WriteString("          EOF\n")
+}
+
+// buildGitHubGeminiMCPServer generates the GitHub MCP server configuration
+// Always uses Docker MCP as the default
+func (e *GeminiEngine) buildGitHubGeminiMCPServer(githubTool any) GeminiMCPServer {
+	githubDockerImageVersion := getGitHubDockerImageVersion(githubTool)
+
+	server := GeminiMCPServer{
+		Command: "docker",
+		Args: []string{
+			"run",
+			"-i",
+			"--rm",
+			"-e",
+			"GITHUB_PERSONAL_ACCESS_TOKEN",
+			"ghcr.io/github/github-mcp-server:" + githubDockerImageVersion,
+		},
+		Env: map[string]string{
+			"GITHUB_PERSONAL_ACCESS_TOKEN": "${{ secrets.GITHUB_TOKEN }}",
+		},
+		Trust: true,
+	}
+
+	if toolConfig, ok := githubTool.(map[string]any); ok {
+		server.IncludeTools = geminiIncludeTools(toolConfig)
+	}
+
+	return server
+}
+
+// buildGeminiMCPServer generates custom MCP server configuration for a single tool in Gemini settings
+func (e *GeminiEngine) buildGeminiMCPServer(toolName string, toolConfig map[string]any) (GeminiMCPServer, error) {
+	var server GeminiMCPServer
+
+	mcpConfig, err := getMCPConfig(toolConfig, toolName)
+	if err != nil {
+		return server, fmt.Errorf("failed to parse MCP config for tool '%s': %w", toolName, err)
+	}
+
+	if command, ok := mcpConfig["command"].(string); ok {
+		server.Command = command
+	}
+	if args, ok := mcpConfig["args"].([]any); ok {
+		server.Args = parseStringList(args)
+	}
+	if env, ok := mcpConfig["env"].(map[string]any); ok {
+		server.Env = make(map[string]string)
+		for key, value := range env {
+			if valueStr, ok := value.(string); ok {
+				server.Env[key] = valueStr
+			}
+		}
+	}
+	if url, ok := mcpConfig["url"].(string); ok {
+		// Gemini uses httpUrl for streamable HTTP servers (url is reserved for SSE)
+		server.HTTPURL = url
+	}
+	if headers, ok := mcpConfig["headers"].(map[string]any); ok {
+		server.Headers = make(map[string]string)
+		for key, value := range headers {
+			if valueStr, ok := value.(string); ok {
+				server.Headers[key] = valueStr
+			}
+		}
+	}
+
+	server.IncludeTools = geminiIncludeTools(toolConfig)
+	server.Trust = true
+
+	return server, nil
+}
+
+// geminiIncludeTools returns the allowed MCP tools of a server, or nil when all tools are allowed
+func geminiIncludeTools(toolConfig map[string]any) []string {
+	allowed, ok := toolConfig["allowed"].([]any)
+	if !ok {
+		return nil
+	}
+	includeTools := parseStringList(allowed)
+	for _, tool := range includeTools {
+		if tool == "*" {
+			return nil
+		}
+	}
+	return includeTools
+}
+
+// ParseLogMetrics implements engine-specific log parsing for Gemini
+func (e *GeminiEngine) ParseLogMetrics(logContent string, verbose bool) LogMetrics {
+	var metrics LogMetrics
+
+	// Gemini prints a single JSON result object after any diagnostic output
+	result, resultStart := e.findGeminiResult(logContent)
+	if result != nil {
+		metrics.TokenUsage = e.extractGeminiTokenUsage(result)
+		if resultError, exists := result["error"]; exists && resultError != nil {
+			metrics.ErrorCount++
+		}
+		if verbose {
+			fmt.Printf("Extracted from Gemini result: tokens=%d\n", metrics.TokenUsage)
+		}
+	} else {
+		resultStart = len(logContent)
+	}
+
+	// Count errors and warnings in the diagnostic output preceding the result
+	lines := strings.Split(logContent[:resultStart], "\n")
+	for _, line := range lines {
+		// Skip empty lines
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		lowerLine := strings.ToLower(line)
+		if strings.Contains(lowerLine, "error") {
+			metrics.ErrorCount++
+		}
+		if strings.Contains(lowerLine, "warning") {
+			metrics.WarningCount++
+		}
+	}
+
+	return metrics
+}
+
+// findGeminiResult locates the JSON result object in the log and returns it with its start offset
+func (e *GeminiEngine) findGeminiResult(logContent string) (map[string]any, int) {
+	for offset := 0; offset < len(logContent); {
+		idx := strings.Index(logContent[offset:], "{")
+		if idx < 0 {
+			break
+		}
+		start := offset + idx
+
+		// Only consider objects that start at the beginning of a line
+		if start == 0 || logContent[start-1] == '\n' {
+			var result map[string]any
+			decoder := json.NewDecoder(strings.NewReader(logContent[start:]))
+			if err := decoder.Decode(&result); err == nil {
+				if _, hasStats := result["stats"]; hasStats {
+					return result, start
+				}
+				if _, hasResponse := result["response"]; hasResponse {
+					return result, start
+				}
+			}
+		}
+		offset = start + 1
+	}
+	return nil, 0
+}
+
+// extractGeminiTokenUsage sums the total tokens across all models in the result stats
+func (e *GeminiEngine) extractGeminiTokenUsage(result map[string]any) int {
+	stats, ok := result["stats"].(map[string]any)
+	if !ok {
+		return 0
+	}
+	models, ok := stats["models"].(map[string]any)
+	if !ok {
+		return 0
+	}
+
+	totalTokens := 0
+	for _, modelStats := range models {
+		modelMap, ok := modelStats.(map[string]any)
+		if !ok {
+			continue
+		}
+		if tokens, ok := modelMap["tokens"].(map[string]any); ok {
+			totalTokens += ConvertToInt(tokens["total"])
+		}
+	}
+	return totalTokens
+}
+
+// GetLogParserScript returns the JavaScript script name for parsing Gemini logs
+func (e *GeminiEngine) GetLogParserScript() string {
+	return "parse_gemini_log"
+}
+
+// geminiInfrastructureErrorLine matches the lines Gemini CLI prints when a Gemini API call fails
+const geminiInfrastructureErrorLine = `^(\[API Error: |Error when talking to Gemini API)`
+
+// GetInfrastructureErrorCheck checks the Gemini CLI output for a Gemini API error
+func (e *GeminiEngine) GetInfrastructureErrorCheck(logFile string) string {
+	return fmt.Sprintf("grep -qE '%s' %s 2>/dev/null", geminiInfrastructureErrorLine, logFile)
+}
@@ -0,0 +1,264 @@
+package workflow
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGeminiEngine(t *testing.T) {
+	engine := NewGeminiEngine()
+
+	// Test basic properties
+	if engine.GetID() != "gemini" {
+		t.Errorf("Expected ID 'gemini', got '%s'", engine.GetID())
+	}
+
+	if engine.GetDisplayName() != "Gemini CLI" {
+		t.Errorf("Expected display name 'Gemini CLI', got '%s'", engine.GetDisplayName())
+	}
+
+	if !engine.IsExperimental() {
+		t.Error("Gemini engine should be experimental")
+	}
+
+	if !engine.SupportsToolsWhitelist() || !engine.SupportsHTTPTransport() || !engine.SupportsMaxTurns() {
+		t.Error("Gemini engine should support tool allow-listing, HTTP transport and max-turns")
+	}
+
+	if engine.SupportsBashPolicy() {
+		t.Error("Gemini engine should rely on the bash policy shim")
+	}
+
+	if engine.GetLogParserScript() != "parse_gemini_log" {
+		t.Errorf("Expected log parser 'parse_gemini_log', got '%s'", engine.GetLogParserScript())
+	}
+
+	// Test installation steps
+	steps := engine.GetInstallationSteps(&WorkflowData{})
+	if len(steps) != 2 {
+		t.Fatalf("Expected 2 installation steps, got %d", len(steps))
+	}
+	if !strings.Contains(strings.Join(steps[1], "\n"), "npm install -g @google/gemini-cli") {
+		t.Errorf("Expected Gemini CLI install step, got:\n%s", strings.Join(steps[1], "\n"))
+	}
+
+	steps = engine.GetInstallationSteps(&WorkflowData{EngineConfig: &EngineConfig{ID: "gemini", Version: "0.5.0"}})
+	if !strings.Contains(strings.Join(steps[1], "\n"), "npm install -g @google/gemini-cli@0.5.0") {
+		t.Errorf("Expected versioned Gemini CLI install step, got:\n%s", strings.Join(steps[1], "\n"))
+	}
+}
+
+func TestGeminiEngineExecutionSteps(t *testing.T) {
+	engine := NewGeminiEngine()
+
+	t.Run("defaults", func(t *testing.T) {
+		workflowData := &WorkflowData{
+			Name:  "test-workflow",
+			Tools: map[string]any{"bash": nil, "web-fetch": nil},
+		}
+		execSteps := engine.GetExecutionSteps(workflowData, "test-log")
+		if len(execSteps) != 1 {
+			t.Fatalf("Expected 1 step for Gemini execution, got %d", len(execSteps))
+		}
+		stepContent := strings.Join([]string(execSteps[0]), "\n")
+
+		expected := []string{
+			"name: Run Gemini",
+			"set -o pipefail",
+			"--model gemini-2.5-pro",
+			"--output-format json",
+			"tee test-log",
+			`"run_shell_command"`,
+			`"web_fetch"`,
+			"GEMINI_API_KEY: ${{ secrets.GEMINI_API_KEY }}",
+			"GEMINI_CLI_SYSTEM_SETTINGS_PATH: /tmp/mcp-config/settings.json",
+		}
+		for _, want := range expected {
+			if !strings.Contains(stepContent, want) {
+				t.Errorf("Expected %q in step content:\n%s", want, stepContent)
+			}
+		}
+		if strings.Contains(stepContent, "excludeTools") || strings.Contains(stepContent, "maxSessionTurns") {
+			t.Errorf("Expected no tool exclusions or turn limit in step content:\n%s", stepContent)
+		}
+	})
+
+	t.Run("engine config, safe outputs and network permissions", func(t *testing.T) {
+		workflowData := &WorkflowData{
+			Name:               "test-workflow",
+			Tools:              map[string]any{"web-fetch": nil, "web-search": nil},
+			SafeOutputs:        &SafeOutputsConfig{},
+			NetworkPermissions: &NetworkPermissions{Allowed: []string{"example.com"}},
+			EngineConfig: &EngineConfig{
+				ID:       "gemini",
+				Model:    "gemini-2.5-flash",
+				MaxTurns: "7",
+				Env:      map[string]string{"CUSTOM_VAR": "value"},
+			},
+		}
+		stepContent := strings.Join([]string(engine.GetExecutionSteps(workflowData, "test-log")[0]), "\n")
+
+		expected := []string{
+			"--model gemini-2.5-flash",
+			`"maxSessionTurns": 7`,
+			`"write_file"`,
+			`"excludeTools": [`,
+			"GITHUB_AW_MAX_TURNS: 7",
+			"GITHUB_AW_SAFE_OUTPUTS: ${{ env.GITHUB_AW_SAFE_OUTPUTS }}",
+			"CUSTOM_VAR: value",
+		}
+		for _, want := range expected {
+			if !strings.Contains(stepContent, want) {
+				t.Errorf("Expected %q in step content:\n%s", want, stepContent)
+			}
+		}
+
+		coreTools := engine.computeGeminiCoreTools(workflowData.Tools, workflowData.SafeOutputs, workflowData.NetworkPermissions)
+		for _, tool := range coreTools {
+			if tool == "web_fetch" || tool == "google_web_search" {
+				t.Errorf("Expected web tools to be disabled under network permissions, got %v", coreTools)
+			}
+		}
+	})
+}
+
+func TestGeminiEngineRenderMCPConfig(t *testing.T) {
+	engine := NewGeminiEngine()
+
+	tools := map[string]any{
+		"github": map[string]any{
+			"allowed": []any{"get_issue", "add_issue_comment"},
+		},
+		"remote": map[string]any{
+			"mcp": map[string]any{
+				"type":    "http",
+				"url":     "https://mcp.example.com/mcp",
+				"headers": map[string]any{"Authorization": "Bearer ${{ secrets.TOKEN }}"},
+			},
+			"allowed": []any{"*"},
+		},
+	}
+
+	var yaml strings.Builder
+	engine.RenderMCPConfig(&yaml, tools, []string{"github", "remote"})
+	output := yaml.String()
+
+	expected := []string{
+		"cat > /tmp/mcp-config/settings.json << 'EOF'",
+		`"mcpServers": {`,
+		`"ghcr.io/github/github-mcp-server:sha-09deac4"`,
+		`"includeTools": [`,
+		`"add_issue_comment"`,
+		`"httpUrl": "https://mcp.example.com/mcp"`,
+		`"Authorization": "Bearer ${{ secrets.TOKEN }}"`,
+		"          EOF",
+	}
+	for _, want := range expected {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in MCP config:\n%s", want, output)
+		}
+	}
+
+	// Wildcard access must not restrict the server tools
+	remoteIdx := strings.Index(output, `"remote": {`)
+	if remoteIdx < 0 || strings.Contains(output[remoteIdx:], "includeTools") {
+		t.Errorf("Expected no includeTools for wildcard server:\n%s", output)
+	}
+}
+
+func TestGeminiEngineParseLogMetrics(t *testing.T) {
+	engine := NewGeminiEngine()
+
+	logContent := `Loaded cached credentials.
+Warning: skipping unreadable file
+{
+  "response": "Done.",
+  "stats": {
+    "models": {
+      "gemini-2.5-pro": {
+        "api": { "totalRequests": 3, "totalErrors": 0, "totalLatencyMs": 5000 },
+        "tokens": { "prompt": 1200, "candidates": 300, "total": 1600, "cached": 0, "thoughts": 100, "tool": 0 }
+      },
+      "gemini-2.5-flash": {
+        "api": { "totalRequests": 1, "totalErrors": 0, "totalLatencyMs": 400 },
+        "tokens": { "prompt": 200, "candidates": 50, "total": 250 }
+      }
+    },
+    "tools": { "totalCalls": 2, "totalSuccess": 2, "totalFail": 0, "byName": {} }
+  }
+}`
+
+	metrics := engine.ParseLogMetrics(logContent, false)
+	if metrics.TokenUsage != 1850 {
+		t.Errorf("Expected token usage 1850, got %d", metrics.TokenUsage)
+	}
+	if metrics.WarningCount != 1 {
+		t.Errorf("Expected 1 warning, got %d", metrics.WarningCount)
+	}
+	if metrics.ErrorCount != 0 {
+		t.Errorf("Expected 0 errors, got %d", metrics.ErrorCount)
+	}
+
+	errorLog := `{"response": "", "stats": {"models": {}}, "error": {"type": "FatalAuthenticationError", "message": "API key not valid"}}`
+	metrics = engine.ParseLogMetrics(errorLog, false)
+	if metrics.ErrorCount != 1 {
+		t.Errorf("Expected 1 error from result payload, got %d", metrics.ErrorCount)
+	}
+
+	metrics = engine.ParseLogMetrics("Error: gemini exited\nno result", false)
+	if metrics.ErrorCount != 1 || metrics.TokenUsage != 0 {
+		t.Errorf("Expected 1 error and no tokens without result, got %+v", metrics)
+	}
+}
+
+func TestGeminiEngineCompile(t *testing.T) {
+	tmpDir := t.TempDir()
+	workflowPath := filepath.Join(tmpDir, "test-gemini.md")
+	content := `---
+on: push
+permissions:
+  contents: read
+engine:
+  id: gemini
+  max-turns: 5
+tools:
+  github:
+    allowed: [get_issue]
+  bash: ["git status"]
+safe-outputs:
+  add-issue-comment:
+---
+
+# Gemini Test
+
+Summarize the repository.`
+	if err := os.WriteFile(workflowPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	compiler := NewCompiler(false, "", "test")
+	if err := compiler.CompileWorkflow(workflowPath); err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+
+	lockContent, err := os.ReadFile(strings.TrimSuffix(workflowPath, ".md") + ".lock.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock := string(lockContent)
+
+	expected := []string{
+		"- name: Install Gemini CLI",
+		"- name: Setup Bash Policy Shim",
+		"- name: Run Gemini",
+		`engine_id: "gemini"`,
+		"Gemini log parsed successfully",
+	}
+	for _, want := range expected {
+		if !strings.Contains(lock, want) {
+			t.Errorf("Expected %q in lock file", want)
+		}
+	}
+}
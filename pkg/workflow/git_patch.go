@@ -1,6 +1,9 @@
 package workflow
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
 
 // generateGitPatchStep generates a step that creates and uploads a git patch of changes
 func (c *Compiler) generateGitPatchStep(yaml *strings.Builder, data *WorkflowData) {
@@ -124,7 +127,7 @@ func (c *Compiler) generateGitPatchStep(yaml *strings.Builder, data *WorkflowDat
 	yaml.WriteString("        if: always()\n")
 	yaml.WriteString("        uses: actions/upload-artifact@v4\n")
 	yaml.WriteString("        with:\n")
-	yaml.WriteString("          name: aw.patch\n")
+	fmt.Fprintf(yaml, "          name: %s\n", artifactName(data, "aw.patch"))
 	yaml.WriteString("          path: /tmp/aw.patch\n")
 	yaml.WriteString("          if-no-files-found: ignore\n")
 }
@@ -13,6 +13,7 @@ type Job struct {
 	If             string
 	Permissions    string
 	TimeoutMinutes int
+	Strategy       string // Rendered strategy section, e.g. an engine matrix
 	Steps          []string
 	Depends        []string // Job dependencies (needs clause)
 	Outputs        map[string]string
@@ -177,6 +178,11 @@ func (jm *JobManager) renderJob(job *Job) string {
 		yaml.WriteString(fmt.Sprintf("    timeout-minutes: %d\n", job.TimeoutMinutes))
 	}
 
+	// Add strategy section if present
+	if job.Strategy != "" {
+		yaml.WriteString(fmt.Sprintf("    %s\n", job.Strategy))
+	}
+
 	// Add outputs section
 	if len(job.Outputs) > 0 {
 		yaml.WriteString("    outputs:\n")
@@ -57,9 +57,18 @@ var parseClaudeLogScript string
 //go:embed js/parse_codex_log.cjs
 var parseCodexLogScript string
 
+//go:embed js/parse_gemini_log.cjs
+var parseGeminiLogScript string
+
 //go:embed js/missing_tool.cjs
 var missingToolScript string
 
+//go:embed js/verify_lock_file.cjs
+var verifyLockFileScript string
+
+//go:embed js/compare_engine_outputs.cjs
+var compareEngineOutputsScript string
+
 // FormatJavaScriptForYAML formats a JavaScript script with proper indentation for embedding in YAML
 func FormatJavaScriptForYAML(script string) []string {
 	var formattedLines []string
@@ -91,6 +100,8 @@ func GetLogParserScript(name string) string {
 		return parseClaudeLogScript
 	case "parse_codex_log":
 		return parseCodexLogScript
+	case "parse_gemini_log":
+		return parseGeminiLogScript
 	default:
 		return ""
 	}
@@ -4,14 +4,21 @@ async function main() {
   /**
    * Sanitizes content for safe output in GitHub Actions
    * @param {string} content - The content to sanitize
+   * @param {Object} [options] - Sanitize policy for the output type (see getSanitizeOptions)
    * @returns {string} The sanitized content
    */
-  function sanitizeContent(content) {
+  function sanitizeContent(content, options) {
     if (!content || typeof content !== "string") {
       return "";
     }
+    options = options || {};
 
-    // Read allowed domains from environment variable
+    // Handles that may be mentioned without neutralization
+    const allowedMentions = (options["allowed-mentions"] || []).map(m =>
+      m.replace(/^@/, "").toLowerCase()
+    );
+
+    // Read allowed domains from the sanitize policy or environment variable
     const allowedDomainsEnv = process.env.GITHUB_AW_ALLOWED_DOMAINS;
     const defaultAllowedDomains = [
       "github.com",
@@ -22,12 +29,17 @@ async function main() {
       "codespaces.new",
     ];
 
-    const allowedDomains = allowedDomainsEnv
-      ? allowedDomainsEnv
-          .split(",")
-          .map(d => d.trim())
-          .filter(d => d)
-      : defaultAllowedDomains;
+    // An empty list falls back to the defaults instead of redacting every URL
+    const configuredDomains = options["allowed-url-domains"];
+    const allowedDomains =
+      Array.isArray(configuredDomains) && configuredDomains.length > 0
+        ? configuredDomains
+        : allowedDomainsEnv
+          ? allowedDomainsEnv
+              .split(",")
+              .map(d => d.trim())
+              .filter(d => d)
+          : defaultAllowedDomains;
 
     let sanitized = content;
 
@@ -37,6 +49,21 @@ async function main() {
     // Remove control characters (except newlines and tabs)
     sanitized = sanitized.replace(/[\x00-\x08\x0B\x0C\x0E-\x1F\x7F]/g, "");
 
+    // Remove HTML tags and comments instead of escaping them
+    if (options["strip-html"]) {
+      sanitized = sanitized
+        .replace(/<!--[\s\S]*?-->/g, "")
+        .replace(/<\/?[A-Za-z][^<>]*>/g, "");
+    }
+
+    // Escape code fences so the content renders as plain text
+    if (options["allow-code-fences"] === false) {
+      sanitized = sanitized.replace(
+        /^(\s*)(`{3,}|~{3,})/gm,
+        (_m, indent, fence) => indent + fence.replace(/(.)/g, "\\$1")
+      );
+    }
+
     // XML character escaping
     sanitized = sanitized
       .replace(/&/g, "&amp;") // Must be first to avoid double-escaping
@@ -51,8 +78,8 @@ async function main() {
     // Domain filtering for HTTPS URIs
     sanitized = sanitizeUrlDomains(sanitized);
 
-    // Limit total length to prevent DoS (0.5MB max)
-    const maxLength = 524288;
+    // Limit total length to prevent DoS (0.5MB max unless configured)
+    const maxLength = options["max-length"] || 524288;
     if (sanitized.length > maxLength) {
       sanitized =
         sanitized.substring(0, maxLength) +
@@ -128,7 +155,10 @@ async function main() {
       // Replace @name or @org/team outside code with `@name`
       return s.replace(
         /(^|[^\w`])@([A-Za-z0-9](?:[A-Za-z0-9-]{0,37}[A-Za-z0-9])?(?:\/[A-Za-z0-9._-]+)?)/g,
-        (_m, p1, p2) => `${p1}\`@${p2}\``
+        (_m, p1, p2) =>
+          allowedMentions.includes(p2.toLowerCase())
+            ? `${p1}@${p2}`
+            : `${p1}\`@${p2}\``
       );
     }
 
@@ -146,6 +176,24 @@ async function main() {
     }
   }
 
+  /**
+   * Gets the sanitize policy for a given output type
+   * @param {string} itemType - The output item type
+   * @param {Object} config - The safe-outputs configuration
+   * @returns {Object} The sanitize policy (empty for built-in defaults)
+   */
+  function getSanitizeOptions(itemType, config) {
+    if (
+      config &&
+      config[itemType] &&
+      typeof config[itemType] === "object" &&
+      config[itemType].sanitize
+    ) {
+      return config[itemType].sanitize;
+    }
+    return {};
+  }
+
   /**
    * Gets the maximum allowed count for a given output type
    * @param {string} itemType - The output item type
@@ -368,6 +416,12 @@ async function main() {
         continue;
       }
 
+      // Sanitize policy configured for this output type
+      const sanitizeOptions = getSanitizeOptions(
+        itemType,
+        expectedOutputTypes
+      );
+
       // Basic validation based on type
       switch (itemType) {
         case "create-issue":
@@ -384,12 +438,14 @@ async function main() {
             continue;
           }
           // Sanitize text content
-          item.title = sanitizeContent(item.title);
-          item.body = sanitizeContent(item.body);
+          item.title = sanitizeContent(item.title, sanitizeOptions);
+          item.body = sanitizeContent(item.body, sanitizeOptions);
           // Sanitize labels if present
           if (item.labels && Array.isArray(item.labels)) {
             item.labels = item.labels.map(label =>
-              typeof label === "string" ? sanitizeContent(label) : label
+              typeof label === "string"
+                ? sanitizeContent(label, sanitizeOptions)
+                : label
             );
           }
           break;
@@ -402,7 +458,7 @@ async function main() {
             continue;
           }
           // Sanitize text content
-          item.body = sanitizeContent(item.body);
+          item.body = sanitizeContent(item.body, sanitizeOptions);
           break;
 
         case "create-pull-request":
@@ -419,16 +475,18 @@ async function main() {
             continue;
           }
           // Sanitize text content
-          item.title = sanitizeContent(item.title);
-          item.body = sanitizeContent(item.body);
+          item.title = sanitizeContent(item.title, sanitizeOptions);
+          item.body = sanitizeContent(item.body, sanitizeOptions);
           // Sanitize branch name if present
           if (item.branch && typeof item.branch === "string") {
-            item.branch = sanitizeContent(item.branch);
+            item.branch = sanitizeContent(item.branch, sanitizeOptions);
           }
           // Sanitize labels if present
           if (item.labels && Array.isArray(item.labels)) {
             item.labels = item.labels.map(label =>
-              typeof label === "string" ? sanitizeContent(label) : label
+              typeof label === "string"
+                ? sanitizeContent(label, sanitizeOptions)
+                : label
             );
           }
           break;
@@ -447,7 +505,9 @@ async function main() {
             continue;
           }
           // Sanitize label strings
-          item.labels = item.labels.map(label => sanitizeContent(label));
+          item.labels = item.labels.map(label =>
+            sanitizeContent(label, sanitizeOptions)
+          );
           break;
 
         case "update-issue":
@@ -482,7 +542,7 @@ async function main() {
               );
               continue;
             }
-            item.title = sanitizeContent(item.title);
+            item.title = sanitizeContent(item.title, sanitizeOptions);
           }
           // Validate body if provided
           if (item.body !== undefined) {
@@ -492,7 +552,7 @@ async function main() {
               );
               continue;
             }
-            item.body = sanitizeContent(item.body);
+            item.body = sanitizeContent(item.body, sanitizeOptions);
           }
           // Validate issue_number if provided (for target "*")
           if (item.issue_number !== undefined) {
@@ -517,7 +577,7 @@ async function main() {
               );
               continue;
             }
-            item.message = sanitizeContent(item.message);
+            item.message = sanitizeContent(item.message, sanitizeOptions);
           }
           // Validate pull_request_number if provided (for target "*")
           if (item.pull_request_number !== undefined) {
@@ -571,7 +631,7 @@ async function main() {
             continue;
           }
           // Sanitize required text content
-          item.body = sanitizeContent(item.body);
+          item.body = sanitizeContent(item.body, sanitizeOptions);
           // Validate optional start_line field
           if (item.start_line !== undefined) {
             if (
@@ -631,8 +691,8 @@ async function main() {
             continue;
           }
           // Sanitize text content
-          item.title = sanitizeContent(item.title);
-          item.body = sanitizeContent(item.body);
+          item.title = sanitizeContent(item.title, sanitizeOptions);
+          item.body = sanitizeContent(item.body, sanitizeOptions);
           break;
 
         case "missing-tool":
@@ -651,8 +711,8 @@ async function main() {
             continue;
           }
           // Sanitize text content
-          item.tool = sanitizeContent(item.tool);
-          item.reason = sanitizeContent(item.reason);
+          item.tool = sanitizeContent(item.tool, sanitizeOptions);
+          item.reason = sanitizeContent(item.reason, sanitizeOptions);
           // Validate optional alternatives field
           if (item.alternatives !== undefined) {
             if (typeof item.alternatives !== "string") {
@@ -661,7 +721,10 @@ async function main() {
               );
               continue;
             }
-            item.alternatives = sanitizeContent(item.alternatives);
+            item.alternatives = sanitizeContent(
+              item.alternatives,
+              sanitizeOptions
+            );
           }
           break;
 
@@ -685,7 +748,7 @@ async function main() {
           }
           // If SARIF is a string, sanitize it
           if (typeof item.sarif === "string") {
-            item.sarif = sanitizeContent(item.sarif);
+            item.sarif = sanitizeContent(item.sarif, sanitizeOptions);
           }
           // Validate optional category field
           if (item.category !== undefined) {
@@ -695,7 +758,7 @@ async function main() {
               );
               continue;
             }
-            item.category = sanitizeContent(item.category);
+            item.category = sanitizeContent(item.category, sanitizeOptions);
           }
           break;
 
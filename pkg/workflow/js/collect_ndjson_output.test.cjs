@@ -1152,4 +1152,97 @@ Line 3"}
     // Verify exportVariable was not called if file writing failed
     expect(mockCore.exportVariable).not.toHaveBeenCalled();
   });
+
+  it("should apply per-output sanitize policy from config", async () => {
+    const testFile = "/tmp/test-ndjson-output.txt";
+    const ndjsonContent = JSON.stringify({
+      type: "create-issue",
+      title: "Ping @octocat and @someone",
+      body: "<b>bold</b> <!-- hidden -->\n```js\ncode\n```\nhttps://example.com/docs https://evil.com/x",
+    });
+
+    fs.writeFileSync(testFile, ndjsonContent);
+    process.env.GITHUB_AW_SAFE_OUTPUTS = testFile;
+    process.env.GITHUB_AW_SAFE_OUTPUTS_CONFIG = JSON.stringify({
+      "create-issue": {
+        enabled: true,
+        sanitize: {
+          "allowed-mentions": ["@OctoCat"],
+          "allowed-url-domains": ["example.com"],
+          "strip-html": true,
+          "allow-code-fences": false,
+        },
+      },
+    });
+
+    await eval(`(async () => { ${collectScript} })()`);
+
+    const setOutputCalls = mockCore.setOutput.mock.calls;
+    const outputCall = setOutputCalls.find(call => call[0] === "output");
+    expect(outputCall).toBeDefined();
+
+    const parsedOutput = JSON.parse(outputCall[1]);
+    expect(parsedOutput.items).toHaveLength(1);
+    const item = parsedOutput.items[0];
+    expect(item.title).toBe("Ping @octocat and `@someone`");
+    expect(item.body).not.toContain("&lt;b&gt;");
+    expect(item.body).not.toContain("hidden");
+    expect(item.body).toContain("\\`\\`\\`js");
+    expect(item.body).toContain("https://example.com/docs");
+    expect(item.body).toContain("(redacted)");
+  });
+
+  it("should keep the default domains when allowed-url-domains is empty", async () => {
+    const testFile = "/tmp/test-ndjson-output.txt";
+    const ndjsonContent = JSON.stringify({
+      type: "create-issue",
+      title: "Links",
+      body: "https://github.com/octo/repo https://evil.com/x",
+    });
+
+    fs.writeFileSync(testFile, ndjsonContent);
+    process.env.GITHUB_AW_SAFE_OUTPUTS = testFile;
+    process.env.GITHUB_AW_SAFE_OUTPUTS_CONFIG = JSON.stringify({
+      "create-issue": {
+        enabled: true,
+        sanitize: {
+          "allowed-url-domains": [],
+        },
+      },
+    });
+
+    await eval(`(async () => { ${collectScript} })()`);
+
+    const setOutputCalls = mockCore.setOutput.mock.calls;
+    const outputCall = setOutputCalls.find(call => call[0] === "output");
+    expect(outputCall).toBeDefined();
+
+    const parsedOutput = JSON.parse(outputCall[1]);
+    expect(parsedOutput.items).toHaveLength(1);
+    expect(parsedOutput.items[0].body).toContain("https://github.com/octo/repo");
+    expect(parsedOutput.items[0].body).toContain("(redacted)");
+  });
+
+  it("should truncate content to configured max-length", async () => {
+    const testFile = "/tmp/test-ndjson-output.txt";
+    const ndjsonContent = JSON.stringify({
+      type: "add-issue-comment",
+      body: "a".repeat(200),
+    });
+
+    fs.writeFileSync(testFile, ndjsonContent);
+    process.env.GITHUB_AW_SAFE_OUTPUTS = testFile;
+    process.env.GITHUB_AW_SAFE_OUTPUTS_CONFIG = JSON.stringify({
+      "add-issue-comment": { enabled: true, sanitize: { "max-length": 50 } },
+    });
+
+    await eval(`(async () => { ${collectScript} })()`);
+
+    const setOutputCalls = mockCore.setOutput.mock.calls;
+    const outputCall = setOutputCalls.find(call => call[0] === "output");
+    const parsedOutput = JSON.parse(outputCall[1]);
+    expect(parsedOutput.items[0].body).toBe(
+      "a".repeat(50) + "\n[Content truncated due to length]"
+    );
+  });
 });
@@ -0,0 +1,208 @@
+/**
+ * Reads a file from the downloaded artifact of a matrix leg
+ * @param {string} dir - Directory the artifacts were downloaded to
+ * @param {string} artifact - Artifact name without the leg suffix
+ * @param {string} key - Matrix leg name
+ * @returns {string|null} File content, or null if the leg did not upload it
+ */
+function readLegArtifact(dir, artifact, key) {
+  const fs = require("fs");
+  const path = require("path");
+  const file = path.join(dir, `${artifact}-${key}`, artifact);
+  if (!fs.existsSync(file)) {
+    return null;
+  }
+  return fs.readFileSync(file, "utf8");
+}
+
+/**
+ * Extracts token usage and cost from an agent log
+ * @param {string} engine - Engine ID of the leg
+ * @param {string} log - Agent log content
+ * @returns {{tokens: number|null, cost: number|null, turns: number|null}} Metrics found in the log
+ */
+function extractMetrics(engine, log) {
+  const metrics = { tokens: null, cost: null, turns: null };
+  if (!log) {
+    return metrics;
+  }
+
+  if (engine === "claude") {
+    // Claude writes a JSON array of messages; the result entry carries the totals
+    try {
+      const entries = JSON.parse(log);
+      if (Array.isArray(entries)) {
+        for (const entry of entries) {
+          if (entry.type !== "result") {
+            continue;
+          }
+          if (typeof entry.total_cost_usd === "number") {
+            metrics.cost = entry.total_cost_usd;
+          }
+          if (typeof entry.num_turns === "number") {
+            metrics.turns = entry.num_turns;
+          }
+          if (entry.usage) {
+            metrics.tokens =
+              (entry.usage.input_tokens || 0) +
+              (entry.usage.output_tokens || 0) +
+              (entry.usage.cache_creation_input_tokens || 0) +
+              (entry.usage.cache_read_input_tokens || 0);
+          }
+        }
+      }
+    } catch (error) {
+      // Not JSON, no metrics
+    }
+    return metrics;
+  }
+
+  if (engine === "gemini") {
+    // Gemini writes a JSON object with per-model token statistics
+    try {
+      const result = JSON.parse(log);
+      const models = (result.stats && result.stats.models) || {};
+      for (const model of Object.values(models)) {
+        if (model.tokens && typeof model.tokens.total === "number") {
+          metrics.tokens = (metrics.tokens || 0) + model.tokens.total;
+        }
+      }
+    } catch (error) {
+      // Not JSON, no metrics
+    }
+    return metrics;
+  }
+
+  // Codex and text logs report tokens used per turn
+  const tokenPattern = /tokens\s+used[:\s]+([\d,]+)/gi;
+  let match;
+  while ((match = tokenPattern.exec(log)) !== null) {
+    metrics.tokens =
+      (metrics.tokens || 0) + parseInt(match[1].replace(/,/g, ""), 10);
+  }
+  return metrics;
+}
+
+/**
+ * Summarizes the safe output items of a leg
+ * @param {string|null} output - agent_output.json content
+ * @returns {{items: Array<any>, counts: string}} Items and a count per type
+ */
+function summarizeOutput(output) {
+  if (!output) {
+    return { items: [], counts: "" };
+  }
+  let items = [];
+  try {
+    const parsed = JSON.parse(output);
+    items = Array.isArray(parsed.items) ? parsed.items : [];
+  } catch (error) {
+    return { items: [], counts: "" };
+  }
+  /** @type {Record<string, number>} */
+  const counts = {};
+  for (const item of items) {
+    counts[item.type] = (counts[item.type] || 0) + 1;
+  }
+  return {
+    items,
+    counts: Object.entries(counts)
+      .map(([type, count]) => `${count} ${type}`)
+      .join(", "),
+  };
+}
+
+/**
+ * Formats a metric value for the comparison table
+ * @param {number|null} value - Metric value
+ * @param {(value: number) => string} format - Formatter for present values
+ * @returns {string} Formatted value, or a dash when missing
+ */
+function formatMetric(value, format) {
+  return value === null ? "-" : format(value);
+}
+
+async function main() {
+  const legs = JSON.parse(process.env.GITHUB_AW_COMPARE_LEGS || "[]");
+  const dir = process.env.GITHUB_AW_COMPARE_DIR || "/tmp/aw-compare";
+  const logName = process.env.GITHUB_AW_AGENT_LOG || "";
+  const winner = process.env.GITHUB_AW_COMPARE_WINNER || "";
+
+  if (legs.length === 0) {
+    core.info("No engine matrix legs to compare");
+    return;
+  }
+
+  let summary = "## Engine Comparison\n\n";
+  summary +=
+    "| Engine | Model | Status | Tokens | Cost | Turns | Outputs | Patch |\n";
+  summary +=
+    "|--------|-------|--------|--------|------|-------|---------|-------|\n";
+
+  let details = "";
+  for (const leg of legs) {
+    const info = readLegArtifact(dir, "aw_info.json", leg.key);
+    const log = logName ? readLegArtifact(dir, logName, leg.key) : null;
+    const output = readLegArtifact(dir, "agent_output.json", leg.key);
+    const patch = readLegArtifact(dir, "aw.patch", leg.key);
+
+    let model = leg.model || "";
+    if (info) {
+      try {
+        model = JSON.parse(info).model || model;
+      } catch (error) {
+        // Keep the configured model
+      }
+    }
+
+    const metrics = extractMetrics(leg.engine, log || "");
+    const { items, counts } = summarizeOutput(output);
+    const status = log === null ? "❌ no log" : "✅ completed";
+    const patchLines = patch
+      ? patch.split("\n").filter(line => /^[+-][^+-]/.test(line)).length
+      : 0;
+    const label = leg.key === winner ? `**${leg.key}** 🏆` : leg.key;
+
+    const tokens = formatMetric(metrics.tokens, v =>
+      v.toLocaleString("en-US")
+    );
+    const cost = formatMetric(metrics.cost, v => `$${v.toFixed(4)}`);
+    const turns = formatMetric(metrics.turns, v => String(v));
+    const patchSize = patch ? `${patchLines} lines` : "-";
+    summary += `| ${label} | ${model || "default"} | ${status} | ${tokens} | ${cost} | ${turns} | ${counts || "none"} | ${patchSize} |\n`;
+
+    core.info(
+      `${leg.key}: tokens=${metrics.tokens}, cost=${metrics.cost}, outputs=${items.length}`
+    );
+
+    if (items.length > 0) {
+      details += `<details>\n<summary>${leg.key} outputs</summary>\n\n`;
+      details += "```json\n" + JSON.stringify(items, null, 2) + "\n```\n\n";
+      details += "</details>\n\n";
+    }
+
+    if (leg.key === winner) {
+      core.setOutput("output", output || "");
+      if (patch) {
+        const path = require("path");
+        core.setOutput(
+          "patch",
+          path.join(dir, `aw.patch-${leg.key}`, "aw.patch")
+        );
+      }
+    }
+  }
+
+  if (winner) {
+    summary += `\nSafe outputs are applied from **${winner}**.\n\n`;
+  } else {
+    summary +=
+      "\nNo winner is configured, safe outputs are shown for preview only.\n\n";
+  }
+
+  await core.summary.addRaw(summary + details).write();
+}
+
+main().catch(error => {
+  core.setFailed(error instanceof Error ? error.message : String(error));
+});
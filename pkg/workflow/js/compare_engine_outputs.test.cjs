@@ -0,0 +1,151 @@
+import { describe, it, expect, beforeEach, afterEach, vi } from "vitest";
+import fs from "fs";
+import os from "os";
+import path from "path";
+
+describe("compare_engine_outputs.cjs", () => {
+  let mockCore;
+  let compareScript;
+  let tmpDir;
+  let summaryContent;
+
+  const writeArtifact = (artifact, key, content) => {
+    const dir = path.join(tmpDir, `${artifact}-${key}`);
+    fs.mkdirSync(dir, { recursive: true });
+    fs.writeFileSync(path.join(dir, artifact), content);
+  };
+
+  beforeEach(() => {
+    summaryContent = "";
+    mockCore = {
+      setOutput: vi.fn(),
+      setFailed: vi.fn(),
+      info: vi.fn(),
+      warning: vi.fn(),
+      error: vi.fn(),
+      summary: {
+        addRaw: vi.fn().mockImplementation(content => {
+          summaryContent += content;
+          return mockCore.summary;
+        }),
+        write: vi.fn().mockResolvedValue(),
+      },
+    };
+    global.core = mockCore;
+
+    global.require = vi.fn().mockImplementation(module => {
+      if (module === "fs") {
+        return fs;
+      }
+      if (module === "path") {
+        return path;
+      }
+      throw new Error(`Module not found: ${module}`);
+    });
+
+    tmpDir = fs.mkdtempSync(path.join(os.tmpdir(), "aw-compare-"));
+    process.env.GITHUB_AW_COMPARE_DIR = tmpDir;
+    process.env.GITHUB_AW_AGENT_LOG = "test-workflow.log";
+    process.env.GITHUB_AW_COMPARE_LEGS = JSON.stringify([
+      { key: "claude", engine: "claude", name: "Claude Code", model: "" },
+      { key: "codex", engine: "codex", name: "Codex", model: "o4-mini" },
+    ]);
+
+    const scriptPath = path.join(__dirname, "compare_engine_outputs.cjs");
+    compareScript = fs.readFileSync(scriptPath, "utf8");
+  });
+
+  afterEach(() => {
+    fs.rmSync(tmpDir, { recursive: true, force: true });
+    delete process.env.GITHUB_AW_COMPARE_DIR;
+    delete process.env.GITHUB_AW_AGENT_LOG;
+    delete process.env.GITHUB_AW_COMPARE_LEGS;
+    delete process.env.GITHUB_AW_COMPARE_WINNER;
+    delete global.core;
+    delete global.require;
+  });
+
+  const runScript = async () => {
+    const scriptFunction = new Function(compareScript);
+    scriptFunction();
+    await new Promise(resolve => setTimeout(resolve, 0));
+  };
+
+  const writeLegs = () => {
+    writeArtifact(
+      "test-workflow.log",
+      "claude",
+      JSON.stringify([
+        { type: "system", subtype: "init" },
+        {
+          type: "result",
+          total_cost_usd: 0.1234,
+          num_turns: 3,
+          usage: { input_tokens: 1000, output_tokens: 200 },
+        },
+      ])
+    );
+    writeArtifact(
+      "test-workflow.log",
+      "codex",
+      "[2025-08-01] tokens used: 1,500\n[2025-08-01] tokens used: 500\n"
+    );
+    writeArtifact(
+      "agent_output.json",
+      "claude",
+      JSON.stringify({
+        items: [{ type: "create-issue", title: "From Claude" }],
+      })
+    );
+    writeArtifact(
+      "agent_output.json",
+      "codex",
+      JSON.stringify({ items: [{ type: "create-issue", title: "From Codex" }] })
+    );
+    writeArtifact("aw.patch", "codex", "+added line\n-removed line\n");
+  };
+
+  it("should render token usage and cost side by side", async () => {
+    writeLegs();
+
+    await runScript();
+
+    expect(summaryContent).toContain("## Engine Comparison");
+    expect(summaryContent).toContain(
+      "| claude | default | ✅ completed | 1,200 | $0.1234 | 3 |"
+    );
+    expect(summaryContent).toContain(
+      "| codex | o4-mini | ✅ completed | 2,000 | - | - |"
+    );
+    expect(summaryContent).toContain("2 lines");
+    expect(summaryContent).toContain("From Claude");
+    expect(summaryContent).toContain("From Codex");
+    expect(summaryContent).toContain("preview only");
+    expect(mockCore.setOutput).not.toHaveBeenCalled();
+  });
+
+  it("should expose the winner's output and patch", async () => {
+    writeLegs();
+    process.env.GITHUB_AW_COMPARE_WINNER = "codex";
+
+    await runScript();
+
+    expect(summaryContent).toContain("**codex** 🏆");
+    expect(mockCore.setOutput).toHaveBeenCalledWith(
+      "output",
+      JSON.stringify({ items: [{ type: "create-issue", title: "From Codex" }] })
+    );
+    expect(mockCore.setOutput).toHaveBeenCalledWith(
+      "patch",
+      path.join(tmpDir, "aw.patch-codex", "aw.patch")
+    );
+  });
+
+  it("should report legs without logs", async () => {
+    await runScript();
+
+    expect(summaryContent).toContain(
+      "| claude | default | ❌ no log | - | - | - | none | - |"
+    );
+  });
+});
@@ -0,0 +1,192 @@
+function main() {
+  const fs = require("fs");
+
+  try {
+    const logFile = process.env.AGENT_LOG_FILE;
+    if (!logFile) {
+      console.log("No agent log file specified");
+      return;
+    }
+
+    if (!fs.existsSync(logFile)) {
+      console.log(`Log file not found: ${logFile}`);
+      return;
+    }
+
+    const content = fs.readFileSync(logFile, "utf8");
+    const parsedLog = parseGeminiLog(content);
+
+    if (parsedLog) {
+      core.summary.addRaw(parsedLog).write();
+      console.log("Gemini log parsed successfully");
+    } else {
+      core.error("Failed to parse Gemini log");
+    }
+  } catch (error) {
+    core.setFailed(error.message);
+  }
+}
+
+/**
+ * Finds the JSON result object printed by Gemini CLI after any diagnostic output
+ * @param {string} logContent - The raw log content
+ * @returns {Object|null} The parsed result object, or null if none was found
+ */
+function findGeminiResult(logContent) {
+  const lines = logContent.split("\n");
+  for (let i = 0; i < lines.length; i++) {
+    if (!lines[i].startsWith("{")) {
+      continue;
+    }
+    // Try progressively longer blocks until the object parses
+    for (let j = lines.length; j > i; j--) {
+      const candidate = lines.slice(i, j).join("\n").trim();
+      if (!candidate.endsWith("}")) {
+        continue;
+      }
+      try {
+        const result = JSON.parse(candidate);
+        if (result && (result.stats || result.response !== undefined)) {
+          return result;
+        }
+      } catch (error) {
+        // Not a complete JSON object, keep looking
+      }
+    }
+  }
+  return null;
+}
+
+function parseGeminiLog(logContent) {
+  try {
+    const result = findGeminiResult(logContent);
+    if (!result) {
+      return "## 🤖 Commands and Tools\n\nNo Gemini result found in log.\n\n";
+    }
+
+    const stats = result.stats || {};
+    let markdown = "## 🤖 Commands and Tools\n\n";
+
+    // Summarize tool usage by name
+    const byName = (stats.tools && stats.tools.byName) || {};
+    const toolNames = Object.keys(byName).sort();
+    if (toolNames.length > 0) {
+      for (const toolName of toolNames) {
+        const toolStats = byName[toolName] || {};
+        const statusIcon = toolStats.fail > 0 ? "❌" : "✅";
+        const count = toolStats.count || 0;
+        const calls = count === 1 ? "call" : "calls";
+        markdown += `* ${statusIcon} \`${formatToolName(toolName)}\` (${count} ${calls})\n`;
+      }
+    } else {
+      markdown += "No commands or tools used.\n";
+    }
+
+    // Add Information section
+    markdown += "\n## 📊 Information\n\n";
+
+    const models = stats.models || {};
+    let totalTokens = 0;
+    let totalRequests = 0;
+    for (const modelName of Object.keys(models)) {
+      const modelStats = models[modelName] || {};
+      const tokens = modelStats.tokens || {};
+      const api = modelStats.api || {};
+      totalTokens += tokens.total || 0;
+      totalRequests += api.totalRequests || 0;
+      markdown += `**Model:** ${modelName}\n\n`;
+      if (tokens.total) {
+        markdown += `**Token Usage (${modelName}):**\n`;
+        const breakdown = [
+          ["Prompt", tokens.prompt],
+          ["Output", tokens.candidates],
+          ["Cached", tokens.cached],
+          ["Thoughts", tokens.thoughts],
+        ];
+        for (const [label, value] of breakdown) {
+          if (value) {
+            markdown += `- ${label}: ${value.toLocaleString()}\n`;
+          }
+        }
+        markdown += "\n";
+      }
+    }
+
+    if (totalTokens > 0) {
+      markdown += `**Total Tokens Used:** ${totalTokens.toLocaleString()}\n\n`;
+    }
+
+    if (totalRequests > 0) {
+      markdown += `**API Requests:** ${totalRequests}\n\n`;
+    }
+
+    if (stats.tools && stats.tools.totalCalls) {
+      markdown += `**Tool Calls:** ${stats.tools.totalCalls}`;
+      if (stats.tools.totalFail) {
+        markdown += ` (${stats.tools.totalFail} failed)`;
+      }
+      markdown += "\n\n";
+    }
+
+    if (
+      stats.files &&
+      (stats.files.totalLinesAdded || stats.files.totalLinesRemoved)
+    ) {
+      const added = stats.files.totalLinesAdded || 0;
+      const removed = stats.files.totalLinesRemoved || 0;
+      markdown += `**Lines Changed:** +${added} -${removed}\n\n`;
+    }
+
+    if (result.error) {
+      const message =
+        result.error.message || String(result.error.type || "unknown");
+      markdown += `**Error:** ${truncateString(message, 500)}\n\n`;
+    }
+
+    markdown += "\n## 🤖 Response\n\n";
+
+    if (result.response && result.response.trim()) {
+      markdown += `${result.response.trim()}\n\n`;
+    } else {
+      markdown += "No response from Gemini.\n\n";
+    }
+
+    return markdown;
+  } catch (error) {
+    core.error(`Error parsing Gemini log: ${error}`);
+    return "## 🤖 Commands and Tools\n\nError parsing log content.\n\n## 🤖 Response\n\nUnable to parse response from log.\n\n";
+  }
+}
+
+/**
+ * Formats a Gemini tool name, rendering MCP tools as server::tool
+ * @param {string} toolName - The tool name from the Gemini stats
+ * @returns {string} The formatted tool name
+ */
+function formatToolName(toolName) {
+  if (!toolName) return "";
+  // MCP tools are reported as server__tool
+  const parts = toolName.split("__");
+  if (parts.length > 1) {
+    return `${parts[0]}::${parts.slice(1).join("_")}`;
+  }
+  return toolName;
+}
+
+function truncateString(str, maxLength) {
+  if (!str) return "";
+  if (str.length <= maxLength) return str;
+  return str.substring(0, maxLength) + "...";
+}
+
+// Export for testing
+if (typeof module !== "undefined" && module.exports) {
+  module.exports = {
+    parseGeminiLog,
+    findGeminiResult,
+    formatToolName,
+    truncateString,
+  };
+}
+
+main();
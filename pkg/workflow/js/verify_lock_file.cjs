@@ -0,0 +1,82 @@
+// Must stay in sync with ComputeLockFileHash in lock_integrity.go
+const HASH_LINE_PATTERN = /^# Lock file hash: sha256:([0-9a-f]{64})$/;
+
+/**
+ * Computes the SHA-256 of lock file content with the hash header line removed
+ * @param {string} content - Lock file content
+ * @returns {string} Hex-encoded hash
+ */
+function computeLockFileHash(content) {
+  const crypto = require("crypto");
+  const lines = content
+    .replace(/\r\n/g, "\n")
+    .split("\n")
+    .filter(line => !HASH_LINE_PATTERN.test(line));
+  return crypto.createHash("sha256").update(lines.join("\n"), "utf8").digest("hex");
+}
+
+/**
+ * Extracts the hash recorded in the leading comment block of the lock file
+ * @param {string} content - Lock file content
+ * @returns {string} Recorded hash, or empty string if absent
+ */
+function extractLockFileHash(content) {
+  for (const line of content.replace(/\r\n/g, "\n").split("\n")) {
+    if (!line.startsWith("#")) {
+      if (line.trim() === "") {
+        continue;
+      }
+      break;
+    }
+    const match = line.match(HASH_LINE_PATTERN);
+    if (match) {
+      return match[1];
+    }
+  }
+  return "";
+}
+
+async function main() {
+  // GITHUB_WORKFLOW_REF looks like owner/repo/.github/workflows/name.lock.yml@refs/heads/main
+  const workflowRef = process.env.GITHUB_WORKFLOW_REF || "";
+  const workflowSha = process.env.GITHUB_WORKFLOW_SHA || context.sha;
+  const refMatch = workflowRef.match(/^([^/]+)\/([^/]+)\/(.+?)@/);
+  if (!refMatch) {
+    core.setFailed(`Unable to determine workflow file from GITHUB_WORKFLOW_REF: ${workflowRef}`);
+    return;
+  }
+  const [, owner, repo, path] = refMatch;
+
+  core.info(`Verifying ${path} at ${workflowSha}`);
+
+  const { data } = await github.rest.repos.getContent({
+    owner,
+    repo,
+    path,
+    ref: workflowSha,
+  });
+  if (Array.isArray(data) || !("content" in data) || typeof data.content !== "string") {
+    core.setFailed(`${path} is not a file`);
+    return;
+  }
+  const content = Buffer.from(data.content, "base64").toString("utf8");
+
+  const recorded = extractLockFileHash(content);
+  if (!recorded) {
+    core.setFailed(`${path} has no recorded lock file hash. Recompile the workflow with 'gh aw compile'.`);
+    return;
+  }
+
+  const actual = computeLockFileHash(content);
+  if (actual !== recorded) {
+    core.setFailed(
+      `${path} does not match the hash recorded by the compiler (recorded sha256:${recorded}, actual sha256:${actual}). ` +
+        "The lock file was edited after compilation; edit the .md file and run 'gh aw compile' instead."
+    );
+    return;
+  }
+
+  core.info(`Lock file integrity verified (sha256:${actual})`);
+}
+
+await main();
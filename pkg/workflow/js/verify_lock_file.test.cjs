@@ -0,0 +1,116 @@
+import { describe, it, expect, beforeEach, afterEach, vi } from "vitest";
+import fs from "fs";
+import path from "path";
+import crypto from "crypto";
+
+const mockCore = {
+  info: vi.fn(),
+  setFailed: vi.fn(),
+};
+
+const mockGithub = {
+  rest: {
+    repos: {
+      getContent: vi.fn(),
+    },
+  },
+};
+
+global.core = mockCore;
+global.github = mockGithub;
+global.context = { sha: "fallback-sha" };
+
+/**
+ * Builds a lock file whose header records the hash of its own content
+ * @param {string} body - Workflow YAML after the header
+ * @returns {string}
+ */
+function buildLockFile(body) {
+  const header = "# This file was automatically generated by gh-aw. DO NOT EDIT.\n#\n";
+  const hash = crypto
+    .createHash("sha256")
+    .update(header + "\n" + body, "utf8")
+    .digest("hex");
+  return `${header}# Lock file hash: sha256:${hash}\n\n${body}`;
+}
+
+describe("verify_lock_file.cjs", () => {
+  let verifyScript;
+
+  beforeEach(() => {
+    vi.clearAllMocks();
+    global.require = vi.fn().mockImplementation(module => {
+      if (module === "crypto") {
+        return crypto;
+      }
+      throw new Error(`Module not found: ${module}`);
+    });
+    process.env.GITHUB_WORKFLOW_REF =
+      "testowner/testrepo/.github/workflows/test.lock.yml@refs/heads/main";
+    process.env.GITHUB_WORKFLOW_SHA = "abc123";
+
+    const scriptPath = path.join(
+      process.cwd(),
+      "pkg/workflow/js/verify_lock_file.cjs"
+    );
+    verifyScript = fs.readFileSync(scriptPath, "utf8");
+  });
+
+  afterEach(() => {
+    delete process.env.GITHUB_WORKFLOW_REF;
+    delete process.env.GITHUB_WORKFLOW_SHA;
+    delete global.require;
+  });
+
+  const mockLockFile = content => {
+    mockGithub.rest.repos.getContent.mockResolvedValue({
+      data: { content: Buffer.from(content).toString("base64") },
+    });
+  };
+
+  it("should pass when the lock file matches its recorded hash", async () => {
+    mockLockFile(buildLockFile('name: "Test"\npermissions: {}\n'));
+
+    await eval(`(async () => { ${verifyScript} })()`);
+
+    expect(mockGithub.rest.repos.getContent).toHaveBeenCalledWith({
+      owner: "testowner",
+      repo: "testrepo",
+      path: ".github/workflows/test.lock.yml",
+      ref: "abc123",
+    });
+    expect(mockCore.setFailed).not.toHaveBeenCalled();
+  });
+
+  it("should fail when the lock file was edited after compilation", async () => {
+    const lockFile = buildLockFile('name: "Test"\npermissions: {}\n');
+    mockLockFile(lockFile.replace("permissions: {}", "permissions: write-all"));
+
+    await eval(`(async () => { ${verifyScript} })()`);
+
+    expect(mockCore.setFailed).toHaveBeenCalledWith(
+      expect.stringContaining("does not match the hash recorded by the compiler")
+    );
+  });
+
+  it("should fail when the lock file has no recorded hash", async () => {
+    mockLockFile('# header\n\nname: "Test"\n');
+
+    await eval(`(async () => { ${verifyScript} })()`);
+
+    expect(mockCore.setFailed).toHaveBeenCalledWith(
+      expect.stringContaining("has no recorded lock file hash")
+    );
+  });
+
+  it("should fail when the workflow ref cannot be parsed", async () => {
+    process.env.GITHUB_WORKFLOW_REF = "invalid";
+
+    await eval(`(async () => { ${verifyScript} })()`);
+
+    expect(mockCore.setFailed).toHaveBeenCalledWith(
+      expect.stringContaining("Unable to determine workflow file")
+    );
+    expect(mockGithub.rest.repos.getContent).not.toHaveBeenCalled();
+  });
+});
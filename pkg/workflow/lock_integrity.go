@@ -0,0 +1,104 @@
+package workflow
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/githubnext/gh-aw/pkg/constants"
+)
+
+// lockFileVerificationJobName is the first job of every compiled workflow. All other jobs depend on
+// it, directly or through another job, so a tampered lock file fails before anything else runs.
+const lockFileVerificationJobName = "verify_lock_file"
+
+// LockFileHashPrefix starts the header comment line that records the lock file hash
+const LockFileHashPrefix = "# Lock file hash: sha256:"
+
+// lockFileHashLineRegex matches the hash header line; the same pattern is used by verify_lock_file.cjs
+var lockFileHashLineRegex = regexp.MustCompile(`^# Lock file hash: sha256:([0-9a-f]{64})$`)
+
+// ComputeLockFileHash returns the SHA-256 of the lock file content with the hash header line removed.
+// Line endings are normalized to \n so that checkouts with autocrlf still verify.
+func ComputeLockFileHash(content string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	lines := strings.Split(content, "\n")
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if lockFileHashLineRegex.MatchString(line) {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	sum := sha256.Sum256([]byte(strings.Join(kept, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// ExtractLockFileHash returns the hash recorded in the lock file header, or empty string if none is present
+func ExtractLockFileHash(content string) string {
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			// The hash is part of the leading comment block
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			break
+		}
+		if match := lockFileHashLineRegex.FindStringSubmatch(line); match != nil {
+			return match[1]
+		}
+	}
+	return ""
+}
+
+// VerifyLockFileContent checks that the lock file content matches the hash recorded by the compiler
+func VerifyLockFileContent(content string) error {
+	recorded := ExtractLockFileHash(content)
+	if recorded == "" {
+		return fmt.Errorf("no lock file hash found in header; recompile with '%s compile'", constants.CLIExtensionPrefix)
+	}
+	if actual := ComputeLockFileHash(content); actual != recorded {
+		return fmt.Errorf("lock file hash mismatch: recorded sha256:%s, actual sha256:%s. The lock file was modified after compilation", recorded, actual)
+	}
+	return nil
+}
+
+// stampLockFileHash inserts the hash line at the end of the header comment block of generated YAML
+func stampLockFileHash(yamlContent string) string {
+	headerEnd := strings.Index(yamlContent, "\n\n")
+	if headerEnd < 0 {
+		return LockFileHashPrefix + ComputeLockFileHash(yamlContent) + "\n" + yamlContent
+	}
+	// The separator line is hashed; only the hash line itself is excluded
+	before := yamlContent[:headerEnd+1] + "#\n"
+	after := yamlContent[headerEnd+1:]
+	hash := ComputeLockFileHash(before + after)
+	return before + LockFileHashPrefix + hash + "\n" + after
+}
+
+// buildLockFileVerificationJob creates the job that verifies the lock file before any other job runs.
+// It shares the workflow condition with the task job, so filtered-out events do not start a runner.
+func (c *Compiler) buildLockFileVerificationJob(data *WorkflowData) *Job {
+	var stepBuilder strings.Builder
+	c.generateLockFileIntegrityCheck(&stepBuilder)
+
+	return &Job{
+		Name:           lockFileVerificationJobName,
+		If:             data.If,
+		RunsOn:         "runs-on: ubuntu-latest",
+		Permissions:    "permissions:\n      contents: read", // Reads the lock file through the contents API
+		TimeoutMinutes: 5,
+		Steps:          []string{stepBuilder.String()},
+	}
+}
+
+// generateLockFileIntegrityCheck generates the step that verifies the running lock file against its recorded hash
+func (c *Compiler) generateLockFileIntegrityCheck(yaml *strings.Builder) {
+	yaml.WriteString("      - name: Verify lock file integrity\n")
+	yaml.WriteString("        uses: actions/github-script@v7\n")
+	yaml.WriteString("        with:\n")
+	yaml.WriteString("          script: |\n")
+	WriteJavaScriptToYAML(yaml, verifyLockFileScript)
+}
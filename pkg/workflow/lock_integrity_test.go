@@ -0,0 +1,224 @@
+package workflow
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestStampLockFileHash(t *testing.T) {
+	content := "# This file was automatically generated by gh-aw. DO NOT EDIT.\n#   gh aw compile\n\nname: \"Test\"\non: push\n"
+
+	stamped := stampLockFileHash(content)
+
+	hash := ExtractLockFileHash(stamped)
+	if hash == "" {
+		t.Fatalf("expected a recorded hash in:\n%s", stamped)
+	}
+	if !strings.Contains(stamped, "#   gh aw compile\n#\n"+LockFileHashPrefix+hash+"\n\nname:") {
+		t.Errorf("expected hash line at the end of the header comment, got:\n%s", stamped)
+	}
+	if err := VerifyLockFileContent(stamped); err != nil {
+		t.Errorf("expected stamped content to verify, got: %v", err)
+	}
+}
+
+func TestVerifyLockFileContent(t *testing.T) {
+	stamped := stampLockFileHash("# header\n\nname: \"Test\"\npermissions: {}\n")
+
+	tests := []struct {
+		name        string
+		content     string
+		expectError string
+	}{
+		{
+			name:    "unchanged",
+			content: stamped,
+		},
+		{
+			name:    "windows line endings",
+			content: strings.ReplaceAll(stamped, "\n", "\r\n"),
+		},
+		{
+			name:        "edited permissions",
+			content:     strings.Replace(stamped, "permissions: {}", "permissions: write-all", 1),
+			expectError: "lock file hash mismatch",
+		},
+		{
+			name:        "missing hash",
+			content:     "# header\n\nname: \"Test\"\n",
+			expectError: "no lock file hash found",
+		},
+		{
+			name:        "hash line outside header is ignored",
+			content:     "# header\n\nname: \"Test\"\n" + LockFileHashPrefix + strings.Repeat("0", 64) + "\n",
+			expectError: "no lock file hash found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyLockFileContent(tt.content)
+			if tt.expectError == "" {
+				if err != nil {
+					t.Errorf("expected no error, got: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+				t.Errorf("expected error containing %q, got: %v", tt.expectError, err)
+			}
+		})
+	}
+}
+
+func TestCompiledLockFileIntegrity(t *testing.T) {
+	tmpDir := t.TempDir()
+	workflowPath := filepath.Join(tmpDir, "test-integrity.md")
+	content := `---
+on:
+  schedule:
+    - cron: "0 9 * * 1"
+  stop-after: "2030-01-01 00:00:00"
+permissions:
+  contents: read
+---
+
+# Integrity Test
+
+Check the lock file hash.`
+	if err := os.WriteFile(workflowPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	compiler := NewCompiler(false, "", "test")
+	if err := compiler.CompileWorkflow(workflowPath); err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+
+	lockContent, err := os.ReadFile(strings.TrimSuffix(workflowPath, ".md") + ".lock.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock := string(lockContent)
+
+	if err := VerifyLockFileContent(lock); err != nil {
+		t.Errorf("expected compiled lock file to verify, got: %v", err)
+	}
+	if !strings.Contains(lock, "# Effective stop-time: 2030-01-01 00:00:00\n#\n"+LockFileHashPrefix) {
+		t.Errorf("expected hash line after the stop-time header comment")
+	}
+
+	// The integrity check runs in the first job, which can read the lock file
+	verifyJob := extractJobSection(lock, lockFileVerificationJobName)
+	if !strings.Contains(verifyJob, "- name: Verify lock file integrity") {
+		t.Errorf("expected the integrity check in the %s job:\n%s", lockFileVerificationJobName, verifyJob)
+	}
+	if !strings.Contains(verifyJob, "permissions:\n      contents: read") {
+		t.Errorf("expected the %s job to have contents: read:\n%s", lockFileVerificationJobName, verifyJob)
+	}
+	if strings.Count(lock, "- name: Verify lock file integrity") != 1 {
+		t.Error("expected the integrity check to run once")
+	}
+}
+
+func TestLockFileVerificationJobRunsFirst(t *testing.T) {
+	tmpDir := t.TempDir()
+	workflowPath := filepath.Join(tmpDir, "test-verify-first.md")
+	content := `---
+on:
+  issues:
+    types: [opened]
+  reaction: eyes
+permissions:
+  issues: write
+---
+
+# Verify First
+
+Summarize the issue: "${{ needs.task.outputs.text }}"`
+	if err := os.WriteFile(workflowPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	compiler := NewCompiler(false, "", "test")
+	if err := compiler.CompileWorkflow(workflowPath); err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	lockContent, err := os.ReadFile(strings.TrimSuffix(workflowPath, ".md") + ".lock.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock := string(lockContent)
+
+	// The task job would otherwise start first, the other jobs are verified through it
+	for jobName, needs := range map[string]string{"task": lockFileVerificationJobName, "add_reaction": "task", "verify-first": "task"} {
+		if job := extractJobSection(lock, jobName); !strings.Contains(job, "needs: "+needs) {
+			t.Errorf("expected the %s job to depend on %s:\n%s", jobName, needs, job)
+		}
+	}
+}
+
+func TestCustomJobsDependOnLockFileVerification(t *testing.T) {
+	compiler := NewCompiler(false, "", "test")
+	data := &WorkflowData{Jobs: map[string]any{
+		"notify": map[string]any{"runs-on": "ubuntu-latest"},
+		"report": map[string]any{"runs-on": "ubuntu-latest", "depends": "notify"},
+	}}
+	if err := compiler.buildCustomJobs(data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]string{"notify": lockFileVerificationJobName, "report": "notify"}
+	for jobName, needs := range expected {
+		job, ok := compiler.jobManager.GetJob(jobName)
+		if !ok {
+			t.Fatalf("expected job %s", jobName)
+		}
+		if strings.Join(job.Depends, ",") != needs {
+			t.Errorf("expected the %s job to depend on %s, got %v", jobName, needs, job.Depends)
+		}
+	}
+}
+
+func TestLockFileVerificationJobSharesTaskCondition(t *testing.T) {
+	tmpDir := t.TempDir()
+	workflowPath := filepath.Join(tmpDir, "test-verify-condition.md")
+	content := `---
+on:
+  command:
+    name: triage-bot
+permissions:
+  issues: write
+---
+
+# Verify Condition
+
+Triage the issue.`
+	if err := os.WriteFile(workflowPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	compiler := NewCompiler(false, "", "test")
+	if err := compiler.CompileWorkflow(workflowPath); err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+	lockContent, err := os.ReadFile(strings.TrimSuffix(workflowPath, ".md") + ".lock.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock := string(lockContent)
+
+	// Events that do not mention the command skip the verification job instead of starting a runner
+	verifyJob := extractJobSection(lock, lockFileVerificationJobName)
+	taskJob := extractJobSection(lock, "task")
+	condition := regexp.MustCompile(`(?m)^    if: .*$`).FindString(taskJob)
+	if condition == "" || !strings.Contains(condition, "triage-bot") {
+		t.Fatalf("expected the task job to have the command condition:\n%s", taskJob)
+	}
+	if !strings.Contains(verifyJob, condition+"\n") {
+		t.Errorf("expected the %s job to have the task job condition %q:\n%s", lockFileVerificationJobName, condition, verifyJob)
+	}
+}
@@ -41,6 +41,24 @@ func TestLogParserSnapshots(t *testing.T) {
 				t.Fatalf("Failed to read log file %s: %v", logPath, err)
 			}
 
+			// Read the expected baseline
+			expectedPath := filepath.Join(testDataDir, tt.expectedFile)
+			expectedContent, err := os.ReadFile(expectedPath)
+			if err != nil {
+				t.Fatalf("Failed to read expected baseline %s: %v", expectedPath, err)
+			}
+
+			// The Go renderer must produce the same transcript as the JavaScript parser
+			engine, err := NewEngineRegistry().GetEngine(tt.engine)
+			if err != nil {
+				t.Fatalf("Failed to get engine %s: %v", tt.engine, err)
+			}
+			// The baseline captures the whole script output, including the status line the codex parser logs
+			expectedTranscript := strings.TrimSpace(strings.TrimSuffix(string(expectedContent), "Codex log parsed successfully"))
+			if rendered := strings.TrimSpace(engine.RenderLog(string(logContent))); rendered != expectedTranscript {
+				t.Errorf("Go rendered markdown differs from baseline %s.\nGenerated:\n%s", expectedPath, rendered)
+			}
+
 			// Get the JavaScript parser script
 			scriptName := fmt.Sprintf("parse_%s_log", tt.engine)
 			jsScript := GetLogParserScript(scriptName)
@@ -55,13 +73,6 @@ func TestLogParserSnapshots(t *testing.T) {
 				t.Fatalf("Failed to run JavaScript log parser: %v", err)
 			}
 
-			// Read the expected baseline
-			expectedPath := filepath.Join(testDataDir, tt.expectedFile)
-			expectedContent, err := os.ReadFile(expectedPath)
-			if err != nil {
-				t.Fatalf("Failed to read expected baseline %s: %v", expectedPath, err)
-			}
-
 			// Compare the results
 			if markdown != string(expectedContent) {
 				// Update the baseline file for manual inspection
@@ -0,0 +1,528 @@
+package workflow
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// The renderers in this file are Go ports of the step summary renderers in
+// js/parse_claude_log.cjs and js/parse_codex_log.cjs. Both produce the same markdown,
+// which TestLogParserSnapshots checks against the same baselines.
+
+// claudeInternalTools are Claude tools left out of the command summary
+var claudeInternalTools = []string{"Read", "Write", "Edit", "MultiEdit", "LS", "Grep", "Glob", "TodoWrite"}
+
+// runnerWorkspacePrefix matches the /home/runner/work/repo/repo/ prefix of file paths
+var runnerWorkspacePrefix = regexp.MustCompile(`^/[^/]*/[^/]*/[^/]*/[^/]*/`)
+
+// jsWhitespace matches the characters of the JavaScript \s class
+var jsWhitespace = regexp.MustCompile(`[\t\n\v\f\r \x{00a0}\x{1680}\x{2000}-\x{200a}\x{2028}\x{2029}\x{202f}\x{205f}\x{3000}\x{feff}]+`)
+
+// claudeLogEntry is an entry of a Claude stream-json log
+type claudeLogEntry struct {
+	Type    string `json:"type"`
+	Message *struct {
+		Content json.RawMessage `json:"content"`
+	} `json:"message"`
+	NumTurns          any            `json:"num_turns"`
+	DurationMs        any            `json:"duration_ms"`
+	TotalCostUSD      any            `json:"total_cost_usd"`
+	Usage             map[string]any `json:"usage"`
+	PermissionDenials []any          `json:"permission_denials"`
+}
+
+// claudeContentBlock is a content block of a Claude message
+type claudeContentBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text"`
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Input     json.RawMessage `json:"input"`
+	ToolUseID string          `json:"tool_use_id"`
+	IsError   bool            `json:"is_error"`
+}
+
+// contentBlocks returns the content blocks of the entry's message, if its content is a list
+func (entry *claudeLogEntry) contentBlocks() []claudeContentBlock {
+	if entry.Message == nil {
+		return nil
+	}
+	var blocks []claudeContentBlock
+	if err := json.Unmarshal(entry.Message.Content, &blocks); err != nil {
+		return nil
+	}
+	return blocks
+}
+
+// RenderLog renders a Claude log as the markdown transcript of the step summary
+func (e *ClaudeEngine) RenderLog(logContent string) string {
+	var entries []claudeLogEntry
+	if err := json.Unmarshal([]byte(logContent), &entries); err != nil {
+		var other any
+		if json.Unmarshal([]byte(logContent), &other) == nil {
+			return "## Agent Log Summary\n\nLog format not recognized as Claude JSON array.\n"
+		}
+		return fmt.Sprintf("## Agent Log Summary\n\nError parsing Claude log: %v\n", err)
+	}
+
+	var markdown strings.Builder
+	markdown.WriteString("## 🤖 Commands and Tools\n\n")
+
+	// First pass: collect tool results by tool_use_id
+	toolResults := make(map[string]claudeContentBlock)
+	for i := range entries {
+		if entries[i].Type != "user" {
+			continue
+		}
+		for _, block := range entries[i].contentBlocks() {
+			if block.Type == "tool_result" && block.ToolUseID != "" {
+				toolResults[block.ToolUseID] = block
+			}
+		}
+	}
+
+	// Collect all external tool uses for the summary
+	var commandSummary []string
+	for i := range entries {
+		if entries[i].Type != "assistant" {
+			continue
+		}
+		for _, block := range entries[i].contentBlocks() {
+			if block.Type != "tool_use" || slices.Contains(claudeInternalTools, block.Name) {
+				continue
+			}
+			statusIcon := claudeToolStatusIcon(toolResults, block.ID)
+			input := parseOrderedObject(block.Input)
+			switch {
+			case block.Name == "Bash":
+				commandSummary = append(commandSummary, fmt.Sprintf("* %s `%s`", statusIcon, formatBashCommand(input.string("command"))))
+			case strings.HasPrefix(block.Name, "mcp__"):
+				commandSummary = append(commandSummary, fmt.Sprintf("* %s `%s(...)`", statusIcon, formatMCPName(block.Name)))
+			default:
+				commandSummary = append(commandSummary, fmt.Sprintf("* %s %s", statusIcon, block.Name))
+			}
+		}
+	}
+	writeCommandSummary(&markdown, commandSummary)
+
+	// Add the information section from the last entry with result metadata
+	markdown.WriteString("\n## 📊 Information\n\n")
+	if len(entries) > 0 {
+		last := entries[len(entries)-1]
+		if jsTruthy(last.NumTurns) || jsTruthy(last.DurationMs) || jsTruthy(last.TotalCostUSD) || last.Usage != nil {
+			if jsTruthy(last.NumTurns) {
+				fmt.Fprintf(&markdown, "**Turns:** %s\n\n", jsString(last.NumTurns))
+			}
+			if durationMs, ok := last.DurationMs.(float64); ok && durationMs != 0 {
+				durationSec := int(math.Floor(durationMs/1000 + 0.5))
+				fmt.Fprintf(&markdown, "**Duration:** %dm %ds\n\n", durationSec/60, durationSec%60)
+			}
+			if cost, ok := last.TotalCostUSD.(float64); ok && cost != 0 {
+				fmt.Fprintf(&markdown, "**Total Cost:** $%.4f\n\n", cost)
+			}
+			if last.Usage != nil && (jsTruthy(last.Usage["input_tokens"]) || jsTruthy(last.Usage["output_tokens"])) {
+				markdown.WriteString("**Token Usage:**\n")
+				for _, field := range []struct{ key, label string }{
+					{"input_tokens", "Input"},
+					{"cache_creation_input_tokens", "Cache Creation"},
+					{"cache_read_input_tokens", "Cache Read"},
+					{"output_tokens", "Output"},
+				} {
+					if jsTruthy(last.Usage[field.key]) {
+						fmt.Fprintf(&markdown, "- %s: %s\n", field.label, formatLocaleNumber(ConvertToInt(last.Usage[field.key])))
+					}
+				}
+				markdown.WriteString("\n")
+			}
+			if len(last.PermissionDenials) > 0 {
+				fmt.Fprintf(&markdown, "**Permission Denials:** %d\n\n", len(last.PermissionDenials))
+			}
+		}
+	}
+
+	// Second pass: the assistant messages in sequence
+	markdown.WriteString("\n## 🤖 Reasoning\n\n")
+	for i := range entries {
+		if entries[i].Type != "assistant" {
+			continue
+		}
+		for _, block := range entries[i].contentBlocks() {
+			switch block.Type {
+			case "text":
+				if text := jsTrim(block.Text); text != "" {
+					markdown.WriteString(text + "\n\n")
+				}
+			case "tool_use":
+				markdown.WriteString(formatClaudeToolUse(block, claudeToolStatusIcon(toolResults, block.ID)))
+			}
+		}
+	}
+
+	return markdown.String()
+}
+
+// claudeToolStatusIcon returns the status icon of the tool use from its result
+func claudeToolStatusIcon(toolResults map[string]claudeContentBlock, toolUseID string) string {
+	result, ok := toolResults[toolUseID]
+	if !ok {
+		return "❓"
+	}
+	if result.IsError {
+		return "❌"
+	}
+	return "✅"
+}
+
+// formatClaudeToolUse renders a Claude tool use in the reasoning section
+func formatClaudeToolUse(toolUse claudeContentBlock, statusIcon string) string {
+	input := parseOrderedObject(toolUse.Input)
+
+	switch toolUse.Name {
+	case "TodoWrite":
+		return ""
+	case "Bash":
+		var markdown string
+		if description := input.string("description"); description != "" {
+			markdown = description + ":\n\n"
+		}
+		return markdown + fmt.Sprintf("%s `%s`\n\n", statusIcon, formatBashCommand(input.string("command")))
+	case "Read":
+		return fmt.Sprintf("%s Read `%s`\n\n", statusIcon, runnerWorkspacePrefix.ReplaceAllString(input.firstString("file_path", "path"), ""))
+	case "Write", "Edit", "MultiEdit":
+		return fmt.Sprintf("%s Write `%s`\n\n", statusIcon, runnerWorkspacePrefix.ReplaceAllString(input.firstString("file_path", "path"), ""))
+	case "Grep", "Glob":
+		return fmt.Sprintf("%s Search for `%s`\n\n", statusIcon, truncateJSString(input.firstString("query", "pattern"), 80))
+	case "LS":
+		lsPath := input.string("path")
+		relativePath := runnerWorkspacePrefix.ReplaceAllString(lsPath, "")
+		if relativePath == "" {
+			relativePath = lsPath
+		}
+		return fmt.Sprintf("%s LS: %s\n\n", statusIcon, relativePath)
+	}
+
+	if strings.HasPrefix(toolUse.Name, "mcp__") {
+		return fmt.Sprintf("%s %s(%s)\n\n", statusIcon, formatMCPName(toolUse.Name), formatMCPParameters(input))
+	}
+
+	// Generic tools show their most important parameter
+	if len(input.keys) > 0 {
+		mainParam := input.keys[0]
+		for _, key := range input.keys {
+			if slices.Contains([]string{"query", "command", "path", "file_path", "content"}, key) {
+				mainParam = key
+				break
+			}
+		}
+		if value := jsString(input.values[mainParam]); value != "" {
+			return fmt.Sprintf("%s %s: %s\n\n", statusIcon, toolUse.Name, truncateJSString(value, 100))
+		}
+	}
+	return fmt.Sprintf("%s %s\n\n", statusIcon, toolUse.Name)
+}
+
+// formatMCPName converts mcp__github__search_issues to github::search_issues
+func formatMCPName(toolName string) string {
+	parts := strings.Split(toolName, "__")
+	if len(parts) >= 3 {
+		return parts[1] + "::" + strings.Join(parts[2:], "_")
+	}
+	return toolName
+}
+
+// formatMCPParameters renders up to four MCP tool parameters
+func formatMCPParameters(input orderedObject) string {
+	var params []string
+	for i, key := range input.keys {
+		if i == 4 {
+			params = append(params, "...")
+			break
+		}
+		params = append(params, fmt.Sprintf("%s: %s", key, truncateJSString(jsString(input.values[key]), 40)))
+	}
+	return strings.Join(params, ", ")
+}
+
+var (
+	// codexRenderToolPattern matches the tool name of a Codex tool call line
+	codexRenderToolPattern = regexp.MustCompile(`\] tool ([^(]+)\(`)
+	// codexRenderExecPattern matches the command of a Codex exec line
+	codexRenderExecPattern = regexp.MustCompile(`exec (.+?) in`)
+	// codexRenderTokensPattern matches the token usage of a Codex turn
+	codexRenderTokensPattern = regexp.MustCompile(`tokens used: (\d+)`)
+)
+
+// codexMetadataMarkers mark the Codex header lines left out of the reasoning section
+var codexMetadataMarkers = []string{
+	"OpenAI Codex", "workdir:", "model:", "provider:", "approval:", "sandbox:",
+	"reasoning effort:", "reasoning summaries:", "tokens used:",
+}
+
+// RenderLog renders a Codex log as the markdown transcript of the step summary
+func (e *CodexEngine) RenderLog(logContent string) string {
+	lines := strings.Split(logContent, "\n")
+
+	var markdown strings.Builder
+	markdown.WriteString("## 🤖 Commands and Tools\n\n")
+
+	// First pass: collect commands for the summary
+	var commandSummary []string
+	for i, line := range lines {
+		if strings.Contains(line, "] tool ") && strings.Contains(line, "(") {
+			if match := codexRenderToolPattern.FindStringSubmatch(line); match != nil {
+				commandSummary = append(commandSummary, fmt.Sprintf("* %s `%s(...)`", codexToolStatusIcon(lines, i), formatCodexToolName(match[1])))
+			}
+		} else if strings.Contains(line, "] exec ") {
+			if match := codexRenderExecPattern.FindStringSubmatch(line); match != nil {
+				commandSummary = append(commandSummary, fmt.Sprintf("* %s `%s`", codexExecStatusIcon(lines, i), formatBashCommand(match[1])))
+			}
+		}
+	}
+	writeCommandSummary(&markdown, commandSummary)
+
+	// Add the information section
+	markdown.WriteString("\n## 📊 Information\n\n")
+	totalTokens := 0
+	for _, match := range codexRenderTokensPattern.FindAllStringSubmatch(logContent, -1) {
+		tokens, _ := strconv.Atoi(match[1])
+		totalTokens += tokens
+	}
+	if totalTokens > 0 {
+		fmt.Fprintf(&markdown, "**Total Tokens Used:** %s\n\n", formatLocaleNumber(totalTokens))
+	}
+	if toolCalls := strings.Count(logContent, "] tool "); toolCalls > 0 {
+		fmt.Fprintf(&markdown, "**Tool Calls:** %d\n\n", toolCalls)
+	}
+	if execCommands := strings.Count(logContent, "] exec "); execCommands > 0 {
+		fmt.Fprintf(&markdown, "**Commands Executed:** %d\n\n", execCommands)
+	}
+
+	// Second pass: the conversation flow with interleaved reasoning, tools and commands
+	markdown.WriteString("\n## 🤖 Reasoning\n\n")
+	inThinkingSection := false
+	for i, line := range lines {
+		if slices.ContainsFunc(codexMetadataMarkers, func(marker string) bool { return strings.Contains(line, marker) }) ||
+			strings.HasPrefix(line, "--------") {
+			continue
+		}
+
+		if strings.Contains(line, "] thinking") {
+			inThinkingSection = true
+			continue
+		}
+
+		if strings.Contains(line, "] tool ") && strings.Contains(line, "(") {
+			inThinkingSection = false
+			if match := codexRenderToolPattern.FindStringSubmatch(line); match != nil {
+				fmt.Fprintf(&markdown, "%s %s(...)\n\n", codexToolStatusIcon(lines, i), formatCodexToolName(match[1]))
+			}
+			continue
+		}
+
+		if strings.Contains(line, "] exec ") {
+			inThinkingSection = false
+			if match := codexRenderExecPattern.FindStringSubmatch(line); match != nil {
+				fmt.Fprintf(&markdown, "%s `%s`\n\n", codexExecStatusIcon(lines, i), formatBashCommand(match[1]))
+			}
+			continue
+		}
+
+		if trimmed := jsTrim(line); inThinkingSection && jsLength(trimmed) > 20 && !strings.HasPrefix(line, "[2025-") {
+			markdown.WriteString(trimmed + "\n\n")
+		}
+	}
+
+	return markdown.String()
+}
+
+// formatCodexToolName converts github.search_issues to github::search_issues
+func formatCodexToolName(toolName string) string {
+	if provider, method, found := strings.Cut(toolName, "."); found {
+		return provider + "::" + strings.ReplaceAll(method, ".", "_")
+	}
+	return toolName
+}
+
+// codexToolStatusIcon looks ahead of a Codex tool call for its result
+func codexToolStatusIcon(lines []string, index int) string {
+	for _, next := range lines[index+1 : min(index+5, len(lines))] {
+		if strings.Contains(next, "success in") {
+			return "✅"
+		}
+		if strings.Contains(next, "failure in") || strings.Contains(next, "error in") || strings.Contains(next, "failed in") {
+			return "❌"
+		}
+	}
+	return "❓"
+}
+
+// codexExecStatusIcon looks ahead of a Codex command for its result
+func codexExecStatusIcon(lines []string, index int) string {
+	for _, next := range lines[index+1 : min(index+5, len(lines))] {
+		if strings.Contains(next, "succeeded in") {
+			return "✅"
+		}
+		if strings.Contains(next, "failed in") || strings.Contains(next, "error") {
+			return "❌"
+		}
+	}
+	return "❓"
+}
+
+// writeCommandSummary writes the command summary list of a transcript
+func writeCommandSummary(markdown *strings.Builder, commandSummary []string) {
+	if len(commandSummary) == 0 {
+		markdown.WriteString("No commands or tools used.\n")
+		return
+	}
+	for _, command := range commandSummary {
+		markdown.WriteString(command + "\n")
+	}
+}
+
+// formatBashCommand renders a command on a single line with escaped backticks,
+// truncated to 80 characters
+func formatBashCommand(command string) string {
+	formatted := jsTrim(jsWhitespace.ReplaceAllString(command, " "))
+	formatted = strings.ReplaceAll(formatted, "`", "\\`")
+	return truncateJSString(formatted, 80)
+}
+
+// orderedObject is a JSON object that keeps the order of its keys, like JavaScript objects do
+type orderedObject struct {
+	keys   []string
+	values map[string]any
+}
+
+// parseOrderedObject parses a JSON object, returning an empty object for anything else
+func parseOrderedObject(raw json.RawMessage) orderedObject {
+	object := orderedObject{values: make(map[string]any)}
+	if err := json.Unmarshal(raw, &object.values); err != nil {
+		object.values = make(map[string]any)
+		return object
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	if _, err := decoder.Token(); err != nil { // Opening brace
+		return object
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		key, _ := token.(string)
+		if !slices.Contains(object.keys, key) {
+			object.keys = append(object.keys, key)
+		}
+		var skip json.RawMessage
+		if err := decoder.Decode(&skip); err != nil {
+			break
+		}
+	}
+	return object
+}
+
+// string returns the value of the key if it is a non-empty string
+func (o orderedObject) string(key string) string {
+	value, _ := o.values[key].(string)
+	return value
+}
+
+// firstString returns the first non-empty string value of the keys
+func (o orderedObject) firstString(keys ...string) string {
+	for _, key := range keys {
+		if value := o.string(key); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// jsTruthy reports whether a decoded JSON value is truthy in JavaScript
+func jsTruthy(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0 && !math.IsNaN(v)
+	case string:
+		return v != ""
+	}
+	return true
+}
+
+// jsString converts a decoded JSON value like String(value || "") does in JavaScript
+func jsString(value any) string {
+	if !jsTruthy(value) {
+		return ""
+	}
+	switch v := value.(type) {
+	case bool:
+		return "true"
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			if item != nil {
+				parts[i] = jsString(item)
+				if item == false || item == 0.0 {
+					parts[i] = fmt.Sprint(item)
+				}
+			}
+		}
+		return strings.Join(parts, ",")
+	}
+	return "[object Object]"
+}
+
+// jsLength returns the length of a string in UTF-16 code units, like JavaScript does
+func jsLength(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
+
+// truncateJSString truncates a string to the given number of UTF-16 code units and
+// adds an ellipsis, like truncateString in the JavaScript renderers
+func truncateJSString(s string, maxLength int) string {
+	units := utf16.Encode([]rune(s))
+	if len(units) <= maxLength {
+		return s
+	}
+	return string(utf16.Decode(units[:maxLength])) + "..."
+}
+
+// jsTrim removes the whitespace String.prototype.trim removes
+func jsTrim(s string) string {
+	return strings.TrimFunc(s, func(r rune) bool {
+		return jsWhitespace.MatchString(string(r))
+	})
+}
+
+// formatLocaleNumber formats a number with thousands separators, like toLocaleString in en-US
+func formatLocaleNumber(n int) string {
+	digits := strconv.Itoa(n)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	var result strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			result.WriteByte(',')
+		}
+		result.WriteRune(digit)
+	}
+	return sign + result.String()
+}
@@ -0,0 +1,67 @@
+package workflow
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestFormatBashCommand(t *testing.T) {
+	tests := []struct {
+		command  string
+		expected string
+	}{
+		{"", ""},
+		{"  ls\n\t-la  ", "ls -la"},
+		{"echo `date`", "echo \\`date\\`"},
+		{strings.Repeat("a", 81), strings.Repeat("a", 80) + "..."},
+		{"echo hi", "echo hi"},
+	}
+
+	for _, tt := range tests {
+		if result := formatBashCommand(tt.command); result != tt.expected {
+			t.Errorf("formatBashCommand(%q) = %q, expected %q", tt.command, result, tt.expected)
+		}
+	}
+}
+
+func TestTruncateJSString(t *testing.T) {
+	// JavaScript counts UTF-16 code units, so the emoji counts twice
+	if result := truncateJSString("ab😀cd", 4); result != "ab😀..." {
+		t.Errorf("Expected truncation in UTF-16 code units, got %q", result)
+	}
+	if result := truncateJSString("héllo", 5); result != "héllo" {
+		t.Errorf("Expected no truncation, got %q", result)
+	}
+}
+
+func TestFormatLocaleNumber(t *testing.T) {
+	tests := map[int]string{0: "0", 999: "999", 1000: "1,000", 1234567: "1,234,567", -45000: "-45,000"}
+	for n, expected := range tests {
+		if result := formatLocaleNumber(n); result != expected {
+			t.Errorf("formatLocaleNumber(%d) = %q, expected %q", n, result, expected)
+		}
+	}
+}
+
+func TestFormatClaudeToolUseKeepsParameterOrder(t *testing.T) {
+	toolUse := claudeContentBlock{
+		Name:  "mcp__github__search_issues",
+		Input: json.RawMessage(`{"query":"is:open","per_page":10,"sort":"","labels":["bug","p1"],"extra":true}`),
+	}
+
+	expected := "✅ github::search_issues(query: is:open, per_page: 10, sort: , labels: bug,p1, ...)\n\n"
+	if result := formatClaudeToolUse(toolUse, "✅"); result != expected {
+		t.Errorf("Expected %q, got %q", expected, result)
+	}
+}
+
+func TestRenderLogUnsupportedFormat(t *testing.T) {
+	claude := NewClaudeEngine()
+	if result := claude.RenderLog(`{"type":"result"}`); !strings.Contains(result, "Log format not recognized") {
+		t.Errorf("Expected an unrecognized format summary, got %q", result)
+	}
+	if result := NewGeminiEngine().RenderLog("output"); result != "" {
+		t.Errorf("Expected no rendering for engines without a renderer, got %q", result)
+	}
+}
@@ -5,17 +5,140 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
+)
+
+// Stop reasons reported in LogMetrics.StopReason
+const (
+	StopReasonCompleted = "completed"
+	StopReasonMaxTurns  = "max-turns"
+	StopReasonError     = "error"
 )
 
 // LogMetrics represents extracted metrics from log files
 type LogMetrics struct {
 	TokenUsage    int
+	InputTokens   int
+	OutputTokens  int
+	CacheTokens   int // Cache creation and cache read input tokens
 	EstimatedCost float64
+	CostUnknown   bool // Tokens were used but could not be priced, EstimatedCost is left unset
 	ErrorCount    int
 	WarningCount  int
+	Turns         int
+	ToolCalls     map[string]ToolCallMetrics // Keyed by tool name, MCP tools as "server.tool"
+	ToolDuration  time.Duration
+	StopReason    string // One of the StopReason constants, empty when the log does not tell
+	Model         string // Model of the agent session, empty when the log does not tell
 	// Timestamp removed - use GitHub API timestamps instead of parsing from logs
 }
 
+// ToolCallMetrics counts the calls of a single tool
+type ToolCallMetrics struct {
+	Calls    int
+	Failures int
+	Duration time.Duration
+}
+
+// AddToolCall records a call of the tool
+func (m *LogMetrics) AddToolCall(name string) {
+	if m.ToolCalls == nil {
+		m.ToolCalls = make(map[string]ToolCallMetrics)
+	}
+	tool := m.ToolCalls[name]
+	tool.Calls++
+	m.ToolCalls[name] = tool
+}
+
+// AddToolResult records the outcome and latency of a call of the tool
+func (m *LogMetrics) AddToolResult(name string, failed bool, duration time.Duration) {
+	if m.ToolCalls == nil {
+		m.ToolCalls = make(map[string]ToolCallMetrics)
+	}
+	tool := m.ToolCalls[name]
+	if failed {
+		tool.Failures++
+	}
+	tool.Duration += duration
+	m.ToolCalls[name] = tool
+	m.ToolDuration += duration
+}
+
+// TotalToolCalls returns the number of tool calls across all tools
+func (m LogMetrics) TotalToolCalls() int {
+	total := 0
+	for _, tool := range m.ToolCalls {
+		total += tool.Calls
+	}
+	return total
+}
+
+// TotalToolFailures returns the number of failed tool calls across all tools
+func (m LogMetrics) TotalToolFailures() int {
+	total := 0
+	for _, tool := range m.ToolCalls {
+		total += tool.Failures
+	}
+	return total
+}
+
+// Add aggregates the metrics of another log into these metrics. The stop reason
+// keeps the most severe of the two.
+func (m *LogMetrics) Add(other LogMetrics) {
+	m.TokenUsage += other.TokenUsage
+	m.InputTokens += other.InputTokens
+	m.OutputTokens += other.OutputTokens
+	m.CacheTokens += other.CacheTokens
+	m.EstimatedCost += other.EstimatedCost
+	m.CostUnknown = m.CostUnknown || other.CostUnknown
+	m.ErrorCount += other.ErrorCount
+	m.WarningCount += other.WarningCount
+	m.Turns += other.Turns
+	m.ToolDuration += other.ToolDuration
+
+	for name, tool := range other.ToolCalls {
+		if m.ToolCalls == nil {
+			m.ToolCalls = make(map[string]ToolCallMetrics)
+		}
+		existing := m.ToolCalls[name]
+		existing.Calls += tool.Calls
+		existing.Failures += tool.Failures
+		existing.Duration += tool.Duration
+		m.ToolCalls[name] = existing
+	}
+
+	if m.Model == "" {
+		m.Model = other.Model
+	}
+
+	if stopReasonSeverity(other.StopReason) > stopReasonSeverity(m.StopReason) {
+		m.StopReason = other.StopReason
+	}
+}
+
+// stopReasonSeverity orders stop reasons from unknown to error
+func stopReasonSeverity(reason string) int {
+	switch reason {
+	case StopReasonCompleted:
+		return 1
+	case StopReasonMaxTurns:
+		return 2
+	case StopReasonError:
+		return 3
+	}
+	return 0
+}
+
+// NormalizeToolName turns MCP tool names like "mcp__github__get_issue" into "github.get_issue"
+func NormalizeToolName(name string) string {
+	if rest, ok := strings.CutPrefix(name, "mcp__"); ok {
+		if server, tool, found := strings.Cut(rest, "__"); found {
+			return server + "." + tool
+		}
+	}
+	return name
+}
+
 // ExtractFirstMatch extracts the first regex match from a string
 func ExtractFirstMatch(text, pattern string) string {
 	re := regexp.MustCompile(`(?i)` + pattern)
@@ -3,6 +3,7 @@ package workflow
 import (
 	"encoding/json"
 	"testing"
+	"time"
 )
 
 func TestExtractFirstMatch(t *testing.T) {
@@ -563,3 +564,120 @@ func TestExtractJSONMetricsIntegration(t *testing.T) {
 		t.Errorf("Expected no cost information, got %f", metrics.EstimatedCost)
 	}
 }
+
+func TestNormalizeToolName(t *testing.T) {
+	tests := map[string]string{
+		"mcp__github__get_issue": "github.get_issue",
+		"mcp__safe_outputs":      "mcp__safe_outputs",
+		"Bash":                   "Bash",
+		"github.get_issue":       "github.get_issue",
+	}
+	for input, expected := range tests {
+		if result := NormalizeToolName(input); result != expected {
+			t.Errorf("NormalizeToolName(%q) = %q, expected %q", input, result, expected)
+		}
+	}
+}
+
+func TestLogMetricsAdd(t *testing.T) {
+	var metrics LogMetrics
+	metrics.AddToolCall("github.get_issue")
+	metrics.AddToolResult("github.get_issue", false, 100*time.Millisecond)
+	metrics.StopReason = StopReasonCompleted
+
+	other := LogMetrics{TokenUsage: 30, InputTokens: 10, OutputTokens: 20, Turns: 2, StopReason: StopReasonMaxTurns}
+	other.AddToolCall("github.get_issue")
+	other.AddToolResult("github.get_issue", true, 50*time.Millisecond)
+	other.AddToolCall("Bash")
+
+	metrics.Add(other)
+
+	if metrics.TokenUsage != 30 || metrics.InputTokens != 10 || metrics.OutputTokens != 20 || metrics.Turns != 2 {
+		t.Errorf("Unexpected token and turn totals: %+v", metrics)
+	}
+	expected := ToolCallMetrics{Calls: 2, Failures: 1, Duration: 150 * time.Millisecond}
+	if metrics.ToolCalls["github.get_issue"] != expected {
+		t.Errorf("Expected %+v for github.get_issue, got %+v", expected, metrics.ToolCalls["github.get_issue"])
+	}
+	if metrics.TotalToolCalls() != 3 || metrics.TotalToolFailures() != 1 {
+		t.Errorf("Expected 3 tool calls with 1 failure, got %d with %d", metrics.TotalToolCalls(), metrics.TotalToolFailures())
+	}
+	if metrics.ToolDuration != 150*time.Millisecond {
+		t.Errorf("Expected tool duration 150ms, got %v", metrics.ToolDuration)
+	}
+	if metrics.StopReason != StopReasonMaxTurns {
+		t.Errorf("Expected the most severe stop reason %q, got %q", StopReasonMaxTurns, metrics.StopReason)
+	}
+}
+
+func TestClaudeEngineSessionMetrics(t *testing.T) {
+	logContent := `[
+  {"type": "system", "subtype": "init"},
+  {"type": "assistant", "message": {"content": [
+    {"type": "tool_use", "id": "toolu_1", "name": "mcp__github__get_issue", "input": {}},
+    {"type": "tool_use", "id": "toolu_2", "name": "Bash", "input": {}}
+  ]}},
+  {"type": "user", "message": {"content": [
+    {"type": "tool_result", "tool_use_id": "toolu_1", "content": "ok", "is_error": false},
+    {"type": "tool_result", "tool_use_id": "toolu_2", "content": "not found", "is_error": true}
+  ]}},
+  {"type": "result", "subtype": "error_max_turns", "is_error": true, "num_turns": 5,
+   "duration_ms": 9000, "duration_api_ms": 6000, "total_cost_usd": 0.5,
+   "usage": {"input_tokens": 100, "output_tokens": 50, "cache_creation_input_tokens": 1000, "cache_read_input_tokens": 2000}}
+]`
+
+	metrics := NewClaudeEngine().ParseLogMetrics(logContent, false)
+
+	if metrics.Turns != 5 {
+		t.Errorf("Expected 5 turns, got %d", metrics.Turns)
+	}
+	if metrics.InputTokens != 100 || metrics.OutputTokens != 50 || metrics.CacheTokens != 3000 {
+		t.Errorf("Unexpected token split: input=%d output=%d cache=%d", metrics.InputTokens, metrics.OutputTokens, metrics.CacheTokens)
+	}
+	if metrics.ToolCalls["github.get_issue"] != (ToolCallMetrics{Calls: 1}) {
+		t.Errorf("Expected one successful github.get_issue call, got %+v", metrics.ToolCalls["github.get_issue"])
+	}
+	if metrics.ToolCalls["Bash"] != (ToolCallMetrics{Calls: 1, Failures: 1}) {
+		t.Errorf("Expected one failed Bash call, got %+v", metrics.ToolCalls["Bash"])
+	}
+	if metrics.ToolDuration != 3*time.Second {
+		t.Errorf("Expected tool duration 3s, got %v", metrics.ToolDuration)
+	}
+	if metrics.StopReason != StopReasonMaxTurns {
+		t.Errorf("Expected stop reason %q, got %q", StopReasonMaxTurns, metrics.StopReason)
+	}
+}
+
+func TestCodexEngineSessionMetrics(t *testing.T) {
+	logContent := `[2025-08-31T12:37:33] tool github.get_issue({"number":1})
+[2025-08-31T12:37:33] github.get_issue({"number":1}) success in 120ms:
+{"title": "Bug"}
+[2025-08-31T12:37:33] tokens used: 1000
+[2025-08-31T12:37:40] exec bash -lc 'make test' in /home/runner/work/repo
+[2025-08-31T12:37:42] bash -lc 'make test' exited 2 in 1.5s:
+make: *** No rule to make target 'test'
+[2025-08-31T12:37:42] tokens used: 500`
+
+	metrics := NewCodexEngine().ParseLogMetrics(logContent, false)
+
+	if metrics.Turns != 2 || metrics.TokenUsage != 1500 {
+		t.Errorf("Expected 2 turns using 1500 tokens, got %d turns using %d", metrics.Turns, metrics.TokenUsage)
+	}
+	if metrics.ToolCalls["github.get_issue"] != (ToolCallMetrics{Calls: 1, Duration: 120 * time.Millisecond}) {
+		t.Errorf("Unexpected github.get_issue metrics: %+v", metrics.ToolCalls["github.get_issue"])
+	}
+	if metrics.ToolCalls["exec"] != (ToolCallMetrics{Calls: 1, Failures: 1, Duration: 1500 * time.Millisecond}) {
+		t.Errorf("Unexpected exec metrics: %+v", metrics.ToolCalls["exec"])
+	}
+	if metrics.ToolDuration != 1620*time.Millisecond {
+		t.Errorf("Expected tool duration 1.62s, got %v", metrics.ToolDuration)
+	}
+	if metrics.StopReason != StopReasonCompleted {
+		t.Errorf("Expected stop reason %q, got %q", StopReasonCompleted, metrics.StopReason)
+	}
+
+	metrics = NewCodexEngine().ParseLogMetrics(logContent+"\n[2025-08-31T12:37:50] ERROR: stream disconnected", false)
+	if metrics.StopReason != StopReasonError {
+		t.Errorf("Expected stop reason %q, got %q", StopReasonError, metrics.StopReason)
+	}
+}
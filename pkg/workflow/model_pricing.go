@@ -0,0 +1,178 @@
+package workflow
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/githubnext/gh-aw/pkg/console"
+	"github.com/goccy/go-yaml"
+)
+
+// ModelPricingVersion is the version of the built-in model pricing table
+const ModelPricingVersion = "2025-09-01"
+
+// ModelPricingFile is the repository file overriding the built-in model pricing
+const ModelPricingFile = ".github/aw-pricing.yml"
+
+// ModelPrice is the price of a model in USD per million tokens
+type ModelPrice struct {
+	Input       float64 `yaml:"input"`
+	Output      float64 `yaml:"output"`
+	CachedInput float64 `yaml:"cached-input,omitempty"` // Priced as input when not set
+}
+
+// ModelPricing is a versioned table of model prices keyed by engine ID and model
+type ModelPricing struct {
+	Version string                           `yaml:"version,omitempty"`
+	Models  map[string]map[string]ModelPrice `yaml:"models"`
+}
+
+// DefaultModelPricing returns the built-in model pricing table. Models are matched by
+// prefix, so "claude-sonnet-4" also prices "claude-sonnet-4-20250514".
+func DefaultModelPricing() *ModelPricing {
+	return &ModelPricing{
+		Version: ModelPricingVersion,
+		Models: map[string]map[string]ModelPrice{
+			"claude": {
+				"claude-opus-4":     {Input: 15, Output: 75, CachedInput: 1.5},
+				"claude-sonnet-4":   {Input: 3, Output: 15, CachedInput: 0.3},
+				"claude-3-7-sonnet": {Input: 3, Output: 15, CachedInput: 0.3},
+				"claude-3-5-sonnet": {Input: 3, Output: 15, CachedInput: 0.3},
+				"claude-3-5-haiku":  {Input: 0.8, Output: 4, CachedInput: 0.08},
+			},
+			"codex": {
+				"gpt-5":             {Input: 1.25, Output: 10, CachedInput: 0.125},
+				"gpt-5-mini":        {Input: 0.25, Output: 2, CachedInput: 0.025},
+				"gpt-5-nano":        {Input: 0.05, Output: 0.4, CachedInput: 0.005},
+				"gpt-4.1":           {Input: 2, Output: 8, CachedInput: 0.5},
+				"o3":                {Input: 2, Output: 8, CachedInput: 0.5},
+				"o4-mini":           {Input: 1.1, Output: 4.4, CachedInput: 0.275},
+				"codex-mini-latest": {Input: 1.5, Output: 6, CachedInput: 0.375},
+			},
+		},
+	}
+}
+
+// ParseModelPricing parses and validates a model pricing file
+func ParseModelPricing(content []byte) (*ModelPricing, error) {
+	var pricing ModelPricing
+	if err := yaml.Unmarshal(content, &pricing); err != nil {
+		return nil, fmt.Errorf("failed to parse model pricing: %w", err)
+	}
+	for engineID, models := range pricing.Models {
+		for model, price := range models {
+			if price.Input < 0 || price.Output < 0 || price.CachedInput < 0 {
+				return nil, fmt.Errorf("model pricing for %s model '%s' cannot be negative", engineID, model)
+			}
+		}
+	}
+	return &pricing, nil
+}
+
+// LoadModelPricing returns the built-in model pricing with the models of the pricing files
+// replacing built-in ones. The repository file takes precedence over the one in the home directory.
+func LoadModelPricing() (*ModelPricing, error) {
+	pricing := DefaultModelPricing()
+	for _, path := range modelPricingFiles() {
+		content, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return pricing, fmt.Errorf("failed to read model pricing %s: %w", path, err)
+		}
+		override, err := ParseModelPricing(content)
+		if err != nil {
+			return pricing, fmt.Errorf("invalid model pricing %s: %w", path, err)
+		}
+		pricing.merge(override)
+	}
+	return pricing, nil
+}
+
+// modelPricingFiles returns the pricing files in increasing order of precedence
+func modelPricingFiles() []string {
+	var files []string
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		files = append(files, filepath.Join(homeDir, ".aw", "pricing.yml"))
+	}
+	return append(files, ModelPricingFile)
+}
+
+// merge replaces the prices of the models in the other table, and its version when set
+func (p *ModelPricing) merge(other *ModelPricing) {
+	if other.Version != "" {
+		p.Version = other.Version
+	}
+	for engineID, models := range other.Models {
+		if p.Models[engineID] == nil {
+			p.Models[engineID] = make(map[string]ModelPrice)
+		}
+		for model, price := range models {
+			p.Models[engineID][model] = price
+		}
+	}
+}
+
+// Lookup returns the price of the model of the engine, matching the model exactly or
+// by the longest model prefix in the table
+func (p *ModelPricing) Lookup(engineID, model string) (ModelPrice, bool) {
+	models := p.Models[engineID]
+	if price, ok := models[model]; ok {
+		return price, true
+	}
+
+	var best string
+	for prefix := range models {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return ModelPrice{}, false
+	}
+	return models[best], true
+}
+
+// Cost returns the cost in USD of the tokens
+func (price ModelPrice) Cost(inputTokens, outputTokens, cachedTokens int) float64 {
+	cachedInput := price.CachedInput
+	if cachedInput == 0 {
+		cachedInput = price.Input
+	}
+	return (float64(inputTokens)*price.Input + float64(outputTokens)*price.Output + float64(cachedTokens)*cachedInput) / 1_000_000
+}
+
+// EstimateCost prices the token usage of the metrics for the model of the engine. It reports
+// false when the model has no price or when the log only reports total tokens, since input,
+// output and cached tokens are priced differently.
+func (p *ModelPricing) EstimateCost(engineID, model string, metrics LogMetrics) (float64, bool) {
+	price, ok := p.Lookup(engineID, model)
+	if !ok {
+		return 0, false
+	}
+	if metrics.InputTokens == 0 && metrics.OutputTokens == 0 && metrics.CacheTokens == 0 {
+		return 0, false
+	}
+	return price.Cost(metrics.InputTokens, metrics.OutputTokens, metrics.CacheTokens), true
+}
+
+var (
+	globalModelPricing   *ModelPricing
+	modelPricingInitOnce sync.Once
+)
+
+// GetModelPricing returns the model pricing loaded once per process, warning about invalid pricing files
+func GetModelPricing() *ModelPricing {
+	modelPricingInitOnce.Do(func() {
+		pricing, err := LoadModelPricing()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, console.FormatWarningMessage(err.Error()))
+		}
+		globalModelPricing = pricing
+	})
+	return globalModelPricing
+}
@@ -0,0 +1,134 @@
+package workflow
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestModelPricingLookup(t *testing.T) {
+	pricing := DefaultModelPricing()
+
+	tests := []struct {
+		engine   string
+		model    string
+		expected ModelPrice
+		found    bool
+	}{
+		{"codex", "o4-mini", ModelPrice{Input: 1.1, Output: 4.4, CachedInput: 0.275}, true},
+		{"codex", "gpt-5-mini-2025-08-07", ModelPrice{Input: 0.25, Output: 2, CachedInput: 0.025}, true},
+		{"codex", "gpt-5", ModelPrice{Input: 1.25, Output: 10, CachedInput: 0.125}, true},
+		{"claude", "claude-sonnet-4-20250514", ModelPrice{Input: 3, Output: 15, CachedInput: 0.3}, true},
+		{"claude", "o4-mini", ModelPrice{}, false},
+		{"codex", "unknown-model", ModelPrice{}, false},
+	}
+
+	for _, tt := range tests {
+		price, found := pricing.Lookup(tt.engine, tt.model)
+		if found != tt.found || price != tt.expected {
+			t.Errorf("Lookup(%q, %q) = %+v, %v, expected %+v, %v", tt.engine, tt.model, price, found, tt.expected, tt.found)
+		}
+	}
+}
+
+func TestModelPricingEstimateCost(t *testing.T) {
+	pricing := DefaultModelPricing()
+
+	// Token split priced per kind
+	cost, ok := pricing.EstimateCost("claude", "claude-sonnet-4", LogMetrics{InputTokens: 1_000_000, OutputTokens: 100_000, CacheTokens: 2_000_000})
+	if !ok || math.Abs(cost-(3+1.5+0.6)) > 1e-9 {
+		t.Errorf("Expected $5.10, got %f (ok: %v)", cost, ok)
+	}
+
+	// Total tokens only cannot be priced
+	if cost, ok := pricing.EstimateCost("codex", "o4-mini", LogMetrics{TokenUsage: 500_000}); ok || cost != 0 {
+		t.Errorf("Expected an unknown cost for total tokens only, got %f (ok: %v)", cost, ok)
+	}
+
+	if cost, ok := pricing.EstimateCost("codex", "", LogMetrics{InputTokens: 500_000}); ok || cost != 0 {
+		t.Errorf("Expected no cost without a model, got %f (ok: %v)", cost, ok)
+	}
+}
+
+func TestParseModelPricingOverride(t *testing.T) {
+	override, err := ParseModelPricing([]byte(`version: 2025-10-01-internal
+models:
+  codex:
+    o4-mini:
+      input: 0.5
+      output: 2
+  custom:
+    local-llama:
+      input: 0
+      output: 0
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	pricing := DefaultModelPricing()
+	pricing.merge(override)
+
+	if pricing.Version != "2025-10-01-internal" {
+		t.Errorf("Expected the override version, got %s", pricing.Version)
+	}
+	if price, _ := pricing.Lookup("codex", "o4-mini"); price != (ModelPrice{Input: 0.5, Output: 2}) {
+		t.Errorf("Expected the overridden o4-mini price, got %+v", price)
+	}
+	if _, found := pricing.Lookup("codex", "gpt-5"); !found {
+		t.Error("Expected built-in prices to be kept")
+	}
+	if _, found := pricing.Lookup("custom", "local-llama"); !found {
+		t.Error("Expected prices of new engines to be added")
+	}
+
+	// Cached input defaults to the input price
+	if cost := (ModelPrice{Input: 0.5, Output: 2}).Cost(0, 0, 1_000_000); cost != 0.5 {
+		t.Errorf("Expected cached tokens priced as input, got %f", cost)
+	}
+}
+
+func TestParseModelPricingNegative(t *testing.T) {
+	_, err := ParseModelPricing([]byte("models:\n  codex:\n    o3:\n      input: -1\n      output: 8\n"))
+	if err == nil || !strings.Contains(err.Error(), "cannot be negative") {
+		t.Errorf("Expected a negative price error, got %v", err)
+	}
+}
+
+func TestCodexEngineEstimatedCost(t *testing.T) {
+	logContent := `[2025-08-31T12:37:08] OpenAI Codex v0.27.0 (research preview)
+--------
+workdir: /home/runner/work/repo/repo
+model: o4-mini
+provider: openai
+--------
+[2025-08-31T12:37:08] User instructions:
+Summarize the repository.
+[2025-08-31T12:37:20] Token usage: total=1,500,000 input=400,000 (+ 800,000 cached) output=300,000 (reasoning 100,000)`
+
+	metrics := NewCodexEngine().ParseLogMetrics(logContent, false)
+	if metrics.TokenUsage != 1_500_000 || metrics.InputTokens != 400_000 || metrics.CacheTokens != 800_000 || metrics.OutputTokens != 300_000 {
+		t.Errorf("Expected the Codex token split, got %+v", metrics)
+	}
+	if math.Abs(metrics.EstimatedCost-1.98) > 1e-9 || metrics.CostUnknown {
+		t.Errorf("Expected the o4-mini price of the token split ($1.98), got %f (unknown: %v)", metrics.EstimatedCost, metrics.CostUnknown)
+	}
+}
+
+func TestCodexEngineUnknownCost(t *testing.T) {
+	logContent := `[2025-08-31T12:37:08] OpenAI Codex v0.27.0 (research preview)
+--------
+model: o4-mini
+--------
+[2025-08-31T12:37:08] User instructions:
+Summarize the repository.
+[2025-08-31T12:37:20] tokens used: 200000`
+
+	metrics := NewCodexEngine().ParseLogMetrics(logContent, false)
+	if metrics.TokenUsage != 200000 {
+		t.Errorf("Expected 200000 tokens, got %d", metrics.TokenUsage)
+	}
+	if metrics.EstimatedCost != 0 || !metrics.CostUnknown {
+		t.Errorf("Expected an unknown cost for total tokens only, got %f (unknown: %v)", metrics.EstimatedCost, metrics.CostUnknown)
+	}
+}
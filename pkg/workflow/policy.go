@@ -0,0 +1,560 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/githubnext/gh-aw/pkg/console"
+	"github.com/githubnext/gh-aw/pkg/parser"
+	"github.com/goccy/go-yaml"
+)
+
+const (
+	// RepositoryPolicyPath is the repository-level policy file, relative to the repository root
+	RepositoryPolicyPath = ".github/aw-policy.yml"
+	// OrgPolicyPath is where an organization-wide default policy can be vendored into a repository
+	OrgPolicyPath = ".github/aw/org-policy.yml"
+)
+
+// githubPermissionScopes lists the GITHUB_TOKEN permission scopes used to expand read-all/write-all
+var githubPermissionScopes = []string{
+	"actions",
+	"attestations",
+	"checks",
+	"contents",
+	"deployments",
+	"discussions",
+	"id-token",
+	"issues",
+	"models",
+	"packages",
+	"pages",
+	"pull-requests",
+	"repository-projects",
+	"security-events",
+	"statuses",
+}
+
+// WorkflowPolicy holds guardrails that every workflow in a repository must satisfy at compile time
+type WorkflowPolicy struct {
+	AllowedEngines    []string       `yaml:"allowed-engines,omitempty"`     // Engine IDs workflows may use
+	AllowedModels     []string       `yaml:"allowed-models,omitempty"`      // Models workflows may request via engine.model
+	MaxPermissions    any            `yaml:"max-permissions,omitempty"`     // "read-all", "write-all" or a map of scope to none/read/write
+	Network           *NetworkPolicy `yaml:"network,omitempty"`             // Network restrictions
+	ForbiddenTools    []string       `yaml:"forbidden-tools,omitempty"`     // Tool names that may not appear under tools:
+	RequireStopAfter  bool           `yaml:"require-stop-after,omitempty"`  // Scheduled workflows must set on.stop-after
+	MaxTimeoutMinutes int            `yaml:"max-timeout-minutes,omitempty"` // Upper bound for timeout_minutes
+
+	// Sources records which files contributed to this policy, for error messages
+	Sources []string `yaml:"-"`
+}
+
+// NetworkPolicy holds the network section of a workflow policy
+type NetworkPolicy struct {
+	Restricted bool     `yaml:"restricted,omitempty"` // Workflows must declare an explicit network.allowed list on an engine that enforces it
+	Allowed    []string `yaml:"allowed,omitempty"`    // Domains and ecosystem identifiers workflows may request
+}
+
+// PolicyViolation describes a single way in which a workflow breaks the policy
+type PolicyViolation struct {
+	Path    string // JSON path into the frontmatter, e.g. /permissions/contents
+	Message string
+}
+
+// ParseWorkflowPolicy parses a policy file, rejecting unknown keys so that typos cannot silently loosen a policy
+func ParseWorkflowPolicy(content []byte) (*WorkflowPolicy, error) {
+	policy := &WorkflowPolicy{}
+	if err := yaml.UnmarshalWithOptions(content, policy, yaml.DisallowUnknownField()); err != nil {
+		return nil, err
+	}
+	if _, err := policy.maxPermissionLevels(); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// LoadWorkflowPolicy finds the repository containing markdownPath and loads the org and repository
+// policy files from it. Returns nil if neither file exists.
+func LoadWorkflowPolicy(markdownPath string) (*WorkflowPolicy, error) {
+	root := findPolicyRoot(filepath.Dir(markdownPath))
+	if root == "" {
+		return nil, nil
+	}
+
+	var policy *WorkflowPolicy
+	for _, relPath := range []string{OrgPolicyPath, RepositoryPolicyPath} {
+		policyPath := filepath.Join(root, relPath)
+		content, err := os.ReadFile(policyPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read policy file %s: %w", relPath, err)
+		}
+		filePolicy, err := ParseWorkflowPolicy(content)
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy file %s: %w", relPath, err)
+		}
+		filePolicy.Sources = []string{relPath}
+		policy = mergeWorkflowPolicies(policy, filePolicy)
+	}
+	return policy, nil
+}
+
+// findPolicyRoot walks up from dir to the nearest directory that contains a .git entry or a policy file
+func findPolicyRoot(dir string) string {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		for _, marker := range []string{".git", RepositoryPolicyPath, OrgPolicyPath} {
+			if _, err := os.Stat(filepath.Join(absDir, marker)); err == nil {
+				return absDir
+			}
+		}
+		parent := filepath.Dir(absDir)
+		if parent == absDir {
+			return ""
+		}
+		absDir = parent
+	}
+}
+
+// mergeWorkflowPolicies combines two policies keeping the most restrictive value of every rule,
+// so a repository policy can only tighten the organization defaults
+func mergeWorkflowPolicies(base, override *WorkflowPolicy) *WorkflowPolicy {
+	if base == nil {
+		return override
+	}
+	if override == nil {
+		return base
+	}
+
+	merged := &WorkflowPolicy{
+		AllowedEngines:    intersectAllowLists(base.AllowedEngines, override.AllowedEngines),
+		AllowedModels:     intersectAllowLists(base.AllowedModels, override.AllowedModels),
+		ForbiddenTools:    append(slices.Clone(base.ForbiddenTools), override.ForbiddenTools...),
+		RequireStopAfter:  base.RequireStopAfter || override.RequireStopAfter,
+		MaxTimeoutMinutes: minPositive(base.MaxTimeoutMinutes, override.MaxTimeoutMinutes),
+		Sources:           append(slices.Clone(base.Sources), override.Sources...),
+	}
+
+	// Permissions: take the lower level for every scope
+	baseLevels, _ := base.maxPermissionLevels()
+	overrideLevels, _ := override.maxPermissionLevels()
+	switch {
+	case baseLevels == nil:
+		merged.MaxPermissions = override.MaxPermissions
+	case overrideLevels == nil:
+		merged.MaxPermissions = base.MaxPermissions
+	default:
+		perms := make(map[string]any)
+		for _, scope := range githubPermissionScopes {
+			perms[scope] = permissionLevelName(min(baseLevels[scope], overrideLevels[scope]))
+		}
+		merged.MaxPermissions = perms
+	}
+
+	// Network
+	if base.Network != nil || override.Network != nil {
+		merged.Network = &NetworkPolicy{}
+		var baseAllowed, overrideAllowed []string
+		if base.Network != nil {
+			merged.Network.Restricted = base.Network.Restricted
+			baseAllowed = base.Network.Allowed
+		}
+		if override.Network != nil {
+			merged.Network.Restricted = merged.Network.Restricted || override.Network.Restricted
+			overrideAllowed = override.Network.Allowed
+		}
+		merged.Network.Allowed = intersectAllowLists(baseAllowed, overrideAllowed)
+	}
+
+	return merged
+}
+
+// intersectAllowLists intersects two allow-lists where a nil list means "no restriction" and an
+// empty list means "nothing is allowed"
+func intersectAllowLists(a, b []string) []string {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	var result []string
+	for _, item := range a {
+		if slices.Contains(b, item) {
+			result = append(result, item)
+		}
+	}
+	if result == nil {
+		// Both lists restrict but share nothing: nothing is allowed
+		result = []string{}
+	}
+	return result
+}
+
+// allowListPermits reports whether an allow-list permits an item, where a nil list permits everything
+func allowListPermits(allowList []string, item string) bool {
+	return allowList == nil || slices.Contains(allowList, item)
+}
+
+// formatAllowList formats an allow-list for a policy violation message
+func formatAllowList(allowList []string) string {
+	if len(allowList) == 0 {
+		return "none"
+	}
+	return strings.Join(allowList, ", ")
+}
+
+// minPositive returns the smaller of two limits where zero means "no limit"
+func minPositive(a, b int) int {
+	if a <= 0 {
+		return b
+	}
+	if b <= 0 {
+		return a
+	}
+	return min(a, b)
+}
+
+// permission levels ordered from least to most privileged
+const (
+	permissionNone = iota
+	permissionRead
+	permissionWrite
+)
+
+func parsePermissionLevel(value string) (int, error) {
+	switch value {
+	case "none":
+		return permissionNone, nil
+	case "read":
+		return permissionRead, nil
+	case "write":
+		return permissionWrite, nil
+	default:
+		return 0, fmt.Errorf("invalid permission level '%s' (expected none, read or write)", value)
+	}
+}
+
+func permissionLevelName(level int) string {
+	switch level {
+	case permissionWrite:
+		return "write"
+	case permissionRead:
+		return "read"
+	default:
+		return "none"
+	}
+}
+
+// expandPermissionLevels converts a permissions value (shorthand string or scope map) into a level per scope.
+// Scopes missing from a map are treated as none, matching GitHub Actions semantics.
+func expandPermissionLevels(value any) (map[string]int, error) {
+	levels := make(map[string]int)
+	switch v := value.(type) {
+	case string:
+		var level int
+		switch v {
+		case "read-all":
+			level = permissionRead
+		case "write-all":
+			level = permissionWrite
+		case "":
+			level = permissionNone
+		default:
+			return nil, fmt.Errorf("invalid permissions value '%s' (expected read-all, write-all or a map of scopes)", v)
+		}
+		for _, scope := range githubPermissionScopes {
+			levels[scope] = level
+		}
+	case map[string]any:
+		for scope, raw := range v {
+			levelStr, ok := raw.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid permission level for '%s'", scope)
+			}
+			level, err := parsePermissionLevel(levelStr)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", scope, err)
+			}
+			levels[scope] = level
+		}
+	case nil:
+		// {} or no value: no permissions
+	default:
+		return nil, fmt.Errorf("invalid permissions format")
+	}
+	return levels, nil
+}
+
+// maxPermissionLevels returns the policy's permission ceiling per scope, or nil if the policy sets none
+func (p *WorkflowPolicy) maxPermissionLevels() (map[string]int, error) {
+	if p.MaxPermissions == nil {
+		return nil, nil
+	}
+	levels, err := expandPermissionLevels(p.MaxPermissions)
+	if err != nil {
+		return nil, fmt.Errorf("max-permissions: %w", err)
+	}
+	return levels, nil
+}
+
+// validateWorkflowPolicy checks the fully parsed workflow against the policy and returns every violation found
+func (c *Compiler) validateWorkflowPolicy(policy *WorkflowPolicy, data *WorkflowData) []PolicyViolation {
+	if policy == nil {
+		return nil
+	}
+
+	var violations []PolicyViolation
+
+	for _, engine := range policyEngines(data) {
+		violations = append(violations, validateEnginePolicy(policy, engine.path, engine.id, engine.model)...)
+	}
+
+	violations = append(violations, validatePermissionsPolicy(policy, data.Permissions)...)
+	violations = append(violations, c.validateNetworkPolicy(policy, data)...)
+
+	if len(policy.ForbiddenTools) > 0 {
+		var toolNames []string
+		for name := range data.Tools {
+			toolNames = append(toolNames, name)
+		}
+		slices.Sort(toolNames)
+		for _, name := range toolNames {
+			if slices.Contains(policy.ForbiddenTools, name) {
+				violations = append(violations, PolicyViolation{
+					Path:    "/tools/" + name,
+					Message: fmt.Sprintf("tool '%s' is forbidden by policy", name),
+				})
+			}
+		}
+	}
+
+	if policy.RequireStopAfter && data.StopTime == "" && workflowHasScheduleTrigger(data.On) {
+		violations = append(violations, PolicyViolation{
+			Path:    "/on",
+			Message: "scheduled workflows must set 'stop-after' in the 'on' section",
+		})
+	}
+
+	if policy.MaxTimeoutMinutes > 0 {
+		timeout := extractTimeoutMinutes(data.TimeoutMinutes)
+		if timeout > policy.MaxTimeoutMinutes {
+			violations = append(violations, PolicyViolation{
+				Path:    "/timeout_minutes",
+				Message: fmt.Sprintf("timeout_minutes %d exceeds the policy maximum of %d", timeout, policy.MaxTimeoutMinutes),
+			})
+		}
+	}
+
+	return violations
+}
+
+// policyEngine is an engine that may run the prompt, with the JSON path of its configuration in the frontmatter
+type policyEngine struct {
+	path  string
+	id    string
+	model string
+}
+
+// policyEngines returns every engine that may run the prompt: the primary engine, its fallbacks and the matrix legs
+func policyEngines(data *WorkflowData) []policyEngine {
+	var model string
+	if data.EngineConfig != nil {
+		model = data.EngineConfig.Model
+	}
+	engines := []policyEngine{{path: "/engine", id: data.AI, model: model}}
+	if data.EngineConfig == nil {
+		return engines
+	}
+	for i, fallback := range data.EngineConfig.Fallback {
+		engines = append(engines, policyEngine{path: fmt.Sprintf("/engine/fallback/%d", i), id: fallback.ID, model: fallback.Model})
+	}
+	for i, leg := range data.EngineConfig.Matrix {
+		engines = append(engines, policyEngine{path: fmt.Sprintf("/engine/matrix/%d", i), id: leg.ID, model: leg.Model})
+	}
+	return engines
+}
+
+// validateEnginePolicy checks an engine and its model against the policy allow-lists. path is the
+// JSON path of the engine configuration in the frontmatter.
+func validateEnginePolicy(policy *WorkflowPolicy, path string, engineID string, model string) []PolicyViolation {
+	var violations []PolicyViolation
+	if !allowListPermits(policy.AllowedEngines, engineID) {
+		violations = append(violations, PolicyViolation{
+			Path:    path,
+			Message: fmt.Sprintf("engine '%s' is not allowed by policy (allowed: %s)", engineID, formatAllowList(policy.AllowedEngines)),
+		})
+	}
+	if model != "" && !allowListPermits(policy.AllowedModels, model) {
+		violations = append(violations, PolicyViolation{
+			Path:    path + "/model",
+			Message: fmt.Sprintf("model '%s' is not allowed by policy (allowed: %s)", model, formatAllowList(policy.AllowedModels)),
+		})
+	}
+	return violations
+}
+
+// validatePermissionsPolicy compares the effective workflow permissions with the policy ceiling
+func validatePermissionsPolicy(policy *WorkflowPolicy, permissionsYAML string) []PolicyViolation {
+	maxLevels, err := policy.maxPermissionLevels()
+	if err != nil || maxLevels == nil {
+		return nil
+	}
+
+	var section map[string]any
+	if err := yaml.Unmarshal([]byte(permissionsYAML), &section); err != nil {
+		return []PolicyViolation{{Path: "/permissions", Message: fmt.Sprintf("unable to parse permissions: %v", err)}}
+	}
+	levels, err := expandPermissionLevels(section["permissions"])
+	if err != nil {
+		return []PolicyViolation{{Path: "/permissions", Message: err.Error()}}
+	}
+
+	var scopes []string
+	for scope := range levels {
+		scopes = append(scopes, scope)
+	}
+	slices.Sort(scopes)
+
+	var violations []PolicyViolation
+	for _, scope := range scopes {
+		if levels[scope] > maxLevels[scope] {
+			path := "/permissions/" + scope
+			if _, isShorthand := section["permissions"].(string); isShorthand {
+				path = "/permissions"
+			}
+			violations = append(violations, PolicyViolation{
+				Path: path,
+				Message: fmt.Sprintf("permission '%s: %s' exceeds the policy maximum '%s'",
+					scope, permissionLevelName(levels[scope]), permissionLevelName(maxLevels[scope])),
+			})
+		}
+	}
+	return violations
+}
+
+// validateNetworkPolicy checks that network access is explicitly restricted and within the policy allow-list
+func (c *Compiler) validateNetworkPolicy(policy *WorkflowPolicy, data *WorkflowData) []PolicyViolation {
+	if policy.Network == nil {
+		return nil
+	}
+
+	var violations []PolicyViolation
+	if policy.Network.Restricted {
+		if data.Network == "" || data.NetworkPermissions == nil || data.NetworkPermissions.Mode == "defaults" {
+			violations = append(violations, PolicyViolation{
+				Path:    "/network",
+				Message: "policy requires an explicit 'network: { allowed: [...] }' configuration",
+			})
+		} else {
+			// Network permissions are currently only enforced by the Claude engine hooks
+			for _, engine := range policyEngines(data) {
+				if engine.id != "claude" {
+					violations = append(violations, PolicyViolation{
+						Path:    engine.path,
+						Message: fmt.Sprintf("policy requires restricted network access, which engine '%s' does not enforce", engine.id),
+					})
+				}
+			}
+		}
+	}
+
+	if policy.Network.Allowed != nil && data.NetworkPermissions != nil {
+		requested := data.NetworkPermissions.Allowed
+		if data.NetworkPermissions.Mode == "defaults" {
+			requested = []string{"defaults"}
+		}
+		for i, domain := range requested {
+			if !allowListPermits(policy.Network.Allowed, domain) {
+				path := "/network"
+				if data.NetworkPermissions.Mode != "defaults" {
+					path = fmt.Sprintf("/network/allowed/%d", i)
+				}
+				violations = append(violations, PolicyViolation{
+					Path:    path,
+					Message: fmt.Sprintf("network access to '%s' is not allowed by policy", domain),
+				})
+			}
+		}
+	}
+	return violations
+}
+
+// workflowHasScheduleTrigger reports whether the rendered on: section contains a schedule trigger
+func workflowHasScheduleTrigger(onYAML string) bool {
+	var section map[string]any
+	if err := yaml.Unmarshal([]byte(onYAML), &section); err != nil {
+		return false
+	}
+	if events, ok := section["on"].(map[string]any); ok {
+		_, hasSchedule := events["schedule"]
+		return hasSchedule
+	}
+	return false
+}
+
+// extractTimeoutMinutes parses the value out of a rendered "timeout_minutes: N" line
+func extractTimeoutMinutes(timeoutYAML string) int {
+	value := strings.TrimSpace(strings.TrimPrefix(timeoutYAML, "timeout_minutes:"))
+	minutes, err := strconv.Atoi(value)
+	if err != nil {
+		return 0
+	}
+	return minutes
+}
+
+// formatPolicyViolations renders policy violations as located compiler errors in the workflow markdown file
+func formatPolicyViolations(markdownPath string, policy *WorkflowPolicy, violations []PolicyViolation) error {
+	var frontmatterYAML string
+	var lines []string
+	frontmatterStart := 2
+	if content, err := os.ReadFile(markdownPath); err == nil {
+		lines = strings.Split(string(content), "\n")
+		if result, err := parser.ExtractFrontmatterFromContent(string(content)); err == nil {
+			frontmatterYAML = strings.Join(result.FrontmatterLines, "\n")
+			if result.FrontmatterStart > 0 {
+				frontmatterStart = result.FrontmatterStart
+			}
+		}
+	}
+
+	var output strings.Builder
+	for _, violation := range violations {
+		line, column := frontmatterStart, 1
+		if frontmatterYAML != "" {
+			location := parser.LocateJSONPathInYAML(frontmatterYAML, violation.Path)
+			if location.Found {
+				line = location.Line + frontmatterStart - 1
+				column = location.Column
+			}
+		}
+
+		var context []string
+		for i := max(1, line-2); i <= min(len(lines), line+2); i++ {
+			context = append(context, lines[i-1])
+		}
+
+		output.WriteString(console.FormatError(console.CompilerError{
+			Position: console.ErrorPosition{
+				File:   markdownPath,
+				Line:   line,
+				Column: column,
+			},
+			Type:    "error",
+			Message: "policy violation: " + violation.Message,
+			Context: context,
+			Hint:    fmt.Sprintf("this rule is set in %s", strings.Join(policy.Sources, " and ")),
+		}))
+	}
+	return errors.New(strings.TrimSuffix(output.String(), "\n"))
+}
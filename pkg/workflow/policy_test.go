@@ -0,0 +1,493 @@
+package workflow
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseWorkflowPolicy(t *testing.T) {
+	policy, err := ParseWorkflowPolicy([]byte(`
+allowed-engines: [claude]
+allowed-models: [claude-sonnet-4]
+max-permissions:
+  contents: read
+  issues: write
+network:
+  restricted: true
+  allowed: [defaults, github]
+forbidden-tools: [web-fetch]
+require-stop-after: true
+max-timeout-minutes: 30
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(policy.AllowedEngines) != 1 || policy.AllowedEngines[0] != "claude" {
+		t.Errorf("unexpected allowed engines: %v", policy.AllowedEngines)
+	}
+	if policy.Network == nil || !policy.Network.Restricted || len(policy.Network.Allowed) != 2 {
+		t.Errorf("unexpected network policy: %+v", policy.Network)
+	}
+	if !policy.RequireStopAfter || policy.MaxTimeoutMinutes != 30 {
+		t.Errorf("unexpected schedule/timeout rules: %+v", policy)
+	}
+
+	levels, err := policy.maxPermissionLevels()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if levels["contents"] != permissionRead || levels["issues"] != permissionWrite || levels["actions"] != permissionNone {
+		t.Errorf("unexpected permission levels: %v", levels)
+	}
+}
+
+func TestParseWorkflowPolicyErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "unknown key", content: "allowed-engine: [claude]"},
+		{name: "invalid permission level", content: "max-permissions:\n  contents: admin"},
+		{name: "invalid permission shorthand", content: "max-permissions: everything"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseWorkflowPolicy([]byte(tt.content)); err == nil {
+				t.Errorf("expected error for %q", tt.content)
+			}
+		})
+	}
+}
+
+func TestMergeWorkflowPolicies(t *testing.T) {
+	org := &WorkflowPolicy{
+		AllowedEngines:    []string{"claude", "codex"},
+		MaxPermissions:    "read-all",
+		ForbiddenTools:    []string{"playwright"},
+		MaxTimeoutMinutes: 60,
+		Network:           &NetworkPolicy{Allowed: []string{"defaults", "python"}},
+		Sources:           []string{OrgPolicyPath},
+	}
+	repo := &WorkflowPolicy{
+		AllowedEngines:    []string{"claude"},
+		MaxPermissions:    map[string]any{"contents": "write", "issues": "read"},
+		ForbiddenTools:    []string{"web-fetch"},
+		MaxTimeoutMinutes: 90,
+		RequireStopAfter:  true,
+		Network:           &NetworkPolicy{Restricted: true},
+		Sources:           []string{RepositoryPolicyPath},
+	}
+
+	merged := mergeWorkflowPolicies(org, repo)
+
+	if strings.Join(merged.AllowedEngines, ",") != "claude" {
+		t.Errorf("expected engines to be intersected, got %v", merged.AllowedEngines)
+	}
+	if strings.Join(merged.ForbiddenTools, ",") != "playwright,web-fetch" {
+		t.Errorf("expected forbidden tools to be unioned, got %v", merged.ForbiddenTools)
+	}
+	if merged.MaxTimeoutMinutes != 60 {
+		t.Errorf("expected the lower timeout to win, got %d", merged.MaxTimeoutMinutes)
+	}
+	if !merged.RequireStopAfter {
+		t.Error("expected require-stop-after to be kept")
+	}
+	if merged.Network == nil || !merged.Network.Restricted || strings.Join(merged.Network.Allowed, ",") != "defaults,python" {
+		t.Errorf("unexpected merged network policy: %+v", merged.Network)
+	}
+
+	levels, err := merged.maxPermissionLevels()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if levels["contents"] != permissionRead {
+		t.Errorf("expected contents to be capped at read by the org policy, got %s", permissionLevelName(levels["contents"]))
+	}
+	if levels["issues"] != permissionRead {
+		t.Errorf("expected issues to stay read, got %s", permissionLevelName(levels["issues"]))
+	}
+	if levels["actions"] != permissionNone {
+		t.Errorf("expected actions to be none since the repo policy omits it, got %s", permissionLevelName(levels["actions"]))
+	}
+	if len(merged.Sources) != 2 {
+		t.Errorf("expected both sources to be recorded, got %v", merged.Sources)
+	}
+}
+
+func TestMergeWorkflowPoliciesWithoutOverlap(t *testing.T) {
+	org := &WorkflowPolicy{
+		AllowedEngines: []string{"claude"},
+		AllowedModels:  []string{"claude-sonnet-4"},
+		Network:        &NetworkPolicy{Allowed: []string{"python"}},
+	}
+	repo := &WorkflowPolicy{
+		AllowedEngines: []string{"codex"},
+		AllowedModels:  []string{"gpt-5"},
+		Network:        &NetworkPolicy{Allowed: []string{"node"}},
+	}
+
+	merged := mergeWorkflowPolicies(org, repo)
+
+	// Lists that share no entries allow nothing, unlike an omitted list which allows everything
+	if merged.AllowedEngines == nil || len(merged.AllowedEngines) != 0 {
+		t.Errorf("expected no engines to be allowed, got %#v", merged.AllowedEngines)
+	}
+	if merged.AllowedModels == nil || len(merged.AllowedModels) != 0 {
+		t.Errorf("expected no models to be allowed, got %#v", merged.AllowedModels)
+	}
+	if merged.Network == nil || merged.Network.Allowed == nil || len(merged.Network.Allowed) != 0 {
+		t.Errorf("expected no network access to be allowed, got %+v", merged.Network)
+	}
+	for _, item := range []string{"claude", "codex", "gemini"} {
+		if allowListPermits(merged.AllowedEngines, item) {
+			t.Errorf("expected engine '%s' to be rejected", item)
+		}
+	}
+
+	unrestricted := mergeWorkflowPolicies(&WorkflowPolicy{}, &WorkflowPolicy{MaxTimeoutMinutes: 10})
+	if unrestricted.AllowedEngines != nil || !allowListPermits(unrestricted.AllowedEngines, "gemini") {
+		t.Errorf("expected omitted allow-lists to stay unrestricted, got %#v", unrestricted.AllowedEngines)
+	}
+}
+
+func TestCompileWorkflowWithPolicy(t *testing.T) {
+	tests := []struct {
+		name           string
+		orgPolicy      string
+		repoPolicy     string
+		workflow       string
+		expectedErrors []string
+	}{
+		{
+			name:       "compliant workflow",
+			repoPolicy: "allowed-engines: [claude]\nmax-permissions: read-all\nmax-timeout-minutes: 10",
+			workflow: `---
+on: workflow_dispatch
+permissions:
+  contents: read
+engine: claude
+timeout_minutes: 10
+---
+
+# Compliant`,
+		},
+		{
+			name:       "engine not allowed",
+			repoPolicy: "allowed-engines: [claude]",
+			workflow: `---
+on: workflow_dispatch
+engine: codex
+---
+
+# Codex`,
+			expectedErrors: []string{"engine 'codex' is not allowed by policy", "test.md:3:"},
+		},
+		{
+			name:       "model not allowed",
+			repoPolicy: "allowed-models: [claude-sonnet-4]",
+			workflow: `---
+on: workflow_dispatch
+engine:
+  id: claude
+  model: claude-opus-4
+---
+
+# Model`,
+			expectedErrors: []string{"model 'claude-opus-4' is not allowed by policy", "test.md:5:"},
+		},
+		{
+			name:       "fallback engine not allowed",
+			repoPolicy: "allowed-engines: [claude]",
+			workflow: `---
+on: workflow_dispatch
+engine:
+  id: claude
+  fallback:
+    - codex
+---
+
+# Fallback`,
+			expectedErrors: []string{"engine 'codex' is not allowed by policy", "test.md:6:"},
+		},
+		{
+			name:       "fallback model not allowed",
+			repoPolicy: "allowed-models: [claude-sonnet-4]",
+			workflow: `---
+on: workflow_dispatch
+engine:
+  id: claude
+  model: claude-sonnet-4
+  fallback:
+    - id: codex
+      model: gpt-5
+---
+
+# Fallback model`,
+			expectedErrors: []string{"model 'gpt-5' is not allowed by policy", "test.md:8:"},
+		},
+		{
+			name:       "matrix engine and model not allowed",
+			repoPolicy: "allowed-engines: [claude]\nallowed-models: [claude-sonnet-4]",
+			workflow: `---
+on: workflow_dispatch
+engine:
+  matrix:
+    - claude
+    - id: codex
+      model: o4-mini
+  compare: true
+---
+
+# Matrix`,
+			expectedErrors: []string{
+				"engine 'codex' is not allowed by policy", "test.md:6:",
+				"model 'o4-mini' is not allowed by policy", "test.md:7:",
+			},
+		},
+		{
+			name:       "permissions exceed maximum",
+			repoPolicy: "max-permissions:\n  contents: read\n  issues: write",
+			workflow: `---
+on: workflow_dispatch
+permissions:
+  contents: write
+  issues: write
+---
+
+# Permissions`,
+			expectedErrors: []string{"permission 'contents: write' exceeds the policy maximum 'read'", "test.md:4:"},
+		},
+		{
+			name:       "default read-all exceeds scoped maximum",
+			repoPolicy: "max-permissions:\n  contents: read",
+			workflow: `---
+on: workflow_dispatch
+---
+
+# Default permissions`,
+			expectedErrors: []string{"permission 'issues: read' exceeds the policy maximum 'none'"},
+		},
+		{
+			name:       "network must be restricted",
+			repoPolicy: "network:\n  restricted: true",
+			workflow: `---
+on: workflow_dispatch
+---
+
+# Network`,
+			expectedErrors: []string{"policy requires an explicit 'network: { allowed: [...] }' configuration"},
+		},
+		{
+			name:       "network domain outside policy allow-list",
+			repoPolicy: "network:\n  restricted: true\n  allowed: [defaults, github]",
+			workflow: `---
+on: workflow_dispatch
+engine: claude
+network:
+  allowed:
+    - github
+    - example.com
+---
+
+# Network`,
+			expectedErrors: []string{"network access to 'example.com' is not allowed by policy", "test.md:7:"},
+		},
+		{
+			name:       "restricted network with fallback engine that does not enforce it",
+			repoPolicy: "network:\n  restricted: true",
+			workflow: `---
+on: workflow_dispatch
+engine:
+  id: claude
+  fallback: [codex]
+network:
+  allowed: [github]
+---
+
+# Network`,
+			expectedErrors: []string{"policy requires restricted network access, which engine 'codex' does not enforce"},
+		},
+		{
+			name:       "restricted network with matrix leg that does not enforce it",
+			repoPolicy: "network:\n  restricted: true",
+			workflow: `---
+on: workflow_dispatch
+engine:
+  matrix: [claude, codex]
+  compare: true
+network:
+  allowed: [github]
+---
+
+# Network`,
+			expectedErrors: []string{"policy requires restricted network access, which engine 'codex' does not enforce"},
+		},
+		{
+			name:       "forbidden tool",
+			repoPolicy: "forbidden-tools: [web-fetch]",
+			workflow: `---
+on: workflow_dispatch
+tools:
+  web-fetch:
+---
+
+# Tools`,
+			expectedErrors: []string{"tool 'web-fetch' is forbidden by policy", "test.md:4:"},
+		},
+		{
+			name:       "scheduled workflow without stop-after",
+			repoPolicy: "require-stop-after: true",
+			workflow: `---
+on:
+  schedule:
+    - cron: "0 9 * * 1"
+---
+
+# Schedule`,
+			expectedErrors: []string{"scheduled workflows must set 'stop-after'"},
+		},
+		{
+			name:       "scheduled workflow with stop-after",
+			repoPolicy: "require-stop-after: true",
+			workflow: `---
+on:
+  schedule:
+    - cron: "0 9 * * 1"
+  stop-after: "+7d"
+---
+
+# Schedule`,
+		},
+		{
+			name:       "timeout exceeds maximum",
+			repoPolicy: "max-timeout-minutes: 15",
+			workflow: `---
+on: workflow_dispatch
+timeout_minutes: 60
+---
+
+# Timeout`,
+			expectedErrors: []string{"timeout_minutes 60 exceeds the policy maximum of 15", "test.md:3:"},
+		},
+		{
+			name:      "org policy applies without repository policy",
+			orgPolicy: "allowed-engines: [claude]",
+			workflow: `---
+on: workflow_dispatch
+engine: codex
+---
+
+# Codex`,
+			expectedErrors: []string{"engine 'codex' is not allowed by policy", OrgPolicyPath},
+		},
+		{
+			name:       "org and repository engine allow-lists share no entries",
+			orgPolicy:  "allowed-engines: [claude]\nallowed-models: [claude-sonnet-4]",
+			repoPolicy: "allowed-engines: [codex]\nallowed-models: [gpt-5]",
+			workflow: `---
+on: workflow_dispatch
+engine:
+  id: gemini
+  model: gemini-2.5-pro
+---
+
+# Gemini`,
+			expectedErrors: []string{
+				"engine 'gemini' is not allowed by policy (allowed: none)",
+				"model 'gemini-2.5-pro' is not allowed by policy (allowed: none)",
+			},
+		},
+		{
+			name:       "org and repository network allow-lists share no entries",
+			orgPolicy:  "network:\n  allowed: [python]",
+			repoPolicy: "network:\n  allowed: [node]",
+			workflow: `---
+on: workflow_dispatch
+engine: claude
+network:
+  allowed:
+    - python
+---
+
+# Network`,
+			expectedErrors: []string{"network access to 'python' is not allowed by policy"},
+		},
+		{
+			name:       "repository policy cannot loosen org policy",
+			orgPolicy:  "max-timeout-minutes: 10",
+			repoPolicy: "max-timeout-minutes: 120",
+			workflow: `---
+on: workflow_dispatch
+timeout_minutes: 30
+---
+
+# Timeout`,
+			expectedErrors: []string{"timeout_minutes 30 exceeds the policy maximum of 10"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			workflowsDir := filepath.Join(tmpDir, ".github", "workflows")
+			if err := os.MkdirAll(filepath.Join(tmpDir, ".github", "aw"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.MkdirAll(workflowsDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Mkdir(filepath.Join(tmpDir, ".git"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if tt.orgPolicy != "" {
+				if err := os.WriteFile(filepath.Join(tmpDir, OrgPolicyPath), []byte(tt.orgPolicy), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.repoPolicy != "" {
+				if err := os.WriteFile(filepath.Join(tmpDir, RepositoryPolicyPath), []byte(tt.repoPolicy), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			workflowPath := filepath.Join(workflowsDir, "test.md")
+			if err := os.WriteFile(workflowPath, []byte(tt.workflow), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			compiler := NewCompiler(false, "", "test")
+			err := compiler.CompileWorkflow(workflowPath)
+
+			if len(tt.expectedErrors) == 0 {
+				if err != nil {
+					t.Fatalf("expected compilation to succeed, got: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected policy violation, compilation succeeded")
+			}
+			for _, expected := range tt.expectedErrors {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("expected error to contain %q, got:\n%v", expected, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadWorkflowPolicyWithoutPolicyFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(tmpDir, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := LoadWorkflowPolicy(filepath.Join(tmpDir, ".github", "workflows", "test.md"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if policy != nil {
+		t.Errorf("expected no policy, got %+v", policy)
+	}
+}
@@ -0,0 +1,137 @@
+package workflow
+
+// safeOutputTypes lists the safe output types that accept a sanitize policy
+var safeOutputTypes = []string{
+	"create-issue",
+	"create-discussion",
+	"add-issue-comment",
+	"create-pull-request",
+	"create-pull-request-review-comment",
+	"create-security-report",
+	"add-issue-label",
+	"update-issue",
+	"push-to-branch",
+	"missing-tool",
+}
+
+// SanitizeConfig holds the sanitization policy applied to text fields of agent output.
+// Unset fields fall back to the safe-outputs default and then to the built-in behavior.
+type SanitizeConfig struct {
+	AllowedMentions   []string `yaml:"allowed-mentions,omitempty" json:"allowed-mentions,omitempty"`       // Handles (user or org/team) that are not neutralized
+	MaxLength         int      `yaml:"max-length,omitempty" json:"max-length,omitempty"`                   // Maximum content length in characters (default: 524288)
+	AllowedURLDomains []string `yaml:"allowed-url-domains,omitempty" json:"allowed-url-domains,omitempty"` // Domains whose https URLs are kept (default: safe-outputs.allowed-domains or GitHub domains)
+	StripHTML         *bool    `yaml:"strip-html,omitempty" json:"strip-html,omitempty"`                   // Remove HTML tags instead of escaping them (default: false)
+	AllowCodeFences   *bool    `yaml:"allow-code-fences,omitempty" json:"allow-code-fences,omitempty"`     // Keep code fences intact (default: true)
+}
+
+// parseSanitizeConfig handles a sanitize configuration block
+func (c *Compiler) parseSanitizeConfig(value any) *SanitizeConfig {
+	configMap, ok := value.(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	sanitizeConfig := &SanitizeConfig{}
+
+	// Parse allowed-mentions
+	if mentions, exists := configMap["allowed-mentions"]; exists {
+		sanitizeConfig.AllowedMentions = parseStringList(mentions)
+	}
+
+	// Parse max-length
+	if maxLength, exists := configMap["max-length"]; exists {
+		if maxLengthInt, ok := c.parseIntValue(maxLength); ok {
+			sanitizeConfig.MaxLength = maxLengthInt
+		}
+	}
+
+	// Parse allowed-url-domains
+	if domains, exists := configMap["allowed-url-domains"]; exists {
+		sanitizeConfig.AllowedURLDomains = parseStringList(domains)
+	}
+
+	// Parse strip-html
+	if stripHTML, exists := configMap["strip-html"]; exists {
+		if stripHTMLBool, ok := stripHTML.(bool); ok {
+			sanitizeConfig.StripHTML = &stripHTMLBool
+		}
+	}
+
+	// Parse allow-code-fences
+	if allowCodeFences, exists := configMap["allow-code-fences"]; exists {
+		if allowCodeFencesBool, ok := allowCodeFences.(bool); ok {
+			sanitizeConfig.AllowCodeFences = &allowCodeFencesBool
+		}
+	}
+
+	return sanitizeConfig
+}
+
+// parseSanitizeConfigs handles the top-level sanitize default and per-output sanitize overrides
+func (c *Compiler) parseSanitizeConfigs(outputMap map[string]any, config *SafeOutputsConfig) {
+	if sanitize, exists := outputMap["sanitize"]; exists {
+		config.Sanitize = c.parseSanitizeConfig(sanitize)
+	}
+
+	for _, outputType := range safeOutputTypes {
+		outputConfig, ok := outputMap[outputType].(map[string]any)
+		if !ok {
+			continue
+		}
+		if sanitize, exists := outputConfig["sanitize"]; exists {
+			if sanitizeConfig := c.parseSanitizeConfig(sanitize); sanitizeConfig != nil {
+				if config.SanitizeOverrides == nil {
+					config.SanitizeOverrides = make(map[string]*SanitizeConfig)
+				}
+				config.SanitizeOverrides[outputType] = sanitizeConfig
+			}
+		}
+	}
+}
+
+// parseStringList converts a YAML list into its string entries
+func parseStringList(value any) []string {
+	items, ok := value.([]any)
+	if !ok {
+		return nil
+	}
+	var result []string
+	for _, item := range items {
+		if itemStr, ok := item.(string); ok {
+			result = append(result, itemStr)
+		}
+	}
+	return result
+}
+
+// effectiveSanitizeConfig returns the sanitize policy for an output type, layering the
+// per-output override on top of the safe-outputs default. Returns nil if neither is set.
+func (c *SafeOutputsConfig) effectiveSanitizeConfig(outputType string) *SanitizeConfig {
+	override := c.SanitizeOverrides[outputType]
+	if c.Sanitize == nil && override == nil {
+		return nil
+	}
+
+	result := &SanitizeConfig{}
+	for _, layer := range []*SanitizeConfig{c.Sanitize, override} {
+		if layer == nil {
+			continue
+		}
+		if layer.AllowedMentions != nil {
+			result.AllowedMentions = layer.AllowedMentions
+		}
+		if layer.MaxLength > 0 {
+			result.MaxLength = layer.MaxLength
+		}
+		if layer.AllowedURLDomains != nil {
+			result.AllowedURLDomains = layer.AllowedURLDomains
+		}
+		if layer.StripHTML != nil {
+			result.StripHTML = layer.StripHTML
+		}
+		if layer.AllowCodeFences != nil {
+			result.AllowCodeFences = layer.AllowCodeFences
+		}
+	}
+	return result
+}
@@ -0,0 +1,124 @@
+package workflow
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSafeOutputsSanitizeConfiguration(t *testing.T) {
+	compiler := &Compiler{}
+
+	frontmatter := map[string]any{
+		"safe-outputs": map[string]any{
+			"sanitize": map[string]any{
+				"allowed-mentions": []any{"octocat"},
+				"max-length":       1000,
+				"strip-html":       true,
+			},
+			"create-issue": map[string]any{
+				"sanitize": map[string]any{
+					"allowed-mentions":    []any{"github/security"},
+					"allowed-url-domains": []any{"example.com"},
+					"allow-code-fences":   false,
+				},
+			},
+			"add-issue-comment": nil,
+		},
+	}
+
+	config := compiler.extractSafeOutputsConfig(frontmatter)
+	if config == nil {
+		t.Fatal("Expected config to be parsed")
+	}
+	if config.Sanitize == nil {
+		t.Fatal("Expected default sanitize policy to be parsed")
+	}
+	if config.SanitizeOverrides["create-issue"] == nil {
+		t.Fatal("Expected create-issue sanitize override to be parsed")
+	}
+
+	t.Run("override is layered on top of the default", func(t *testing.T) {
+		effective := config.effectiveSanitizeConfig("create-issue")
+		if !reflect.DeepEqual(effective.AllowedMentions, []string{"github/security"}) {
+			t.Errorf("Expected override mentions, got %v", effective.AllowedMentions)
+		}
+		if effective.MaxLength != 1000 {
+			t.Errorf("Expected default max-length 1000, got %d", effective.MaxLength)
+		}
+		if !reflect.DeepEqual(effective.AllowedURLDomains, []string{"example.com"}) {
+			t.Errorf("Expected override domains, got %v", effective.AllowedURLDomains)
+		}
+		if effective.StripHTML == nil || !*effective.StripHTML {
+			t.Errorf("Expected strip-html inherited from default")
+		}
+		if effective.AllowCodeFences == nil || *effective.AllowCodeFences {
+			t.Errorf("Expected allow-code-fences false from override")
+		}
+	})
+
+	t.Run("outputs without override use the default", func(t *testing.T) {
+		effective := config.effectiveSanitizeConfig("add-issue-comment")
+		if !reflect.DeepEqual(effective.AllowedMentions, []string{"octocat"}) {
+			t.Errorf("Expected default mentions, got %v", effective.AllowedMentions)
+		}
+		if effective.AllowCodeFences != nil {
+			t.Errorf("Expected allow-code-fences to be unset")
+		}
+	})
+
+	t.Run("no policy when nothing is configured", func(t *testing.T) {
+		empty := &SafeOutputsConfig{}
+		if empty.effectiveSanitizeConfig("create-issue") != nil {
+			t.Errorf("Expected nil sanitize policy")
+		}
+	})
+}
+
+func TestSafeOutputsSanitizeInCollectStep(t *testing.T) {
+	tmpDir := t.TempDir()
+	workflowPath := filepath.Join(tmpDir, "test-sanitize.md")
+	content := `---
+on: push
+permissions:
+  contents: read
+engine: claude
+safe-outputs:
+  sanitize:
+    max-length: 2000
+  create-issue:
+    sanitize:
+      allowed-mentions: [octocat]
+  add-issue-label:
+---
+
+# Sanitize Test
+
+Create an issue.`
+	if err := os.WriteFile(workflowPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	compiler := NewCompiler(false, "", "test")
+	if err := compiler.CompileWorkflow(workflowPath); err != nil {
+		t.Fatalf("unexpected compile error: %v", err)
+	}
+
+	lockContent, err := os.ReadFile(strings.TrimSuffix(workflowPath, ".md") + ".lock.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lock := string(lockContent)
+
+	expected := []string{
+		`\"create-issue\":{\"enabled\":true,\"sanitize\":{\"allowed-mentions\":[\"octocat\"],\"max-length\":2000}}`,
+		`\"add-issue-label\":{\"enabled\":true,\"sanitize\":{\"max-length\":2000}}`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(lock, want) {
+			t.Errorf("expected GITHUB_AW_SAFE_OUTPUTS_CONFIG to contain %s", want)
+		}
+	}
+}
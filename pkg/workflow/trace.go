@@ -0,0 +1,101 @@
+package workflow
+
+import "time"
+
+// Trace event types of an agent session
+const (
+	TraceEventSession    = "session"
+	TraceEventTurn       = "turn"
+	TraceEventToolCall   = "tool_call"
+	TraceEventToolResult = "tool_result"
+	TraceEventModelUsage = "model_usage"
+)
+
+// TraceEvent is a single engine-neutral event of an agent session. Fields that do not
+// apply to the event type are left empty.
+type TraceEvent struct {
+	Type            string    `json:"type"`
+	Timestamp       time.Time `json:"timestamp,omitzero"` // Zero when the engine log has no timestamps
+	Engine          string    `json:"engine,omitempty"`
+	Model           string    `json:"model,omitempty"`
+	Turn            int       `json:"turn,omitempty"` // Turn of the event, the number of turns for the session
+	ToolName        string    `json:"tool_name,omitempty"`
+	ToolCallID      string    `json:"tool_call_id,omitempty"`
+	IsError         bool      `json:"is_error,omitempty"`
+	DurationMs      int64     `json:"duration_ms,omitempty"`
+	ModelDurationMs int64     `json:"model_duration_ms,omitempty"` // Time spent in model API calls
+	InputTokens     int       `json:"input_tokens,omitempty"`
+	OutputTokens    int       `json:"output_tokens,omitempty"`
+	CacheTokens     int       `json:"cache_tokens,omitempty"`
+	TotalTokens     int       `json:"total_tokens,omitempty"`
+	StopReason      string    `json:"stop_reason,omitempty"`
+}
+
+// AgentTrace is the sequence of events of one agent session. The first event is the
+// session event, which carries the totals of the session.
+type AgentTrace struct {
+	Events []TraceEvent
+}
+
+// newAgentTrace starts the trace of a session of the engine
+func newAgentTrace(engineID string) *AgentTrace {
+	return &AgentTrace{Events: []TraceEvent{{Type: TraceEventSession, Engine: engineID}}}
+}
+
+// Session returns the session event of the trace
+func (t *AgentTrace) Session() *TraceEvent {
+	return &t.Events[0]
+}
+
+// IsEmpty reports whether the trace has no events besides the session event
+func (t *AgentTrace) IsEmpty() bool {
+	return t == nil || len(t.Events) <= 1
+}
+
+// add appends an event to the trace
+func (t *AgentTrace) add(event TraceEvent) {
+	t.Events = append(t.Events, event)
+}
+
+// addToMetrics adds the turns, tool calls, token split and stop reason of the trace to the metrics
+func (t *AgentTrace) addToMetrics(metrics *LogMetrics) {
+	if t.IsEmpty() {
+		return
+	}
+
+	turns := 0
+	for _, event := range t.Events {
+		switch event.Type {
+		case TraceEventTurn:
+			turns++
+		case TraceEventToolCall:
+			metrics.AddToolCall(event.ToolName)
+		case TraceEventToolResult:
+			metrics.AddToolResult(event.ToolName, event.IsError, time.Duration(event.DurationMs)*time.Millisecond)
+		}
+	}
+
+	session := t.Session()
+	metrics.Turns = turns
+	if session.Turn > 0 {
+		metrics.Turns = session.Turn
+	}
+	metrics.InputTokens = session.InputTokens
+	metrics.OutputTokens = session.OutputTokens
+	metrics.CacheTokens = session.CacheTokens
+	metrics.StopReason = session.StopReason
+	metrics.Model = session.Model
+
+	// Engines that do not time tool calls report the time spent outside model API calls instead
+	if metrics.ToolDuration == 0 && session.ModelDurationMs > 0 && session.DurationMs > session.ModelDurationMs {
+		metrics.ToolDuration = time.Duration(session.DurationMs-session.ModelDurationMs) * time.Millisecond
+	}
+
+	// Engines that do not report the cost are priced from their token usage, the cost of
+	// tokens that cannot be priced is left unset and marked unknown
+	if metrics.EstimatedCost == 0 {
+		cost, ok := GetModelPricing().EstimateCost(session.Engine, session.Model, *metrics)
+		metrics.EstimatedCost = cost
+		metrics.CostUnknown = !ok && metrics.TokenUsage > 0
+	}
+}
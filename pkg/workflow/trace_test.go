@@ -0,0 +1,105 @@
+package workflow
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClaudeEngineParseLogTrace(t *testing.T) {
+	logContent := `{"type": "system", "subtype": "init", "model": "claude-sonnet-4-20250514"}
+{"type": "assistant", "message": {"id": "msg_1", "model": "claude-sonnet-4-20250514", "usage": {"input_tokens": 10, "output_tokens": 5}, "content": [{"type": "text", "text": "Looking"}]}}
+{"type": "assistant", "message": {"id": "msg_1", "model": "claude-sonnet-4-20250514", "usage": {"input_tokens": 10, "output_tokens": 5}, "content": [{"type": "tool_use", "id": "toolu_1", "name": "mcp__github__get_issue", "input": {}}]}}
+{"type": "user", "message": {"content": [{"type": "tool_result", "tool_use_id": "toolu_1", "content": "ok"}]}}
+{"type": "assistant", "message": {"id": "msg_2", "model": "claude-sonnet-4-20250514", "usage": {"input_tokens": 20, "output_tokens": 8}, "content": [{"type": "text", "text": "Done"}]}}
+{"type": "result", "subtype": "success", "is_error": false, "num_turns": 2, "duration_ms": 5000, "duration_api_ms": 4000, "usage": {"input_tokens": 30, "output_tokens": 13}}`
+
+	trace := NewClaudeEngine().ParseLogTrace(logContent)
+
+	expectedTypes := []string{
+		TraceEventSession,
+		TraceEventTurn, TraceEventModelUsage, TraceEventToolCall, TraceEventToolResult,
+		TraceEventTurn, TraceEventModelUsage,
+	}
+	if len(trace.Events) != len(expectedTypes) {
+		t.Fatalf("Expected %d events, got %d: %+v", len(expectedTypes), len(trace.Events), trace.Events)
+	}
+	for i, eventType := range expectedTypes {
+		if trace.Events[i].Type != eventType {
+			t.Errorf("Expected event %d to be %q, got %q", i, eventType, trace.Events[i].Type)
+		}
+	}
+
+	session := trace.Session()
+	if session.Engine != "claude" || session.Model != "claude-sonnet-4-20250514" {
+		t.Errorf("Unexpected session engine and model: %+v", session)
+	}
+	if session.Turn != 2 || session.TotalTokens != 43 || session.StopReason != StopReasonCompleted {
+		t.Errorf("Unexpected session totals: %+v", session)
+	}
+	if call := trace.Events[3]; call.ToolName != "github.get_issue" || call.ToolCallID != "toolu_1" || call.Turn != 1 {
+		t.Errorf("Unexpected tool call: %+v", call)
+	}
+}
+
+func TestCodexEngineParseLogTrace(t *testing.T) {
+	logContent := `[2025-08-31T12:37:08] OpenAI Codex v0.27.0 (research preview)
+--------
+model: o4-mini
+--------
+[2025-08-31T12:37:08] User instructions:
+Summarize the repository.
+[2025-08-31T12:37:20] tool github.get_issue({"number":1})
+[2025-08-31T12:37:21] github.get_issue({"number":1}) success in 120ms:
+[1, 2, 3] are the issue labels
+[2025-08-31T12:37:22] tokens used: 1000
+[2025-08-31T12:37:30] codex
+The repository is a CLI.
+[2025-08-31T12:37:31] tokens used: 500`
+
+	trace := NewCodexEngine().ParseLogTrace(logContent)
+
+	expectedTypes := []string{
+		TraceEventSession,
+		TraceEventTurn, TraceEventToolCall, TraceEventToolResult, TraceEventModelUsage,
+		TraceEventTurn, TraceEventModelUsage,
+	}
+	if len(trace.Events) != len(expectedTypes) {
+		t.Fatalf("Expected %d events, got %d: %+v", len(expectedTypes), len(trace.Events), trace.Events)
+	}
+	for i, eventType := range expectedTypes {
+		if trace.Events[i].Type != eventType {
+			t.Errorf("Expected event %d to be %q, got %q", i, eventType, trace.Events[i].Type)
+		}
+	}
+
+	session := trace.Session()
+	start := time.Date(2025, 8, 31, 12, 37, 8, 0, time.UTC)
+	if !session.Timestamp.Equal(start) || session.DurationMs != 23000 {
+		t.Errorf("Expected session starting at %v lasting 23s, got %v lasting %dms", start, session.Timestamp, session.DurationMs)
+	}
+	if session.Model != "o4-mini" || session.Turn != 2 || session.TotalTokens != 1500 || session.StopReason != StopReasonCompleted {
+		t.Errorf("Unexpected session totals: %+v", session)
+	}
+
+	// The first turn starts with the prompt, the second where the first ended
+	if !trace.Events[1].Timestamp.Equal(start) {
+		t.Errorf("Expected the first turn to start at %v, got %v", start, trace.Events[1].Timestamp)
+	}
+	if turnStart := start.Add(14 * time.Second); !trace.Events[5].Timestamp.Equal(turnStart) {
+		t.Errorf("Expected the second turn to start at %v, got %v", turnStart, trace.Events[5].Timestamp)
+	}
+
+	result := trace.Events[3]
+	if result.ToolCallID != trace.Events[2].ToolCallID || result.DurationMs != 120 || result.IsError {
+		t.Errorf("Unexpected tool result: %+v", result)
+	}
+}
+
+func TestBaseEngineParseLogTrace(t *testing.T) {
+	if trace := NewCustomEngine().ParseLogTrace("anything"); trace != nil {
+		t.Errorf("Expected no trace support for the custom engine, got %+v", trace)
+	}
+	if !(*AgentTrace)(nil).IsEmpty() {
+		t.Error("Expected a nil trace to be empty")
+	}
+}